@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxbytes
+
+import (
+	"io"
+)
+
+const (
+	minReadSize = 512
+	copyBufSize = 32 << 10
+)
+
+// Payload holds bytes acquired from the default bytes pool. Release it after use,
+// and do not touch the bytes after that. Payload is used by pointer, a copy of it
+// would release the same bytes twice.
+type Payload struct {
+	bufp *[]byte
+}
+
+// Bytes returns the payload bytes
+func (p *Payload) Bytes() []byte {
+	if p == nil || p.bufp == nil {
+		return nil
+	}
+	return *p.bufp
+}
+
+// Len returns the length of the payload
+func (p *Payload) Len() int {
+	return len(p.Bytes())
+}
+
+// Release puts the bytes back to the pool. It is safe to call Release many times.
+func (p *Payload) Release() {
+	if p != nil && p.bufp != nil {
+		ReleaseBytes(p.bufp)
+		p.bufp = nil
+	}
+}
+
+// ReadAll reads from @r until EOF like io.ReadAll, but the bytes are acquired
+// from the default bytes pool
+func ReadAll(r io.Reader) (*Payload, error) {
+	bufp := AcquireBytes(minReadSize)
+	buf := (*bufp)[:0]
+	for {
+		if len(buf) == cap(buf) {
+			// grow by acquiring a double sized buffer from the pool
+			nbufp := AcquireBytes(2 * cap(buf))
+			nbuf := append((*nbufp)[:0], buf...)
+			ReleaseBytes(bufp)
+			bufp, buf = nbufp, nbuf
+		}
+
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			*bufp = buf
+			if err == io.EOF {
+				err = nil
+			}
+			return &Payload{bufp: bufp}, err
+		}
+	}
+}
+
+// ReadFull reads exactly @n bytes from @r like io.ReadFull, but the bytes are
+// acquired from the default bytes pool
+func ReadFull(r io.Reader, n int) (*Payload, error) {
+	bufp := AcquireBytes(n)
+	// the bytes beyond the max slot of the pool are returned with zero length
+	*bufp = (*bufp)[:n]
+	if _, err := io.ReadFull(r, *bufp); err != nil {
+		ReleaseBytes(bufp)
+		return nil, err
+	}
+	return &Payload{bufp: bufp}, nil
+}
+
+// CopyWithPool copies from @src to @dst like io.Copy, but the scratch buffer is
+// acquired from the default bytes pool
+func CopyWithPool(dst io.Writer, src io.Reader) (int64, error) {
+	bufp := AcquireBytes(copyBufSize)
+	defer ReleaseBytes(bufp)
+	return io.CopyBuffer(dst, src, (*bufp)[:copyBufSize])
+}
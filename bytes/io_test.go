@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxbytes
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAll(t *testing.T) {
+	for _, size := range []int{0, 1, 511, 512, 513, 5000, 100 << 10} {
+		data := bytes.Repeat([]byte("x"), size)
+		p, err := ReadAll(iotest.HalfReader(bytes.NewReader(data)))
+		assert.Nil(t, err)
+		assert.Equal(t, size, p.Len())
+		assert.True(t, bytes.Equal(data, p.Bytes()))
+		p.Release()
+		p.Release()
+		assert.Nil(t, p.Bytes())
+	}
+
+	p, err := ReadAll(iotest.TimeoutReader(strings.NewReader(strings.Repeat("y", 1024))))
+	assert.Equal(t, iotest.ErrTimeout, err)
+	assert.Equal(t, 512, p.Len())
+	p.Release()
+}
+
+func TestReadFull(t *testing.T) {
+	p, err := ReadFull(strings.NewReader("hello, world"), 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(p.Bytes()))
+	p.Release()
+
+	p, err = ReadFull(strings.NewReader("hi"), 5)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Nil(t, p)
+	assert.Nil(t, p.Bytes())
+	p.Release()
+
+	// larger than the max slot of the pool
+	data := strings.Repeat("x", 100<<10)
+	p, err = ReadFull(strings.NewReader(data), len(data))
+	assert.Nil(t, err)
+	assert.Equal(t, data, string(p.Bytes()))
+	p.Release()
+}
+
+func TestCopyWithPool(t *testing.T) {
+	data := strings.Repeat("dubbo-go", 10000)
+	var dst bytes.Buffer
+	n, err := CopyWithPool(struct{ io.Writer }{&dst}, struct{ io.Reader }{strings.NewReader(data)})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, data, dst.String())
+}
@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package gxexpiring implements containers whose entries expire actively
+package gxexpiring
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	gxtime "github.com/dubbogo/gost/time"
+)
+
+// EvictReason is the reason why an entry leaves the map
+type EvictReason int
+
+const (
+	// EvictExpired means the ttl of the entry elapsed
+	EvictExpired EvictReason = iota
+	// EvictEvicted means the entry is deleted
+	EvictEvicted
+	// EvictReplaced means the entry is replaced by a new value of the same key
+	EvictReplaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "expired"
+	case EvictEvicted:
+		return "evicted"
+	case EvictReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// EvictFunc is called when an entry leaves the map
+type EvictFunc[K comparable, V any] func(key K, value V, reason EvictReason)
+
+type entry[V any] struct {
+	value    V
+	deadline time.Time
+	stop     func() // stops the expiration timer
+}
+
+// ExpiringMap is a goroutine-safe map whose entries are removed by the timer
+// wheel when their ttl elapses, rather than lazily on read. A runtime timer is
+// used instead if the timer queue of the wheel is full, and the expired entries
+// which have not been removed yet are removed on read.
+type ExpiringMap[K comparable, V any] struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	entries map[K]*entry[V]
+	onEvict EvictFunc[K, V]
+	wheel   *gxtime.TimerWheel
+}
+
+// NewExpiringMap returns a map whose entries expire after @ttl by default.
+// @onEvict may be nil.
+func NewExpiringMap[K comparable, V any](ttl time.Duration, onEvict EvictFunc[K, V]) *ExpiringMap[K, V] {
+	gxtime.InitDefaultTimerWheel()
+	return &ExpiringMap[K, V]{
+		ttl:     ttl,
+		entries: make(map[K]*entry[V]),
+		onEvict: onEvict,
+		wheel:   gxtime.GetDefaultTimerWheel(),
+	}
+}
+
+// Set puts @key/@value with the default ttl
+func (m *ExpiringMap[K, V]) Set(key K, value V) {
+	m.SetWithTTL(key, value, m.ttl)
+}
+
+// SetWithTTL puts @key/@value which expires after @ttl
+func (m *ExpiringMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	e := &entry[V]{
+		value:    value,
+		deadline: time.Now().Add(ttl),
+	}
+
+	m.lock.Lock()
+	old, ok := m.entries[key]
+	if ok {
+		old.stop()
+	}
+	m.entries[key] = e
+	m.schedule(key, e, ttl)
+	m.lock.Unlock()
+
+	if ok {
+		m.evict(key, old.value, EvictReplaced)
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (m *ExpiringMap[K, V]) schedule(key K, e *entry[V], ttl time.Duration) {
+	fire := func() {
+		m.expire(key, e)
+	}
+	if t := m.wheel.AfterFunc(ttl, fire); t != nil {
+		e.stop = t.Stop
+		return
+	}
+
+	// the timer queue of the wheel is full
+	t := time.AfterFunc(ttl, fire)
+	e.stop = func() {
+		t.Stop()
+	}
+}
+
+// Get returns the value of @key if it has not expired
+func (m *ExpiringMap[K, V]) Get(key K) (V, bool) {
+	m.lock.Lock()
+	e, ok := m.entries[key]
+	if ok && time.Now().Before(e.deadline) {
+		m.lock.Unlock()
+		return e.value, true
+	}
+	m.lock.Unlock()
+
+	if ok {
+		// the timer has not fired yet
+		m.expire(key, e)
+	}
+	var zero V
+	return zero, false
+}
+
+// TTL returns the remaining ttl of @key
+func (m *ExpiringMap[K, V]) TTL(key K) (time.Duration, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return 0, false
+	}
+	return time.Until(e.deadline), true
+}
+
+// Delete removes @key and calls the evict func with EvictEvicted
+func (m *ExpiringMap[K, V]) Delete(key K) bool {
+	m.lock.Lock()
+	e, ok := m.entries[key]
+	if ok {
+		delete(m.entries, key)
+		e.stop()
+	}
+	m.lock.Unlock()
+
+	if ok {
+		m.evict(key, e.value, EvictEvicted)
+	}
+	return ok
+}
+
+// Len returns the number of entries
+func (m *ExpiringMap[K, V]) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.entries)
+}
+
+// Range calls @fn for every entry until @fn returns false.
+// NOTICE: the map is locked while ranging, @fn must not modify the map.
+func (m *ExpiringMap[K, V]) Range(fn func(key K, value V) bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for k, e := range m.entries {
+		if !fn(k, e.value) {
+			return
+		}
+	}
+}
+
+// Clear removes all the entries and calls the evict func with EvictEvicted
+func (m *ExpiringMap[K, V]) Clear() {
+	m.lock.Lock()
+	entries := m.entries
+	m.entries = make(map[K]*entry[V])
+	for _, e := range entries {
+		e.stop()
+	}
+	m.lock.Unlock()
+
+	for k, e := range entries {
+		m.evict(k, e.value, EvictEvicted)
+	}
+}
+
+func (m *ExpiringMap[K, V]) expire(key K, e *entry[V]) {
+	m.lock.Lock()
+	// the entry may have been replaced or deleted
+	if cur, ok := m.entries[key]; !ok || cur != e {
+		m.lock.Unlock()
+		return
+	}
+	delete(m.entries, key)
+	m.lock.Unlock()
+
+	m.evict(key, e.value, EvictExpired)
+}
+
+func (m *ExpiringMap[K, V]) evict(key K, value V, reason EvictReason) {
+	if m.onEvict != nil {
+		m.onEvict(key, value, reason)
+	}
+}
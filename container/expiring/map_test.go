@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxexpiring
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxtime "github.com/dubbogo/gost/time"
+)
+
+type evicted struct {
+	key    string
+	value  int
+	reason EvictReason
+}
+
+func TestExpiringMap(t *testing.T) {
+	var (
+		lock sync.Mutex
+		got  []evicted
+	)
+	m := NewExpiringMap[string, int](100*time.Millisecond, func(key string, value int, reason EvictReason) {
+		lock.Lock()
+		got = append(got, evicted{key, value, reason})
+		lock.Unlock()
+	})
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.SetWithTTL("c", 3, time.Hour)
+	m.Set("a", 10)
+	assert.True(t, m.Delete("b"))
+	assert.False(t, m.Delete("b"))
+
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+	ttl, ok := m.TTL("c")
+	assert.True(t, ok)
+	assert.True(t, ttl > time.Minute)
+
+	// "a" is removed actively without reading it
+	assert.Eventually(t, func() bool { return m.Len() == 1 }, 2*time.Second, 10*time.Millisecond)
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+
+	m.Clear()
+	assert.Equal(t, 0, m.Len())
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []evicted{
+		{"a", 1, EvictReplaced},
+		{"b", 2, EvictEvicted},
+		{"a", 10, EvictExpired},
+		{"c", 3, EvictEvicted},
+	}, got)
+}
+
+func TestExpiringMapAfterDrained(t *testing.T) {
+	m := NewExpiringMap[string, int](50*time.Millisecond, nil)
+
+	// the entries set after all the others expired still expire
+	for i := 0; i < 2; i++ {
+		m.Set("a", i)
+		assert.Eventually(t, func() bool { return m.Len() == 0 }, 2*time.Second, 10*time.Millisecond)
+	}
+}
+
+func TestExpiringMapWheelUnavailable(t *testing.T) {
+	var expired int32
+	m := NewExpiringMap[string, int](50*time.Millisecond, func(key string, value int, reason EvictReason) {
+		if reason == EvictExpired {
+			atomic.AddInt32(&expired, 1)
+		}
+	})
+	// the timers can not be added to a closed wheel
+	w := gxtime.NewTimerWheel()
+	w.Close()
+	m.wheel = w
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	assert.True(t, m.Delete("b"))
+	assert.Eventually(t, func() bool { return m.Len() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&expired))
+}
@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxoption
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+var (
+	// ErrNoneValue is returned when unwrapping an absent Option
+	ErrNoneValue = perrors.New("option has no value")
+
+	nullJSON = []byte("null")
+)
+
+// Option represents a value which may be absent
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some returns an Option holding @v
+func Some[T any](v T) Option[T] {
+	return Option[T]{value: v, ok: true}
+}
+
+// None returns an absent Option
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// FromPair converts the (value, ok) pair to an Option
+func FromPair[T any](v T, ok bool) Option[T] {
+	if !ok {
+		return None[T]()
+	}
+	return Some(v)
+}
+
+// IsSome returns true if the value is present
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+// IsNone returns true if the value is absent
+func (o Option[T]) IsNone() bool {
+	return !o.ok
+}
+
+// Get returns the value and whether it is present
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// Unwrap returns the value. It panics if the value is absent.
+func (o Option[T]) Unwrap() T {
+	if !o.ok {
+		panic(ErrNoneValue)
+	}
+	return o.value
+}
+
+// OrElse returns the value if it is present, otherwise returns @v
+func (o Option[T]) OrElse(v T) T {
+	if !o.ok {
+		return v
+	}
+	return o.value
+}
+
+// OrElseGet returns the value if it is present, otherwise returns the result of @f
+func (o Option[T]) OrElseGet(f func() T) T {
+	if !o.ok {
+		return f()
+	}
+	return o.value
+}
+
+// MarshalJSON marshals the value, or null if it is absent
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if !o.ok {
+		return nullJSON, nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON regards null as an absent value
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), nullJSON) {
+		*o = None[T]()
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Some(v)
+	return nil
+}
+
+// Map converts the value of @o by @f if it is present
+func Map[T, U any](o Option[T], f func(T) U) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return Some(f(o.value))
+}
+
+// FlatMap converts the value of @o by @f if it is present
+func FlatMap[T, U any](o Option[T], f func(T) Option[U]) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return f(o.value)
+}
@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxoption
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOption(t *testing.T) {
+	o := Some(1)
+	assert.True(t, o.IsSome())
+	assert.Equal(t, 1, o.Unwrap())
+	assert.Equal(t, 1, o.OrElse(2))
+
+	n := None[int]()
+	assert.True(t, n.IsNone())
+	assert.Equal(t, 2, n.OrElse(2))
+	assert.Equal(t, 3, n.OrElseGet(func() int { return 3 }))
+	assert.Panics(t, func() { n.Unwrap() })
+
+	s := Map(o, strconv.Itoa)
+	assert.Equal(t, "1", s.Unwrap())
+	assert.True(t, Map(n, strconv.Itoa).IsNone())
+	assert.True(t, FromPair(0, false).IsNone())
+
+	var v struct {
+		A Option[int]    `json:"a"`
+		B Option[string] `json:"b"`
+	}
+	v.A = Some(10)
+	data, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":10,"b":null}`, string(data))
+
+	v.A = None[int]()
+	assert.Nil(t, json.Unmarshal([]byte(`{"a":null,"b":"x"}`), &v))
+	assert.True(t, v.A.IsNone())
+	assert.Equal(t, "x", v.B.Unwrap())
+}
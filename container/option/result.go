@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxoption
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Result represents a value, or an error if the operation failed,
+// or neither if the value is absent.
+type Result[T any] struct {
+	value Option[T]
+	err   error
+}
+
+// Ok returns a Result holding @v
+func Ok[T any](v T) Result[T] {
+	return Result[T]{value: Some(v)}
+}
+
+// Absent returns a Result holding neither a value nor an error
+func Absent[T any]() Result[T] {
+	return Result[T]{}
+}
+
+// Err returns a failed Result
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// From converts the (value, error) pair to a Result
+func From[T any](v T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(v)
+}
+
+// IsOk returns true if there is no error, the value may be absent
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr returns true if the operation failed
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+// Error returns the error
+func (r Result[T]) Error() error {
+	return r.err
+}
+
+// Option returns the value as an Option, it is absent if the operation failed
+func (r Result[T]) Option() Option[T] {
+	return r.value
+}
+
+// Get returns the value, whether it is present and the error
+func (r Result[T]) Get() (T, bool, error) {
+	v, ok := r.value.Get()
+	return v, ok, r.err
+}
+
+// Unwrap returns the value. It panics if the operation failed or the value is absent.
+func (r Result[T]) Unwrap() T {
+	if r.err != nil {
+		panic(r.err)
+	}
+	return r.value.Unwrap()
+}
+
+// OrElse returns the value if it is present, otherwise returns @v
+func (r Result[T]) OrElse(v T) T {
+	return r.value.OrElse(v)
+}
+
+type resultJSON[T any] struct {
+	Value Option[T] `json:"value"`
+	Error string    `json:"error,omitempty"`
+}
+
+// MarshalJSON marshals the Result as {"value": ..., "error": "..."}
+func (r Result[T]) MarshalJSON() ([]byte, error) {
+	j := resultJSON[T]{Value: r.value}
+	if r.err != nil {
+		j.Error = r.err.Error()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON unmarshals the Result marshaled by MarshalJSON. Only the message of
+// the error survives, the error is restored as a new error holding the message.
+func (r *Result[T]) UnmarshalJSON(data []byte) error {
+	var j resultJSON[T]
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*r = Result[T]{value: j.Value}
+	if j.Error != "" {
+		r.err = errors.New(j.Error)
+	}
+	return nil
+}
+
+// MapResult converts the value of @r by @f if it is present
+func MapResult[T, U any](r Result[T], f func(T) U) Result[U] {
+	return Result[U]{value: Map(r.value, f), err: r.err}
+}
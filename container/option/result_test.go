@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxoption
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult(t *testing.T) {
+	r := From(strconv.Atoi("12"))
+	assert.True(t, r.IsOk())
+	assert.Equal(t, 12, r.Unwrap())
+
+	r = From(strconv.Atoi("a"))
+	assert.True(t, r.IsErr())
+	assert.Equal(t, 1, r.OrElse(1))
+	assert.Panics(t, func() { r.Unwrap() })
+
+	a := Absent[int]()
+	v, ok, err := a.Get()
+	assert.Equal(t, 0, v)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(Err[int](perrors.New("bad")))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"value":null,"error":"bad"}`, string(data))
+
+	var decoded Result[int]
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.IsErr())
+	assert.Equal(t, "bad", decoded.Error().Error())
+
+	data, err = json.Marshal(Ok(7))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"value":7}`, string(data))
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.IsOk())
+	assert.Equal(t, 7, decoded.Unwrap())
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"value":null}`), &decoded))
+	assert.True(t, decoded.IsOk())
+	assert.True(t, decoded.Option().IsNone())
+	assert.NotNil(t, json.Unmarshal([]byte(`{"value":"x"}`), &decoded))
+
+	assert.Equal(t, "12", MapResult(Ok(12), strconv.Itoa).Unwrap())
+}
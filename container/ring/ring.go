@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package gxring implements a generic fixed-capacity ring buffer
+package gxring
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrRingFull is returned when pushing to a full ring in RingReject mode
+var ErrRingFull = errors.New("ring: full")
+
+// RingMode decides what to do when pushing to a full ring
+type RingMode int
+
+const (
+	// RingOverwrite overwrites the oldest element
+	RingOverwrite RingMode = iota
+	// RingReject rejects the new element with ErrRingFull
+	RingReject
+)
+
+// Ring is a goroutine-safe fixed-capacity circular buffer, which keeps the
+// last N elements such as events, errors or latencies. It does not allocate
+// after creation.
+type Ring[T any] struct {
+	lock sync.RWMutex
+	mode RingMode
+	buf  []T
+	head int // index of the oldest element
+	size int
+}
+
+// NewRing returns a ring of @capacity elements
+func NewRing[T any](capacity int, mode RingMode) *Ring[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Ring[T]{
+		mode: mode,
+		buf:  make([]T, capacity),
+	}
+}
+
+// Push appends @v as the newest element
+func (r *Ring[T]) Push(v T) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.size == len(r.buf) {
+		if r.mode == RingReject {
+			return ErrRingFull
+		}
+		r.buf[r.head] = v
+		r.head = (r.head + 1) % len(r.buf)
+		return nil
+	}
+
+	r.buf[(r.head+r.size)%len(r.buf)] = v
+	r.size++
+	return nil
+}
+
+// Pop removes and returns the oldest element
+func (r *Ring[T]) Pop() (T, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+	v := r.buf[r.head]
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+	return v, true
+}
+
+// Peek returns the newest element
+func (r *Ring[T]) Peek() (T, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.size == 0 {
+		var zero T
+		return zero, false
+	}
+	return r.buf[(r.head+r.size-1)%len(r.buf)], true
+}
+
+// Len returns the number of elements
+func (r *Ring[T]) Len() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.size
+}
+
+// Cap returns the capacity
+func (r *Ring[T]) Cap() int {
+	return len(r.buf)
+}
+
+// Reset removes all the elements
+func (r *Ring[T]) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var zero T
+	for i := range r.buf {
+		r.buf[i] = zero
+	}
+	r.head, r.size = 0, 0
+}
+
+// Snapshot appends the elements from the oldest to the newest to @dst and returns
+// the result, pass a reused @dst to avoid allocation
+func (r *Ring[T]) Snapshot(dst []T) []T {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for i := 0; i < r.size; i++ {
+		dst = append(dst, r.buf[(r.head+i)%len(r.buf)])
+	}
+	return dst
+}
+
+// Range calls @fn for the elements from the oldest to the newest until @fn returns false.
+// NOTICE: the ring is locked while ranging, @fn must not modify the ring.
+func (r *Ring[T]) Range(fn func(v T) bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for i := 0; i < r.size; i++ {
+		if !fn(r.buf[(r.head+i)%len(r.buf)]) {
+			return
+		}
+	}
+}
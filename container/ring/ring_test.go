@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxring
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingOverwrite(t *testing.T) {
+	r := NewRing[int](3, RingOverwrite)
+	assert.Equal(t, 3, r.Cap())
+	_, ok := r.Peek()
+	assert.False(t, ok)
+
+	for i := 1; i <= 5; i++ {
+		assert.Nil(t, r.Push(i))
+	}
+	assert.Equal(t, 3, r.Len())
+	assert.Equal(t, []int{3, 4, 5}, r.Snapshot(nil))
+	v, ok := r.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+
+	var got []int
+	r.Range(func(v int) bool {
+		got = append(got, v)
+		return v < 4
+	})
+	assert.Equal(t, []int{3, 4}, got)
+
+	v, ok = r.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	assert.Nil(t, r.Push(6))
+	assert.Equal(t, []int{4, 5, 6}, r.Snapshot(nil))
+
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+	_, ok = r.Pop()
+	assert.False(t, ok)
+}
+
+func TestRingReject(t *testing.T) {
+	r := NewRing[string](2, RingReject)
+	assert.Nil(t, r.Push("a"))
+	assert.Nil(t, r.Push("b"))
+	assert.Equal(t, ErrRingFull, r.Push("c"))
+	assert.Equal(t, []string{"a", "b"}, r.Snapshot(nil))
+}
+
+func TestRingZeroAlloc(t *testing.T) {
+	r := NewRing[int](16, RingOverwrite)
+	dst := make([]int, 0, 16)
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < 32; i++ {
+			_ = r.Push(i)
+		}
+		dst = r.Snapshot(dst[:0])
+	})
+	assert.Equal(t, float64(0), allocs)
+}
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package gxselector implements selectors which pick items from a collection
+package gxselector
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+)
+
+var (
+	// ErrIndexOutOfRange is returned when the index of an item does not exist
+	ErrIndexOutOfRange = errors.New("selector: index out of range")
+	// ErrNegativeWeight is returned when a weight is negative
+	ErrNegativeWeight = errors.New("selector: negative weight")
+)
+
+// WeightedSelector picks items randomly in proportion to their weights.
+// Picks and weight updates are both O(log n) by a Fenwick tree of the weights.
+// It is safe for concurrent use.
+type WeightedSelector[T any] struct {
+	lock    sync.RWMutex
+	items   []T
+	weights []int64
+	tree    []int64 // Fenwick tree, 1-based
+	total   int64
+}
+
+// NewWeightedSelector returns an empty weighted selector
+func NewWeightedSelector[T any]() *WeightedSelector[T] {
+	return &WeightedSelector[T]{
+		tree: make([]int64, 1),
+	}
+}
+
+// Add adds @item with @weight and returns its index
+func (s *WeightedSelector[T]) Add(item T, weight int64) (int, error) {
+	if weight < 0 {
+		return -1, ErrNegativeWeight
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.items = append(s.items, item)
+	s.weights = append(s.weights, 0)
+	// the new node of the Fenwick tree covers the range (i - lowbit(i), i]
+	i := len(s.items)
+	var sum int64
+	for j := i - 1; j > i-(i&-i); j -= j & -j {
+		sum += s.tree[j]
+	}
+	s.tree = append(s.tree, sum)
+	s.update(i-1, weight)
+	return i - 1, nil
+}
+
+// Update changes the weight of the item at @index, a zero weight means the item
+// will never be picked
+func (s *WeightedSelector[T]) Update(index int, weight int64) error {
+	if weight < 0 {
+		return ErrNegativeWeight
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if index < 0 || index >= len(s.items) {
+		return ErrIndexOutOfRange
+	}
+	s.update(index, weight)
+	return nil
+}
+
+// NOTICE: need to get the lock before calling this method
+func (s *WeightedSelector[T]) update(index int, weight int64) {
+	delta := weight - s.weights[index]
+	s.weights[index] = weight
+	s.total += delta
+	for i := index + 1; i < len(s.tree); i += i & -i {
+		s.tree[i] += delta
+	}
+}
+
+// Pick returns a random item in proportion to the weights, false if the total weight is zero
+func (s *WeightedSelector[T]) Pick() (T, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	index := s.pickIndex(rand.Int63n)
+	if index < 0 {
+		var zero T
+		return zero, false
+	}
+	return s.items[index], true
+}
+
+// PickIndex returns the index of a random item in proportion to the weights,
+// -1 if the total weight is zero
+func (s *WeightedSelector[T]) PickIndex() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.pickIndex(rand.Int63n)
+}
+
+// NOTICE: need to get the lock before calling this method
+func (s *WeightedSelector[T]) pickIndex(int63n func(int64) int64) int {
+	if s.total <= 0 {
+		return -1
+	}
+
+	// find the first index whose prefix sum is greater than r
+	r := int64(int63n(s.total))
+	pos := 0
+	step := 1
+	for step*2 < len(s.tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next < len(s.tree) && s.tree[next] <= r {
+			pos = next
+			r -= s.tree[next]
+		}
+	}
+	return pos
+}
+
+// Get returns the item and weight at @index
+func (s *WeightedSelector[T]) Get(index int) (T, int64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if index < 0 || index >= len(s.items) {
+		var zero T
+		return zero, 0, ErrIndexOutOfRange
+	}
+	return s.items[index], s.weights[index], nil
+}
+
+// Len returns the number of items
+func (s *WeightedSelector[T]) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.items)
+}
+
+// Total returns the total weight
+func (s *WeightedSelector[T]) Total() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.total
+}
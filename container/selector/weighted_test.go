@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxselector
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedSelectorPickIndex(t *testing.T) {
+	s := NewWeightedSelector[string]()
+	_, ok := s.Pick()
+	assert.False(t, ok)
+
+	weights := []int64{3, 0, 5, 1, 7, 2, 0, 4, 6}
+	for i, w := range weights {
+		index, err := s.Add(string(rune('a'+i)), w)
+		assert.Nil(t, err)
+		assert.Equal(t, i, index)
+	}
+	assert.Equal(t, int64(28), s.Total())
+
+	// every r in [0, total) maps to the item whose weight range covers it
+	check := func() {
+		var expected []int
+		for i, w := range weights {
+			for j := int64(0); j < w; j++ {
+				expected = append(expected, i)
+			}
+		}
+		for r, index := range expected {
+			got := s.pickIndex(func(int64) int64 { return int64(r) })
+			assert.Equal(t, index, got, "r %d", r)
+		}
+	}
+	check()
+
+	assert.Nil(t, s.Update(4, 0))
+	assert.Nil(t, s.Update(1, 2))
+	weights[4], weights[1] = 0, 2
+	check()
+
+	assert.Equal(t, ErrIndexOutOfRange, s.Update(100, 1))
+	assert.Equal(t, ErrNegativeWeight, s.Update(0, -1))
+	item, w, err := s.Get(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", item)
+	assert.Equal(t, int64(2), w)
+}
+
+func TestWeightedSelectorDistribution(t *testing.T) {
+	s := NewWeightedSelector[int]()
+	_, _ = s.Add(0, 1)
+	_, _ = s.Add(1, 9)
+
+	counts := make([]int, 2)
+	for i := 0; i < 10000; i++ {
+		v, ok := s.Pick()
+		assert.True(t, ok)
+		counts[v]++
+	}
+	assert.InDelta(t, 1000, counts[0], 300)
+
+	assert.Nil(t, s.Update(1, 0))
+	for i := 0; i < 100; i++ {
+		v, _ := s.Pick()
+		assert.Equal(t, 0, v)
+	}
+}
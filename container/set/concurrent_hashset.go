@@ -0,0 +1,296 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShards default number of the shards of ConcurrentHashSet
+const DefaultShards = 32
+
+// shard is a copy on write part of ConcurrentHashSet, the readers load its
+// items without lock, and the writers replace them under the lock
+type shard[T comparable] struct {
+	lock  sync.Mutex
+	items atomic.Value // Set[T], which is never changed after stored
+}
+
+func (s *shard[T]) load() Set[T] {
+	return s.items.Load().(Set[T])
+}
+
+// update replaces the items by the copy changed by @fn under the lock
+func (s *shard[T]) update(fn func(items Set[T]) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	items := s.load()
+	copied := make(Set[T], len(items))
+	for item := range items {
+		copied[item] = itemExists
+	}
+	if fn(copied) {
+		s.items.Store(copied)
+	}
+}
+
+// ConcurrentHashSet is a set safe for concurrent use. The items are sharded by
+// their hashes, Contains reads the shards without lock, and a write copies the
+// shard it changes, which costs O(n/shards) for a set of n items, so it suits
+// the sets read much more than written. A set written often should have more
+// shards, or be a Set guarded by a mutex.
+type ConcurrentHashSet[T comparable] struct {
+	shards []*shard[T]
+	hash   func(T) uint64
+}
+
+// NewConcurrentHashSet creates a set of @shards shards, DefaultShards if it is
+// not positive. @hash hashes the items, and the equal items must have the same
+// hash. The default one hashes the strings and numbers directly and the others
+// by reflection, eg: the pointers by their addresses, so a custom one is
+// faster for the structs.
+func NewConcurrentHashSet[T comparable](shards int, hash func(T) uint64) *ConcurrentHashSet[T] {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	if hash == nil {
+		hash = hashOf[T]
+	}
+	set := &ConcurrentHashSet[T]{shards: make([]*shard[T], shards), hash: hash}
+	for i := range set.shards {
+		set.shards[i] = &shard[T]{}
+		set.shards[i].items.Store(make(Set[T]))
+	}
+	return set
+}
+
+func (set *ConcurrentHashSet[T]) shardOf(item T) *shard[T] {
+	return set.shards[set.hash(item)%uint64(len(set.shards))]
+}
+
+// group groups @items by their shards
+func (set *ConcurrentHashSet[T]) group(items []T) map[*shard[T]][]T {
+	groups := make(map[*shard[T]][]T)
+	for _, item := range items {
+		s := set.shardOf(item)
+		groups[s] = append(groups[s], item)
+	}
+	return groups
+}
+
+// Add adds @items to the set
+func (set *ConcurrentHashSet[T]) Add(items ...T) {
+	for s, group := range set.group(items) {
+		if s.load().Contains(group...) {
+			continue
+		}
+		s.update(func(copied Set[T]) bool {
+			copied.Add(group...)
+			return true
+		})
+	}
+}
+
+// Remove removes @items from the set
+func (set *ConcurrentHashSet[T]) Remove(items ...T) {
+	for s, group := range set.group(items) {
+		s.update(func(copied Set[T]) bool {
+			size := len(copied)
+			copied.Remove(group...)
+			return len(copied) != size
+		})
+	}
+}
+
+// Contains returns whether the set contains all @items without lock
+func (set *ConcurrentHashSet[T]) Contains(items ...T) bool {
+	for _, item := range items {
+		if _, ok := set.shardOf(item).load()[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of the items, which is not a snapshot if the set is
+// written concurrently
+func (set *ConcurrentHashSet[T]) Size() int {
+	size := 0
+	for _, s := range set.shards {
+		size += len(s.load())
+	}
+	return size
+}
+
+// Empty returns whether the set is empty
+func (set *ConcurrentHashSet[T]) Empty() bool {
+	return set.Size() == 0
+}
+
+// Clear removes all items
+func (set *ConcurrentHashSet[T]) Clear() {
+	for _, s := range set.shards {
+		s.lock.Lock()
+		s.items.Store(make(Set[T]))
+		s.lock.Unlock()
+	}
+}
+
+// Values returns the items in no particular order
+func (set *ConcurrentHashSet[T]) Values() []T {
+	values := make([]T, 0, set.Size())
+	for _, s := range set.shards {
+		values = append(values, s.load().Values()...)
+	}
+	return values
+}
+
+// Iterate calls @fn with every item in no particular order until it returns
+// false, the set can be written by @fn
+func (set *ConcurrentHashSet[T]) Iterate(fn func(item T) bool) {
+	for _, s := range set.shards {
+		for item := range s.load() {
+			if !fn(item) {
+				return
+			}
+		}
+	}
+}
+
+func (set *ConcurrentHashSet[T]) String() string {
+	items := make([]string, 0)
+	set.Iterate(func(item T) bool {
+		items = append(items, fmt.Sprintf("%v", item))
+		return true
+	})
+	return "ConcurrentHashSet\n" + strings.Join(items, ", ")
+}
+
+// hashOf hashes @item, the strings and numbers are hashed directly and the
+// others by hashValue
+func hashOf[T comparable](item T) uint64 {
+	switch v := any(item).(type) {
+	case string:
+		return hashString(v)
+	case int:
+		return mix(uint64(v))
+	case int8:
+		return mix(uint64(v))
+	case int16:
+		return mix(uint64(v))
+	case int32:
+		return mix(uint64(v))
+	case int64:
+		return mix(uint64(v))
+	case uint:
+		return mix(uint64(v))
+	case uint8:
+		return mix(uint64(v))
+	case uint16:
+		return mix(uint64(v))
+	case uint32:
+		return mix(uint64(v))
+	case uint64:
+		return mix(v)
+	case uintptr:
+		return mix(uint64(v))
+	case float32:
+		return hashFloat(float64(v))
+	case float64:
+		return hashFloat(v)
+	}
+	return hashValue(reflect.ValueOf(&item).Elem())
+}
+
+// hashValue hashes @v consistently with ==, eg: the pointers by their addresses
+// and the interfaces by their dynamic values
+func hashValue(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.String:
+		return hashString(v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			return mix(1)
+		}
+		return mix(0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return mix(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return mix(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return hashFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return mix(hashFloat(real(c)) ^ hashFloat(imag(c)) + 1)
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return mix(uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return hashValue(v.Elem())
+	case reflect.Array:
+		h := uint64(0)
+		for i := 0; i < v.Len(); i++ {
+			h = mix(h*31 + hashValue(v.Index(i)))
+		}
+		return h
+	case reflect.Struct:
+		h := uint64(0)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name == "_" {
+				// the blank fields are not compared
+				continue
+			}
+			h = mix(h*31 + hashValue(v.Field(i)))
+		}
+		return h
+	}
+	// the values of the other kinds are not comparable
+	return 0
+}
+
+// hashFloat hashes @f, -0 and 0 have the same hash since they are equal
+func hashFloat(f float64) uint64 {
+	if f == 0 {
+		return mix(0)
+	}
+	return mix(math.Float64bits(f))
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix spreads the bits of @x, which is the finalizer of splitmix64
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentHashSet(t *testing.T) {
+	set := NewConcurrentHashSet[int](4, nil)
+	set.Add(3, 1, 2, 2)
+	assert.Equal(t, 3, set.Size())
+	assert.True(t, set.Contains(1, 2, 3))
+	assert.False(t, set.Contains(1, 4))
+
+	set.Remove(3, 4)
+	values := set.Values()
+	sort.Ints(values)
+	assert.Equal(t, []int{1, 2}, values)
+
+	// the set can be written while iterating
+	set.Iterate(func(item int) bool {
+		set.Remove(item)
+		return true
+	})
+	assert.True(t, set.Empty())
+
+	set.Add(1)
+	set.Clear()
+	assert.Equal(t, "ConcurrentHashSet\n", set.String())
+}
+
+func TestConcurrentHashSetConcurrency(t *testing.T) {
+	set := NewConcurrentHashSet[string](0, nil)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				item := strconv.Itoa(w*100 + i)
+				set.Add(item)
+				assert.True(t, set.Contains(item))
+				if i%2 == 0 {
+					set.Remove(item)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	assert.Equal(t, 400, set.Size())
+}
+
+func TestConcurrentHashSetHash(t *testing.T) {
+	type key struct {
+		a string
+		b int
+	}
+	set := NewConcurrentHashSet[key](0, nil)
+	set.Add(key{"a", 1}, key{"b", 2})
+	assert.True(t, set.Contains(key{"a", 1}))
+	assert.False(t, set.Contains(key{"a", 2}))
+
+	// all items are in one shard with a constant hash
+	set = NewConcurrentHashSet[key](8, func(key) uint64 {
+		return 7
+	})
+	set.Add(key{"a", 1}, key{"b", 2})
+	assert.Equal(t, 2, len(set.shards[7].load()))
+}
+
+func TestConcurrentHashSetHashPointer(t *testing.T) {
+	type node struct {
+		name string
+	}
+	a, b := &node{"a"}, &node{"a"}
+	set := NewConcurrentHashSet[*node](64, nil)
+	set.Add(a, b)
+	assert.Equal(t, 2, set.Size())
+	assert.True(t, set.Contains(a, b))
+	assert.False(t, set.Contains(&node{"a"}))
+
+	// the pointers are hashed by their addresses, not by their contents
+	a.name = "changed"
+	assert.True(t, set.Contains(a))
+	set.Remove(a)
+	assert.False(t, set.Contains(a))
+	assert.True(t, set.Contains(b))
+
+	type key struct {
+		n    *node
+		item any
+	}
+	keys := NewConcurrentHashSet[key](64, nil)
+	keys.Add(key{b, 1}, key{b, b}, key{})
+	assert.True(t, keys.Contains(key{b, 1}, key{b, b}, key{}))
+	assert.False(t, keys.Contains(key{&node{"a"}, 1}))
+}
+
+func TestConcurrentHashSetHashFloat(t *testing.T) {
+	negativeZero := math.Copysign(0, -1)
+	set := NewConcurrentHashSet[float64](64, nil)
+	set.Add(0)
+	assert.True(t, set.Contains(negativeZero))
+	set.Add(negativeZero)
+	assert.Equal(t, 1, set.Size())
+
+	type point struct {
+		x, y float64
+	}
+	points := NewConcurrentHashSet[point](64, nil)
+	points.Add(point{negativeZero, 1})
+	assert.True(t, points.Contains(point{0, 1}))
+	assert.Equal(t, hashOf(point{0, 1}), hashOf(point{negativeZero, 1}))
+	assert.Equal(t, hashOf(complex(0, 1)), hashOf(complex(negativeZero, 1)))
+}
+
+func BenchmarkConcurrentHashSetContains(b *testing.B) {
+	set := NewConcurrentHashSet[int](0, nil)
+	for n := 0; n < 1000; n++ {
+		set.Add(n)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := 0
+		for pb.Next() {
+			set.Contains(n % 1000)
+			n++
+		}
+	})
+}
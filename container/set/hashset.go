@@ -24,6 +24,9 @@ import (
 
 var itemExists = struct{}{}
 
+// HashSet is the set of interface{} items. Set is preferred for the items of
+// a comparable type, HashSet can not wrap Set[interface{}] until the module
+// requires go1.20, in which interface{} satisfies comparable.
 type HashSet struct {
 	Items map[interface{}]struct{}
 }
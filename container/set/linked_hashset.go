@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"fmt"
+	"strings"
+)
+
+type linkedNode[T comparable] struct {
+	item       T
+	prev, next *linkedNode[T]
+}
+
+// LinkedHashSet is a set iterated in the order of insertion, like the
+// LinkedHashSet of Java. Adding an existing item keeps its position. It is not
+// safe for concurrent use.
+type LinkedHashSet[T comparable] struct {
+	items map[T]*linkedNode[T]
+	head  *linkedNode[T]
+	tail  *linkedNode[T]
+}
+
+// NewLinkedHashSet creates a linked hash set of @values in order
+func NewLinkedHashSet[T comparable](values ...T) *LinkedHashSet[T] {
+	set := &LinkedHashSet[T]{items: make(map[T]*linkedNode[T], len(values))}
+	set.Add(values...)
+	return set
+}
+
+// Add appends @items which are not in the set yet
+func (set *LinkedHashSet[T]) Add(items ...T) {
+	for _, item := range items {
+		if _, ok := set.items[item]; ok {
+			continue
+		}
+		node := &linkedNode[T]{item: item, prev: set.tail}
+		if set.tail == nil {
+			set.head = node
+		} else {
+			set.tail.next = node
+		}
+		set.tail = node
+		set.items[item] = node
+	}
+}
+
+// Remove removes @items from the set
+func (set *LinkedHashSet[T]) Remove(items ...T) {
+	for _, item := range items {
+		node, ok := set.items[item]
+		if !ok {
+			continue
+		}
+		if node.prev == nil {
+			set.head = node.next
+		} else {
+			node.prev.next = node.next
+		}
+		if node.next == nil {
+			set.tail = node.prev
+		} else {
+			node.next.prev = node.prev
+		}
+		delete(set.items, item)
+	}
+}
+
+// Contains returns whether the set contains all @items
+func (set *LinkedHashSet[T]) Contains(items ...T) bool {
+	for _, item := range items {
+		if _, ok := set.items[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns whether the set is empty
+func (set *LinkedHashSet[T]) Empty() bool {
+	return len(set.items) == 0
+}
+
+// Size returns the number of the items
+func (set *LinkedHashSet[T]) Size() int {
+	return len(set.items)
+}
+
+// Clear removes all items
+func (set *LinkedHashSet[T]) Clear() {
+	set.items = make(map[T]*linkedNode[T])
+	set.head = nil
+	set.tail = nil
+}
+
+// Values returns the items in the order of insertion
+func (set *LinkedHashSet[T]) Values() []T {
+	values := make([]T, 0, len(set.items))
+	for node := set.head; node != nil; node = node.next {
+		values = append(values, node.item)
+	}
+	return values
+}
+
+// Iterate calls @fn with every item in the order of insertion until it returns
+// false, the set must not be written by @fn
+func (set *LinkedHashSet[T]) Iterate(fn func(item T) bool) {
+	for node := set.head; node != nil; node = node.next {
+		if !fn(node.item) {
+			return
+		}
+	}
+}
+
+func (set *LinkedHashSet[T]) String() string {
+	items := make([]string, 0, len(set.items))
+	for node := set.head; node != nil; node = node.next {
+		items = append(items, fmt.Sprintf("%v", node.item))
+	}
+	return "LinkedHashSet\n" + strings.Join(items, ", ")
+}
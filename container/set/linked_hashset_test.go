@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkedHashSet(t *testing.T) {
+	s := NewLinkedHashSet("echo", "token", "tps", "token", "generic")
+	assert.Equal(t, 4, s.Size())
+	assert.Equal(t, []string{"echo", "token", "tps", "generic"}, s.Values())
+	assert.True(t, s.Contains("echo", "generic"))
+	assert.False(t, s.Contains("echo", "auth"))
+
+	s.Add("echo", "auth")
+	assert.Equal(t, []string{"echo", "token", "tps", "generic", "auth"}, s.Values())
+
+	s.Remove("echo", "tps", "auth", "missing")
+	assert.Equal(t, []string{"token", "generic"}, s.Values())
+
+	s.Add("echo")
+	assert.Equal(t, []string{"token", "generic", "echo"}, s.Values())
+
+	var items []string
+	s.Iterate(func(item string) bool {
+		items = append(items, item)
+		return len(items) < 2
+	})
+	assert.Equal(t, []string{"token", "generic"}, items)
+	assert.Equal(t, "LinkedHashSet\ntoken, generic, echo", s.String())
+
+	s.Remove("token", "generic", "echo")
+	assert.True(t, s.Empty())
+	assert.Empty(t, s.Values())
+
+	s.Add("a")
+	s.Clear()
+	assert.True(t, s.Empty())
+	s.Add("b")
+	assert.Equal(t, []string{"b"}, s.Values())
+}
@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set is a set of the comparable items, it is not safe for concurrent use
+type Set[T comparable] map[T]struct{}
+
+// New creates a set of @values
+func New[T comparable](values ...T) Set[T] {
+	set := make(Set[T], len(values))
+	set.Add(values...)
+	return set
+}
+
+// Add adds @items to the set
+func (set Set[T]) Add(items ...T) {
+	for _, item := range items {
+		set[item] = itemExists
+	}
+}
+
+// Remove removes @items from the set
+func (set Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(set, item)
+	}
+}
+
+// Contains returns whether the set contains all @items
+func (set Set[T]) Contains(items ...T) bool {
+	for _, item := range items {
+		if _, contains := set[item]; !contains {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns whether the set is empty
+func (set Set[T]) Empty() bool {
+	return len(set) == 0
+}
+
+// Size returns the number of the items
+func (set Set[T]) Size() int {
+	return len(set)
+}
+
+// Clear removes all items
+func (set Set[T]) Clear() {
+	for item := range set {
+		delete(set, item)
+	}
+}
+
+// Values returns the items in no particular order
+func (set Set[T]) Values() []T {
+	values := make([]T, 0, len(set))
+	for item := range set {
+		values = append(values, item)
+	}
+	return values
+}
+
+// Iterate calls @fn with every item in no particular order until it returns false
+func (set Set[T]) Iterate(fn func(item T) bool) {
+	for item := range set {
+		if !fn(item) {
+			return
+		}
+	}
+}
+
+// Union returns a new set of the items in the set or @other
+func (set Set[T]) Union(other Set[T]) Set[T] {
+	union := make(Set[T], len(set)+len(other))
+	for item := range set {
+		union[item] = itemExists
+	}
+	for item := range other {
+		union[item] = itemExists
+	}
+	return union
+}
+
+// Intersect returns a new set of the items in both the set and @other
+func (set Set[T]) Intersect(other Set[T]) Set[T] {
+	small, large := set, other
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	intersection := make(Set[T])
+	for item := range small {
+		if _, ok := large[item]; ok {
+			intersection[item] = itemExists
+		}
+	}
+	return intersection
+}
+
+// Difference returns a new set of the items in the set but not in @other
+func (set Set[T]) Difference(other Set[T]) Set[T] {
+	difference := make(Set[T])
+	for item := range set {
+		if _, ok := other[item]; !ok {
+			difference[item] = itemExists
+		}
+	}
+	return difference
+}
+
+func (set Set[T]) String() string {
+	items := make([]string, 0, len(set))
+	for item := range set {
+		items = append(items, fmt.Sprintf("%v", item))
+	}
+	return "Set\n" + strings.Join(items, ", ")
+}
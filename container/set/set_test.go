@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"sort"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func sorted(set Set[int]) []int {
+	values := set.Values()
+	sort.Ints(values)
+	return values
+}
+
+func TestSet(t *testing.T) {
+	set := New(3, 1, 2, 2)
+	assert.Equal(t, 3, set.Size())
+	assert.True(t, set.Contains(1, 2, 3))
+	assert.False(t, set.Contains(1, 4))
+	assert.True(t, set.Contains())
+
+	set.Remove(3, 4)
+	assert.Equal(t, []int{1, 2}, sorted(set))
+	set.Add(5)
+	assert.Equal(t, []int{1, 2, 5}, sorted(set))
+
+	sum := 0
+	set.Iterate(func(item int) bool {
+		sum += item
+		return true
+	})
+	assert.Equal(t, 8, sum)
+	count := 0
+	set.Iterate(func(int) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+
+	set.Clear()
+	assert.True(t, set.Empty())
+	assert.Equal(t, "Set\n", set.String())
+}
+
+func TestSetOperations(t *testing.T) {
+	a, b := New(1, 2, 3), New(2, 3, 4)
+	assert.Equal(t, []int{1, 2, 3, 4}, sorted(a.Union(b)))
+	assert.Equal(t, []int{2, 3}, sorted(a.Intersect(b)))
+	assert.Equal(t, []int{1}, sorted(a.Difference(b)))
+	assert.Equal(t, []int{4}, sorted(b.Difference(a)))
+	assert.Empty(t, a.Intersect(New[int]()))
+
+	// the operands are not changed
+	assert.Equal(t, []int{1, 2, 3}, sorted(a))
+	assert.Equal(t, []int{2, 3, 4}, sorted(b))
+}
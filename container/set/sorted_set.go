@@ -0,0 +1,263 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	// maxLevel max level of the skip list, which suits 2^32 items
+	maxLevel = 32
+	// levelProbability probability of an item rising to the next level
+	levelProbability = 0.25
+)
+
+// Ordered is the types ordered by the operator <
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+type skipNode[T any] struct {
+	item T
+	next []*skipNode[T]
+}
+
+// SortedSet is a set iterated in order, which is backed by a skip list. The
+// lookups, insertions and deletions take O(log n) expected time. It is not safe
+// for concurrent use.
+type SortedSet[T any] struct {
+	less  func(a, b T) bool
+	head  *skipNode[T]
+	level int
+	size  int
+	rand  *rand.Rand
+}
+
+// NewSortedSet creates a sorted set of @values in ascending order
+func NewSortedSet[T Ordered](values ...T) *SortedSet[T] {
+	set := NewSortedSetFunc(func(a, b T) bool {
+		return a < b
+	})
+	set.Add(values...)
+	return set
+}
+
+// NewSortedSetFunc creates a sorted set in the order of @less, the items
+// neither less than the other are the same item
+func NewSortedSetFunc[T any](less func(a, b T) bool) *SortedSet[T] {
+	return &SortedSet[T]{
+		less:  less,
+		head:  &skipNode[T]{next: make([]*skipNode[T], maxLevel)},
+		level: 1,
+		rand:  rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (set *SortedSet[T]) randomLevel() int {
+	level := 1
+	for level < maxLevel && set.rand.Float64() < levelProbability {
+		level++
+	}
+	return level
+}
+
+// search returns the last nodes before @item at every level
+func (set *SortedSet[T]) search(item T) []*skipNode[T] {
+	prev := make([]*skipNode[T], maxLevel)
+	node := set.head
+	for i := set.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && set.less(node.next[i].item, item) {
+			node = node.next[i]
+		}
+		prev[i] = node
+	}
+	return prev
+}
+
+// lowerBound returns the first node not less than @item
+func (set *SortedSet[T]) lowerBound(item T) *skipNode[T] {
+	node := set.head
+	for i := set.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && set.less(node.next[i].item, item) {
+			node = node.next[i]
+		}
+	}
+	return node.next[0]
+}
+
+func (set *SortedSet[T]) equal(node *skipNode[T], item T) bool {
+	return node != nil && !set.less(item, node.item)
+}
+
+// Add adds @items to the set
+func (set *SortedSet[T]) Add(items ...T) {
+	for _, item := range items {
+		prev := set.search(item)
+		if set.equal(prev[0].next[0], item) {
+			continue
+		}
+
+		level := set.randomLevel()
+		if level > set.level {
+			for i := set.level; i < level; i++ {
+				prev[i] = set.head
+			}
+			set.level = level
+		}
+		node := &skipNode[T]{item: item, next: make([]*skipNode[T], level)}
+		for i := 0; i < level; i++ {
+			node.next[i] = prev[i].next[i]
+			prev[i].next[i] = node
+		}
+		set.size++
+	}
+}
+
+// Remove removes @items from the set
+func (set *SortedSet[T]) Remove(items ...T) {
+	for _, item := range items {
+		prev := set.search(item)
+		node := prev[0].next[0]
+		if !set.equal(node, item) {
+			continue
+		}
+		for i := 0; i < len(node.next); i++ {
+			prev[i].next[i] = node.next[i]
+		}
+		for set.level > 1 && set.head.next[set.level-1] == nil {
+			set.level--
+		}
+		set.size--
+	}
+}
+
+// Contains returns whether the set contains all @items
+func (set *SortedSet[T]) Contains(items ...T) bool {
+	for _, item := range items {
+		if !set.equal(set.lowerBound(item), item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns whether the set is empty
+func (set *SortedSet[T]) Empty() bool {
+	return set.size == 0
+}
+
+// Size returns the number of the items
+func (set *SortedSet[T]) Size() int {
+	return set.size
+}
+
+// Clear removes all items
+func (set *SortedSet[T]) Clear() {
+	set.head = &skipNode[T]{next: make([]*skipNode[T], maxLevel)}
+	set.level = 1
+	set.size = 0
+}
+
+// Values returns the items in order
+func (set *SortedSet[T]) Values() []T {
+	values := make([]T, 0, set.size)
+	for node := set.head.next[0]; node != nil; node = node.next[0] {
+		values = append(values, node.item)
+	}
+	return values
+}
+
+// Min returns the least item, it returns false if the set is empty
+func (set *SortedSet[T]) Min() (T, bool) {
+	if node := set.head.next[0]; node != nil {
+		return node.item, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Max returns the greatest item, it returns false if the set is empty
+func (set *SortedSet[T]) Max() (T, bool) {
+	node := set.head
+	for i := set.level - 1; i >= 0; i-- {
+		for node.next[i] != nil {
+			node = node.next[i]
+		}
+	}
+	if node == set.head {
+		var zero T
+		return zero, false
+	}
+	return node.item, true
+}
+
+// Floor returns the greatest item not greater than @item, it returns false if there is none
+func (set *SortedSet[T]) Floor(item T) (T, bool) {
+	node := set.head
+	for i := set.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && !set.less(item, node.next[i].item) {
+			node = node.next[i]
+		}
+	}
+	if node == set.head {
+		var zero T
+		return zero, false
+	}
+	return node.item, true
+}
+
+// Ceiling returns the least item not less than @item, it returns false if there is none
+func (set *SortedSet[T]) Ceiling(item T) (T, bool) {
+	if node := set.lowerBound(item); node != nil {
+		return node.item, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Range calls @fn with the items in [@from, @to) in order until it returns false
+func (set *SortedSet[T]) Range(from, to T, fn func(item T) bool) {
+	for node := set.lowerBound(from); node != nil && set.less(node.item, to); node = node.next[0] {
+		if !fn(node.item) {
+			return
+		}
+	}
+}
+
+// Iterate calls @fn with every item in order until it returns false, the set
+// must not be written by @fn
+func (set *SortedSet[T]) Iterate(fn func(item T) bool) {
+	for node := set.head.next[0]; node != nil; node = node.next[0] {
+		if !fn(node.item) {
+			return
+		}
+	}
+}
+
+func (set *SortedSet[T]) String() string {
+	items := make([]string, 0, set.size)
+	for node := set.head.next[0]; node != nil; node = node.next[0] {
+		items = append(items, fmt.Sprintf("%v", node.item))
+	}
+	return "SortedSet\n" + strings.Join(items, ", ")
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxset
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedSet(t *testing.T) {
+	s := NewSortedSet(5, 1, 3, 9, 7, 3)
+	assert.Equal(t, 5, s.Size())
+	assert.Equal(t, []int{1, 3, 5, 7, 9}, s.Values())
+	assert.True(t, s.Contains(1, 9))
+	assert.False(t, s.Contains(1, 2))
+
+	s.Remove(1, 2, 9)
+	assert.Equal(t, []int{3, 5, 7}, s.Values())
+
+	min, ok := s.Min()
+	assert.True(t, ok)
+	assert.Equal(t, 3, min)
+	max, ok := s.Max()
+	assert.True(t, ok)
+	assert.Equal(t, 7, max)
+
+	s.Clear()
+	assert.True(t, s.Empty())
+	_, ok = s.Min()
+	assert.False(t, ok)
+	_, ok = s.Max()
+	assert.False(t, ok)
+}
+
+func TestSortedSetFloorCeiling(t *testing.T) {
+	s := NewSortedSet(10, 20, 30)
+
+	cases := []struct {
+		item                 int
+		floor, ceiling       int
+		hasFloor, hasCeiling bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{15, 10, 20, true, true},
+		{30, 30, 30, true, true},
+		{35, 30, 0, true, false},
+	}
+	for _, c := range cases {
+		floor, ok := s.Floor(c.item)
+		assert.Equal(t, c.hasFloor, ok, "floor of %d", c.item)
+		assert.Equal(t, c.floor, floor, "floor of %d", c.item)
+		ceiling, ok := s.Ceiling(c.item)
+		assert.Equal(t, c.hasCeiling, ok, "ceiling of %d", c.item)
+		assert.Equal(t, c.ceiling, ceiling, "ceiling of %d", c.item)
+	}
+}
+
+func TestSortedSetRange(t *testing.T) {
+	s := NewSortedSet("a", "b", "c", "d", "e")
+
+	var items []string
+	s.Range("b", "e", func(item string) bool {
+		items = append(items, item)
+		return true
+	})
+	assert.Equal(t, []string{"b", "c", "d"}, items)
+
+	items = nil
+	s.Range("bb", "z", func(item string) bool {
+		items = append(items, item)
+		return len(items) < 2
+	})
+	assert.Equal(t, []string{"c", "d"}, items)
+
+	items = nil
+	s.Iterate(func(item string) bool {
+		items = append(items, item)
+		return true
+	})
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, items)
+}
+
+func TestSortedSetFunc(t *testing.T) {
+	type weighted struct {
+		name   string
+		weight int
+	}
+	s := NewSortedSetFunc(func(a, b weighted) bool {
+		return a.weight > b.weight
+	})
+	s.Add(weighted{"a", 1}, weighted{"b", 3}, weighted{"c", 2})
+
+	max, ok := s.Min()
+	assert.True(t, ok)
+	assert.Equal(t, "b", max.name)
+	assert.True(t, s.Contains(weighted{weight: 2}))
+}
+
+func TestSortedSetRandom(t *testing.T) {
+	s := NewSortedSet[int]()
+	expected := make(map[int]struct{})
+	for i := 0; i < 10000; i++ {
+		item := rand.Intn(1000)
+		if rand.Intn(3) == 0 {
+			s.Remove(item)
+			delete(expected, item)
+		} else {
+			s.Add(item)
+			expected[item] = struct{}{}
+		}
+	}
+
+	values := make([]int, 0, len(expected))
+	for item := range expected {
+		values = append(values, item)
+	}
+	sort.Ints(values)
+	assert.Equal(t, len(values), s.Size())
+	assert.Equal(t, values, s.Values())
+}
@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxslice
+
+// Iterator is a lazy sequence, the elements are produced and transformed one by
+// one on demand, so a large data set is not copied by the chained operations.
+// It is not safe for concurrent use.
+type Iterator[T any] struct {
+	next func() (T, bool)
+}
+
+// FromFunc returns the Iterator producing elements by @next until it returns false
+func FromFunc[T any](next func() (T, bool)) *Iterator[T] {
+	done := false
+	return &Iterator[T]{next: func() (T, bool) {
+		if !done {
+			if v, ok := next(); ok {
+				return v, true
+			}
+			done = true
+		}
+		var zero T
+		return zero, false
+	}}
+}
+
+// FromSlice returns the Iterator over the elements of @s
+func FromSlice[T any](s []T) *Iterator[T] {
+	i := 0
+	return FromFunc(func() (T, bool) {
+		if i >= len(s) {
+			var zero T
+			return zero, false
+		}
+		i++
+		return s[i-1], true
+	})
+}
+
+// FromChan returns the Iterator over the elements received from @ch until it is closed
+func FromChan[T any](ch <-chan T) *Iterator[T] {
+	return FromFunc(func() (T, bool) {
+		v, ok := <-ch
+		return v, ok
+	})
+}
+
+// Next returns the next element, or false if there is no more element
+func (it *Iterator[T]) Next() (T, bool) {
+	return it.next()
+}
+
+// Filter returns the Iterator over the elements satisfying @f
+func (it *Iterator[T]) Filter(f func(T) bool) *Iterator[T] {
+	return FromFunc(func() (T, bool) {
+		for {
+			v, ok := it.next()
+			if !ok || f(v) {
+				return v, ok
+			}
+		}
+	})
+}
+
+// Take returns the Iterator over the first @n elements
+func (it *Iterator[T]) Take(n int) *Iterator[T] {
+	return FromFunc(func() (T, bool) {
+		if n <= 0 {
+			var zero T
+			return zero, false
+		}
+		n--
+		return it.next()
+	})
+}
+
+// Skip returns the Iterator skipping the first @n elements
+func (it *Iterator[T]) Skip(n int) *Iterator[T] {
+	return FromFunc(func() (T, bool) {
+		for ; n > 0; n-- {
+			if _, ok := it.next(); !ok {
+				var zero T
+				return zero, false
+			}
+		}
+		return it.next()
+	})
+}
+
+// ForEach calls @f on the remaining elements until it returns false
+func (it *Iterator[T]) ForEach(f func(T) bool) {
+	for {
+		v, ok := it.next()
+		if !ok || !f(v) {
+			return
+		}
+	}
+}
+
+// Collect returns the remaining elements in a slice
+func (it *Iterator[T]) Collect() []T {
+	var s []T
+	it.ForEach(func(v T) bool {
+		s = append(s, v)
+		return true
+	})
+	return s
+}
+
+// MapIter returns the Iterator over the results of applying @f to the elements of @it
+func MapIter[T, U any](it *Iterator[T], f func(T) U) *Iterator[U] {
+	return FromFunc(func() (U, bool) {
+		v, ok := it.next()
+		if !ok {
+			var zero U
+			return zero, false
+		}
+		return f(v), true
+	})
+}
+
+// ChunkIter returns the Iterator over the chunks of @size elements of @it, the last
+// chunk may be smaller. It panics if @size is not positive.
+func ChunkIter[T any](it *Iterator[T], size int) *Iterator[[]T] {
+	if size <= 0 {
+		panic("gxslice: chunk size should be positive")
+	}
+	return FromFunc(func() ([]T, bool) {
+		var chunk []T
+		for len(chunk) < size {
+			v, ok := it.next()
+			if !ok {
+				break
+			}
+			chunk = append(chunk, v)
+		}
+		return chunk, len(chunk) > 0
+	})
+}
+
+// ReduceIter folds the remaining elements of @it into an accumulator beginning with @init
+func ReduceIter[T, A any](it *Iterator[T], init A, f func(acc A, v T) A) A {
+	acc := init
+	it.ForEach(func(v T) bool {
+		acc = f(acc, v)
+		return true
+	})
+	return acc
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxslice provides the functional utilities over generic slices, and
+// the lazy Iterator for the large data which should not be copied in one go.
+package gxslice
+
+// Map returns the results of applying @f to the elements of @s
+func Map[T, U any](s []T, f func(T) U) []U {
+	if s == nil {
+		return nil
+	}
+	r := make([]U, len(s))
+	for i, v := range s {
+		r[i] = f(v)
+	}
+	return r
+}
+
+// Filter returns the elements of @s satisfying @f in a new slice
+func Filter[T any](s []T, f func(T) bool) []T {
+	var r []T
+	for _, v := range s {
+		if f(v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// Reduce folds the elements of @s into an accumulator beginning with @init
+func Reduce[T, A any](s []T, init A, f func(acc A, v T) A) A {
+	acc := init
+	for _, v := range s {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// GroupBy groups the elements of @s by the key returned by @key, the elements
+// in a group keep their order in @s
+func GroupBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, v := range s {
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+	return groups
+}
+
+// Chunk splits @s into the chunks of @size elements, the last chunk may be smaller.
+// The chunks share the memory of @s. It panics if @size is not positive.
+func Chunk[T any](s []T, size int) [][]T {
+	if size <= 0 {
+		panic("gxslice: chunk size should be positive")
+	}
+	chunks := make([][]T, 0, (len(s)+size-1)/size)
+	for len(s) > size {
+		chunks = append(chunks, s[:size:size])
+		s = s[size:]
+	}
+	if len(s) > 0 {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+// Distinct returns the elements of @s without duplicates, keeping the first occurrences
+func Distinct[T comparable](s []T) []T {
+	return DistinctBy(s, func(v T) T { return v })
+}
+
+// DistinctBy returns the elements of @s whose keys returned by @key are distinct,
+// keeping the first occurrences
+func DistinctBy[T any, K comparable](s []T, key func(T) K) []T {
+	var r []T
+	seen := make(map[K]struct{}, len(s))
+	for _, v := range s {
+		k := key(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		r = append(r, v)
+	}
+	return r
+}
+
+// Partition splits @s into the elements satisfying @f and the others
+func Partition[T any](s []T, f func(T) bool) (matched, unmatched []T) {
+	for _, v := range s {
+		if f(v) {
+			matched = append(matched, v)
+		} else {
+			unmatched = append(unmatched, v)
+		}
+	}
+	return
+}
+
+// Any checks whether any element of @s satisfies @f
+func Any[T any](s []T, f func(T) bool) bool {
+	for _, v := range s {
+		if f(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// All checks whether all the elements of @s satisfy @f
+func All[T any](s []T, f func(T) bool) bool {
+	for _, v := range s {
+		if !f(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the first element of @s satisfying @f
+func Find[T any](s []T, f func(T) bool) (T, bool) {
+	for _, v := range s {
+		if f(v) {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// ToMap indexes the elements of @s by the key returned by @key, the later
+// elements overwrite the former ones with the same key
+func ToMap[T any, K comparable](s []T, key func(T) K) map[K]T {
+	m := make(map[K]T, len(s))
+	for _, v := range s {
+		m[key(v)] = v
+	}
+	return m
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxslice
+
+import (
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+type instance struct {
+	host string
+	zone string
+}
+
+func TestSlice(t *testing.T) {
+	s := []int{1, 2, 3, 4, 5}
+
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, Map(s, strconv.Itoa))
+	assert.Nil(t, Map[int, string](nil, strconv.Itoa))
+	isEven := func(v int) bool { return v%2 == 0 }
+	assert.Equal(t, []int{2, 4}, Filter(s, isEven))
+	assert.Equal(t, 15, Reduce(s, 0, func(acc, v int) int { return acc + v }))
+	even, odd := Partition(s, isEven)
+	assert.Equal(t, []int{2, 4}, even)
+	assert.Equal(t, []int{1, 3, 5}, odd)
+	assert.True(t, Any(s, isEven))
+	assert.False(t, All(s, isEven))
+	v, ok := Find(s, isEven)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	_, ok = Find(s, func(v int) bool { return v > 5 })
+	assert.False(t, ok)
+
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, Chunk(s, 2))
+	assert.Equal(t, [][]int{}, Chunk([]int{}, 2))
+	chunks := Chunk(s, 2)
+	chunks[0] = append(chunks[0], 10)
+	assert.Equal(t, 3, s[2], "appending to a chunk should not overwrite the next one")
+	assert.Panics(t, func() { Chunk(s, 0) })
+
+	assert.Equal(t, []int{3, 1, 2}, Distinct([]int{3, 1, 3, 2, 1}))
+}
+
+func TestGroupBy(t *testing.T) {
+	instances := []instance{{"a", "hz"}, {"b", "sh"}, {"c", "hz"}, {"a", "bj"}}
+	groups := GroupBy(instances, func(i instance) string { return i.zone })
+	assert.Equal(t, map[string][]instance{
+		"hz": {{"a", "hz"}, {"c", "hz"}},
+		"sh": {{"b", "sh"}},
+		"bj": {{"a", "bj"}},
+	}, groups)
+
+	host := func(i instance) string { return i.host }
+	assert.Equal(t, []instance{{"a", "hz"}, {"b", "sh"}, {"c", "hz"}}, DistinctBy(instances, host))
+	assert.Equal(t, instance{"a", "bj"}, ToMap(instances, host)["a"])
+}
+
+func TestIterator(t *testing.T) {
+	produced := 0
+	naturals := FromFunc(func() (int, bool) {
+		produced++
+		return produced, true
+	})
+
+	squares := MapIter(naturals.Filter(func(v int) bool { return v%2 == 1 }).Skip(1), func(v int) int { return v * v })
+	assert.Equal(t, []int{9, 25, 49}, squares.Take(3).Collect())
+	assert.Equal(t, 7, produced, "the elements should be produced lazily")
+
+	chunks := ChunkIter(FromSlice([]string{"a", "b", "c", "d", "e"}), 2).Collect()
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, chunks)
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+	it := FromChan(ch)
+	assert.Equal(t, 6, ReduceIter(it, 0, func(acc, v int) int { return acc + v }))
+	_, ok := it.Next()
+	assert.False(t, ok)
+
+	var visited []int
+	FromSlice([]int{1, 2, 3}).ForEach(func(v int) bool {
+		visited = append(visited, v)
+		return v < 2
+	})
+	assert.Equal(t, []int{1, 2}, visited)
+	assert.Nil(t, FromSlice([]int{1}).Skip(2).Collect())
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxcontext
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// mergedContext is cancelled when either of its parents is done,
+// and looks up values from the first parent then the second one.
+type mergedContext struct {
+	first  context.Context
+	second context.Context
+
+	lock sync.Mutex
+	done chan struct{}
+	err  error
+}
+
+// Merge returns a context which is cancelled when either @first or @second is done,
+// or when the returned cancel func is called. Its deadline is the earlier one
+// of the two parents, and its values come from @first then @second.
+func Merge(first, second context.Context) (context.Context, context.CancelFunc) {
+	mc := &mergedContext{
+		first:  first,
+		second: second,
+		done:   make(chan struct{}),
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-first.Done():
+			mc.cancel(first.Err())
+		case <-second.Done():
+			mc.cancel(second.Err())
+		case <-stop:
+		}
+	}()
+
+	var once sync.Once
+	return mc, func() {
+		once.Do(func() { close(stop) })
+		mc.cancel(context.Canceled)
+	}
+}
+
+func (c *mergedContext) cancel(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+func (c *mergedContext) Deadline() (time.Time, bool) {
+	d1, ok1 := c.first.Deadline()
+	d2, ok2 := c.second.Deadline()
+	switch {
+	case ok1 && ok2:
+		if d2.Before(d1) {
+			return d2, true
+		}
+		return d1, true
+	case ok1:
+		return d1, true
+	default:
+		return d2, ok2
+	}
+}
+
+func (c *mergedContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *mergedContext) Err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
+
+func (c *mergedContext) Value(key interface{}) interface{} {
+	if v := c.first.Value(key); v != nil {
+		return v
+	}
+	return c.second.Value(key)
+}
+
+// detachedContext keeps the values of its parent but never be done
+type detachedContext struct {
+	parent context.Context
+}
+
+// Detach returns a context which keeps the values of @ctx but drops its deadline
+// and cancellation. It is used to hand request-scoped values to pooled workers
+// and timers which outlive the request.
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// Key is a typed context key, which avoids key collisions and type assertions
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a typed context key
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the key name
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// WithValue returns a copy of @ctx in which the value of @k is @v
+func (k *Key[T]) WithValue(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// Value returns the value of @k in @ctx
+func (k *Key[T]) Value(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// ValueOr returns the value of @k in @ctx, or @def if it does not exist
+func (k *Key[T]) ValueOr(ctx context.Context, def T) T {
+	if v, ok := k.Value(ctx); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxcontext
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	userKey = NewKey[string]("user")
+	idKey   = NewKey[int]("id")
+)
+
+func TestMerge(t *testing.T) {
+	ctx1, cancel1 := context.WithCancel(userKey.WithValue(context.Background(), "alex"))
+	ctx2, cancel2 := context.WithTimeout(idKey.WithValue(context.Background(), 1), time.Hour)
+	defer cancel2()
+
+	ctx, cancel := Merge(ctx1, ctx2)
+	defer cancel()
+	assert.Equal(t, "alex", userKey.ValueOr(ctx, ""))
+	id, ok := idKey.Value(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 1, id)
+	_, ok = ctx.Deadline()
+	assert.True(t, ok)
+	assert.Nil(t, ctx.Err())
+
+	cancel1()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("merged context should be done")
+	}
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	ctx, cancel = Merge(context.Background(), context.Background())
+	cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithTimeout(userKey.WithValue(context.Background(), "alex"), time.Millisecond)
+	defer cancel()
+
+	ctx := Detach(parent)
+	<-parent.Done()
+	assert.Nil(t, ctx.Err())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, "alex", userKey.ValueOr(ctx, ""))
+	assert.Equal(t, 2, idKey.ValueOr(ctx, 2))
+}
@@ -0,0 +1,381 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxapollo provides a client of the apollo config service, which
+// caches the configs of the namespaces, keeps them updated by the long polling
+// notifications, and implements the read only gxkv client by them.
+package gxapollo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+var (
+	// ErrNoServer is returned when creating a client without server address
+	ErrNoServer = perrors.New("no apollo server address")
+	// ErrNoAppID is returned when creating a client without app id
+	ErrNoAppID = perrors.New("no apollo app id")
+	// ErrClientClosed is returned when using a closed client
+	ErrClientClosed = perrors.New("apollo client closed")
+)
+
+// namespace is the cached configs of a namespace
+type namespace struct {
+	configs        map[string]string
+	releaseKey     string
+	notificationID int64
+}
+
+// Client is a client of the apollo config servers, the requests fail over to
+// the next server when the current one is unavailable
+type Client struct {
+	servers    []string
+	current    int32 // index of the server which responds last
+	options    *Options
+	httpClient *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	lock       sync.RWMutex
+	namespaces map[string]*namespace
+	watchers   map[*watcher]struct{}
+}
+
+// NewClient creates a client of the config servers @addrs of the application
+// @appID, eg: "127.0.0.1:8080", and fetches the configs of its namespaces
+func NewClient(addrs []string, appID string, opts ...Option) (*Client, error) {
+	if len(addrs) == 0 {
+		return nil, ErrNoServer
+	}
+	if appID == "" {
+		return nil, ErrNoAppID
+	}
+
+	options := &Options{
+		AppID:           appID,
+		Cluster:         DefaultCluster,
+		Namespaces:      []string{DefaultNamespace},
+		Timeout:         DefaultTimeout,
+		RefreshInterval: DefaultRefreshInterval,
+		LongPollTimeout: defaultLongPollTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	servers := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "://") {
+			addr = "http://" + addr
+		}
+		servers = append(servers, strings.TrimSuffix(addr, "/"))
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		// the requests are bounded by their contexts, the long pollings last longer than Timeout
+		httpClient = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		servers:    servers,
+		options:    options,
+		httpClient: httpClient,
+		ctx:        ctx,
+		cancel:     cancel,
+		namespaces: make(map[string]*namespace, len(options.Namespaces)),
+		watchers:   make(map[*watcher]struct{}),
+	}
+	for _, ns := range options.Namespaces {
+		c.namespaces[ns] = &namespace{configs: make(map[string]string), notificationID: -1}
+		if err := c.fetch(ns); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	c.wg.Add(2)
+	go c.pollLoop()
+	go c.refreshLoop()
+	return c, nil
+}
+
+// Close stops polling and closes the watch chans
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.cancel()
+		c.wg.Wait()
+	})
+}
+
+func (c *Client) closed() bool {
+	return c.ctx.Err() != nil
+}
+
+// GetConfigs returns the configs of the namespace @ns
+func (c *Client) GetConfigs(ns string) map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	configs := make(map[string]string)
+	if n, ok := c.namespaces[ns]; ok {
+		for k, v := range n.configs {
+			configs[k] = v
+		}
+	}
+	return configs
+}
+
+// fetch fetches the configs of the namespace @ns if its release changes
+func (c *Client) fetch(ns string) error {
+	c.lock.RLock()
+	releaseKey := c.namespaces[ns].releaseKey
+	c.lock.RUnlock()
+
+	params := url.Values{"releaseKey": {releaseKey}}
+	if c.options.IP != "" {
+		params.Set("ip", c.options.IP)
+	}
+	path := "/configs/" + url.PathEscape(c.options.AppID) + "/" + url.PathEscape(c.options.Cluster) + "/" + url.PathEscape(ns)
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.options.Timeout)
+	defer cancel()
+	status, body, err := c.do(ctx, path, params)
+	if err != nil {
+		return perrors.WithMessagef(err, "fetch namespace %s", ns)
+	}
+
+	var result struct {
+		Configurations map[string]string `json:"configurations"`
+		ReleaseKey     string            `json:"releaseKey"`
+	}
+	switch status {
+	case http.StatusOK:
+		if err = json.Unmarshal(body, &result); err != nil {
+			return perrors.WithMessagef(err, "fetch namespace %s", ns)
+		}
+	case http.StatusNotModified:
+		return nil
+	case http.StatusNotFound:
+		// the namespace has not been released
+	default:
+		return perrors.Errorf("fetch namespace %s: status %d, %s", ns, status, body)
+	}
+	c.update(ns, result.Configurations, result.ReleaseKey)
+	return nil
+}
+
+// update replaces the configs of @ns and notifies the watchers of the changes
+func (c *Client) update(ns string, configs map[string]string, releaseKey string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n := c.namespaces[ns]
+	var events []gxkv.Event
+	for k, v := range configs {
+		if old, ok := n.configs[k]; !ok || old != v {
+			events = append(events, gxkv.Event{Type: gxkv.EventPut, Key: ns + keySeparator + k, Value: v, PrevValue: old})
+		}
+	}
+	for k, old := range n.configs {
+		if _, ok := configs[k]; !ok {
+			events = append(events, gxkv.Event{Type: gxkv.EventDelete, Key: ns + keySeparator + k, PrevValue: old})
+		}
+	}
+	if configs == nil {
+		configs = make(map[string]string)
+	}
+	n.configs, n.releaseKey = configs, releaseKey
+
+	for _, event := range events {
+		for w := range c.watchers {
+			if strings.HasPrefix(event.Key, w.prefix) {
+				w.queue(event)
+			}
+		}
+	}
+}
+
+// pollLoop long polls the notifications of the namespaces, and fetches the
+// namespaces notified until the client is closed
+func (c *Client) pollLoop() {
+	defer c.wg.Done()
+	for !c.closed() {
+		notified, err := c.poll()
+		if err != nil {
+			if !c.sleep(retryInterval) {
+				return
+			}
+			continue
+		}
+		for _, ns := range notified {
+			_ = c.fetch(ns)
+		}
+	}
+}
+
+type notification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int64  `json:"notificationId"`
+}
+
+// poll long polls the notifications, and returns the namespaces notified
+func (c *Client) poll() ([]string, error) {
+	c.lock.RLock()
+	notifications := make([]notification, 0, len(c.namespaces))
+	for ns, n := range c.namespaces {
+		notifications = append(notifications, notification{NamespaceName: ns, NotificationID: n.notificationID})
+	}
+	c.lock.RUnlock()
+	data, err := json.Marshal(notifications)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{
+		"appId":         {c.options.AppID},
+		"cluster":       {c.options.Cluster},
+		"notifications": {string(data)},
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.options.LongPollTimeout)
+	defer cancel()
+	status, body, err := c.do(ctx, "/notifications/v2", params)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, perrors.Errorf("poll notifications: status %d, %s", status, body)
+	}
+
+	if err = json.Unmarshal(body, &notifications); err != nil {
+		return nil, err
+	}
+	notified := make([]string, 0, len(notifications))
+	c.lock.Lock()
+	for _, n := range notifications {
+		if ns, ok := c.namespaces[n.NamespaceName]; ok {
+			ns.notificationID = n.NotificationID
+			notified = append(notified, n.NamespaceName)
+		}
+	}
+	c.lock.Unlock()
+	return notified, nil
+}
+
+// refreshLoop fetches the namespaces every refresh interval in case that a
+// notification is missed
+func (c *Client) refreshLoop() {
+	defer c.wg.Done()
+	for c.sleep(c.options.RefreshInterval) {
+		for _, ns := range c.options.Namespaces {
+			_ = c.fetch(ns)
+		}
+	}
+}
+
+// do gets @path of the servers with @params, and returns the status and body
+// of the response. It fails over to the next server if a server is unavailable.
+func (c *Client) do(ctx context.Context, path string, params url.Values) (int, []byte, error) {
+	pathWithQuery := path + "?" + params.Encode()
+
+	var err error
+	start := int(atomic.LoadInt32(&c.current))
+	for i := 0; i < len(c.servers); i++ {
+		index := (start + i) % len(c.servers)
+		var (
+			status int
+			body   []byte
+		)
+		status, body, err = c.send(ctx, c.servers[index], pathWithQuery)
+		if err == nil && status < http.StatusInternalServerError {
+			atomic.StoreInt32(&c.current, int32(index))
+			return status, body, nil
+		}
+		if err == nil {
+			err = perrors.Errorf("status %d, %s", status, body)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return 0, nil, perrors.WithMessagef(err, "apollo GET %s", path)
+}
+
+func (c *Client) send(ctx context.Context, server, pathWithQuery string) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, server+pathWithQuery, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if c.options.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+		req.Header.Set("Authorization", "Apollo "+c.options.AppID+":"+sign(c.options.Secret, timestamp, pathWithQuery))
+		req.Header.Set("Timestamp", timestamp)
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// sign signs the request of @pathWithQuery at @timestamp by @secret
+func sign(secret, timestamp, pathWithQuery string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + pathWithQuery))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sleep waits for @d, it returns false if the client is closed
+func (c *Client) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxapollo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// fakeServer serves the configs and notifications of the namespaces
+type fakeServer struct {
+	lock     sync.Mutex
+	releases map[string]int64 // namespace -> release, which is the notification id too
+	configs  map[string]map[string]string
+	changed  chan struct{}
+	signed   bool
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		releases: make(map[string]int64),
+		configs:  make(map[string]map[string]string),
+		changed:  make(chan struct{}),
+	}
+}
+
+func (s *fakeServer) release(ns string, configs map[string]string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.releases[ns]++
+	s.configs[ns] = configs
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Apollo app:") {
+		s.lock.Lock()
+		s.signed = true
+		s.lock.Unlock()
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/configs/app/default/") {
+		ns := strings.TrimPrefix(r.URL.Path, "/configs/app/default/")
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		release, ok := s.releases[ns]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		releaseKey := string(rune('0' + release))
+		if r.URL.Query().Get("releaseKey") == releaseKey {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"configurations": s.configs[ns], "releaseKey": releaseKey})
+		return
+	}
+
+	var notifications []notification
+	_ = json.Unmarshal([]byte(r.URL.Query().Get("notifications")), &notifications)
+	for {
+		s.lock.Lock()
+		changed := s.changed
+		result := make([]notification, 0)
+		for _, n := range notifications {
+			if release := s.releases[n.NamespaceName]; release > n.NotificationID && release > 0 {
+				result = append(result, notification{NamespaceName: n.NamespaceName, NotificationID: release})
+			}
+		}
+		s.lock.Unlock()
+		if len(result) != 0 {
+			_ = json.NewEncoder(w).Encode(result)
+			return
+		}
+		select {
+		case <-changed:
+		case <-time.After(100 * time.Millisecond):
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := newFakeServer()
+	s.release("application", map[string]string{"timeout": "3s"})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c, err := NewClient([]string{ts.URL}, "app", WithNamespaces("application", "dubbo"), WithSecret("secret"))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	v, err := c.Get("application/timeout")
+	assert.NoError(t, err)
+	assert.Equal(t, "3s", v)
+	_, err = c.Get("dubbo/registry")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	assert.Equal(t, ErrReadOnly, c.Put("application/timeout", "1s"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "dubbo/")
+	assert.NoError(t, err)
+
+	s.release("dubbo", map[string]string{"registry": "etcd", "retries": "2"})
+	received := map[string]gxkv.Event{}
+	for len(received) < 2 {
+		e := <-events
+		received[e.Key] = e
+	}
+	assert.Equal(t, map[string]gxkv.Event{
+		"dubbo/registry": {Type: gxkv.EventPut, Key: "dubbo/registry", Value: "etcd"},
+		"dubbo/retries":  {Type: gxkv.EventPut, Key: "dubbo/retries", Value: "2"},
+	}, received)
+
+	s.release("dubbo", map[string]string{"registry": "zookeeper"})
+	received = map[string]gxkv.Event{}
+	for len(received) < 2 {
+		e := <-events
+		received[e.Key] = e
+	}
+	assert.Equal(t, map[string]gxkv.Event{
+		"dubbo/registry": {Type: gxkv.EventPut, Key: "dubbo/registry", Value: "zookeeper", PrevValue: "etcd"},
+		"dubbo/retries":  {Type: gxkv.EventDelete, Key: "dubbo/retries", PrevValue: "2"},
+	}, received)
+
+	kvs, err := c.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "application/timeout", Value: "3s"}, {Key: "dubbo/registry", Value: "zookeeper"}}, kvs)
+	assert.Equal(t, map[string]string{"registry": "zookeeper"}, c.GetConfigs("dubbo"))
+
+	s.lock.Lock()
+	assert.True(t, s.signed)
+	s.lock.Unlock()
+}
+
+func TestSign(t *testing.T) {
+	// printf '1576478257344\n/configs/100004458/default/application?ip=10.0.0.1' | openssl dgst -sha1 -hmac secret -binary | base64
+	assert.Equal(t, "RXiVhNO+1OaKj3CmIcGnaI2ngUY=", sign("secret", "1576478257344", "/configs/100004458/default/application?ip=10.0.0.1"))
+}
@@ -0,0 +1,200 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxapollo
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// keySeparator separates the namespace and the config key in a kv key, eg:
+// "application/dubbo.registry.address"
+const keySeparator = "/"
+
+// ErrReadOnly is returned by the writes, the configs are released by the apollo portal
+var ErrReadOnly = perrors.New("apollo kv client is read only")
+
+var _ gxkv.Client = (*Client)(nil)
+
+func init() {
+	gxkv.Register("apollo", newKVClient)
+}
+
+// newKVClient creates a client by @u, eg:
+// "apollo://host1:8080,host2:8080?appId=app&cluster=default&namespaces=application,dubbo&secret=s"
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	timeout, err := u.Duration("timeout", DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(u.Addrs, u.String("appId", ""),
+		WithCluster(u.String("cluster", DefaultCluster)),
+		WithNamespaces(strings.Split(u.String("namespaces", DefaultNamespace), ",")...),
+		WithSecret(u.String("secret", "")),
+		WithTimeout(timeout),
+	)
+}
+
+// Get gets the cached config of @key in the form of "namespace/config key"
+func (c *Client) Get(key string) (string, error) {
+	if c.closed() {
+		return "", ErrClientClosed
+	}
+	ns, k := splitKey(key)
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if n, ok := c.namespaces[ns]; ok {
+		if v, ok := n.configs[k]; ok {
+			return v, nil
+		}
+	}
+	return "", gxkv.ErrKeyNotFound
+}
+
+// List lists the cached configs whose keys have @prefix in key order
+func (c *Client) List(prefix string) ([]gxkv.KeyValue, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+
+	c.lock.RLock()
+	kvs := make([]gxkv.KeyValue, 0)
+	for ns, n := range c.namespaces {
+		for k, v := range n.configs {
+			if key := ns + keySeparator + k; strings.HasPrefix(key, prefix) {
+				kvs = append(kvs, gxkv.KeyValue{Key: key, Value: v})
+			}
+		}
+	}
+	c.lock.RUnlock()
+
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs, nil
+}
+
+// Put returns ErrReadOnly
+func (c *Client) Put(key, value string) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly
+func (c *Client) Delete(key string) error {
+	return ErrReadOnly
+}
+
+// RegisterEphemeral returns ErrReadOnly
+func (c *Client) RegisterEphemeral(key, value string) error {
+	return ErrReadOnly
+}
+
+// WatchPrefix sends the changes of the configs whose keys have @prefix to the
+// returned chan until @ctx is done or the client is closed. The events have no
+// revision.
+func (c *Client) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+
+	w := &watcher{
+		prefix: prefix,
+		out:    make(chan gxkv.Event),
+		ready:  make(chan struct{}, 1),
+	}
+	c.lock.Lock()
+	c.watchers[w] = struct{}{}
+	c.lock.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() {
+			c.lock.Lock()
+			delete(c.watchers, w)
+			c.lock.Unlock()
+			close(w.out)
+		}()
+		for {
+			select {
+			case <-w.ready:
+			case <-ctx.Done():
+				return
+			case <-c.ctx.Done():
+				return
+			}
+			for _, event := range w.take() {
+				select {
+				case w.out <- event:
+				case <-ctx.Done():
+					return
+				case <-c.ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return w.out, nil
+}
+
+func splitKey(key string) (string, string) {
+	if i := strings.Index(key, keySeparator); i >= 0 {
+		return key[:i], key[i+len(keySeparator):]
+	}
+	return "", key
+}
+
+// watcher queues the events of a prefix without blocking the updates, and
+// sends them to out in order
+type watcher struct {
+	prefix string
+	out    chan gxkv.Event
+
+	lock   sync.Mutex
+	events []gxkv.Event
+	ready  chan struct{}
+}
+
+func (w *watcher) queue(event gxkv.Event) {
+	w.lock.Lock()
+	w.events = append(w.events, event)
+	w.lock.Unlock()
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) take() []gxkv.Event {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	events := w.events
+	w.events = nil
+	return events
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxapollo
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultCluster default cluster of the configs
+	DefaultCluster = "default"
+	// DefaultNamespace default namespace of the configs
+	DefaultNamespace = "application"
+	// DefaultTimeout default timeout of fetching the configs
+	DefaultTimeout = 3 * time.Second
+	// DefaultRefreshInterval default interval of fetching the configs without notification
+	DefaultRefreshInterval = 5 * time.Minute
+	// defaultLongPollTimeout timeout of a notification long polling, the server
+	// holds a polling for 60 seconds
+	defaultLongPollTimeout = 90 * time.Second
+	// retryInterval interval of polling again after a polling fails
+	retryInterval = time.Second
+)
+
+// Options of the apollo client
+type Options struct {
+	// AppID id of the application
+	AppID string
+	// Cluster cluster of the configs, DefaultCluster by default
+	Cluster string
+	// Namespaces namespaces of the configs, DefaultNamespace by default
+	Namespaces []string
+	// Secret signs the requests if the access key of the application is enabled
+	Secret string
+	// IP ip of the client, which is used by the gray releases
+	IP string
+	// Timeout timeout of fetching the configs
+	Timeout time.Duration
+	// RefreshInterval interval of fetching the configs without notification
+	RefreshInterval time.Duration
+	// LongPollTimeout timeout of a notification long polling
+	LongPollTimeout time.Duration
+	// HTTPClient sends the requests, a default client is used if it is nil
+	HTTPClient *http.Client
+}
+
+// Option will define a function of handling Options
+type Option func(*Options)
+
+// WithCluster sets the cluster of the configs
+func WithCluster(cluster string) Option {
+	return func(opt *Options) {
+		opt.Cluster = cluster
+	}
+}
+
+// WithNamespaces sets the namespaces of the configs
+func WithNamespaces(namespaces ...string) Option {
+	return func(opt *Options) {
+		opt.Namespaces = namespaces
+	}
+}
+
+// WithSecret sets the secret signing the requests
+func WithSecret(secret string) Option {
+	return func(opt *Options) {
+		opt.Secret = secret
+	}
+}
+
+// WithIP sets the ip of the client
+func WithIP(ip string) Option {
+	return func(opt *Options) {
+		opt.IP = ip
+	}
+}
+
+// WithTimeout sets the timeout of fetching the configs
+func WithTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.Timeout = timeout
+	}
+}
+
+// WithRefreshInterval sets the interval of fetching the configs without notification
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.RefreshInterval = interval
+	}
+}
+
+// WithLongPollTimeout sets the timeout of a notification long polling
+func WithLongPollTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.LongPollTimeout = timeout
+	}
+}
+
+// WithHTTPClient sets the http client sending the requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(opt *Options) {
+		opt.HTTPClient = client
+	}
+}
@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxkv
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+// cacheRetryInterval interval of watching again after the watch of a CachedClient breaks
+const cacheRetryInterval = time.Second
+
+// CacheOption will define a function of handling the options of CachedClient
+type CacheOption func(*CachedClient)
+
+// WithMaxStaleness reads the keys again whose cached values are older than
+// @staleness, although the watch keeps them updated. It bounds the staleness
+// when the events are delayed. The values never expire by default.
+func WithMaxStaleness(staleness time.Duration) CacheOption {
+	return func(c *CachedClient) {
+		c.maxStaleness = staleness
+	}
+}
+
+// CacheStats is the statistics of the Gets of a CachedClient
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// HitRate returns the ratio of the hits to the Gets, it is 0 if there is no Get
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cacheEntry struct {
+	value  string
+	exists bool // false caches the absence of the key
+	loaded time.Time
+}
+
+// CachedClient serves the Gets of the keys with a prefix from the values cached
+// in the process, which are kept coherent by watching the prefix. The other
+// keys and methods go to the client directly.
+type CachedClient struct {
+	Client
+	prefix       string
+	maxStaleness time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	lock       sync.RWMutex
+	entries    map[string]cacheEntry
+	watching   bool   // the values are cached only while watching
+	generation uint64 // increased by every change, so a read racing with a change is not cached
+}
+
+// NewCachedClient creates a client caching the values of the keys with @prefix
+// of @client, it fails if @prefix can not be watched
+func NewCachedClient(client Client, prefix string, opts ...CacheOption) (*CachedClient, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CachedClient{
+		Client:  client,
+		prefix:  prefix,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		entries: make(map[string]cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	events, err := client.WatchPrefix(ctx, prefix)
+	if err != nil {
+		cancel()
+		return nil, perrors.WithMessagef(err, "watch cache prefix %s", prefix)
+	}
+	c.watching = true
+	go c.watch(events)
+	return c, nil
+}
+
+// watch applies the events to the cache, and watches again after the watch breaks
+func (c *CachedClient) watch(events <-chan Event) {
+	defer close(c.done)
+	for {
+		for event := range events {
+			c.apply(event)
+		}
+
+		// the events may be lost, so the cache is dropped until watching again
+		c.lock.Lock()
+		c.watching = false
+		c.entries = make(map[string]cacheEntry)
+		c.generation++
+		c.lock.Unlock()
+
+		var err error
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(cacheRetryInterval):
+			}
+			if events, err = c.Client.WatchPrefix(c.ctx, c.prefix); err == nil {
+				break
+			}
+		}
+		c.lock.Lock()
+		c.watching = true
+		c.lock.Unlock()
+	}
+}
+
+func (c *CachedClient) apply(event Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	c.entries[event.Key] = cacheEntry{value: event.Value, exists: event.Type == EventPut, loaded: time.Now()}
+}
+
+// invalidate drops the cached value of @key
+func (c *CachedClient) invalidate(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	delete(c.entries, key)
+}
+
+// Get gets the value of @key from the cache if it is cached and fresh, or from
+// the client otherwise
+func (c *CachedClient) Get(key string) (string, error) {
+	if !strings.HasPrefix(key, c.prefix) {
+		return c.Client.Get(key)
+	}
+
+	c.lock.RLock()
+	entry, ok := c.entries[key]
+	generation, watching := c.generation, c.watching
+	c.lock.RUnlock()
+	if ok && (c.maxStaleness <= 0 || time.Since(entry.loaded) <= c.maxStaleness) {
+		c.hits.Inc()
+		if !entry.exists {
+			return "", ErrKeyNotFound
+		}
+		return entry.value, nil
+	}
+
+	c.misses.Inc()
+	value, err := c.Client.Get(key)
+	if err != nil && perrors.Cause(err) != ErrKeyNotFound {
+		return "", err
+	}
+	if watching {
+		c.lock.Lock()
+		if c.generation == generation {
+			c.entries[key] = cacheEntry{value: value, exists: err == nil, loaded: time.Now()}
+		}
+		c.lock.Unlock()
+	}
+	return value, err
+}
+
+// Put puts @key and drops its cached value
+func (c *CachedClient) Put(key, value string) error {
+	defer c.invalidate(key)
+	return c.Client.Put(key, value)
+}
+
+// Delete deletes @key and drops its cached value
+func (c *CachedClient) Delete(key string) error {
+	defer c.invalidate(key)
+	return c.Client.Delete(key)
+}
+
+// RegisterEphemeral registers @key and drops its cached value
+func (c *CachedClient) RegisterEphemeral(key, value string) error {
+	defer c.invalidate(key)
+	return c.Client.RegisterEphemeral(key, value)
+}
+
+// Stats returns the statistics of the Gets
+func (c *CachedClient) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// Close stops watching and closes the client
+func (c *CachedClient) Close() {
+	c.cancel()
+	<-c.done
+	c.Client.Close()
+}
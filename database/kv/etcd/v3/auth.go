@@ -0,0 +1,237 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+)
+
+const defaultAuthFailureThreshold = 3
+
+// ErrAuthCredentialsMissing is returned when re-authentication is required
+// but the client has no credentials
+var ErrAuthCredentialsMissing = perrors.New("etcd auth credentials missing")
+
+// AuthFailureHandler is called with the number of consecutive failures and
+// the last error when the client can not re-authenticate repeatedly
+type AuthFailureHandler func(failures int, err error)
+
+// isAuthError checks whether @err means the auth token is empty, expired or invalid
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch rpctypes.Error(err) {
+	case rpctypes.ErrUserEmpty, rpctypes.ErrInvalidAuthToken:
+		return true
+	}
+	return false
+}
+
+// doWithAuthRetry runs @fn with the raw client. If @fn fails because of auth,
+// the raw client is rebuilt with the stored credentials and @fn is retried once.
+func (c *Client) doWithAuthRetry(fn func(rawClient *clientv3.Client) error) error {
+	rawClient := c.GetRawClient()
+	if rawClient == nil {
+		return ErrNilETCDV3Client
+	}
+
+	err := fn(rawClient)
+	if !isAuthError(err) {
+		return err
+	}
+
+	if rerr := c.reauth(rawClient, err); rerr != nil {
+		return err
+	}
+	if rawClient = c.GetRawClient(); rawClient == nil {
+		return ErrNilETCDV3Client
+	}
+	err = fn(rawClient)
+	if isAuthError(err) {
+		c.lock.Lock()
+		c.authFailed(err)
+		c.lock.Unlock()
+	}
+	return err
+}
+
+// reauth rebuilds the raw client @old with the stored credentials, since the
+// token of the raw client can not be refreshed from outside.
+// Nothing is done if @old has already been replaced by another goroutine.
+func (c *Client) reauth(old *clientv3.Client, cause error) error {
+	// only one goroutine rebuilds the raw client, and it dials without holding
+	// the client lock, so the other operations are not blocked
+	c.reauthLock.Lock()
+	defer c.reauthLock.Unlock()
+
+	c.lock.RLock()
+	current := c.rawClient
+	c.lock.RUnlock()
+	if current == nil {
+		return ErrNilETCDV3Client
+	}
+	if current != old {
+		return nil
+	}
+	if c.options == nil || c.options.Username == "" {
+		c.lock.Lock()
+		c.authFailed(cause)
+		c.lock.Unlock()
+		return ErrAuthCredentialsMissing
+	}
+
+	rawClient, err := newRawClient(c.ctx, c.options, c.tlsConfig)
+	if err != nil {
+		err = perrors.WithMessage(err, "rebuild raw client")
+		c.lock.Lock()
+		c.authFailed(err)
+		c.lock.Unlock()
+		return err
+	}
+
+	c.lock.Lock()
+	if c.rawClient != old {
+		// the client has been closed meanwhile
+		c.lock.Unlock()
+		rawClient.Close()
+		return ErrNilETCDV3Client
+	}
+	c.logger.Info("etcd client re-authenticated", gxlog.Any("name", c.name), gxlog.Any("cause", cause))
+	c.rawClient = rawClient
+	c.authFailures = 0
+	c.lock.Unlock()
+
+	c.reattachLeases(rawClient)
+	// closing the old raw client lets the session loop renew the session and
+	// the watches resume on the new one
+	old.Close()
+	return nil
+}
+
+// reattachLeases keeps the leases of ephemeral keys alive on the new raw client
+func (c *Client) reattachLeases(rawClient *clientv3.Client) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	for k, id := range c.leases {
+		keepAlive, err := rawClient.KeepAlive(c.ctx, id)
+		if err != nil {
+			c.logger.Warn("etcd client keep alive lease error", gxlog.Any("name", c.name), gxlog.Any("key", k), gxlog.Err(err))
+			continue
+		}
+		go c.keepAliveLoop(rawClient, k, id, keepAlive)
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (c *Client) authFailed(err error) {
+	c.authFailures++
+
+	threshold := defaultAuthFailureThreshold
+	var handler AuthFailureHandler
+	if c.options != nil {
+		if c.options.AuthFailureThreshold > 0 {
+			threshold = c.options.AuthFailureThreshold
+		}
+		handler = c.options.AuthFailureHandler
+	}
+	if handler != nil && c.authFailures >= threshold {
+		go handler(c.authFailures, err)
+	}
+}
+
+// watchWithAuthRetry watches @k like clientv3.Watcher.Watch, but the watch is
+// resumed from the next revision on the new raw client after re-authentication,
+// instead of being closed silently with the old raw client. The compressed
+// values of the events are decompressed.
+func (c *Client) watchWithAuthRetry(ctx context.Context, k string, opts ...clientv3.OpOption) (clientv3.WatchChan, error) {
+	rawClient := c.GetRawClient()
+	if rawClient == nil {
+		return nil, ErrNilETCDV3Client
+	}
+
+	out := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(out)
+
+		var watchErr error
+		end := c.startSpan(ctx, OpWatch, k)
+		defer func() { end(watchErr) }()
+
+		var rev int64
+		for {
+			wopts := opts
+			if rev > 0 {
+				wopts = append(opts[:len(opts):len(opts)], clientv3.WithRev(rev+1))
+			}
+
+			var authErr error
+			c.observe(OpWatch, 0, nil)
+			for resp := range rawClient.Watch(ctx, k, wopts...) {
+				if isAuthError(resp.Err()) {
+					authErr = resp.Err()
+					break
+				}
+				if resp.Err() != nil {
+					watchErr = resp.Err()
+				}
+				c.metrics.watchEvents.Add(uint64(len(resp.Events)))
+				if resp.Header.Revision > rev {
+					rev = resp.Header.Revision
+				}
+				c.decodeEvents(&resp)
+				select {
+				case out <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if authErr != nil {
+				if err := c.reauth(rawClient, authErr); err != nil {
+					watchErr = err
+					c.logger.Error("etcd client watch re-authenticate error", gxlog.Any("name", c.name), gxlog.Any("key", k), gxlog.Err(err))
+					return
+				}
+			}
+			next := c.GetRawClient()
+			if next == nil || next == rawClient {
+				// the watch is cancelled or compacted, or the client is closed
+				return
+			}
+			rawClient = next
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,36 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxetcd
+
+import (
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestIsAuthError(t *testing.T) {
+	assert.False(t, isAuthError(nil))
+	assert.False(t, isAuthError(perrors.New("hello")))
+	assert.False(t, isAuthError(rpctypes.ErrGRPCPermissionDenied))
+	assert.True(t, isAuthError(rpctypes.ErrGRPCUserEmpty))
+	assert.True(t, isAuthError(rpctypes.ErrGRPCInvalidAuthToken))
+	assert.True(t, isAuthError(rpctypes.ErrInvalidAuthToken))
+}
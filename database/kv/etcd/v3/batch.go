@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"sort"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// maxTxnOps is the default max number of operations in a txn of etcd server
+const maxTxnOps = 128
+
+// txnInChunks commits @ops in txns of at most maxTxnOps operations, and returns the
+// responses of the operations in order
+func (c *Client) txnInChunks(ctx context.Context, ops []clientv3.Op) ([]*etcdserverpb.ResponseOp, error) {
+	resps := make([]*etcdserverpb.ResponseOp, 0, len(ops))
+	for start := 0; start < len(ops); start += maxTxnOps {
+		end := start + maxTxnOps
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		var resp *clientv3.TxnResponse
+		err := c.do(ctx, OpTxn, "", func(rawClient *clientv3.Client) (err error) {
+			resp, err = rawClient.Txn(ctx).Then(ops[start:end]...).Commit()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		resps = append(resps, resp.Responses...)
+	}
+	return resps, nil
+}
+
+// sortedKeys returns the keys of @kvs in order, so the txns are deterministic
+func sortedKeys(kvs map[string]string) []string {
+	keys := make([]string, 0, len(kvs))
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Client) batchPut(ctx context.Context, kvs map[string]string) error {
+	ops := make([]clientv3.Op, 0, len(kvs))
+	for _, k := range sortedKeys(kvs) {
+		v, err := c.encodeValue(kvs[k])
+		if err != nil {
+			return perrors.WithMessagef(err, "encode value of key %s", k)
+		}
+		ops = append(ops, clientv3.OpPut(k, v))
+	}
+	_, err := c.txnInChunks(ctx, ops)
+	return err
+}
+
+func (c *Client) batchGet(ctx context.Context, keys []string) (map[string]string, error) {
+	ops := make([]clientv3.Op, 0, len(keys))
+	for _, k := range keys {
+		ops = append(ops, clientv3.OpGet(k, c.readOpts()...))
+	}
+	resps, err := c.txnInChunks(ctx, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make(map[string]string, len(keys))
+	for _, resp := range resps {
+		for _, kv := range resp.GetResponseRange().Kvs {
+			v, err := c.decodeValue(kv.Value)
+			if err != nil {
+				return nil, perrors.WithMessagef(err, "decode value of key %s", kv.Key)
+			}
+			kvs[string(kv.Key)] = v
+		}
+	}
+	return kvs, nil
+}
+
+func (c *Client) batchDelete(ctx context.Context, keys []string) error {
+	ops := make([]clientv3.Op, 0, len(keys))
+	for _, k := range keys {
+		ops = append(ops, clientv3.OpDelete(k))
+	}
+	if _, err := c.txnInChunks(ctx, ops); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		c.removeLease(k, clientv3.NoLease)
+	}
+	return nil
+}
+
+// BatchPut puts @kvs in one txn. If there are more keys than the max operations
+// of a txn, they are put in several txns, and the keys put by the former txns
+// are kept when a latter txn fails.
+func (c *Client) BatchPut(kvs map[string]string) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.BatchPutWithContext(ctx, kvs)
+}
+
+// BatchPutWithContext is like BatchPut, but within @ctx
+func (c *Client) BatchPutWithContext(ctx context.Context, kvs map[string]string) error {
+	err := c.batchPut(ctx, kvs)
+	return perrors.WithMessagef(err, "batch put %d k/v", len(kvs))
+}
+
+// BatchGet gets the values of @keys in one txn, the keys not found are absent in the result
+func (c *Client) BatchGet(keys []string) (map[string]string, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.BatchGetWithContext(ctx, keys)
+}
+
+// BatchGetWithContext is like BatchGet, but within @ctx
+func (c *Client) BatchGetWithContext(ctx context.Context, keys []string) (map[string]string, error) {
+	kvs, err := c.batchGet(ctx, keys)
+	return kvs, perrors.WithMessagef(err, "batch get %d keys", len(keys))
+}
+
+// GetValues gets the values of @keys in one round trip, it is the variadic form of BatchGet
+func (c *Client) GetValues(keys ...string) (map[string]string, error) {
+	return c.BatchGet(keys)
+}
+
+// GetValuesWithContext is like GetValues, but within @ctx
+func (c *Client) GetValuesWithContext(ctx context.Context, keys ...string) (map[string]string, error) {
+	return c.BatchGetWithContext(ctx, keys)
+}
+
+// BatchDelete deletes @keys in one txn, like BatchPut, they are deleted in several
+// txns if there are too many keys
+func (c *Client) BatchDelete(keys []string) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.BatchDeleteWithContext(ctx, keys)
+}
+
+// BatchDeleteWithContext is like BatchDelete, but within @ctx
+func (c *Client) BatchDeleteWithContext(ctx context.Context, keys []string) error {
+	err := c.batchDelete(ctx, keys)
+	return perrors.WithMessagef(err, "batch delete %d keys", len(keys))
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// compareValue returns the comparison that the value of @k is @expected. The
+// stored value may be compressed differently from @expected by a client with a
+// compressor, so the mod revision of the decoded @expected value is compared then.
+// It returns false if the value of @k is not @expected.
+func (c *Client) compareValue(ctx context.Context, k, expected string) (clientv3.Cmp, bool, error) {
+	if c.options == nil || c.options.Compressor == nil {
+		return clientv3.Compare(clientv3.Value(k), "=", expected), true, nil
+	}
+
+	kvs, _, err := c.rangeKeyValues(ctx, k)
+	if err != nil {
+		return clientv3.Cmp{}, false, err
+	}
+	if len(kvs) == 0 || kvs[0].Value != expected {
+		return clientv3.Cmp{}, false, nil
+	}
+	return clientv3.Compare(clientv3.ModRevision(k), "=", kvs[0].ModRevision), true, nil
+}
+
+func (c *Client) cas(ctx context.Context, k, oldValue, newValue string) (bool, error) {
+	cmp, ok, err := c.compareValue(ctx, k, oldValue)
+	if err != nil || !ok {
+		return false, err
+	}
+	v, err := c.encodeValue(newValue)
+	if err != nil {
+		return false, err
+	}
+
+	var resp *clientv3.TxnResponse
+	err = c.do(ctx, OpTxn, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Txn(ctx).If(cmp).Then(clientv3.OpPut(k, v)).Commit()
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+func (c *Client) cad(ctx context.Context, k, expected string) (bool, error) {
+	cmp, ok, err := c.compareValue(ctx, k, expected)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	var resp *clientv3.TxnResponse
+	err = c.do(ctx, OpTxn, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Txn(ctx).If(cmp).Then(clientv3.OpDelete(k)).Commit()
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if resp.Succeeded {
+		c.removeLease(k, clientv3.NoLease)
+	}
+	return resp.Succeeded, nil
+}
+
+func (c *Client) updateWithRev(ctx context.Context, k, v string, expectedModRev int64) (bool, error) {
+	v, err := c.encodeValue(v)
+	if err != nil {
+		return false, err
+	}
+
+	var resp *clientv3.TxnResponse
+	err = c.do(ctx, OpTxn, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(k), "=", expectedModRev)).
+			Then(clientv3.OpPut(k, v)).
+			Commit()
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// UpdateWithRev sets the value of @k only if its mod revision is @expectedModRev,
+// eg: the one returned by GetWithRevision, so that the updates of others since the
+// read are not lost. It returns false if the key has been modified. @expectedModRev
+// 0 means the key should not exist.
+func (c *Client) UpdateWithRev(k, v string, expectedModRev int64) (bool, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.UpdateWithRevWithContext(ctx, k, v, expectedModRev)
+}
+
+// UpdateWithRevWithContext is like UpdateWithRev, but within @ctx
+func (c *Client) UpdateWithRevWithContext(ctx context.Context, k, v string, expectedModRev int64) (bool, error) {
+	ok, err := c.updateWithRev(ctx, k, v, expectedModRev)
+	return ok, perrors.WithMessagef(err, "update with revision (key %s, revision %d)", k, expectedModRev)
+}
+
+// CAS sets the value of @k to @newValue only if its value is @oldValue, it
+// returns false if the value is not @oldValue or the key does not exist
+func (c *Client) CAS(k, oldValue, newValue string) (bool, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.CASWithContext(ctx, k, oldValue, newValue)
+}
+
+// CASWithContext is like CAS, but within @ctx
+func (c *Client) CASWithContext(ctx context.Context, k, oldValue, newValue string) (bool, error) {
+	ok, err := c.cas(ctx, k, oldValue, newValue)
+	return ok, perrors.WithMessagef(err, "compare and swap (key %s)", k)
+}
+
+// CAD deletes @k only if its value is @expected, it returns false if the value
+// is not @expected or the key does not exist
+func (c *Client) CAD(k, expected string) (bool, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.CADWithContext(ctx, k, expected)
+}
+
+// CADWithContext is like CAD, but within @ctx
+func (c *Client) CADWithContext(ctx context.Context, k, expected string) (bool, error) {
+	ok, err := c.cad(ctx, k, expected)
+	return ok, perrors.WithMessagef(err, "compare and delete (key %s)", k)
+}
@@ -19,23 +19,37 @@ package gxetcd
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"strings"
 	"sync"
 	"time"
 )
 
 import (
 	perrors "github.com/pkg/errors"
-	"go.etcd.io/etcd/clientv3"
-	"go.etcd.io/etcd/clientv3/concurrency"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.etcd.io/etcd/client/v3/namespace"
+	uatomic "go.uber.org/atomic"
 	"google.golang.org/grpc"
 )
 
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+	gxlog "github.com/dubbogo/gost/log"
+	gxtls "github.com/dubbogo/gost/net/tls"
+)
+
 var (
 	// ErrNilETCDV3Client raw client nil
 	ErrNilETCDV3Client = perrors.New("etcd raw client is nil") // full describe the ERR
-	// ErrKVPairNotFound not found key
-	ErrKVPairNotFound = perrors.New("k/v pair not found")
+	// ErrKVPairNotFound not found key, it is gxkv.ErrKeyNotFound
+	ErrKVPairNotFound = gxkv.ErrKeyNotFound
+	// ErrEmptyPrefix is returned when deleting the keys of an empty prefix, which are all the keys
+	ErrEmptyPrefix = perrors.New("empty prefix")
 )
 
 // NewConfigClient create new Client
@@ -47,10 +61,10 @@ func NewConfigClient(opts ...Option) *Client {
 		opt(options)
 	}
 
-	newClient, err := NewClient(options.Name, options.Endpoints, options.Timeout, options.Heartbeat)
+	newClient, err := newClient(options)
 	if err != nil {
-		log.Printf("new etcd client (Name{%s}, etcd addresses{%v}, Timeout{%d}) = error{%v}",
-			options.Name, options.Endpoints, options.Timeout, err)
+		loggerOf(options).Error("new etcd client error", gxlog.Any("name", options.Name),
+			gxlog.Any("endpoints", options.Endpoints), gxlog.Any("timeout", options.Timeout), gxlog.Err(err))
 	}
 	return newClient
 }
@@ -65,50 +79,157 @@ type Client struct {
 	endpoints []string
 	timeout   time.Duration
 	heartbeat int
+	options   *Options
+	logger    gxlog.Logger
 
 	ctx       context.Context    // if etcd server connection lose, the ctx.Done will be sent msg
 	cancel    context.CancelFunc // cancel the ctx, all watcher will stopped
 	rawClient *clientv3.Client
+	session   *concurrency.Session // session of the raw client, guarded by lock
+
+	tlsConfig   *tls.Config
+	tlsReloader *gxtls.CertReloader // reloads the client certificate files of mTLS
+
+	poolKey string // key in the client pool if the client is shared
+	refs    int    // reference count of the shared client, guarded by the pool lock
+
+	reauthLock   sync.Mutex
+	sessionLock  sync.Mutex // serializes creating the session of the client created by WithoutSession
+	leaseLock    sync.Mutex
+	leases       map[string]clientv3.LeaseID             // ephemeral key -> lease
+	keepAlives   map[clientv3.LeaseID]context.CancelFunc // lease -> stop of its keepalive
+	ephemerals   map[string]ephemeral                    // ephemeral key -> registration, re-put when the session is recreated
+	storedLeases map[string]int64                        // leases loaded from lease store
+
+	watchDropped uatomic.Uint64   // dropped events of all buffered watches
+	dispatcher   *watchDispatcher // runs the watch handlers if the watch workers are set
+	metrics      *clientMetrics
+
+	authFailures int // consecutive re-authentication failures, guarded by lock
+
+	stateLock   sync.Mutex
+	stateSubs   []chan StateEvent // subscribers of the connection state changes
+	stateClosed bool              // ConnClosed has been published, guarded by stateLock
 
-	exit chan struct{}
-	Wait sync.WaitGroup
+	exit  chan struct{}
+	ready chan struct{} // closed when the first session is created
+	Wait  sync.WaitGroup
 }
 
 // NewClient create a client instance with name, endpoints etc.
-func NewClient(name string, endpoints []string, timeout time.Duration, heartbeat int) (*Client, error) {
+func NewClient(name string, endpoints []string, timeout time.Duration, heartbeat int, opts ...Option) (*Client, error) {
+	options := &Options{
+		Name:      name,
+		Endpoints: endpoints,
+		Timeout:   timeout,
+		Heartbeat: heartbeat,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return newClient(options)
+}
+
+func newClient(options *Options) (*Client, error) {
+	tlsConfig, tlsReloader, err := loadTLSConfig(options)
+	if err != nil {
+		return nil, perrors.WithMessage(err, "load tls config")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	rawClient, err := clientv3.New(clientv3.Config{
-		Context:     ctx,
-		Endpoints:   endpoints,
-		DialTimeout: timeout,
-		DialOptions: []grpc.DialOption{grpc.WithBlock()},
-	})
+	rawClient, err := newRawClient(ctx, options, tlsConfig)
 	if err != nil {
 		cancel()
+		tlsReloader.Close()
 		return nil, perrors.WithMessage(err, "new raw client block connect to server")
 	}
 
 	c := &Client{
-		name:      name,
-		timeout:   timeout,
-		endpoints: endpoints,
-		heartbeat: heartbeat,
+		name:      options.Name,
+		timeout:   options.Timeout,
+		endpoints: options.Endpoints,
+		heartbeat: options.Heartbeat,
+		options:   options,
+		logger:    loggerOf(options),
 
 		ctx:       ctx,
 		cancel:    cancel,
 		rawClient: rawClient,
 
-		exit: make(chan struct{}),
+		tlsConfig:   tlsConfig,
+		tlsReloader: tlsReloader,
+
+		leases:     make(map[string]clientv3.LeaseID),
+		keepAlives: make(map[clientv3.LeaseID]context.CancelFunc),
+		ephemerals: make(map[string]ephemeral),
+
+		metrics: newClientMetrics(),
+
+		exit:  make(chan struct{}),
+		ready: make(chan struct{}),
+	}
+
+	if options.WatchWorkers > 0 {
+		c.dispatcher = newWatchDispatcher(options.WatchWorkers, options.WatchWorkerQueueSize, c.exit)
+	}
+
+	if options.LeaseStore != nil {
+		if c.storedLeases, err = options.LeaseStore.Load(); err != nil {
+			c.logger.Warn("etcd client load leases error", gxlog.Any("name", c.name), gxlog.Err(err))
+		}
 	}
 
 	if err := c.keepSession(); err != nil {
 		cancel()
+		rawClient.Close()
+		tlsReloader.Close()
 		return nil, perrors.WithMessage(err, "client keep session")
 	}
 	return c, nil
 }
 
+// newRawClient creates a raw client which blocks until connected to server,
+// unless the non-blocking dial is enabled
+func newRawClient(ctx context.Context, options *Options, tlsConfig *tls.Config) (*clientv3.Client, error) {
+	dialTimeout := options.Timeout
+	if dialTimeout <= 0 {
+		// never block forever on dialing
+		dialTimeout = DefaultDialTimeout
+	}
+	var dialOptions []grpc.DialOption
+	if !options.NonBlockingDial {
+		dialOptions = append(dialOptions, grpc.WithBlock())
+	}
+	dialOptions = append(dialOptions, options.GRPCDialOptions...)
+	rawClient, err := clientv3.New(clientv3.Config{
+		Context:              ctx,
+		Endpoints:            options.Endpoints,
+		DialTimeout:          dialTimeout,
+		DialOptions:          dialOptions,
+		DialKeepAliveTime:    options.KeepAliveTime,
+		DialKeepAliveTimeout: options.KeepAliveTimeout,
+		AutoSyncInterval:     options.AutoSyncInterval,
+		MaxCallSendMsgSize:   options.MaxCallSendMsgSize,
+		MaxCallRecvMsgSize:   options.MaxCallRecvMsgSize,
+		Username:             options.Username,
+		Password:             options.Password,
+		TLS:                  tlsConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Namespace != "" {
+		// the sessions and locks built on the raw client are namespaced as well
+		rawClient.KV = namespace.NewKV(rawClient.KV, options.Namespace)
+		rawClient.Watcher = namespace.NewWatcher(rawClient.Watcher, options.Namespace)
+		rawClient.Lease = namespace.NewLease(rawClient.Lease, options.Namespace)
+	}
+	return rawClient, nil
+}
+
 // NOTICE: need to get the lock before calling this method
 func (c *Client) clean() {
 	// close raw client
@@ -135,38 +256,111 @@ func (c *Client) stop() bool {
 	}
 }
 
+// stopped checks whether the client has been stopped
+func (c *Client) stopped() bool {
+	select {
+	case <-c.exit:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetCtx return client context
 func (c *Client) GetCtx() context.Context {
 	return c.ctx
 }
 
-// Close close client
+// Close close client, the temporary nodes registered by it are deleted
+// unless their leases are persisted by the lease store
 func (c *Client) Close() {
+	_ = c.CloseCtx(context.Background())
+}
+
+// CloseCtx is like Close, but waits for the keep session goroutine until @ctx is
+// done. The goroutine is force-cancelled then, and an error is returned if it has
+// not exited or the temporary nodes are not deleted cleanly. The temporary nodes
+// are deleted within the timeout of the client even if @ctx is done.
+func (c *Client) CloseCtx(ctx context.Context) error {
 	if c == nil {
-		return
+		return nil
 	}
 
 	// stop the client
 	if ret := c.stop(); !ret {
-		return
+		return nil
 	}
 
 	// wait client keep session stop
-	c.Wait.Wait()
+	var err error
+	done := make(chan struct{})
+	go func() {
+		c.Wait.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// cancelling ctx of raw client aborts the pending calls of the goroutine
+		c.cancel()
+		err = perrors.WithMessage(ctx.Err(), "wait for keep session goroutine")
+	}
+
+	// the keep session goroutine notifies ConnClosed, it does not run if the
+	// session of a client created by WithoutSession is never used
+	var noSession bool
+	defer func() {
+		if noSession {
+			c.notifyState(ConnClosed)
+		}
+	}()
+
+	c.lock.Lock()
+	noSession = c.session == nil && c.options != nil && c.options.WithoutSession && !c.options.NonBlockingDial
+	rawClient := c.rawClient
+	c.lock.Unlock()
+
+	// the lock is not held during the round-trips of the cleanup
+	if rawClient != nil {
+		if cleanErr := c.cleanEphemerals(rawClient); err == nil {
+			err = cleanErr
+		}
+	}
 
 	c.lock.Lock()
-	defer c.lock.Unlock()
 	if c.rawClient != nil {
 		c.clean()
 	}
-	log.Printf("etcd client{Name:%s, Endpoints:%s} exit now.", c.name, c.endpoints)
+	c.lock.Unlock()
+	c.tlsReloader.Close()
+	c.logger.Info("etcd client exit now", gxlog.Any("name", c.name), gxlog.Any("endpoints", c.endpoints))
+	return err
 }
 
 func (c *Client) keepSession() error {
-	s, err := concurrency.NewSession(c.rawClient, concurrency.WithTTL(c.heartbeat))
+	if c.options != nil && c.options.NonBlockingDial {
+		c.Wait.Add(1)
+		go c.connectLoop()
+		return nil
+	}
+
+	if c.options != nil && c.options.WithoutSession {
+		close(c.ready)
+		c.notifyState(ConnConnected)
+		return nil
+	}
+
+	rawClient := c.GetRawClient()
+	if rawClient == nil {
+		return ErrNilETCDV3Client
+	}
+	s, err := c.newSession(rawClient)
 	if err != nil {
 		return perrors.WithMessage(err, "new session with server")
 	}
+	c.setSession(s)
+	close(c.ready)
+	c.notifyState(ConnConnected)
 
 	// must add wg before go keep session goroutine
 	c.Wait.Add(1)
@@ -174,10 +368,44 @@ func (c *Client) keepSession() error {
 	return nil
 }
 
+// newSession creates a session on @rawClient. The lease is granted within the
+// timeout of the client, so that it does not block forever on a dead server.
+func (c *Client) newSession(rawClient *clientv3.Client) (*concurrency.Session, error) {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	ttl := c.heartbeat
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	lease, err := rawClient.Grant(ctx, int64(ttl))
+	cancel()
+	if err != nil {
+		return nil, perrors.WithMessage(err, "grant session lease")
+	}
+
+	s, err := concurrency.NewSession(rawClient, concurrency.WithTTL(ttl), concurrency.WithLease(lease.ID))
+	if err != nil {
+		rawClient.Revoke(c.ctx, lease.ID)
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *Client) setSession(s *concurrency.Session) {
+	c.lock.Lock()
+	c.session = s
+	c.lock.Unlock()
+}
+
 func (c *Client) keepSessionLoop(s *concurrency.Session) {
 	defer func() {
 		c.Wait.Done()
-		log.Printf("etcd client {Endpoints:%v, Name:%s} keep goroutine game over.", c.endpoints, c.name)
+		c.notifyState(ConnClosed)
+		c.logger.Info("etcd client keep session goroutine game over", gxlog.Any("name", c.name), gxlog.Any("endpoints", c.endpoints))
 	}()
 
 	for {
@@ -186,10 +414,40 @@ func (c *Client) keepSessionLoop(s *concurrency.Session) {
 			// Client be stopped, will clean the client hold resources
 			return
 		case <-s.Done():
-			log.Print("etcd server stopped")
+			select {
+			case <-c.Done():
+				return
+			default:
+			}
+			if rawClient := c.GetRawClient(); rawClient != nil {
+				// the lease of the session has expired, eg: on a network blip, or the
+				// raw client has been rebuilt by re-authentication. Granting the lease
+				// of the new session is a round trip, so do not hold the lock. If the
+				// raw client is rebuilt again meanwhile, the new session is done soon
+				// and renewed in the next round.
+				expired := rawClient == s.Client()
+				ns, err := c.newSession(rawClient)
+				if err == nil {
+					s = ns
+					c.setSession(s)
+					if expired {
+						c.reputEphemerals(rawClient)
+						c.notifyState(ConnSessionRecreated)
+					}
+					continue
+				}
+				c.logger.Warn("etcd client renew session error", gxlog.Any("name", c.name), gxlog.Err(err))
+			}
+			if ns := c.reconnect(); ns != nil {
+				s = ns
+				continue
+			}
 			c.lock.Lock()
+			c.logger.Warn("etcd server stopped", gxlog.Any("name", c.name))
 			// when etcd server stopped, cancel ctx, stop all watchers
-			c.clean()
+			if c.rawClient != nil {
+				c.clean()
+			}
 			// when connection lose, stop client, trigger reconnect to etcd
 			c.stop()
 			c.lock.Unlock()
@@ -212,55 +470,108 @@ func (c *Client) GetEndPoints() []string {
 }
 
 // if k not exist will put k/v in etcd, otherwise return nil
-func (c *Client) put(k string, v string, opts ...clientv3.OpOption) error {
-	rawClient := c.GetRawClient()
-
-	if rawClient == nil {
-		return ErrNilETCDV3Client
+func (c *Client) put(ctx context.Context, k string, v string, opts ...clientv3.OpOption) error {
+	v, err := c.encodeValue(v)
+	if err != nil {
+		return err
 	}
 
-	_, err := rawClient.Txn(c.ctx).
-		If(clientv3.Compare(clientv3.Version(k), "<", 1)).
-		Then(clientv3.OpPut(k, v, opts...)).
-		Commit()
-	return err
+	return c.do(ctx, OpPut, k, func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Txn(ctx).
+			If(clientv3.Compare(clientv3.Version(k), "<", 1)).
+			Then(clientv3.OpPut(k, v, opts...)).
+			Commit()
+		return err
+	})
 }
 
 // if k not exist will put k/v in etcd
 // if k is already exist in etcd, replace it
-func (c *Client) update(k string, v string, opts ...clientv3.OpOption) error {
-	rawClient := c.GetRawClient()
-
-	if rawClient == nil {
-		return ErrNilETCDV3Client
+func (c *Client) update(ctx context.Context, k string, v string, opts ...clientv3.OpOption) error {
+	v, err := c.encodeValue(v)
+	if err != nil {
+		return err
 	}
 
-	_, err := rawClient.Txn(c.ctx).
-		If(clientv3.Compare(clientv3.Version(k), "!=", -1)).
-		Then(clientv3.OpPut(k, v, opts...)).
-		Commit()
+	return c.do(ctx, OpPut, k, func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Txn(ctx).
+			If(clientv3.Compare(clientv3.Version(k), "!=", -1)).
+			Then(clientv3.OpPut(k, v, opts...)).
+			Commit()
+		return err
+	})
+}
+
+func (c *Client) delete(ctx context.Context, k string) error {
+	err := c.do(ctx, OpDelete, k, func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Delete(ctx, k)
+		return err
+	})
+	if err == nil {
+		c.removeLease(k, clientv3.NoLease)
+		c.untrackEphemeral(k)
+	}
 	return err
 }
 
-func (c *Client) delete(k string) error {
-	rawClient := c.GetRawClient()
+func (c *Client) deleteWithPrefix(ctx context.Context, prefix string) (int64, error) {
+	if prefix == "" {
+		return 0, ErrEmptyPrefix
+	}
 
-	if rawClient == nil {
-		return ErrNilETCDV3Client
+	var resp *clientv3.DeleteResponse
+	err := c.do(ctx, OpDelete, prefix, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Delete(ctx, prefix, clientv3.WithPrefix())
+		return err
+	})
+	if err != nil {
+		return 0, err
 	}
+	c.removeLeasesWithPrefix(prefix)
+	return resp.Deleted, nil
+}
 
-	_, err := rawClient.Delete(c.ctx, k)
-	return err
+// opCtx returns the context of an operation called without a context, which is
+// bounded by the request timeout of the client
+func (c *Client) opCtx() (context.Context, context.CancelFunc) {
+	timeout := time.Duration(0)
+	if c.options != nil {
+		timeout = c.options.RequestTimeout
+	}
+	if timeout <= 0 {
+		timeout = c.timeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, timeout)
 }
 
-func (c *Client) get(k string) (string, error) {
-	rawClient := c.GetRawClient()
+// cleanupCtx returns the context of a cleanup, eg: revoking the lease of a failed
+// registration. It is bounded by the timeout of the client, but not canceled with
+// the client.
+func (c *Client) cleanupCtx() (context.Context, context.CancelFunc) {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
 
-	if rawClient == nil {
-		return "", ErrNilETCDV3Client
+// readOpts returns the options of read requests
+func (c *Client) readOpts(opts ...clientv3.OpOption) []clientv3.OpOption {
+	if c.options != nil && c.options.SerializableReads {
+		opts = append(opts, clientv3.WithSerializable())
 	}
+	return opts
+}
 
-	resp, err := rawClient.Get(c.ctx, k)
+func (c *Client) get(ctx context.Context, k string, opts ...clientv3.OpOption) (string, error) {
+	var resp *clientv3.GetResponse
+	err := c.do(ctx, OpGet, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Get(ctx, k, c.readOpts(opts...)...)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -269,10 +580,57 @@ func (c *Client) get(k string) (string, error) {
 		return "", ErrKVPairNotFound
 	}
 
-	return string(resp.Kvs[0].Value), nil
+	return c.decodeValue(resp.Kvs[0].Value)
+}
+
+// encodeValue compresses @v if the client has a compressor
+func (c *Client) encodeValue(v string) (string, error) {
+	if c.options == nil || c.options.Compressor == nil {
+		return v, nil
+	}
+
+	data, err := gxcompress.Encode(c.options.Compressor, c.options.CompressThreshold, []byte(v))
+	return string(data), err
+}
+
+// decodeValue decompresses @v if it is compressed and the client has a compressor
+func (c *Client) decodeValue(v []byte) (string, error) {
+	if c.options == nil || c.options.Compressor == nil {
+		return string(v), nil
+	}
+
+	data, err := gxcompress.Decode(v)
+	return string(data), err
+}
+
+// decodeEvents decompresses the values of the events in @resp if the client has
+// a compressor. The values which can not be decompressed are kept as is.
+func (c *Client) decodeEvents(resp *clientv3.WatchResponse) {
+	if c.options == nil || c.options.Compressor == nil {
+		return
+	}
+
+	for _, e := range resp.Events {
+		for _, kv := range []**mvccpb.KeyValue{&e.Kv, &e.PrevKv} {
+			if *kv == nil || !gxcompress.IsCompressed((*kv).Value) {
+				continue
+			}
+			v, err := gxcompress.Decode((*kv).Value)
+			if err != nil {
+				c.logger.Warn("etcd client decode value error", gxlog.Any("name", c.name), gxlog.Any("key", string((*kv).Key)), gxlog.Err(err))
+				continue
+			}
+			decoded := **kv
+			decoded.Value = v
+			*kv = &decoded
+		}
+	}
 }
 
 // CleanKV delete all key and value
+//
+// Deprecated: it deletes the entire keyspace, which is dangerous on the shared
+// clusters, use DeleteWithPrefix instead.
 func (c *Client) CleanKV() error {
 	rawClient := c.GetRawClient()
 
@@ -286,13 +644,17 @@ func (c *Client) CleanKV() error {
 
 // GetChildren return node children
 func (c *Client) GetChildren(k string) ([]string, []string, error) {
-	rawClient := c.GetRawClient()
-
-	if rawClient == nil {
-		return nil, nil, ErrNilETCDV3Client
-	}
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.getChildren(ctx, k)
+}
 
-	resp, err := rawClient.Get(c.ctx, k, clientv3.WithPrefix())
+func (c *Client) getChildren(ctx context.Context, k string, opts ...clientv3.OpOption) ([]string, []string, error) {
+	var resp *clientv3.GetResponse
+	err := c.do(ctx, OpGet, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Get(ctx, k, c.readOpts(append(opts, clientv3.WithPrefix())...)...)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -304,56 +666,311 @@ func (c *Client) GetChildren(k string) ([]string, []string, error) {
 	kList := make([]string, 0, len(resp.Kvs))
 	vList := make([]string, 0, len(resp.Kvs))
 	for _, kv := range resp.Kvs {
+		v, err := c.decodeValue(kv.Value)
+		if err != nil {
+			return nil, nil, perrors.WithMessagef(err, "decode value of key %s", kv.Key)
+		}
 		kList = append(kList, string(kv.Key))
-		vList = append(vList, string(kv.Value))
+		vList = append(vList, v)
 	}
 	return kList, vList, nil
 }
 
-func (c *Client) watchWithPrefix(prefix string) (clientv3.WatchChan, error) {
-	rawClient := c.GetRawClient()
+func (c *Client) getChildrenKeys(ctx context.Context, k string) ([]string, error) {
+	var resp *clientv3.GetResponse
+	err := c.do(ctx, OpGet, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Get(ctx, k, c.readOpts(clientv3.WithPrefix(), clientv3.WithKeysOnly())...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	if rawClient == nil {
-		return nil, ErrNilETCDV3Client
+	if len(resp.Kvs) == 0 {
+		return nil, ErrKVPairNotFound
+	}
+
+	keys := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		keys = append(keys, string(kv.Key))
 	}
+	return keys, nil
+}
 
-	return rawClient.Watch(c.ctx, prefix, clientv3.WithPrefix()), nil
+func (c *Client) count(ctx context.Context, prefix string) (int64, error) {
+	var resp *clientv3.GetResponse
+	err := c.do(ctx, OpGet, prefix, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Get(ctx, prefix, c.readOpts(clientv3.WithPrefix(), clientv3.WithCountOnly())...)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
 }
 
-func (c *Client) watch(k string) (clientv3.WatchChan, error) {
-	rawClient := c.GetRawClient()
+func (c *Client) watchWithPrefix(ctx context.Context, prefix string) (clientv3.WatchChan, error) {
+	return c.watchWithAuthRetry(ctx, prefix, clientv3.WithPrefix())
+}
 
-	if rawClient == nil {
-		return nil, ErrNilETCDV3Client
+func (c *Client) watch(ctx context.Context, k string) (clientv3.WatchChan, error) {
+	return c.watchWithAuthRetry(ctx, k)
+}
+
+func (c *Client) keepAliveKV(k string, v string, ttl time.Duration) (clientv3.LeaseID, error) {
+	ev, err := c.encodeValue(v)
+	if err != nil {
+		return clientv3.NoLease, err
+	}
+	if ttl <= 0 {
+		ttl = c.leaseTTL()
+	}
+	if err = c.ensureSession(); err != nil {
+		return clientv3.NoLease, err
 	}
 
-	return rawClient.Watch(c.ctx, k), nil
+	var leaseID clientv3.LeaseID
+	err = c.do(c.ctx, OpLease, k, func(rawClient *clientv3.Client) error {
+		leaseID = c.restoreLease(rawClient, k)
+		if leaseID == clientv3.NoLease {
+			lease, err := rawClient.Grant(c.ctx, ttlSeconds(ttl))
+			if err != nil {
+				return perrors.WithMessage(err, "grant lease")
+			}
+			leaseID = lease.ID
+		}
+
+		// the keepalive of every lease is stopped on its own, so that it does not
+		// outlive the registration
+		keepAliveCtx, stopKeepAlive := context.WithCancel(c.ctx)
+		keepAlive, err := rawClient.KeepAlive(keepAliveCtx, leaseID)
+		if err != nil || keepAlive == nil {
+			stopKeepAlive()
+			c.revokeLease(rawClient, leaseID)
+			if err != nil {
+				return perrors.WithMessage(err, "keep alive lease")
+			}
+			// the lease has expired
+			return perrors.WithMessage(ErrSessionExpired, "keep alive lease")
+		}
+
+		if _, err = rawClient.Put(c.ctx, k, ev, clientv3.WithLease(leaseID)); err != nil {
+			// nothing is attached to the lease
+			stopKeepAlive()
+			c.revokeLease(rawClient, leaseID)
+			return perrors.WithMessage(err, "put k/v with lease")
+		}
+		c.saveKeepAlive(k, leaseID, stopKeepAlive)
+		c.trackEphemeral(k, v, ttl)
+		go c.keepAliveLoop(rawClient, k, leaseID, keepAlive)
+		return nil
+	})
+	return leaseID, err
 }
 
-func (c *Client) keepAliveKV(k string, v string) error {
-	rawClient := c.GetRawClient()
+// revokeLease revokes @leaseID by @rawClient within the cleanup context
+func (c *Client) revokeLease(rawClient *clientv3.Client, leaseID clientv3.LeaseID) {
+	ctx, cancel := c.cleanupCtx()
+	defer cancel()
+	if _, err := rawClient.Revoke(ctx, leaseID); err != nil && rpctypes.Error(err) != rpctypes.ErrLeaseNotFound {
+		c.logger.Warn("etcd client revoke lease error", gxlog.Any("name", c.name),
+			gxlog.Any("lease", int64(leaseID)), gxlog.Err(err))
+	}
+}
 
-	if rawClient == nil {
-		return ErrNilETCDV3Client
+// saveKeepAlive saves the lease of @k which is kept alive until @stop is called,
+// the keepalive of the lease replaced is stopped
+func (c *Client) saveKeepAlive(k string, leaseID clientv3.LeaseID, stop context.CancelFunc) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	if old, ok := c.leases[k]; ok && old != leaseID {
+		c.stopKeepAliveLocked(old)
 	}
+	c.keepAlives[leaseID] = stop
+	c.leases[k] = leaseID
+	c.persistLeases()
+}
+
+// stopKeepAlive stops the keepalive of @leaseID
+func (c *Client) stopKeepAlive(leaseID clientv3.LeaseID) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	c.stopKeepAliveLocked(leaseID)
+}
+
+// NOTICE: need to get the leaseLock before calling this method
+func (c *Client) stopKeepAliveLocked(leaseID clientv3.LeaseID) {
+	if stop, ok := c.keepAlives[leaseID]; ok {
+		delete(c.keepAlives, leaseID)
+		stop()
+	}
+}
+
+// unregisterTemp deletes the temporary node @k and revokes its lease
+func (c *Client) unregisterTemp(k string) error {
+	c.leaseLock.Lock()
+	leaseID, ok := c.leases[k]
+	c.leaseLock.Unlock()
+
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	if err := c.delete(ctx, k); err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	return c.do(ctx, OpLease, k, func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Revoke(ctx, leaseID)
+		if rpctypes.Error(err) == rpctypes.ErrLeaseNotFound {
+			// the lease has expired
+			return nil
+		}
+		return err
+	})
+}
 
-	// make lease time longer, since 1 second is too short
-	lease, err := rawClient.Grant(c.ctx, int64(30*time.Second.Seconds()))
+// putWithTTL puts @k with a lease of @ttl which is not kept alive
+func (c *Client) putWithTTL(ctx context.Context, k, v string, ttl time.Duration) error {
+	v, err := c.encodeValue(v)
 	if err != nil {
-		return perrors.WithMessage(err, "grant lease")
+		return err
+	}
+	if ttl <= 0 {
+		ttl = c.leaseTTL()
 	}
 
-	keepAlive, err := rawClient.KeepAlive(c.ctx, lease.ID)
-	if err != nil || keepAlive == nil {
-		rawClient.Revoke(c.ctx, lease.ID)
+	err = c.do(ctx, OpPut, k, func(rawClient *clientv3.Client) error {
+		lease, err := rawClient.Grant(ctx, ttlSeconds(ttl))
 		if err != nil {
-			return perrors.WithMessage(err, "keep alive lease")
+			return perrors.WithMessage(err, "grant lease")
 		}
-		return perrors.New("keep alive lease")
+		if _, err = rawClient.Put(ctx, k, v, clientv3.WithLease(lease.ID)); err != nil {
+			rawClient.Revoke(c.ctx, lease.ID)
+			return perrors.WithMessage(err, "put k/v with lease")
+		}
+		return nil
+	})
+	if err == nil {
+		// the key is not kept alive any more if it was a temporary node
+		c.removeLease(k, clientv3.NoLease)
+		c.untrackEphemeral(k)
 	}
+	return err
+}
+
+// leaseTTL returns the ttl of the leases of ephemeral keys
+func (c *Client) leaseTTL() time.Duration {
+	if c.options != nil && c.options.LeaseTTL > 0 {
+		return c.options.LeaseTTL
+	}
+	return DefaultLeaseTTL
+}
+
+// ttlSeconds converts @ttl to seconds, which is at least 1 second
+func ttlSeconds(ttl time.Duration) int64 {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// keepAliveLoop consumes the keepalive responses of the lease of @k, and removes
+// the lease from the lease store when the keepalive fails, eg: the lease expired.
+func (c *Client) keepAliveLoop(rawClient *clientv3.Client, k string, leaseID clientv3.LeaseID,
+	keepAlive <-chan *clientv3.LeaseKeepAliveResponse) {
+	defer c.stopKeepAlive(leaseID)
 
-	_, err = rawClient.Put(c.ctx, k, v, clientv3.WithLease(lease.ID))
-	return perrors.WithMessage(err, "put k/v with lease")
+	for range keepAlive {
+		// drain the responses, the channel is closed when the keepalive fails
+	}
+
+	select {
+	case <-c.ctx.Done():
+		// the client is closed, keep the lease for the restarted client
+		return
+	default:
+	}
+	if c.GetRawClient() != rawClient {
+		// the raw client has been rebuilt by re-authentication, and the lease
+		// has been reattached to the new raw client
+		return
+	}
+	c.logger.Info("etcd client keep alive lease stopped", gxlog.Any("name", c.name), gxlog.Any("key", k), gxlog.Any("lease", int64(leaseID)))
+	c.removeLease(k, leaseID)
+}
+
+// restoreLease returns the persisted lease of @k if it is still alive
+func (c *Client) restoreLease(rawClient *clientv3.Client, k string) clientv3.LeaseID {
+	c.leaseLock.Lock()
+	id, ok := c.storedLeases[k]
+	delete(c.storedLeases, k)
+	c.leaseLock.Unlock()
+	if !ok {
+		return clientv3.NoLease
+	}
+
+	resp, err := rawClient.TimeToLive(c.ctx, clientv3.LeaseID(id))
+	if err != nil || resp.TTL <= 0 {
+		return clientv3.NoLease
+	}
+	return clientv3.LeaseID(id)
+}
+
+// removeLease removes the lease of ephemeral key @k and persists all the leases.
+// If @leaseID is not NoLease, the lease is removed only if it is still the lease of @k.
+func (c *Client) removeLease(k string, leaseID clientv3.LeaseID) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	id, ok := c.leases[k]
+	if !ok || (leaseID != clientv3.NoLease && id != leaseID) {
+		return
+	}
+	delete(c.leases, k)
+	c.stopKeepAliveLocked(id)
+	c.persistLeases()
+}
+
+// removeLeasesWithPrefix removes the leases and the ephemeral registrations of the keys with @prefix
+func (c *Client) removeLeasesWithPrefix(prefix string) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	for k := range c.ephemerals {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.ephemerals, k)
+		}
+	}
+	removed := false
+	for k, id := range c.leases {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.leases, k)
+			c.stopKeepAliveLocked(id)
+			removed = true
+		}
+	}
+	if removed {
+		c.persistLeases()
+	}
+}
+
+// NOTICE: need to get the leaseLock before calling this method
+func (c *Client) persistLeases() {
+	if c.options == nil || c.options.LeaseStore == nil {
+		return
+	}
+
+	leases := make(map[string]int64, len(c.leases))
+	for k, id := range c.leases {
+		leases[k] = int64(id)
+	}
+	if err := c.options.LeaseStore.Save(leases); err != nil {
+		c.logger.Warn("etcd client save leases error", gxlog.Any("name", c.name), gxlog.Err(err))
+	}
 }
 
 // Done return exit chan
@@ -361,12 +978,15 @@ func (c *Client) Done() <-chan struct{} {
 	return c.exit
 }
 
-// Valid check client
+// Valid check client, a client created by WithNonBlockingDial is not valid
+// until it is connected
 func (c *Client) Valid() bool {
 	select {
 	case <-c.exit:
 		return false
+	case <-c.ready:
 	default:
+		return false
 	}
 
 	c.lock.RLock()
@@ -374,50 +994,193 @@ func (c *Client) Valid() bool {
 	return c.rawClient != nil
 }
 
+// WaitReady waits until the client is connected to etcd and its session is
+// created. It returns at once if the client is not created by WithNonBlockingDial.
+func (c *Client) WaitReady(ctx context.Context) error {
+	select {
+	case <-c.ready:
+		return nil
+	case <-c.exit:
+		return ErrClientClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Create key value ...
 func (c *Client) Create(k string, v string) error {
-	err := c.put(k, v)
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.CreateWithContext(ctx, k, v)
+}
+
+// CreateWithContext creates @k if it does not exist within @ctx
+func (c *Client) CreateWithContext(ctx context.Context, k string, v string) error {
+	err := c.put(ctx, k, v)
 	return perrors.WithMessagef(err, "put k/v (key: %s value %s)", k, v)
 }
 
 // Update key value ...
 func (c *Client) Update(k, v string) error {
-	err := c.update(k, v)
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.UpdateWithContext(ctx, k, v)
+}
+
+// UpdateWithContext puts @k whether it exists or not within @ctx
+func (c *Client) UpdateWithContext(ctx context.Context, k, v string) error {
+	err := c.update(ctx, k, v)
 	return perrors.WithMessagef(err, "Update k/v (key: %s value %s)", k, v)
 }
 
 // Delete key
 func (c *Client) Delete(k string) error {
-	err := c.delete(k)
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.DeleteWithContext(ctx, k)
+}
+
+// DeleteWithContext deletes @k within @ctx
+func (c *Client) DeleteWithContext(ctx context.Context, k string) error {
+	err := c.delete(ctx, k)
 	return perrors.WithMessagef(err, "delete k/v (key %s)", k)
 }
 
-// RegisterTemp registers a temporary node
+// PutWithTTL puts @k which is deleted after @ttl, its lease is not kept alive.
+// The default lease ttl of the client is used if @ttl is not positive.
+func (c *Client) PutWithTTL(k, v string, ttl time.Duration) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.PutWithTTLWithContext(ctx, k, v, ttl)
+}
+
+// PutWithTTLWithContext is like PutWithTTL, but within @ctx
+func (c *Client) PutWithTTLWithContext(ctx context.Context, k, v string, ttl time.Duration) error {
+	err := c.putWithTTL(ctx, k, v, ttl)
+	return perrors.WithMessagef(err, "put k/v with ttl (key %s)", k)
+}
+
+// DeleteWithPrefix deletes all the keys with @prefix, and returns the number of the
+// deleted keys. ErrEmptyPrefix is returned if @prefix is empty.
+func (c *Client) DeleteWithPrefix(prefix string) (int64, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.DeleteWithPrefixWithContext(ctx, prefix)
+}
+
+// DeleteWithPrefixWithContext deletes all the keys with @prefix within @ctx
+func (c *Client) DeleteWithPrefixWithContext(ctx context.Context, prefix string) (int64, error) {
+	deleted, err := c.deleteWithPrefix(ctx, prefix)
+	return deleted, perrors.WithMessagef(err, "delete k/v with prefix (prefix %s)", prefix)
+}
+
+// RegisterTemp registers a temporary node, whose lease ttl is the default lease ttl of the client
 func (c *Client) RegisterTemp(k, v string) error {
-	err := c.keepAliveKV(k, v)
+	_, err := c.keepAliveKV(k, v, 0)
 	return perrors.WithMessagef(err, "keepalive kv (key %s)", k)
 }
 
+// RegisterTempWithTTL registers a temporary node, which is deleted when its lease is
+// not kept alive for @ttl. The lease is returned to be revoked or kept alive explicitly.
+func (c *Client) RegisterTempWithTTL(k, v string, ttl time.Duration) (clientv3.LeaseID, error) {
+	leaseID, err := c.keepAliveKV(k, v, ttl)
+	return leaseID, perrors.WithMessagef(err, "keepalive kv (key %s, ttl %v)", k, ttl)
+}
+
+// UnregisterTemp deletes the temporary node @k registered by RegisterTemp, and
+// revokes its lease instead of leaving the lease alive until it expires
+func (c *Client) UnregisterTemp(k string) error {
+	err := c.unregisterTemp(k)
+	return perrors.WithMessagef(err, "unregister temp (key %s)", k)
+}
+
 // GetChildrenKVList gets children kv list by @k
 func (c *Client) GetChildrenKVList(k string) ([]string, []string, error) {
-	kList, vList, err := c.GetChildren(k)
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetChildrenKVListWithContext(ctx, k)
+}
+
+// GetChildrenKVListWithContext gets children kv list by @k within @ctx
+func (c *Client) GetChildrenKVListWithContext(ctx context.Context, k string) ([]string, []string, error) {
+	kList, vList, err := c.getChildren(ctx, k)
 	return kList, vList, perrors.WithMessagef(err, "get key children (key %s)", k)
 }
 
+// GetChildrenKeys gets the children keys of @prefix without their values
+func (c *Client) GetChildrenKeys(prefix string) ([]string, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetChildrenKeysWithContext(ctx, prefix)
+}
+
+// GetChildrenKeysWithContext gets the children keys of @prefix within @ctx
+func (c *Client) GetChildrenKeysWithContext(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := c.getChildrenKeys(ctx, prefix)
+	return keys, perrors.WithMessagef(err, "get children keys (key %s)", prefix)
+}
+
+// Count returns the number of the keys with @prefix without fetching them
+func (c *Client) Count(prefix string) (int64, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.CountWithContext(ctx, prefix)
+}
+
+// CountWithContext returns the number of the keys with @prefix within @ctx
+func (c *Client) CountWithContext(ctx context.Context, prefix string) (int64, error) {
+	n, err := c.count(ctx, prefix)
+	return n, perrors.WithMessagef(err, "count (prefix %s)", prefix)
+}
+
 // Get gets value by @k
 func (c *Client) Get(k string) (string, error) {
-	v, err := c.get(k)
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetWithContext(ctx, k)
+}
+
+// GetWithContext gets value by @k within @ctx
+func (c *Client) GetWithContext(ctx context.Context, k string) (string, error) {
+	v, err := c.get(ctx, k)
 	return v, perrors.WithMessagef(err, "get key value (key %s)", k)
 }
 
+// GetSerializable gets value by @k with a serializable read, which may be served
+// by any member and return stale data
+func (c *Client) GetSerializable(k string) (string, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	v, err := c.get(ctx, k, clientv3.WithSerializable())
+	return v, perrors.WithMessagef(err, "get key value serializable (key %s)", k)
+}
+
+// GetChildrenKVListSerializable gets children kv list by @k with a serializable read
+func (c *Client) GetChildrenKVListSerializable(k string) ([]string, []string, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	kList, vList, err := c.getChildren(ctx, k, clientv3.WithSerializable())
+	return kList, vList, perrors.WithMessagef(err, "get key children serializable (key %s)", k)
+}
+
 // Watch watches on spec key
 func (c *Client) Watch(k string) (clientv3.WatchChan, error) {
-	wc, err := c.watch(k)
+	return c.WatchWithContext(c.ctx, k)
+}
+
+// WatchWithContext watches on spec key until @ctx is done or the client is closed
+func (c *Client) WatchWithContext(ctx context.Context, k string) (clientv3.WatchChan, error) {
+	wc, err := c.watch(ctx, k)
 	return wc, perrors.WithMessagef(err, "watch prefix (key %s)", k)
 }
 
 // WatchWithPrefix watches on spec prefix
 func (c *Client) WatchWithPrefix(prefix string) (clientv3.WatchChan, error) {
-	wc, err := c.watchWithPrefix(prefix)
+	return c.WatchWithPrefixWithContext(c.ctx, prefix)
+}
+
+// WatchWithPrefixWithContext watches on spec prefix until @ctx is done or the client is closed
+func (c *Client) WatchWithPrefixWithContext(ctx context.Context, prefix string) (clientv3.WatchChan, error) {
+	wc, err := c.watchWithPrefix(ctx, prefix)
 	return wc, perrors.WithMessagef(err, "watch prefix (key %s)", prefix)
 }
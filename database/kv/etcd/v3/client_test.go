@@ -18,11 +18,19 @@
 package gxetcd
 
 import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -31,11 +39,22 @@ import (
 	perrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"go.etcd.io/etcd/embed"
-	"go.etcd.io/etcd/mvcc/mvccpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 )
 
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+	"github.com/dubbogo/gost/database/kv/kvtest"
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+	gxlog "github.com/dubbogo/gost/log"
+	gxtime "github.com/dubbogo/gost/time"
+)
+
 const defaultEtcdV3WorkDir = "/tmp/default-dubbo-go-remote.etcd"
 
 // tests dataset
@@ -90,8 +109,9 @@ func (suite *ClientTestSuite) SetupSuite() {
 	lpurl, _ := url.Parse(DefaultListenPeerURLs)
 	lcurl, _ := url.Parse(DefaultListenClientURLs)
 	cfg := embed.NewConfig()
-	cfg.LPUrls = []url.URL{*lpurl}
-	cfg.LCUrls = []url.URL{*lcurl}
+	cfg.ListenPeerUrls = []url.URL{*lpurl}
+	cfg.ListenClientUrls = []url.URL{*lcurl}
+	cfg.AdvertiseClientUrls = []url.URL{*lcurl}
 	cfg.Dir = defaultEtcdV3WorkDir
 	e, err := embed.StartEtcd(cfg)
 	if err != nil {
@@ -289,7 +309,7 @@ func (suite *ClientTestSuite) TestClientWatch() {
 				assert.Error(t, err)
 			}
 
-			if err := c.delete(k); err != nil {
+			if err := c.delete(c.ctx, k); err != nil {
 				assert.Error(t, err)
 			}
 		}
@@ -297,7 +317,7 @@ func (suite *ClientTestSuite) TestClientWatch() {
 		c.Close()
 	}()
 
-	wc, err := c.watch(prefix)
+	wc, err := c.watch(c.ctx, prefix)
 	if err != nil {
 		assert.Error(t, err)
 	}
@@ -338,7 +358,7 @@ func (suite *ClientTestSuite) TestClientRegisterTemp() {
 	}()
 
 	completePath := path.Join("scott", "wang")
-	wc, err := observeC.watch(completePath)
+	wc, err := observeC.watch(observeC.ctx, completePath)
 	if err != nil {
 		assert.Error(t, err)
 	}
@@ -365,6 +385,1624 @@ func (suite *ClientTestSuite) TestClientRegisterTemp() {
 	assert.Contains(t, events, eDelete)
 }
 
+func (suite *ClientTestSuite) TestClientRegisterTempStopKeepAlive() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	keepAlives := func() int {
+		c.leaseLock.Lock()
+		defer c.leaseLock.Unlock()
+		return len(c.keepAlives)
+	}
+
+	first, err := c.RegisterTempWithTTL("keepalive/a", "1", 2*time.Second)
+	assert.Nil(t, err)
+	second, err := c.RegisterTempWithTTL("keepalive/a", "2", 2*time.Second)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 1, keepAlives())
+
+	// the replaced lease is not kept alive any more
+	assert.Eventually(t, func() bool {
+		resp, err := c.GetRawClient().TimeToLive(c.ctx, first)
+		return err == nil && resp.TTL <= 0
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.Nil(t, c.Delete("keepalive/a"))
+	assert.Equal(t, 0, keepAlives())
+}
+
+func (suite *ClientTestSuite) TestClientRegisterTempWithLeaseStore() {
+	t := suite.T()
+	store := NewFileLeaseStore(path.Join(defaultEtcdV3WorkDir, "leases.json"))
+
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithLeaseStore(store))
+	assert.Nil(t, err)
+	assert.Nil(t, c.RegisterTemp("scott/lease", "test"))
+	leases, err := store.Load()
+	assert.Nil(t, err)
+	leaseID, ok := leases["scott/lease"]
+	assert.True(t, ok)
+
+	// a restarted client reattaches to the still-valid lease
+	newC, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithLeaseStore(store))
+	assert.Nil(t, err)
+	assert.Nil(t, newC.RegisterTemp("scott/lease", "test"))
+	leases, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, leaseID, leases["scott/lease"])
+
+	c.Close()
+	newC.Close()
+	suite.client.Close()
+}
+
+func (suite *ClientTestSuite) TestClientLeaseStorePrune() {
+	t := suite.T()
+	defer suite.client.Close()
+	store := NewFileLeaseStore(path.Join(defaultEtcdV3WorkDir, "prune-leases.json"))
+
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithLeaseStore(store))
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.RegisterTemp("scott/deleted", "test"))
+	assert.Nil(t, c.RegisterTemp("scott/revoked", "test"))
+	leases, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(leases))
+
+	// deleting the key removes its lease
+	assert.Nil(t, c.Delete("scott/deleted"))
+	leases, err = store.Load()
+	assert.Nil(t, err)
+	_, ok := leases["scott/deleted"]
+	assert.False(t, ok)
+
+	// the lease is removed when its keepalive fails
+	_, err = c.GetRawClient().Revoke(c.GetCtx(), clientv3.LeaseID(leases["scott/revoked"]))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		leases, err = store.Load()
+		return err == nil && len(leases) == 0
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func (suite *ClientTestSuite) TestClientGetOrCreate() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	c1, err := GetOrCreate("shared", suite.etcdConfig.endpoints, WithTimeout(suite.etcdConfig.timeout))
+	assert.Nil(t, err)
+	c2, err := GetOrCreate("shared", suite.etcdConfig.endpoints, WithTimeout(suite.etcdConfig.timeout))
+	assert.Nil(t, err)
+	assert.True(t, c1 == c2)
+
+	c1.Release()
+	assert.True(t, c2.Valid())
+	c2.Release()
+	assert.False(t, c2.Valid())
+
+	c3, err := GetOrCreate("shared", suite.etcdConfig.endpoints, WithTimeout(suite.etcdConfig.timeout))
+	assert.Nil(t, err)
+	assert.True(t, c3 != c1)
+
+	// the client is shared by endpoints, whatever the name is
+	c4, err := GetOrCreate("another", suite.etcdConfig.endpoints, WithTimeout(suite.etcdConfig.timeout))
+	assert.Nil(t, err)
+	assert.True(t, c3 == c4)
+	c4.Release()
+	c5, err := GetOrCreateClient(WithName("config"), WithEndpoints(suite.etcdConfig.endpoints...),
+		WithTimeout(suite.etcdConfig.timeout))
+	assert.Nil(t, err)
+	assert.True(t, c3 == c5)
+	c5.Release()
+
+	_, err = GetOrCreate("shared", suite.etcdConfig.endpoints, WithTimeout(suite.etcdConfig.timeout),
+		WithSerializableReads())
+	assert.True(t, perrors.Is(err, ErrClientOptionsMismatch))
+	c3.Release()
+
+	// concurrent callers share one client
+	var wg sync.WaitGroup
+	clients := make([]*Client, 8)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i], _ = GetOrCreate("shared", suite.etcdConfig.endpoints, WithTimeout(suite.etcdConfig.timeout))
+		}(i)
+	}
+	wg.Wait()
+	for _, c := range clients {
+		assert.True(t, c != nil && c == clients[0])
+	}
+	for _, c := range clients {
+		c.Release()
+	}
+	assert.False(t, clients[0].Valid())
+}
+
+func (suite *ClientTestSuite) TestClientWatchBuffered() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithWatchBuffer(1, SlowConsumerCoalesce))
+	assert.Nil(t, err)
+	defer c.Close()
+
+	w, err := c.WatchWithPrefixBuffered(prefix)
+	assert.Nil(t, err)
+	defer w.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, c.update(c.ctx, "name", strconv.Itoa(i)))
+	}
+
+	var last string
+	timeout := time.After(3 * time.Second)
+	for last != "9" {
+		select {
+		case resp := <-w.C():
+			for _, e := range resp.Events {
+				last = string(e.Kv.Value)
+			}
+		case <-timeout:
+			t.Fatalf("expect the latest value 9 but got %s", last)
+		}
+	}
+	assert.True(t, w.Coalesced() > 0)
+	assert.Equal(t, uint64(0), w.Dropped())
+
+	dc, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithWatchBuffer(1, SlowConsumerDrop))
+	assert.Nil(t, err)
+	defer dc.Close()
+
+	dw, err := dc.WatchWithPrefixBuffered(prefix)
+	assert.Nil(t, err)
+	defer dw.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, dc.update(dc.ctx, "name", strconv.Itoa(i)))
+	}
+	assert.Eventually(t, func() bool {
+		return dw.Dropped() > 0 && dc.WatchDroppedEvents() == dw.Dropped()
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func (suite *ClientTestSuite) TestClientGetSerializable() {
+	t := suite.T()
+	c := suite.client
+	defer c.Close()
+
+	assert.Nil(t, c.Create("name", "scott.wang"))
+	v, err := c.GetSerializable("name")
+	assert.Nil(t, err)
+	assert.Equal(t, "scott.wang", v)
+
+	kList, _, err := c.GetChildrenKVListSerializable(prefix)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name"}, kList)
+}
+
+func (suite *ClientTestSuite) TestClientWatchChildrenDiff() {
+	t := suite.T()
+	c := suite.client
+	defer c.Close()
+
+	assert.Nil(t, c.Create("name/a", "1"))
+
+	w, err := c.WatchChildrenDiff(prefix)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	assert.Nil(t, c.Create("name/b", "2"))
+	assert.Nil(t, c.Update("name/a", "3"))
+	assert.Nil(t, c.Update("name/a", "3"))
+	assert.Nil(t, c.Delete("name/b"))
+
+	expected := []ChildEvent{
+		{Type: InstanceAdded, Key: "name/a", NewValue: "1"},
+		{Type: InstanceAdded, Key: "name/b", NewValue: "2"},
+		{Type: InstanceUpdated, Key: "name/a", OldValue: "1", NewValue: "3"},
+		{Type: InstanceRemoved, Key: "name/b", OldValue: "2"},
+	}
+	for _, e := range expected {
+		select {
+		case got := <-w.C():
+			got.Revision = 0
+			assert.Equal(t, e, got)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("expect event %+v", e)
+		}
+	}
+	assert.Equal(t, map[string]string{"name/a": "3"}, w.Snapshot())
+}
+
+func (suite *ClientTestSuite) TestClientReauth() {
+	t := suite.T()
+	c := suite.client
+	defer c.Close()
+
+	var failures int32
+	c.options.AuthFailureThreshold = 1
+	c.options.AuthFailureHandler = func(n int, err error) {
+		atomic.StoreInt32(&failures, int32(n))
+	}
+	assert.Equal(t, ErrAuthCredentialsMissing, c.reauth(c.GetRawClient(), rpctypes.ErrInvalidAuthToken))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failures))
+
+	// the credentials are not used until auth is enabled
+	ac, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithAuth("root", "root"))
+	assert.Nil(t, err)
+	defer ac.Close()
+	wc, err := ac.WatchWithPrefix(prefix)
+	assert.Nil(t, err)
+
+	// enable auth mid-flight
+	rawClient := c.GetRawClient()
+	_, err = rawClient.RoleAdd(c.GetCtx(), "root")
+	assert.Nil(t, err)
+	_, err = rawClient.UserAdd(c.GetCtx(), "root", "root")
+	assert.Nil(t, err)
+	_, err = rawClient.UserGrantRole(c.GetCtx(), "root", "root")
+	assert.Nil(t, err)
+	_, err = rawClient.AuthEnable(c.GetCtx())
+	assert.Nil(t, err)
+	defer func() {
+		_, err := ac.GetRawClient().AuthDisable(ac.GetCtx())
+		assert.Nil(t, err)
+		_, err = rawClient.UserDelete(c.GetCtx(), "root")
+		assert.Nil(t, err)
+		_, err = rawClient.RoleDelete(c.GetCtx(), "root")
+		assert.Nil(t, err)
+	}()
+
+	_, err = c.Get("name")
+	assert.True(t, isAuthError(perrors.Cause(err)))
+
+	// the client re-authenticates transparently. Read first, since the embedded
+	// server can not log the failed write requests.
+	old := ac.GetRawClient()
+	_, err = ac.Get("name")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+	assert.True(t, old != ac.GetRawClient())
+	assert.Nil(t, ac.Create("name", "scott.wang"))
+	// the old raw client has been replaced, nothing to do
+	assert.Nil(t, ac.reauth(old, rpctypes.ErrInvalidAuthToken))
+
+	v, err := ac.Get("name")
+	assert.Nil(t, err)
+	assert.Equal(t, "scott.wang", v)
+	assert.Nil(t, ac.RegisterTemp("name/temp", "temp"))
+
+	// the watch resumes on the new raw client
+	keys := make(map[string]bool)
+	timeout := time.After(3 * time.Second)
+	for !keys["name"] || !keys["name/temp"] {
+		select {
+		case resp, ok := <-wc:
+			assert.True(t, ok)
+			for _, e := range resp.Events {
+				keys[string(e.Kv.Key)] = true
+			}
+		case <-timeout:
+			t.Fatalf("expect the watch events but got %v", keys)
+		}
+	}
+	assert.True(t, ac.Valid())
+}
+
+func (suite *ClientTestSuite) TestClientCompression() {
+	t := suite.T()
+	c := suite.client
+	defer c.Close()
+
+	assert.Nil(t, c.Create("name/plain", "scott.wang"))
+
+	cc, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints, suite.etcdConfig.timeout,
+		suite.etcdConfig.heartbeat, WithCompression(gxcompress.NewGzipCodec(gzip.BestSpeed), 16))
+	assert.Nil(t, err)
+	defer cc.Close()
+
+	// the values compressed by the other codecs are readable as well
+	sc, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints, suite.etcdConfig.timeout,
+		suite.etcdConfig.heartbeat, WithCompression(gxcompress.NewSnappyCodec(), 16))
+	assert.Nil(t, err)
+	defer sc.Close()
+	assert.Nil(t, sc.Create("snappy", strings.Repeat("snappy", 64)))
+	v, err := cc.Get("snappy")
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("snappy", 64), v)
+
+	wc, err := cc.WatchWithPrefix(prefix)
+	assert.Nil(t, err)
+	dw, err := cc.WatchChildrenDiff(prefix)
+	assert.Nil(t, err)
+	defer dw.Close()
+
+	value := strings.Repeat("dubbo-go", 64)
+	assert.Nil(t, cc.Create("name/large", value))
+	assert.Nil(t, cc.Create("name/small", "small"))
+
+	// the watches deliver the decompressed values
+	select {
+	case resp := <-wc:
+		assert.Equal(t, value, string(resp.Events[0].Kv.Value))
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the watch event of name/large")
+	}
+	for {
+		select {
+		case e := <-dw.C():
+			if e.Key != "name/large" {
+				continue
+			}
+			assert.Equal(t, value, e.NewValue)
+		case <-time.After(3 * time.Second):
+			t.Fatal("expect the children event of name/large")
+		}
+		break
+	}
+
+	resp, err := c.GetRawClient().Get(c.GetCtx(), "name/large")
+	assert.Nil(t, err)
+	assert.True(t, gxcompress.IsCompressed(resp.Kvs[0].Value))
+
+	v, err = cc.Get("name/large")
+	assert.Nil(t, err)
+	assert.Equal(t, value, v)
+
+	kList, vList, err := cc.GetChildrenKVList(prefix)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name/large", "name/plain", "name/small"}, kList)
+	assert.Equal(t, []string{value, "scott.wang", "small"}, vList)
+}
+
+func (suite *ClientTestSuite) TestClientReconnect() {
+	t := suite.T()
+
+	states := make(chan ConnState, 8)
+	c, err := NewClient("reconnect", suite.etcdConfig.endpoints, suite.etcdConfig.timeout, suite.etcdConfig.heartbeat,
+		WithReconnect(gxtime.ConstantBackoff(10*time.Millisecond), 0),
+		WithConnStateHandler(func(state ConnState) {
+			states <- state
+		}))
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Equal(t, ConnConnected, <-states)
+	events := c.SubscribeStateChanges()
+
+	wc, err := c.Watch("reconnect")
+	assert.Nil(t, err)
+	assert.Nil(t, c.RegisterTemp("reconnect/temp", "t"))
+
+	// revoking the leases loses the session and the temporary node, the session is
+	// recreated on the same connection and the temporary node is registered again
+	c.lock.RLock()
+	s := c.session
+	c.lock.RUnlock()
+	c.leaseLock.Lock()
+	tempLease := c.leases["reconnect/temp"]
+	c.leaseLock.Unlock()
+	_, err = c.GetRawClient().Revoke(c.GetCtx(), tempLease)
+	assert.Nil(t, err)
+	_, err = c.GetRawClient().Revoke(c.GetCtx(), s.Lease())
+	assert.Nil(t, err)
+
+	assert.Equal(t, ConnSessionRecreated, <-states)
+	assert.True(t, c.Valid())
+	c.lock.RLock()
+	assert.True(t, c.session != s)
+	c.lock.RUnlock()
+	v, err := c.Get("reconnect/temp")
+	assert.Nil(t, err)
+	assert.Equal(t, "t", v)
+
+	assert.Nil(t, c.Create("reconnect", "v"))
+	resp := <-wc
+	assert.Equal(t, 1, len(resp.Events))
+	assert.Equal(t, "v", string(resp.Events[0].Kv.Value))
+
+	// the client re-dials when the connection is lost
+	c.GetRawClient().Close()
+	assert.Equal(t, ConnDisconnected, <-states)
+	assert.Equal(t, ConnReconnected, <-states)
+	assert.True(t, c.Valid())
+	v, err = c.Get("reconnect/temp")
+	assert.Nil(t, err)
+	assert.Equal(t, "t", v)
+
+	c.Close()
+	assert.Equal(t, ConnClosed, <-states)
+
+	// the subscribers receive the same changes, and the chan is closed after ConnClosed
+	var subscribed []ConnState
+	for e := range events {
+		subscribed = append(subscribed, e.State)
+	}
+	assert.Equal(t, []ConnState{ConnSessionRecreated, ConnDisconnected, ConnReconnected, ConnClosed}, subscribed)
+	_, ok := <-c.SubscribeStateChanges()
+	assert.False(t, ok)
+}
+
+func (suite *ClientTestSuite) TestClientBatch() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	kvs := make(map[string]string)
+	keys := make([]string, 0, 300)
+	for i := 0; i < 300; i++ {
+		k := "batch/" + strconv.Itoa(i)
+		kvs[k] = strconv.Itoa(i)
+		keys = append(keys, k)
+	}
+	assert.Nil(t, c.BatchPut(kvs))
+
+	got, err := c.BatchGet(append(keys, "batch/missing"))
+	assert.Nil(t, err)
+	assert.Equal(t, kvs, got)
+
+	assert.Nil(t, c.BatchDelete(keys[:200]))
+	got, err = c.BatchGet(keys)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, len(got))
+	_, ok := got["batch/0"]
+	assert.False(t, ok)
+	assert.Equal(t, "299", got["batch/299"])
+
+	got, err = c.GetValues("batch/1", "batch/299", "batch/missing")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"batch/299": "299"}, got)
+}
+
+func (suite *ClientTestSuite) TestClientGetWithRevision() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	_, _, err := c.GetWithRevision("rev/a")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+
+	assert.Nil(t, c.Create("rev/a", "1"))
+	v, rev, err := c.GetWithRevision("rev/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	assert.Nil(t, c.Update("rev/a", "2"))
+	_, rev2, err := c.GetWithRevision("rev/a")
+	assert.Nil(t, err)
+	assert.True(t, rev2 > rev)
+
+	assert.Nil(t, c.Create("rev/b", "3"))
+	kvs, rev, err := c.GetChildrenWithRevision("rev/")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(kvs))
+	assert.Equal(t, "rev/a", kvs[0].Key)
+	assert.Equal(t, "2", kvs[0].Value)
+	assert.Equal(t, int64(2), kvs[0].Version)
+	assert.Equal(t, rev2, kvs[0].ModRevision)
+	assert.True(t, kvs[0].CreateRevision < kvs[0].ModRevision)
+	assert.True(t, rev >= kvs[1].ModRevision)
+
+	// no event after the read is missed by watching from the next revision
+	assert.Nil(t, c.Delete("rev/b"))
+	wc, err := c.watchWithAuthRetry(c.ctx, "rev/", clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+	assert.Nil(t, err)
+	resp := <-wc
+	assert.Equal(t, mvccpb.DELETE, resp.Events[0].Type)
+	assert.Equal(t, "rev/b", string(resp.Events[0].Kv.Key))
+}
+
+func (suite *ClientTestSuite) TestClientWatchFunc() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	type event struct {
+		typ        EventType
+		key, value string
+	}
+	events := make(chan event, 8)
+	cancel, err := c.WatchWithPrefixFunc("func/", func(typ EventType, key, value string) {
+		events <- event{typ, key, value}
+	})
+	assert.Nil(t, err)
+	defer cancel()
+
+	assert.Nil(t, c.Create("func/a", "1"))
+	assert.Nil(t, c.Delete("func/a"))
+	assert.Equal(t, event{EventTypePut, "func/a", "1"}, <-events)
+	assert.Equal(t, event{EventTypeDelete, "func/a", ""}, <-events)
+
+	// the watch is re-established after the raw client is replaced
+	c.lock.Lock()
+	old := c.rawClient
+	c.rawClient = suite.setUpClient().rawClient
+	c.lock.Unlock()
+	old.Close()
+
+	assert.Nil(t, c.Create("func/b", "2"))
+	assert.Equal(t, event{EventTypePut, "func/b", "2"}, <-events)
+
+	cancel()
+	assert.Nil(t, c.Create("func/c", "3"))
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %v after cancel", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (suite *ClientTestSuite) TestClientLock() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+	other := suite.setUpClient()
+	defer other.Close()
+
+	u, err := c.Lock("lock/a", 0)
+	assert.Nil(t, err)
+
+	_, err = other.TryLock("lock/a", 5*time.Second)
+	assert.Equal(t, ErrLocked, perrors.Cause(err))
+
+	acquired := make(chan Unlocker)
+	go func() {
+		u, err := other.Lock("lock/a", 5*time.Second)
+		assert.Nil(t, err)
+		acquired <- u
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the lock should be held")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	assert.Nil(t, u.Unlock())
+	u2 := <-acquired
+	assert.Nil(t, u2.Unlock())
+
+	u, err = c.TryLock("lock/a", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, u.Unlock())
+}
+
+func (suite *ClientTestSuite) TestClientRegisterTempWithTTL() {
+	t := suite.T()
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints, suite.etcdConfig.timeout,
+		suite.etcdConfig.heartbeat, WithLeaseTTL(20*time.Second))
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.RegisterTemp("ttl/default", "v"))
+	c.leaseLock.Lock()
+	id := c.leases["ttl/default"]
+	c.leaseLock.Unlock()
+	resp, err := c.GetRawClient().TimeToLive(c.GetCtx(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), resp.GrantedTTL)
+
+	id, err = c.RegisterTempWithTTL("ttl/custom", "v", 1500*time.Millisecond)
+	assert.Nil(t, err)
+	resp, err = c.GetRawClient().TimeToLive(c.GetCtx(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), resp.GrantedTTL)
+
+	_, err = c.GetRawClient().Revoke(c.GetCtx(), id)
+	assert.Nil(t, err)
+	_, err = c.Get("ttl/custom")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+}
+
+func (suite *ClientTestSuite) TestClientUnregisterTemp() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	id, err := c.RegisterTempWithTTL("unregister/a", "v", 10*time.Second)
+	assert.Nil(t, err)
+	assert.Nil(t, c.UnregisterTemp("unregister/a"))
+
+	_, err = c.Get("unregister/a")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+	resp, err := c.GetRawClient().TimeToLive(c.GetCtx(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), resp.TTL)
+	c.leaseLock.Lock()
+	assert.Equal(t, 0, len(c.leases))
+	c.leaseLock.Unlock()
+
+	// unregistering a plain key just deletes it
+	assert.Nil(t, c.Create("unregister/b", "v"))
+	assert.Nil(t, c.UnregisterTemp("unregister/b"))
+	_, err = c.Get("unregister/b")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+}
+
+func (suite *ClientTestSuite) TestClientGetChildrenKeys() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	_, err := c.GetChildrenKeys("keys/")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+
+	assert.Nil(t, c.Create("keys/a", "1"))
+	assert.Nil(t, c.Create("keys/b", "2"))
+	keys, err := c.GetChildrenKeys("keys/")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"keys/a", "keys/b"}, keys)
+}
+
+func (suite *ClientTestSuite) TestClientCAS() {
+	t := suite.T()
+	suite.client.Close()
+	for _, opts := range [][]Option{
+		nil,
+		{WithCompression(gxcompress.NewGzipCodec(gzip.BestSpeed), 0)},
+	} {
+		func() {
+			c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints, suite.etcdConfig.timeout,
+				suite.etcdConfig.heartbeat, opts...)
+			assert.Nil(t, err)
+			defer c.Close()
+			c.CleanKV()
+
+			ok, err := c.CAS("cas/a", "1", "2")
+			assert.Nil(t, err)
+			assert.False(t, ok)
+
+			assert.Nil(t, c.Create("cas/a", "1"))
+			ok, err = c.CAS("cas/a", "0", "2")
+			assert.Nil(t, err)
+			assert.False(t, ok)
+			ok, err = c.CAS("cas/a", "1", "2")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			v, err := c.Get("cas/a")
+			assert.Nil(t, err)
+			assert.Equal(t, "2", v)
+
+			ok, err = c.CAD("cas/a", "1")
+			assert.Nil(t, err)
+			assert.False(t, ok)
+			ok, err = c.CAD("cas/a", "2")
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			_, err = c.Get("cas/a")
+			assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+		}()
+	}
+}
+
+func (suite *ClientTestSuite) TestClientWithContext() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, c.CreateWithContext(ctx, "ctx/a", "1"))
+	assert.Nil(t, c.UpdateWithContext(ctx, "ctx/a", "2"))
+	v, err := c.GetWithContext(ctx, "ctx/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+	keys, err := c.GetChildrenKeysWithContext(ctx, "ctx/")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ctx/a"}, keys)
+
+	wctx, wcancel := context.WithCancel(context.Background())
+	wc, err := c.WatchWithPrefixWithContext(wctx, "ctx/")
+	assert.Nil(t, err)
+	assert.Nil(t, c.DeleteWithContext(ctx, "ctx/a"))
+	resp := <-wc
+	assert.Equal(t, mvccpb.DELETE, resp.Events[0].Type)
+	wcancel()
+	for range wc {
+		// the chan is closed after the context is cancelled
+	}
+
+	cancelled, cancel2 := context.WithCancel(context.Background())
+	cancel2()
+	_, err = c.GetWithContext(cancelled, "ctx/a")
+	assert.Equal(t, context.Canceled, perrors.Cause(err))
+	_, err = c.BatchGetWithContext(cancelled, []string{"ctx/a"})
+	assert.Equal(t, context.Canceled, perrors.Cause(err))
+}
+
+func (suite *ClientTestSuite) TestClientNamespace() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	nc, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints, suite.etcdConfig.timeout,
+		suite.etcdConfig.heartbeat, WithNamespace("app1/"))
+	assert.Nil(t, err)
+	defer nc.Close()
+
+	wc, err := nc.WatchWithPrefix("service/")
+	assert.Nil(t, err)
+
+	assert.Nil(t, nc.Create("service/a", "1"))
+	assert.Nil(t, nc.RegisterTemp("service/b", "2"))
+	v, err := c.Get("app1/service/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	kList, _, err := nc.GetChildrenKVList("service/")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"service/a", "service/b"}, kList)
+	resp := <-wc
+	assert.Equal(t, "service/a", string(resp.Events[0].Kv.Key))
+
+	// the keys out of the namespace are invisible
+	assert.Nil(t, c.Create("service/c", "3"))
+	_, err = nc.Get("service/c")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+	ok, err := nc.CAS("service/a", "1", "4")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	v, err = c.Get("app1/service/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "4", v)
+}
+
+func (suite *ClientTestSuite) TestClientMetrics() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	var observed sync.Map
+	mc, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints, suite.etcdConfig.timeout,
+		suite.etcdConfig.heartbeat, WithOpObserver(func(op string, latency time.Duration, err error) {
+			observed.Store(op, true)
+		}))
+	assert.Nil(t, err)
+	defer mc.Close()
+
+	assert.Nil(t, mc.Create("metrics/a", "1"))
+	_, err = mc.Get("metrics/a")
+	assert.Nil(t, err)
+	_, err = mc.Get("metrics/none")
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+	assert.Nil(t, mc.Delete("metrics/a"))
+
+	m := mc.Metrics()
+	assert.Equal(t, uint64(1), m.Ops[OpPut].Count)
+	assert.Equal(t, uint64(2), m.Ops[OpGet].Count)
+	assert.Equal(t, uint64(1), m.Ops[OpDelete].Count)
+	assert.Equal(t, uint64(0), m.Ops[OpGet].Errors)
+	assert.True(t, m.Ops[OpGet].Max > 0)
+	assert.True(t, m.Ops[OpGet].P99 <= m.Ops[OpGet].Max)
+	for _, op := range []string{OpPut, OpGet, OpDelete} {
+		_, ok := observed.Load(op)
+		assert.True(t, ok, op)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	assert.Equal(t, defaultLogger, loggerOf(&Options{}))
+
+	logger := gxlog.NewNopLogger()
+	options := &Options{}
+	WithLogger(logger)(options)
+	assert.Equal(t, logger, loggerOf(options))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.False(t, IsRetryable(nil))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(rpctypes.ErrGRPCKeyNotFound))
+	assert.True(t, IsRetryable(rpctypes.ErrGRPCTimeout))
+	assert.True(t, IsRetryable(rpctypes.ErrGRPCLeaderChanged))
+	assert.True(t, IsRetryable(rpctypes.ErrGRPCNoLeader))
+}
+
+func (suite *ClientTestSuite) TestClientRetry() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	c.options.RetryPolicy = &RetryPolicy{MaxAttempts: 3, Backoff: gxtime.ConstantBackoff(time.Millisecond)}
+	defer func() { c.options.RetryPolicy = nil }()
+
+	attempts := 0
+	err := c.do(c.ctx, OpGet, "", func(rawClient *clientv3.Client) error {
+		attempts++
+		if attempts < 3 {
+			return rpctypes.ErrGRPCTimeout
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// the attempts are limited
+	attempts = 0
+	err = c.do(c.ctx, OpPut, "", func(rawClient *clientv3.Client) error {
+		attempts++
+		return rpctypes.ErrGRPCNoLeader
+	})
+	assert.Equal(t, rpctypes.ErrGRPCNoLeader, err)
+	assert.Equal(t, 3, attempts)
+
+	// the non-retryable errors and operations are not retried
+	attempts = 0
+	err = c.do(c.ctx, OpGet, "", func(rawClient *clientv3.Client) error {
+		attempts++
+		return ErrKVPairNotFound
+	})
+	assert.Equal(t, ErrKVPairNotFound, err)
+	assert.Equal(t, 1, attempts)
+	attempts = 0
+	_ = c.do(c.ctx, OpTxn, "", func(rawClient *clientv3.Client) error {
+		attempts++
+		return rpctypes.ErrGRPCTimeout
+	})
+	assert.Equal(t, 1, attempts)
+}
+
+func (suite *ClientTestSuite) TestClientWatchWithOption() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	wc, err := c.WatchWithOptionWithContext(ctx, "opt/", WithWatchPrefix(), WithDeleteOnly(), WithPrevKV())
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.Create("opt/a", "1"))
+	_, rev, err := c.GetWithRevision("opt/a")
+	assert.Nil(t, err)
+	assert.Nil(t, c.Delete("opt/a"))
+	resp := <-wc
+	assert.Equal(t, 1, len(resp.Events))
+	assert.Equal(t, mvccpb.DELETE, resp.Events[0].Type)
+	assert.Equal(t, "1", string(resp.Events[0].PrevKv.Value))
+
+	// the events since the start revision are replayed
+	wc, err = c.WatchWithOptionWithContext(ctx, "opt/a", WithPutOnly(), WithStartRevision(rev))
+	assert.Nil(t, err)
+	resp = <-wc
+	assert.Equal(t, mvccpb.PUT, resp.Events[0].Type)
+	assert.Equal(t, "1", string(resp.Events[0].Kv.Value))
+}
+
+func (suite *ClientTestSuite) TestClientRangeIterator() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	for i := 0; i < 25; i++ {
+		assert.Nil(t, c.Create("iter/"+strconv.Itoa(100+i), strconv.Itoa(i)))
+	}
+	assert.Nil(t, c.Create("iteration", "x"))
+
+	it := c.RangeIteratorWithContext(c.ctx, "iter/", 10)
+	var keys []string
+	for i := 0; ; i++ {
+		k, v, ok := it.Next()
+		if !ok {
+			break
+		}
+		if i == 0 {
+			// the keys changed after the first page are invisible
+			assert.Nil(t, c.Create("iter/199", "new"))
+		}
+		assert.Equal(t, strconv.Itoa(i), v)
+		keys = append(keys, k)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 25, len(keys))
+	assert.Equal(t, "iter/100", keys[0])
+	assert.Equal(t, "iter/124", keys[24])
+
+	it = c.RangeIterator("none/")
+	_, _, ok := it.Next()
+	assert.False(t, ok)
+	assert.Nil(t, it.Err())
+}
+
+func (suite *ClientTestSuite) TestClientCount() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	n, err := c.Count("count/")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	assert.Nil(t, c.Create("count/a", "1"))
+	assert.Nil(t, c.Create("count/b", "2"))
+	assert.Nil(t, c.Create("counter", "3"))
+	n, err = c.Count("count/")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func (suite *ClientTestSuite) TestClientDeleteWithPrefix() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	_, err := c.DeleteWithPrefix("")
+	assert.Equal(t, ErrEmptyPrefix, perrors.Cause(err))
+
+	assert.Nil(t, c.Create("del/a", "1"))
+	assert.Nil(t, c.RegisterTemp("del/b", "2"))
+	assert.Nil(t, c.Create("delta", "3"))
+	deleted, err := c.DeleteWithPrefix("del/")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), deleted)
+	c.leaseLock.Lock()
+	_, ok := c.leases["del/b"]
+	c.leaseLock.Unlock()
+	assert.False(t, ok)
+
+	deleted, err = c.DeleteWithPrefix("del/")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), deleted)
+	v, err := c.Get("delta")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func (suite *ClientTestSuite) TestClientPutWithTTL() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	assert.Nil(t, c.PutWithTTL("ttl/flag", "1", time.Second))
+	v, err := c.Get("ttl/flag")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	// overwrites the existing key
+	assert.Nil(t, c.PutWithTTL("ttl/flag", "2", time.Second))
+	v, err = c.Get("ttl/flag")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+
+	assert.Eventually(t, func() bool {
+		_, err := c.Get("ttl/flag")
+		return perrors.Cause(err) == ErrKVPairNotFound
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func (suite *ClientTestSuite) TestClientMaintenance() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	statuses, err := c.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, len(suite.etcdConfig.endpoints), len(statuses))
+	ep := suite.etcdConfig.endpoints[0]
+	assert.True(t, statuses[ep].IsLeader)
+	assert.True(t, statuses[ep].DBSize > 0)
+
+	assert.Nil(t, c.Create("compact/a", "1"))
+	assert.Nil(t, c.Update("compact/a", "2"))
+	_, rev, err := c.GetWithRevision("compact/a")
+	assert.Nil(t, err)
+	assert.Nil(t, c.Compact(rev))
+	_, err = c.GetRawClient().Get(c.GetCtx(), "compact/a", clientv3.WithRev(rev-1))
+	assert.Equal(t, rpctypes.ErrCompacted, err)
+
+	assert.Nil(t, c.Defragment(ep))
+	assert.Equal(t, uint64(3), c.Metrics().Ops[OpMaintenance].Count)
+}
+
+func (suite *ClientTestSuite) TestClientWatchWithPrefixDebounced() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	notified := make(chan int, 8)
+	cancel, err := c.WatchWithPrefixDebounced("debounce/", 300*time.Millisecond, func(prefix string, events int) {
+		assert.Equal(t, "debounce/", prefix)
+		notified <- events
+	})
+	assert.Nil(t, err)
+	defer cancel()
+
+	// the first event is notified at once
+	assert.Nil(t, c.Create("debounce/0", "v"))
+	assert.Equal(t, 1, <-notified)
+
+	// the burst is coalesced into one notification
+	for i := 1; i <= 10; i++ {
+		assert.Nil(t, c.Create("debounce/"+strconv.Itoa(i), "v"))
+	}
+	total := 0
+	for total < 10 {
+		select {
+		case n := <-notified:
+			total += n
+		case <-time.After(3 * time.Second):
+			t.Fatalf("only %d events are notified", total)
+		}
+	}
+	assert.Equal(t, 10, total)
+	assert.True(t, len(notified) == 0)
+}
+
+func (suite *ClientTestSuite) TestClientSnapshot() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	assert.Nil(t, c.Create("snapshot/a", "1"))
+
+	dir, err := os.MkdirTemp("", "gxetcd-snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	f, err := os.Create(path.Join(dir, "backup.db"))
+	assert.Nil(t, err)
+	assert.Nil(t, c.Snapshot(c.GetCtx(), f))
+	assert.Nil(t, f.Close())
+
+	if _, err = exec.LookPath("etcdutl"); err != nil {
+		t.Skip("etcdutl is not installed")
+	}
+	dataDir := path.Join(dir, "restored")
+	err = RestoreFromSnapshot(RestoreConfig{
+		SnapshotPath:   f.Name(),
+		Name:           "restored",
+		OutputDataDir:  dataDir,
+		PeerURLs:       []string{"http://localhost:12380"},
+		InitialCluster: "restored=http://localhost:12380",
+	})
+	assert.Nil(t, err)
+	_, err = os.Stat(path.Join(dataDir, "member", "snap", "db"))
+	assert.Nil(t, err)
+
+	// the data dir exists
+	assert.NotNil(t, RestoreFromSnapshot(RestoreConfig{
+		SnapshotPath:   f.Name(),
+		Name:           "restored",
+		OutputDataDir:  dataDir,
+		PeerURLs:       []string{"http://localhost:12380"},
+		InitialCluster: "restored=http://localhost:12380",
+	}))
+}
+
+func (suite *ClientTestSuite) TestClientGetRange() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	for _, k := range []string{"log/c", "log/a", "log/d", "log/b"} {
+		assert.Nil(t, c.Create(k, k))
+	}
+	assert.Nil(t, c.Create("logs", "x"))
+
+	kvs, err := c.GetRange("log/", PrefixRangeEnd("log/"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(kvs))
+	assert.Equal(t, "log/a", kvs[0].Key)
+
+	// the latest 2 entries
+	kvs, err = c.GetRange("log/", PrefixRangeEnd("log/"), WithSort(SortByModRevision, SortDescend), WithLimit(2))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(kvs))
+	assert.Equal(t, "log/b", kvs[0].Key)
+	assert.Equal(t, "log/d", kvs[1].Key)
+
+	kvs, err = c.GetRange("log/b", "log/d", WithSort(SortByKey, SortDescend))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(kvs))
+	assert.Equal(t, "log/c", kvs[0].Key)
+	assert.Equal(t, "log/b", kvs[1].Key)
+}
+
+func (suite *ClientTestSuite) TestClientDialOptions() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	c, err := NewClient("dial", suite.etcdConfig.endpoints, suite.etcdConfig.timeout, suite.etcdConfig.heartbeat,
+		WithKeepAlive(10*time.Second, 3*time.Second), WithMaxMsgSize(1024, 1024),
+		WithGRPCDialOptions(grpc.WithUserAgent("gxetcd-test")))
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Create("dial/small", "v"))
+	err = c.Create("dial/large", strings.Repeat("v", 2048))
+	assert.NotNil(t, err)
+}
+
+func (suite *ClientTestSuite) TestClientNonBlockingDial() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	// the client is created at once even if etcd is unreachable
+	start := time.Now()
+	c, err := NewClient("non-blocking", []string{"127.0.0.1:1"}, time.Second, suite.etcdConfig.heartbeat,
+		WithNonBlockingDial())
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.False(t, c.Valid())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	assert.Equal(t, context.DeadlineExceeded, c.WaitReady(ctx))
+	cancel()
+	c.Close()
+	assert.NotNil(t, c.WaitReady(context.Background()))
+
+	c, err = NewClient("non-blocking", suite.etcdConfig.endpoints, suite.etcdConfig.timeout, suite.etcdConfig.heartbeat,
+		WithNonBlockingDial())
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Nil(t, c.WaitReady(context.Background()))
+	assert.True(t, c.Valid())
+	assert.Nil(t, c.Create("non-blocking", "v"))
+}
+
+func (suite *ClientTestSuite) TestClientAutoSync() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	// the unreachable endpoint is replaced by the member list of the cluster
+	c, err := NewClient("auto-sync", append([]string{"127.0.0.1:1"}, suite.etcdConfig.endpoints...),
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithAutoSyncInterval(100*time.Millisecond))
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Eventually(t, func() bool {
+		eps := c.GetRawClient().Endpoints()
+		return len(eps) == 1 && eps[0] == "http://localhost:2381"
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.Nil(t, c.Create("auto-sync", "v"))
+}
+
+func (suite *ClientTestSuite) TestClientObject() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	type instance struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	assert.Nil(t, c.PutObject("object/a", instance{Host: "127.0.0.1", Port: 20000}))
+	assert.Nil(t, c.PutObject("object/a", instance{Host: "127.0.0.1", Port: 20001}))
+	v, err := c.Get("object/a")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"host":"127.0.0.1","port":20001}`, v)
+
+	var got instance
+	assert.Nil(t, c.GetObject("object/a", &got))
+	assert.Equal(t, instance{Host: "127.0.0.1", Port: 20001}, got)
+	err = c.GetObject("object/none", &got)
+	assert.Equal(t, ErrKVPairNotFound, perrors.Cause(err))
+
+	pc, err := NewClient("object", suite.etcdConfig.endpoints, suite.etcdConfig.timeout, suite.etcdConfig.heartbeat,
+		WithObjectCodec(ProtoCodec{}))
+	assert.Nil(t, err)
+	defer pc.Close()
+	assert.NotNil(t, pc.PutObject("object/b", got))
+	kv := &mvccpb.KeyValue{Key: []byte("k"), Value: []byte("v"), Version: 3}
+	assert.Nil(t, pc.PutObject("object/b", kv))
+	gotKV := &mvccpb.KeyValue{}
+	assert.Nil(t, pc.GetObject("object/b", gotKV))
+	assert.Equal(t, kv.Version, gotKV.Version)
+	assert.Equal(t, "v", string(gotKV.Value))
+}
+
+func (suite *ClientTestSuite) TestRegistry() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	r := NewRegistry(c, WithRegistryRoot("/registry"))
+	assert.Equal(t, ErrInvalidInstance, r.Register(ServiceInstance{Host: "127.0.0.1"}))
+
+	a := ServiceInstance{Service: "greeter", Host: "127.0.0.1", Port: 20000}
+	assert.Nil(t, r.Register(a))
+	v, err := c.Get("/registry/greeter/127.0.0.1:20000")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(v, `"port":20000`))
+
+	updates := make(chan []ServiceInstance, 8)
+	cancel, err := r.Subscribe("greeter", func(service string, instances []ServiceInstance) {
+		assert.Equal(t, "greeter", service)
+		updates <- instances
+	})
+	assert.Nil(t, err)
+	defer cancel()
+	instances := <-updates
+	assert.Equal(t, 1, len(instances))
+	assert.Equal(t, "127.0.0.1:20000", instances[0].ID)
+
+	b := ServiceInstance{ID: "b", Service: "greeter", Host: "127.0.0.2", Port: 20000, Metadata: map[string]string{"zone": "z1"}}
+	assert.Nil(t, r.Register(b))
+	instances = <-updates
+	assert.Equal(t, 2, len(instances))
+	assert.Equal(t, "z1", instances[1].Metadata["zone"])
+
+	assert.Nil(t, r.Deregister(a))
+	instances = <-updates
+	assert.Equal(t, []ServiceInstance{b}, instances)
+
+	instances, err = r.ListInstances("greeter")
+	assert.Nil(t, err)
+	assert.Equal(t, []ServiceInstance{b}, instances)
+	instances, err = r.ListInstances("none")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(instances))
+}
+
+func (suite *ClientTestSuite) TestClientCounter() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	ct := c.Counter("counter/seq")
+	n, err := ct.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = ct.Incr(10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), n)
+	n, err = ct.Incr(-3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), n)
+
+	// the ids are unique among the concurrent callers
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ids = make(map[int64]bool)
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				id, err := c.Counter("counter/seq").NextID()
+				assert.Nil(t, err)
+				mu.Lock()
+				ids[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, len(ids))
+	n, err = ct.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(57), n)
+}
+
+func (suite *ClientTestSuite) TestClientDoSTM() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	assert.Nil(t, c.Create("stm/a", "100"))
+	assert.Nil(t, c.Create("stm/b", "0"))
+
+	// transfer between the accounts concurrently, the total keeps the same
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.DoSTM(func(stm STM) error {
+				a, _ := strconv.Atoi(stm.Get("stm/a"))
+				b, _ := strconv.Atoi(stm.Get("stm/b"))
+				stm.Put("stm/a", strconv.Itoa(a-5))
+				stm.Put("stm/b", strconv.Itoa(b+5))
+				return nil
+			})
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	got, err := c.GetValues("stm/a", "stm/b")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"stm/a": "50", "stm/b": "50"}, got)
+
+	aborted := perrors.New("aborted")
+	err = c.DoSTM(func(stm STM) error {
+		stm.Put("stm/a", "0")
+		return aborted
+	})
+	assert.Equal(t, aborted, perrors.Cause(err))
+	v, err := c.Get("stm/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "50", v)
+}
+
+func TestClassifyError(t *testing.T) {
+	c := &Client{exit: make(chan struct{})}
+	assert.Nil(t, c.classifyError(nil))
+
+	err := c.classifyError(perrors.WithMessage(rpctypes.ErrGRPCLeaseNotFound, "revoke"))
+	assert.True(t, errors.Is(err, ErrSessionExpired))
+	assert.Equal(t, rpctypes.ErrGRPCLeaseNotFound, perrors.Cause(err))
+	assert.True(t, errors.Is(perrors.WithMessage(err, "unregister"), ErrSessionExpired))
+
+	err = c.classifyError(ErrNilETCDV3Client)
+	assert.True(t, errors.Is(err, ErrClientClosed))
+	assert.True(t, errors.Is(err, ErrNilETCDV3Client))
+	assert.True(t, errors.Is(c.classifyError(rpctypes.ErrGRPCDuplicateKey), ErrKeyExists))
+
+	// the canceled requests are classified only when the client is stopped
+	assert.Equal(t, context.Canceled, c.classifyError(context.Canceled))
+	c.stop()
+	assert.True(t, errors.Is(c.classifyError(context.Canceled), ErrClientClosed))
+
+	other := perrors.New("other")
+	assert.Equal(t, other, c.classifyError(other))
+	assert.Equal(t, ErrKVPairNotFound, c.classifyError(ErrKVPairNotFound))
+}
+
+func (suite *ClientTestSuite) TestClientCloseCleansEphemerals() {
+	t := suite.T()
+	observeC := suite.setUpClient()
+	defer observeC.Close()
+	defer suite.client.Close()
+
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithLeaseTTL(time.Minute))
+	assert.Nil(t, err)
+	assert.Nil(t, c.RegisterTemp("close/temp", "v"))
+	_, err = observeC.Get("close/temp")
+	assert.Nil(t, err)
+
+	// the temporary node is deleted on close rather than after the lease ttl
+	c.Close()
+	_, err = observeC.Get("close/temp")
+	assert.True(t, errors.Is(err, ErrKVPairNotFound))
+}
+
+func (suite *ClientTestSuite) TestClientGetWithOptions() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	assert.Nil(t, c.Create("stale/a", "1"))
+	assert.Nil(t, c.Create("stale/b", "2"))
+
+	v, err := c.GetWithOptions(c.GetCtx(), "stale/a", WithSerializable())
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	kList, vList, err := c.GetChildrenWithOptions(c.GetCtx(), "stale/", WithSerializable(),
+		WithSort(SortByKey, SortDescend))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stale/b", "stale/a"}, kList)
+	assert.Equal(t, []string{"2", "1"}, vList)
+}
+
+func (suite *ClientTestSuite) TestClientKeepAliveOnce() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	lease, err := c.GetRawClient().Grant(c.GetCtx(), 10)
+	assert.Nil(t, err)
+	ttl, err := c.TimeToLive(lease.ID)
+	assert.Nil(t, err)
+	assert.True(t, ttl > 0 && ttl <= 10*time.Second)
+
+	ttl, err = c.KeepAliveOnce(lease.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Second, ttl)
+
+	_, err = c.GetRawClient().Revoke(c.GetCtx(), lease.ID)
+	assert.Nil(t, err)
+	_, err = c.TimeToLive(lease.ID)
+	assert.True(t, errors.Is(err, ErrSessionExpired))
+	_, err = c.KeepAliveOnce(lease.ID)
+	assert.True(t, errors.Is(err, ErrSessionExpired))
+}
+
+type recordTracer struct {
+	lock  sync.Mutex
+	spans []string
+}
+
+func (r *recordTracer) Start(ctx context.Context, op, key string) func(err error) {
+	return func(err error) {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.spans = append(r.spans, fmt.Sprintf("%s %s %v", op, key, err != nil))
+	}
+}
+
+func (suite *ClientTestSuite) TestClientTracer() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	tracer := &recordTracer{}
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithTracer(tracer))
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Update("trace/a", "1"))
+	_, err = c.Get("trace/a")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetWithContext(ctx, "trace/a")
+	assert.NotNil(t, err)
+
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	assert.Equal(t, []string{"put trace/a false", "get trace/a false", "get trace/a true"}, tracer.spans)
+}
+
+func (suite *ClientTestSuite) TestClientUpdateWithRev() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	ok, err := c.UpdateWithRev("rev/config", "v1", 0)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	_, rev, err := c.GetWithRevision("rev/config")
+	assert.Nil(t, err)
+
+	// another writer updates the key after the read
+	assert.Nil(t, c.Update("rev/config", "v2"))
+	ok, err = c.UpdateWithRev("rev/config", "v3", rev)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	v, rev, err := c.GetWithRevision("rev/config")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", v)
+	ok, err = c.UpdateWithRev("rev/config", "v3", rev)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	v, err = c.Get("rev/config")
+	assert.Nil(t, err)
+	assert.Equal(t, "v3", v)
+}
+
+func (suite *ClientTestSuite) TestClientCloseCtx() {
+	c := suite.client
+	t := suite.T()
+	observeC := suite.setUpClient()
+	defer observeC.Close()
+
+	assert.Nil(t, c.RegisterTemp("close-ctx/temp", "v"))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	assert.Nil(t, c.CloseCtx(ctx))
+	assert.False(t, c.Valid())
+	_, err := observeC.Get("close-ctx/temp")
+	assert.True(t, errors.Is(err, ErrKVPairNotFound))
+
+	// closing a closed client is a no-op
+	assert.Nil(t, c.CloseCtx(ctx))
+
+	// the temporary nodes are deleted even if the context of closing is done
+	c = suite.setUpClient()
+	assert.Nil(t, c.RegisterTemp("close-ctx/expired", "v"))
+	expired, cancelExpired := context.WithCancel(context.Background())
+	cancelExpired()
+	_ = c.CloseCtx(expired)
+	_, err = observeC.Get("close-ctx/expired")
+	assert.True(t, errors.Is(err, ErrKVPairNotFound))
+}
+
+func (suite *ClientTestSuite) TestClientGetAndWatch() {
+	c := suite.client
+	t := suite.T()
+	defer c.Close()
+
+	kvs, wc, err := c.GetAndWatch("snapshot/")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(kvs))
+	assert.Nil(t, c.Create("snapshot/a", "1"))
+	resp := <-wc
+	assert.Equal(t, "snapshot/a", string(resp.Events[0].Kv.Key))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	kvs, wc, err = c.GetAndWatchWithContext(ctx, "snapshot/")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(kvs))
+	assert.Equal(t, "1", kvs[0].Value)
+
+	// the events after the read are not lost although the watch is consumed later
+	assert.Nil(t, c.Update("snapshot/a", "2"))
+	resp = <-wc
+	assert.Equal(t, "2", string(resp.Events[0].Kv.Value))
+	cancel()
+	for range wc {
+	}
+}
+
+func (suite *ClientTestSuite) TestClientWithoutSession() {
+	t := suite.T()
+	defer suite.client.Close()
+
+	c, err := NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithoutSession())
+	assert.Nil(t, err)
+	events := c.SubscribeStateChanges()
+	assert.True(t, c.Valid())
+	assert.Nil(t, c.Update("no-session/a", "v"))
+	c.lock.RLock()
+	assert.Nil(t, c.session)
+	c.lock.RUnlock()
+
+	// the session is created on the first use of RegisterTemp
+	assert.Nil(t, c.RegisterTemp("no-session/temp", "v"))
+	c.lock.RLock()
+	assert.NotNil(t, c.session)
+	c.lock.RUnlock()
+	c.Close()
+	assert.Equal(t, ConnClosed, (<-events).State)
+
+	// ConnClosed is notified although the session is never used
+	c, err = NewClient(suite.etcdConfig.name, suite.etcdConfig.endpoints,
+		suite.etcdConfig.timeout, suite.etcdConfig.heartbeat, WithoutSession())
+	assert.Nil(t, err)
+	events = c.SubscribeStateChanges()
+	c.Close()
+	assert.Equal(t, ConnClosed, (<-events).State)
+}
+
+func (suite *ClientTestSuite) TestClientKV() {
+	var c gxkv.Client = suite.client
+	t := suite.T()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "kv/")
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.Put("kv/b", "2"))
+	assert.Nil(t, c.RegisterEphemeral("kv/a", "1"))
+	v, err := c.Get("kv/b")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+	kvs, err := c.List("kv/")
+	assert.Nil(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "kv/a", Value: "1"}, {Key: "kv/b", Value: "2"}}, kvs)
+
+	assert.Nil(t, c.Delete("kv/b"))
+	_, err = c.Get("kv/b")
+	assert.True(t, errors.Is(err, gxkv.ErrKeyNotFound))
+	kvs, err = c.List("kv/none/")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(kvs))
+
+	e := <-events
+	assert.True(t, e.Revision > 0)
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "kv/b", Value: "2", Revision: e.Revision}, e)
+	e = <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "kv/a", Value: "1", Revision: e.Revision}, e)
+	prev := e.Revision
+	e = <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "kv/b", PrevValue: "2", Revision: e.Revision}, e)
+	assert.True(t, e.Revision > prev)
+}
+
+var _ kvtest.CASClient = (*Client)(nil)
+
+func (suite *ClientTestSuite) TestClientConformance() {
+	kvtest.Run(suite.T(), func() gxkv.Client {
+		return suite.setUpClient()
+	})
+}
+
 func TestClientSuite(t *testing.T) {
 	suite.Run(t, &ClientTestSuite{
 		etcdConfig: struct {
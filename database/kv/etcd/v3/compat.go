@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// The types of the etcd client used by the exported API of the package. The
+// callers should use them instead of importing the etcd client, so that they
+// do not depend on the import path of the client, which has moved from
+// go.etcd.io/etcd/clientv3 to the module go.etcd.io/etcd/client/v3.
+type (
+	// WatchChan is the chan of watch responses returned by Watch
+	WatchChan = clientv3.WatchChan
+	// WatchResponse is a response of a watch
+	WatchResponse = clientv3.WatchResponse
+	// Event is an event of a watch response
+	Event = clientv3.Event
+	// LeaseID is the id of a lease, eg: the one returned by RegisterTempWithTTL
+	LeaseID = clientv3.LeaseID
+)
+
+// NoLease is the id of no lease
+const NoLease = clientv3.NoLease
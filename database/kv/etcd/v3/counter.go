@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"strconv"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Counter is a distributed counter stored in a key as a decimal number, which is
+// updated atomically by the transactions comparing its mod revision
+type Counter struct {
+	client *Client
+	key    string
+}
+
+// Counter returns the counter of @key, the counter is 0 if the key does not exist
+func (c *Client) Counter(key string) *Counter {
+	return &Counter{client: c, key: key}
+}
+
+// load returns the value of the counter and its mod revision, 0 if it does not exist
+func (ct *Counter) load(ctx context.Context) (int64, int64, error) {
+	kvs, _, err := ct.client.rangeKeyValues(ctx, ct.key)
+	if err != nil || len(kvs) == 0 {
+		return 0, 0, err
+	}
+	n, err := strconv.ParseInt(kvs[0].Value, 10, 64)
+	if err != nil {
+		return 0, 0, perrors.WithMessagef(err, "parse counter %s", ct.key)
+	}
+	return n, kvs[0].ModRevision, nil
+}
+
+// Get returns the value of the counter
+func (ct *Counter) Get() (int64, error) {
+	ctx, cancel := ct.client.opCtx()
+	defer cancel()
+	n, _, err := ct.load(ctx)
+	return n, perrors.WithMessagef(err, "get counter (key %s)", ct.key)
+}
+
+// Incr adds @delta to the counter and returns the new value
+func (ct *Counter) Incr(delta int64) (int64, error) {
+	ctx, cancel := ct.client.opCtx()
+	defer cancel()
+	return ct.IncrWithContext(ctx, delta)
+}
+
+// IncrWithContext is like Incr, but within @ctx
+func (ct *Counter) IncrWithContext(ctx context.Context, delta int64) (int64, error) {
+	for {
+		n, rev, err := ct.load(ctx)
+		if err != nil {
+			return 0, perrors.WithMessagef(err, "incr counter (key %s)", ct.key)
+		}
+
+		// the mod revision of a key which does not exist is 0
+		n += delta
+		var resp *clientv3.TxnResponse
+		err = ct.client.do(ctx, OpTxn, ct.key, func(rawClient *clientv3.Client) (err error) {
+			resp, err = rawClient.Txn(ctx).
+				If(clientv3.Compare(clientv3.ModRevision(ct.key), "=", rev)).
+				Then(clientv3.OpPut(ct.key, strconv.FormatInt(n, 10))).
+				Commit()
+			return err
+		})
+		if err != nil {
+			return 0, perrors.WithMessagef(err, "incr counter (key %s)", ct.key)
+		}
+		if resp.Succeeded {
+			return n, nil
+		}
+		// the counter has been updated by others, retry
+	}
+}
+
+// NextID increases the counter by 1 and returns the new value, which is unique in the cluster
+func (ct *Counter) NextID() (int64, error) {
+	return ct.Incr(1)
+}
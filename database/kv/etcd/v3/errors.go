@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"errors"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the categories of the errors returned by the client, the errors of etcd are
+// classified into them, so they can be checked by errors.Is even if they are wrapped
+var (
+	// ErrKeyExists the key to create exists
+	ErrKeyExists = perrors.New("key exists")
+	// ErrSessionExpired the session or the lease of a key has expired
+	ErrSessionExpired = perrors.New("etcd session expired")
+	// ErrClientClosed the client is closed or stopped
+	ErrClientClosed = perrors.New("etcd client closed")
+)
+
+// classifiedError is an error of etcd classified into a category, it matches the
+// category error by errors.Is, and keeps the original error as its cause
+type classifiedError struct {
+	category error
+	cause    error
+}
+
+func (e *classifiedError) Error() string {
+	return e.cause.Error()
+}
+
+// Is reports whether @target is the category of the error
+func (e *classifiedError) Is(target error) bool {
+	return target == e.category
+}
+
+// Unwrap returns the original error
+func (e *classifiedError) Unwrap() error {
+	return e.cause
+}
+
+// Cause returns the original error for perrors.Cause
+func (e *classifiedError) Cause() error {
+	return e.cause
+}
+
+// classifyError classifies @err into the category errors
+func (c *Client) classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var category error
+	cause := perrors.Cause(err)
+	switch {
+	case cause == ErrKVPairNotFound, cause == ErrKeyExists, cause == ErrSessionExpired, cause == ErrClientClosed:
+		return err
+	case cause == ErrNilETCDV3Client:
+		category = ErrClientClosed
+	case c.stopped() && (errors.Is(cause, context.Canceled) || status.Code(cause) == codes.Canceled):
+		category = ErrClientClosed
+	default:
+		switch rpctypes.Error(cause) {
+		case rpctypes.ErrKeyNotFound:
+			category = ErrKVPairNotFound
+		case rpctypes.ErrDuplicateKey:
+			category = ErrKeyExists
+		case rpctypes.ErrLeaseNotFound:
+			category = ErrSessionExpired
+		default:
+			return err
+		}
+	}
+	return &classifiedError{category: category, cause: err}
+}
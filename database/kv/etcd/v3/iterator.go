@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultRangePageSize is the default number of keys fetched by a page of RangeIterator
+const DefaultRangePageSize = 1000
+
+// Iterator iterates the keys of a prefix in order by fetching them page by page.
+// All the pages are read at the revision of the first page, so the iterator sees
+// a consistent snapshot. It is not goroutine safe.
+type Iterator struct {
+	client   *Client
+	ctx      context.Context // nil means every page is fetched within the request timeout
+	next     string          // the first key of the next page
+	end      string
+	pageSize int
+	rev      int64
+
+	page []KeyValue
+	pos  int
+	done bool
+	err  error
+}
+
+// RangeIterator returns the Iterator of the keys with @prefix
+func (c *Client) RangeIterator(prefix string) *Iterator {
+	return c.newIterator(nil, prefix, DefaultRangePageSize)
+}
+
+// RangeIteratorWithContext returns the Iterator of the keys with @prefix, which
+// fetches @pageSize keys a time within @ctx
+func (c *Client) RangeIteratorWithContext(ctx context.Context, prefix string, pageSize int) *Iterator {
+	return c.newIterator(ctx, prefix, pageSize)
+}
+
+func (c *Client) newIterator(ctx context.Context, prefix string, pageSize int) *Iterator {
+	if pageSize <= 0 {
+		pageSize = DefaultRangePageSize
+	}
+	return &Iterator{
+		client:   c,
+		ctx:      ctx,
+		next:     prefix,
+		end:      clientv3.GetPrefixRangeEnd(prefix),
+		pageSize: pageSize,
+	}
+}
+
+// Next returns the next key and value, @ok is false if there are no more keys
+// or an error occurs, which is returned by Err
+func (it *Iterator) Next() (k, v string, ok bool) {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return "", "", false
+		}
+		it.fetch()
+	}
+
+	kv := it.page[it.pos]
+	it.pos++
+	return kv.Key, kv.Value, true
+}
+
+// Err returns the error occurred while iterating
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Revision returns the revision of the snapshot being iterated, 0 before the first page is fetched
+func (it *Iterator) Revision() int64 {
+	return it.rev
+}
+
+// fetch gets the next page
+func (it *Iterator) fetch() {
+	ctx, cancel := it.ctx, context.CancelFunc(func() {})
+	if ctx == nil {
+		ctx, cancel = it.client.opCtx()
+	}
+	defer cancel()
+
+	opts := []clientv3.OpOption{clientv3.WithRange(it.end), clientv3.WithLimit(int64(it.pageSize))}
+	if it.rev > 0 {
+		opts = append(opts, clientv3.WithRev(it.rev))
+	}
+	kvs, rev, err := it.client.rangeKeyValues(ctx, it.next, opts...)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	if it.rev == 0 {
+		it.rev = rev
+	}
+	it.page, it.pos = kvs, 0
+	if len(kvs) < it.pageSize {
+		it.done = true
+		return
+	}
+	it.next = kvs[len(kvs)-1].Key + "\x00"
+}
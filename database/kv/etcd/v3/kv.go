@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"sort"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+var (
+	_ gxkv.Client          = (*Client)(nil)
+	_ gxkv.EphemeralLister = (*Client)(nil)
+)
+
+func init() {
+	gxkv.Register("etcd", newKVClient)
+}
+
+// newKVClient creates a client by @u, eg:
+// "etcd://host1:2379,host2:2379/basepath?timeout=5s&heartbeat=10&username=u&password=p"
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	timeout, err := u.Duration("timeout", DefaultDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	heartbeat, err := u.Int("heartbeat", 0)
+	if err != nil {
+		return nil, err
+	}
+	opts := make([]Option, 0, 2)
+	if u.BasePath != "" {
+		opts = append(opts, WithNamespace(u.BasePath))
+	}
+	if username := u.String("username", ""); username != "" {
+		opts = append(opts, WithAuth(username, u.String("password", "")))
+	}
+	return NewClient(u.String("name", RegistryETCDV3Client), u.Addrs, timeout, heartbeat, opts...)
+}
+
+// Put puts @k whether it exists or not
+func (c *Client) Put(k, v string) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	err := c.update(ctx, k, v)
+	return perrors.WithMessagef(err, "put k/v (key %s)", k)
+}
+
+// List lists the key values with @prefix in key order, it is empty if there is none
+func (c *Client) List(prefix string) ([]gxkv.KeyValue, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	kvs, _, err := c.rangeKeyValues(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "list (prefix %s)", prefix)
+	}
+
+	list := make([]gxkv.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		list = append(list, gxkv.KeyValue{Key: kv.Key, Value: kv.Value})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list, nil
+}
+
+// ListEphemeral lists the keys with @prefix which have leases in key order, eg:
+// the temporary nodes and the keys put with ttls
+func (c *Client) ListEphemeral(prefix string) ([]string, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	kvs, _, err := c.rangeKeyValues(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "list ephemeral (prefix %s)", prefix)
+	}
+
+	keys := make([]string, 0)
+	for _, kv := range kvs {
+		if kv.Lease != 0 {
+			keys = append(keys, kv.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// WatchPrefix sends the events of the keys with @prefix to the returned chan, which
+// is closed when @ctx is done or the client is closed. The revisions of the events
+// are the mod revisions of the keys.
+func (c *Client) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	wc, err := c.watchWithAuthRetry(ctx, prefix, clientv3.WithPrefix(), clientv3.WithPrevKV())
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "watch prefix (key %s)", prefix)
+	}
+
+	out := make(chan gxkv.Event)
+	go func() {
+		defer close(out)
+		for resp := range wc {
+			for _, e := range resp.Events {
+				event := gxkv.Event{Type: gxkv.EventPut, Key: string(e.Kv.Key), Value: string(e.Kv.Value), Revision: e.Kv.ModRevision}
+				if e.Type == mvccpb.DELETE {
+					event.Type, event.Value = gxkv.EventDelete, ""
+				}
+				if e.PrevKv != nil {
+					event.PrevValue = string(e.PrevKv.Value)
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// RegisterEphemeral registers the temporary node @k, it is the same as RegisterTemp
+func (c *Client) RegisterEphemeral(k, v string) error {
+	return c.RegisterTemp(k, v)
+}
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// KeepAliveOnce renews the lease @leaseID once, and returns its new ttl.
+// ErrSessionExpired is returned if the lease has expired.
+func (c *Client) KeepAliveOnce(leaseID clientv3.LeaseID) (time.Duration, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.KeepAliveOnceWithContext(ctx, leaseID)
+}
+
+// KeepAliveOnceWithContext is like KeepAliveOnce, but within @ctx
+func (c *Client) KeepAliveOnceWithContext(ctx context.Context, leaseID clientv3.LeaseID) (time.Duration, error) {
+	var resp *clientv3.LeaseKeepAliveResponse
+	err := c.do(ctx, OpLease, "", func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.KeepAliveOnce(ctx, leaseID)
+		return err
+	})
+	if err != nil {
+		return 0, perrors.WithMessagef(err, "keep alive once (lease %x)", int64(leaseID))
+	}
+	return time.Duration(resp.TTL) * time.Second, nil
+}
+
+// TimeToLive returns the remaining ttl of the lease @leaseID.
+// ErrSessionExpired is returned if the lease has expired.
+func (c *Client) TimeToLive(leaseID clientv3.LeaseID) (time.Duration, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.TimeToLiveWithContext(ctx, leaseID)
+}
+
+// TimeToLiveWithContext is like TimeToLive, but within @ctx
+func (c *Client) TimeToLiveWithContext(ctx context.Context, leaseID clientv3.LeaseID) (time.Duration, error) {
+	var resp *clientv3.LeaseTimeToLiveResponse
+	err := c.do(ctx, OpLease, "", func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.TimeToLive(ctx, leaseID)
+		return err
+	})
+	if err == nil && resp.TTL < 0 {
+		err = ErrSessionExpired
+	}
+	if err != nil {
+		return 0, perrors.WithMessagef(err, "time to live (lease %x)", int64(leaseID))
+	}
+	return time.Duration(resp.TTL) * time.Second, nil
+}
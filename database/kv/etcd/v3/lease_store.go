@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// LeaseStore persists the mapping of ephemeral keys and lease IDs
+type LeaseStore interface {
+	// Load returns the persisted ephemeral key -> lease id mapping
+	Load() (map[string]int64, error)
+	// Save persists the ephemeral key -> lease id mapping
+	Save(leases map[string]int64) error
+}
+
+// fileLeaseStore persists the leases into a local json file
+type fileLeaseStore struct {
+	lock sync.Mutex
+	path string
+}
+
+// NewFileLeaseStore returns a LeaseStore which persists the leases into file @path
+func NewFileLeaseStore(path string) LeaseStore {
+	return &fileLeaseStore{path: path}
+}
+
+func (s *fileLeaseStore) Load() (map[string]int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]int64{}, nil
+		}
+		return nil, perrors.WithStack(err)
+	}
+
+	leases := make(map[string]int64)
+	if err = json.Unmarshal(data, &leases); err != nil {
+		return nil, perrors.WithMessagef(err, "unmarshal lease file %s", s.path)
+	}
+	return leases, nil
+}
+
+func (s *fileLeaseStore) Save(leases map[string]int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data, err := json.Marshal(leases)
+	if err != nil {
+		return perrors.WithStack(err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return perrors.WithStack(err)
+	}
+	// write a temp file then rename it, to avoid leaving a broken file
+	tmp := s.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return perrors.WithStack(err)
+	}
+	return perrors.WithStack(os.Rename(tmp, s.path))
+}
@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLeaseStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gxetcd-lease")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileLeaseStore(filepath.Join(dir, "sub", "leases.json"))
+	leases, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(leases))
+
+	assert.Nil(t, store.Save(map[string]int64{"/dubbo/a": 1, "/dubbo/b": 2}))
+	leases, err = NewFileLeaseStore(filepath.Join(dir, "sub", "leases.json")).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"/dubbo/a": 1, "/dubbo/b": 2}, leases)
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"math"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// ErrLocked is returned by TryLock when the lock is held by others
+var ErrLocked = concurrency.ErrLocked
+
+// Unlocker releases a distributed lock
+type Unlocker interface {
+	// Unlock releases the lock
+	Unlock() error
+}
+
+type etcdUnlocker struct {
+	client  *Client
+	mutex   *concurrency.Mutex
+	session *concurrency.Session // closed on unlock if it is not the session of the client
+	owned   bool
+}
+
+func (u *etcdUnlocker) Unlock() error {
+	err := u.mutex.Unlock(u.client.ctx)
+	if u.owned {
+		u.session.Close()
+	}
+	return perrors.WithMessagef(err, "unlock (key %s)", u.mutex.Key())
+}
+
+// lockSession returns the session of the client if @ttl is not set or the same
+// as the session ttl, or a new session of @ttl which is owned by the lock
+func (c *Client) lockSession(ttl time.Duration) (*concurrency.Session, bool, error) {
+	if err := c.ensureSession(); err != nil {
+		return nil, false, err
+	}
+
+	seconds := int(math.Ceil(ttl.Seconds()))
+	if seconds <= 0 || seconds == c.heartbeat {
+		c.lock.RLock()
+		s := c.session
+		c.lock.RUnlock()
+		if s != nil {
+			select {
+			case <-s.Done():
+			default:
+				return s, false, nil
+			}
+		}
+		if seconds <= 0 {
+			seconds = c.heartbeat
+		}
+	}
+
+	rawClient := c.GetRawClient()
+	if rawClient == nil {
+		return nil, false, ErrNilETCDV3Client
+	}
+	s, err := concurrency.NewSession(rawClient, concurrency.WithTTL(seconds))
+	if err != nil {
+		return nil, false, perrors.WithMessage(err, "new lock session")
+	}
+	return s, true, nil
+}
+
+func (c *Client) acquire(key string, ttl time.Duration, try bool) (Unlocker, error) {
+	s, owned, err := c.lockSession(ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	m := concurrency.NewMutex(s, key)
+	if try {
+		err = m.TryLock(c.ctx)
+	} else {
+		err = m.Lock(c.ctx)
+	}
+	if err != nil {
+		if owned {
+			s.Close()
+		}
+		return nil, err
+	}
+	return &etcdUnlocker{client: c, mutex: m, session: s, owned: owned}, nil
+}
+
+// Lock acquires the distributed lock of @key, it blocks until the lock is acquired
+// or the client is closed. The lock is released automatically if the holder is
+// lost for @ttl. The session of the client is reused if @ttl is not positive.
+func (c *Client) Lock(key string, ttl time.Duration) (Unlocker, error) {
+	u, err := c.acquire(key, ttl, false)
+	return u, perrors.WithMessagef(err, "lock (key %s)", key)
+}
+
+// TryLock is like Lock, but returns ErrLocked at once if the lock is held by others
+func (c *Client) TryLock(key string, ttl time.Duration) (Unlocker, error) {
+	u, err := c.acquire(key, ttl, true)
+	return u, perrors.WithMessagef(err, "try lock (key %s)", key)
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// MemberStatus is the status of an etcd member
+type MemberStatus struct {
+	Endpoint string
+	// ID id of the member
+	ID uint64
+	// Version etcd version of the member
+	Version string
+	// DBSize size of the backend database in bytes
+	DBSize int64
+	// DBSizeInUse size of the backend database in use, the rest can be reclaimed by defragmentation
+	DBSizeInUse int64
+	// Leader id of the leader
+	Leader    uint64
+	IsLeader  bool
+	IsLearner bool
+	RaftIndex uint64
+	RaftTerm  uint64
+	// Errors alarms of the member
+	Errors []string
+}
+
+// Status returns the status of all the endpoints of the client by the endpoints.
+// The statuses of the available members are returned along with the error of the others.
+func (c *Client) Status() (map[string]MemberStatus, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.StatusWithContext(ctx)
+}
+
+// StatusWithContext is like Status, but within @ctx
+func (c *Client) StatusWithContext(ctx context.Context) (map[string]MemberStatus, error) {
+	statuses := make(map[string]MemberStatus, len(c.endpoints))
+	var errs error
+	for _, ep := range c.endpoints {
+		var resp *clientv3.StatusResponse
+		err := c.do(ctx, OpMaintenance, ep, func(rawClient *clientv3.Client) (err error) {
+			resp, err = rawClient.Status(ctx, ep)
+			return err
+		})
+		if err != nil {
+			if errs == nil {
+				errs = perrors.WithMessagef(err, "status of %s", ep)
+			} else {
+				errs = perrors.WithMessagef(errs, "status of %s: %v", ep, err)
+			}
+			continue
+		}
+		statuses[ep] = MemberStatus{
+			Endpoint:    ep,
+			ID:          resp.Header.MemberId,
+			Version:     resp.Version,
+			DBSize:      resp.DbSize,
+			DBSizeInUse: resp.DbSizeInUse,
+			Leader:      resp.Leader,
+			IsLeader:    resp.Header.MemberId == resp.Leader,
+			IsLearner:   resp.IsLearner,
+			RaftIndex:   resp.RaftIndex,
+			RaftTerm:    resp.RaftTerm,
+			Errors:      resp.Errors,
+		}
+	}
+	return statuses, errs
+}
+
+// Compact compacts the history of the keys before the revision @rev
+func (c *Client) Compact(rev int64) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.CompactWithContext(ctx, rev)
+}
+
+// CompactWithContext is like Compact, but within @ctx
+func (c *Client) CompactWithContext(ctx context.Context, rev int64) error {
+	err := c.do(ctx, OpMaintenance, "", func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Compact(ctx, rev)
+		return err
+	})
+	return perrors.WithMessagef(err, "compact (revision %d)", rev)
+}
+
+// Defragment defragments the backend database of the member of @endpoint to
+// reclaim the space freed by compaction. It blocks the member from serving
+// until it is done, so the members should be defragmented one by one.
+func (c *Client) Defragment(endpoint string) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.DefragmentWithContext(ctx, endpoint)
+}
+
+// DefragmentWithContext is like Defragment, but within @ctx
+func (c *Client) DefragmentWithContext(ctx context.Context, endpoint string) error {
+	err := c.do(ctx, OpMaintenance, endpoint, func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Defragment(ctx, endpoint)
+		return err
+	})
+	return perrors.WithMessagef(err, "defragment (endpoint %s)", endpoint)
+}
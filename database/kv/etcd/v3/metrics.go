@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"time"
+)
+
+import (
+	clientv3 "go.etcd.io/etcd/client/v3"
+	uatomic "go.uber.org/atomic"
+)
+
+import (
+	gxmath "github.com/dubbogo/gost/math"
+)
+
+// the operation names of metrics
+const (
+	OpGet    = "get"
+	OpPut    = "put"
+	OpDelete = "delete"
+	OpTxn    = "txn"
+	OpLease  = "lease"
+	OpWatch  = "watch"
+	// OpMaintenance is the operation of status, compaction and defragmentation
+	OpMaintenance = "maintenance"
+)
+
+var metricOps = []string{OpGet, OpPut, OpDelete, OpTxn, OpLease, OpWatch, OpMaintenance}
+
+// OpObserver is called after every operation with its latency and result, which
+// can be used to export the metrics to prometheus, eg:
+//
+//	WithOpObserver(func(op string, latency time.Duration, err error) {
+//		latencyHistogramVec.WithLabelValues(op).Observe(latency.Seconds())
+//		if err != nil {
+//			errorCounterVec.WithLabelValues(op).Inc()
+//		}
+//	})
+//
+// The latency of OpWatch is 0, it is called when a watch stream is established.
+type OpObserver func(op string, latency time.Duration, err error)
+
+// OpMetrics is the statistics of an operation
+type OpMetrics struct {
+	Count  uint64
+	Errors uint64
+	Mean   time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+// Metrics is the snapshot of the client metrics
+type Metrics struct {
+	// Ops metrics of the operations by their names
+	Ops map[string]OpMetrics
+	// WatchEvents number of the received watch events
+	WatchEvents uint64
+	// WatchDropped number of the events dropped by the buffered watches
+	WatchDropped uint64
+}
+
+type opStats struct {
+	count   uatomic.Uint64
+	errors  uatomic.Uint64
+	latency *gxmath.Histogram
+}
+
+type clientMetrics struct {
+	ops         map[string]*opStats // fixed after created
+	watchEvents uatomic.Uint64
+}
+
+func newClientMetrics() *clientMetrics {
+	m := &clientMetrics{ops: make(map[string]*opStats, len(metricOps))}
+	for _, op := range metricOps {
+		// 5 significant bits keep the error of the percentiles less than 6.25%
+		m.ops[op] = &opStats{latency: gxmath.NewHistogram(gxmath.WithPrecision(5), gxmath.WithMaxValue(int64(time.Minute)))}
+	}
+	return m
+}
+
+// observe records the result of the operation @op
+func (c *Client) observe(op string, latency time.Duration, err error) {
+	if c.metrics != nil {
+		if s, ok := c.metrics.ops[op]; ok {
+			s.count.Inc()
+			if err != nil {
+				s.errors.Inc()
+			}
+			if op != OpWatch {
+				s.latency.RecordDuration(latency)
+			}
+		}
+	}
+	if c.options != nil && c.options.OpObserver != nil {
+		c.options.OpObserver(op, latency, err)
+	}
+}
+
+// do runs the operation @op by @fn with retry, classifies its error and records its metrics
+func (c *Client) do(ctx context.Context, op, key string, fn func(rawClient *clientv3.Client) error) error {
+	end := c.startSpan(ctx, op, key)
+	start := time.Now()
+	err := c.classifyError(c.doWithRetry(op, fn))
+	c.observe(op, time.Since(start), err)
+	end(err)
+	return err
+}
+
+// Metrics returns the snapshot of the client metrics
+func (c *Client) Metrics() Metrics {
+	m := Metrics{
+		Ops:          make(map[string]OpMetrics, len(metricOps)),
+		WatchDropped: c.watchDropped.Load(),
+	}
+	if c.metrics == nil {
+		return m
+	}
+
+	m.WatchEvents = c.metrics.watchEvents.Load()
+	for op, s := range c.metrics.ops {
+		ps := s.latency.Percentiles(50, 90, 99)
+		m.Ops[op] = OpMetrics{
+			Count:  s.count.Load(),
+			Errors: s.errors.Load(),
+			Mean:   time.Duration(s.latency.Mean()),
+			P50:    time.Duration(ps[0]),
+			P90:    time.Duration(ps[1]),
+			P99:    time.Duration(ps[2]),
+			Max:    time.Duration(s.latency.Max()),
+		}
+	}
+	return m
+}
@@ -0,0 +1,389 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxetcdmock provides an in-memory etcd client for the unit tests,
+// which does not need an etcd server.
+package gxetcdmock
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+import (
+	gxetcd "github.com/dubbogo/gost/database/kv/etcd/v3"
+)
+
+// Cluster is an in-memory etcd server shared by its clients, so the writes of a
+// client are seen by the others and notified to their watchers
+type Cluster struct {
+	lock     sync.RWMutex
+	rev      int64
+	kvs      map[string]*mvccpb.KeyValue
+	temps    map[string]*Client // temporary node -> the client registering it
+	watchers map[*watcher]struct{}
+}
+
+// NewCluster creates an empty in-memory cluster
+func NewCluster() *Cluster {
+	return &Cluster{
+		rev:      1,
+		kvs:      make(map[string]*mvccpb.KeyValue),
+		temps:    make(map[string]*Client),
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+// NewClient creates a client of the cluster
+func (cl *Cluster) NewClient() *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		cluster: cl,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Client is an in-memory etcd client with the same surface as gxetcd.Client,
+// the watchers of a key are notified of its every change by any client of the
+// cluster
+type Client struct {
+	cluster *Cluster
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewClient creates a client of a new empty cluster
+func NewClient() *Client {
+	return NewCluster().NewClient()
+}
+
+// GetCtx returns the context of the client, which is done when the client is closed
+func (c *Client) GetCtx() context.Context {
+	return c.ctx
+}
+
+// Valid returns true if the client is not closed
+func (c *Client) Valid() bool {
+	return c.ctx.Err() == nil
+}
+
+// Close closes the client, the temporary nodes registered by it are deleted and
+// its watch channels are closed
+func (c *Client) Close() {
+	c.cluster.lock.Lock()
+	defer c.cluster.lock.Unlock()
+
+	if c.ctx.Err() != nil {
+		return
+	}
+	c.cluster.deleteTemps(c)
+	c.cancel()
+}
+
+// Create puts @k if it does not exist
+func (c *Client) Create(k string, v string) error {
+	c.cluster.lock.Lock()
+	defer c.cluster.lock.Unlock()
+
+	if c.ctx.Err() != nil {
+		return gxetcd.ErrClientClosed
+	}
+	if _, ok := c.cluster.kvs[k]; !ok {
+		c.cluster.put(k, v, nil)
+	}
+	return nil
+}
+
+// Update puts @k whether it exists or not
+func (c *Client) Update(k, v string) error {
+	c.cluster.lock.Lock()
+	defer c.cluster.lock.Unlock()
+
+	if c.ctx.Err() != nil {
+		return gxetcd.ErrClientClosed
+	}
+	c.cluster.put(k, v, nil)
+	return nil
+}
+
+// Delete deletes @k
+func (c *Client) Delete(k string) error {
+	c.cluster.lock.Lock()
+	defer c.cluster.lock.Unlock()
+
+	if c.ctx.Err() != nil {
+		return gxetcd.ErrClientClosed
+	}
+	c.cluster.delete(k)
+	return nil
+}
+
+// RegisterTemp puts the temporary node @k, which is deleted when the client
+// is closed or its session is expired by ExpireSession
+func (c *Client) RegisterTemp(k, v string) error {
+	c.cluster.lock.Lock()
+	defer c.cluster.lock.Unlock()
+
+	if c.ctx.Err() != nil {
+		return gxetcd.ErrClientClosed
+	}
+	c.cluster.put(k, v, c)
+	return nil
+}
+
+// UnregisterTemp deletes the temporary node @k
+func (c *Client) UnregisterTemp(k string) error {
+	return c.Delete(k)
+}
+
+// ExpireSession deletes the temporary nodes registered by the client as if its
+// session has expired
+func (c *Client) ExpireSession() {
+	c.cluster.lock.Lock()
+	defer c.cluster.lock.Unlock()
+
+	c.cluster.deleteTemps(c)
+}
+
+// Get gets the value of @k, gxetcd.ErrKVPairNotFound is returned if it does not exist
+func (c *Client) Get(k string) (string, error) {
+	c.cluster.lock.RLock()
+	defer c.cluster.lock.RUnlock()
+
+	if c.ctx.Err() != nil {
+		return "", gxetcd.ErrClientClosed
+	}
+	kv, ok := c.cluster.kvs[k]
+	if !ok {
+		return "", gxetcd.ErrKVPairNotFound
+	}
+	return string(kv.Value), nil
+}
+
+// GetChildren gets the keys and values with prefix @k in key order
+func (c *Client) GetChildren(k string) ([]string, []string, error) {
+	c.cluster.lock.RLock()
+	defer c.cluster.lock.RUnlock()
+
+	if c.ctx.Err() != nil {
+		return nil, nil, gxetcd.ErrClientClosed
+	}
+	var kList, vList []string
+	for key := range c.cluster.kvs {
+		if strings.HasPrefix(key, k) {
+			kList = append(kList, key)
+		}
+	}
+	if len(kList) == 0 {
+		return nil, nil, gxetcd.ErrKVPairNotFound
+	}
+	sort.Strings(kList)
+	vList = make([]string, 0, len(kList))
+	for _, key := range kList {
+		vList = append(vList, string(c.cluster.kvs[key].Value))
+	}
+	return kList, vList, nil
+}
+
+// GetChildrenKVList gets the keys and values with prefix @k
+func (c *Client) GetChildrenKVList(k string) ([]string, []string, error) {
+	return c.GetChildren(k)
+}
+
+// Watch watches on @k until the client is closed
+func (c *Client) Watch(k string) (clientv3.WatchChan, error) {
+	return c.WatchWithContext(c.ctx, k)
+}
+
+// WatchWithContext watches on @k until @ctx is done or the client is closed
+func (c *Client) WatchWithContext(ctx context.Context, k string) (clientv3.WatchChan, error) {
+	return c.watch(ctx, k, false)
+}
+
+// WatchWithPrefix watches on @prefix until the client is closed
+func (c *Client) WatchWithPrefix(prefix string) (clientv3.WatchChan, error) {
+	return c.WatchWithPrefixWithContext(c.ctx, prefix)
+}
+
+// WatchWithPrefixWithContext watches on @prefix until @ctx is done or the client is closed
+func (c *Client) WatchWithPrefixWithContext(ctx context.Context, prefix string) (clientv3.WatchChan, error) {
+	return c.watch(ctx, prefix, true)
+}
+
+func (c *Client) watch(ctx context.Context, k string, prefix bool) (clientv3.WatchChan, error) {
+	cl := c.cluster
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+
+	if c.ctx.Err() != nil {
+		return nil, gxetcd.ErrClientClosed
+	}
+	w := newWatcher(k, prefix)
+	cl.watchers[w] = struct{}{}
+	go func() {
+		w.loop(ctx, c.ctx)
+		cl.lock.Lock()
+		delete(cl.watchers, w)
+		cl.lock.Unlock()
+	}()
+	return w.out, nil
+}
+
+// put puts @k, which is a temporary node of @owner if it is not nil. Like the
+// lease of etcd, a put without the owner makes the node persistent.
+func (cl *Cluster) put(k, v string, owner *Client) {
+	cl.rev++
+	kv, ok := cl.kvs[k]
+	if ok {
+		kv = &mvccpb.KeyValue{
+			Key:            kv.Key,
+			CreateRevision: kv.CreateRevision,
+			ModRevision:    cl.rev,
+			Version:        kv.Version + 1,
+			Value:          []byte(v),
+		}
+	} else {
+		kv = &mvccpb.KeyValue{
+			Key:            []byte(k),
+			CreateRevision: cl.rev,
+			ModRevision:    cl.rev,
+			Version:        1,
+			Value:          []byte(v),
+		}
+	}
+	cl.kvs[k] = kv
+	if owner != nil {
+		cl.temps[k] = owner
+	} else {
+		delete(cl.temps, k)
+	}
+	cl.notify(&clientv3.Event{Type: mvccpb.PUT, Kv: kv})
+}
+
+func (cl *Cluster) delete(k string) {
+	if _, ok := cl.kvs[k]; !ok {
+		return
+	}
+	cl.rev++
+	delete(cl.kvs, k)
+	delete(cl.temps, k)
+	cl.notify(&clientv3.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(k), ModRevision: cl.rev}})
+}
+
+// deleteTemps deletes the temporary nodes registered by @owner
+func (cl *Cluster) deleteTemps(owner *Client) {
+	var keys []string
+	for k, c := range cl.temps {
+		if c == owner {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cl.delete(k)
+	}
+}
+
+// notify fans @e out to the watchers of its key
+func (cl *Cluster) notify(e *clientv3.Event) {
+	resp := clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: cl.rev},
+		Events: []*clientv3.Event{e},
+	}
+	for w := range cl.watchers {
+		if w.match(string(e.Kv.Key)) {
+			w.push(resp)
+		}
+	}
+}
+
+// watcher queues the responses of a watch, so that a slow consumer does not
+// block the writers
+type watcher struct {
+	key     string
+	prefix  bool
+	out     chan clientv3.WatchResponse
+	lock    sync.Mutex
+	pending []clientv3.WatchResponse
+	wakeup  chan struct{}
+}
+
+func newWatcher(key string, prefix bool) *watcher {
+	return &watcher{
+		key:    key,
+		prefix: prefix,
+		out:    make(chan clientv3.WatchResponse),
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+func (w *watcher) match(k string) bool {
+	if w.prefix {
+		return strings.HasPrefix(k, w.key)
+	}
+	return k == w.key
+}
+
+func (w *watcher) push(resp clientv3.WatchResponse) {
+	w.lock.Lock()
+	w.pending = append(w.pending, resp)
+	w.lock.Unlock()
+
+	select {
+	case w.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// loop delivers the queued responses in order until @ctx or @clientCtx is done
+func (w *watcher) loop(ctx, clientCtx context.Context) {
+	defer close(w.out)
+
+	for {
+		w.lock.Lock()
+		pending := w.pending
+		w.pending = nil
+		w.lock.Unlock()
+
+		for _, resp := range pending {
+			select {
+			case w.out <- resp:
+			case <-ctx.Done():
+				return
+			case <-clientCtx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-w.wakeup:
+		case <-ctx.Done():
+			return
+		case <-clientCtx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcdmock
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+import (
+	gxetcd "github.com/dubbogo/gost/database/kv/etcd/v3"
+)
+
+func TestClientKV(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	assert.Nil(t, c.Create("/services/a", "1"))
+	assert.Nil(t, c.Create("/services/a", "2"))
+	v, err := c.Get("/services/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	assert.Nil(t, c.Update("/services/a", "2"))
+	assert.Nil(t, c.RegisterTemp("/services/b", "3"))
+	kList, vList, err := c.GetChildrenKVList("/services/")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/services/a", "/services/b"}, kList)
+	assert.Equal(t, []string{"2", "3"}, vList)
+
+	c.ExpireSession()
+	_, err = c.Get("/services/b")
+	assert.True(t, errors.Is(err, gxetcd.ErrKVPairNotFound))
+
+	assert.Nil(t, c.Delete("/services/a"))
+	_, _, err = c.GetChildren("/services/")
+	assert.True(t, errors.Is(err, gxetcd.ErrKVPairNotFound))
+
+	c.Close()
+	_, err = c.Get("/services/a")
+	assert.True(t, errors.Is(err, gxetcd.ErrClientClosed))
+}
+
+func TestClientWatch(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	wc, err := c.Watch("/services/a")
+	assert.Nil(t, err)
+	pwc, err := c.WatchWithPrefix("/services/")
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.RegisterTemp("/services/a", "1"))
+	assert.Nil(t, c.Update("/services/b", "2"))
+	assert.Nil(t, c.UnregisterTemp("/services/a"))
+
+	expect := func(typ mvccpb.Event_EventType, key string) {
+		select {
+		case resp := <-pwc:
+			assert.Equal(t, typ, resp.Events[0].Type)
+			assert.Equal(t, key, string(resp.Events[0].Kv.Key))
+		case <-time.After(time.Second):
+			t.Fatalf("no event of key %s", key)
+		}
+	}
+	expect(mvccpb.PUT, "/services/a")
+	expect(mvccpb.PUT, "/services/b")
+	expect(mvccpb.DELETE, "/services/a")
+
+	resp := <-wc
+	assert.True(t, resp.Events[0].IsCreate())
+	resp = <-wc
+	assert.Equal(t, mvccpb.DELETE, resp.Events[0].Type)
+
+	// the watch channels are closed with the client
+	c.Close()
+	_, ok := <-wc
+	assert.False(t, ok)
+}
+
+func TestClusterClients(t *testing.T) {
+	cluster := NewCluster()
+	writer := cluster.NewClient()
+	defer writer.Close()
+	reader := cluster.NewClient()
+	defer reader.Close()
+
+	pwc, err := reader.WatchWithPrefix("/services/")
+	assert.Nil(t, err)
+
+	assert.Nil(t, writer.RegisterTemp("/services/a", "1"))
+	assert.Nil(t, writer.Update("/services/b", "2"))
+	v, err := reader.Get("/services/a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+
+	// the temporary nodes of the writer are deleted when it is closed
+	writer.Close()
+	_, err = reader.Get("/services/a")
+	assert.True(t, errors.Is(err, gxetcd.ErrKVPairNotFound))
+	v, err = reader.Get("/services/b")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", v)
+
+	var events []string
+	for len(events) < 3 {
+		select {
+		case resp := <-pwc:
+			e := resp.Events[0]
+			events = append(events, e.Type.String()+" "+string(e.Kv.Key))
+		case <-time.After(time.Second):
+			t.Fatalf("only events %v are watched", events)
+		}
+	}
+	assert.Equal(t, []string{"PUT /services/a", "PUT /services/b", "DELETE /services/a"}, events)
+
+	// a temporary node overwritten by another client is kept when its registrant is closed
+	other := cluster.NewClient()
+	assert.Nil(t, other.RegisterTemp("/services/c", "3"))
+	assert.Nil(t, reader.Update("/services/c", "4"))
+	other.Close()
+	v, err = reader.Get("/services/c")
+	assert.Nil(t, err)
+	assert.Equal(t, "4", v)
+}
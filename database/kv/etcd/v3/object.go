@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"encoding/json"
+)
+
+import (
+	"github.com/golang/protobuf/proto"
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ObjectCodec marshals the objects of PutObject and GetObject
+type ObjectCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the default ObjectCodec
+type JSONCodec struct{}
+
+// Marshal marshals @v into json
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal unmarshals json @data into @v
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtoCodec marshals the protobuf messages
+type ProtoCodec struct{}
+
+// Marshal marshals the protobuf message @v
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, perrors.Errorf("%T is not a proto message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal unmarshals @data into the protobuf message @v
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return perrors.Errorf("%T is not a proto message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (c *Client) objectCodec() ObjectCodec {
+	if c.options != nil && c.options.ObjectCodec != nil {
+		return c.options.ObjectCodec
+	}
+	return JSONCodec{}
+}
+
+// PutObject marshals @v by the object codec of the client, and puts it into @k.
+// The existing value of @k is overwritten.
+func (c *Client) PutObject(k string, v interface{}) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.PutObjectWithContext(ctx, k, v)
+}
+
+// PutObjectWithContext is like PutObject, but within @ctx
+func (c *Client) PutObjectWithContext(ctx context.Context, k string, v interface{}) error {
+	data, err := c.objectCodec().Marshal(v)
+	if err != nil {
+		return perrors.WithMessagef(err, "marshal object (key %s)", k)
+	}
+	ev, err := c.encodeValue(string(data))
+	if err != nil {
+		return perrors.WithMessagef(err, "encode object (key %s)", k)
+	}
+
+	err = c.do(ctx, OpPut, k, func(rawClient *clientv3.Client) error {
+		_, err := rawClient.Put(ctx, k, ev)
+		return err
+	})
+	return perrors.WithMessagef(err, "put object (key %s)", k)
+}
+
+// GetObject gets the value of @k, and unmarshals it into @out by the object codec of the client
+func (c *Client) GetObject(k string, out interface{}) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetObjectWithContext(ctx, k, out)
+}
+
+// GetObjectWithContext is like GetObject, but within @ctx
+func (c *Client) GetObjectWithContext(ctx context.Context, k string, out interface{}) error {
+	v, err := c.get(ctx, k)
+	if err != nil {
+		return perrors.WithMessagef(err, "get object (key %s)", k)
+	}
+	return perrors.WithMessagef(c.objectCodec().Unmarshal([]byte(v), out), "unmarshal object (key %s)", k)
+}
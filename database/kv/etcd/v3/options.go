@@ -18,9 +18,20 @@
 package gxetcd
 
 import (
+	"crypto/tls"
 	"time"
 )
 
+import (
+	"google.golang.org/grpc"
+)
+
+import (
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+	gxlog "github.com/dubbogo/gost/log"
+	gxtime "github.com/dubbogo/gost/time"
+)
+
 const (
 	// ConnDelay connection delay
 	ConnDelay = 3
@@ -30,6 +41,14 @@ const (
 	RegistryETCDV3Client = "etcd registry"
 	// MetadataETCDV3Client client Name
 	MetadataETCDV3Client = "etcd metadata"
+	// DefaultDialTimeout dial timeout used when the client timeout is not set
+	DefaultDialTimeout = 5 * time.Second
+
+	// DefaultLeaseTTL lease ttl of the temporary nodes used when the lease ttl is not set
+	DefaultLeaseTTL = 30 * time.Second
+
+	// defaultSessionTTL session ttl in seconds used when the heartbeat is not set
+	defaultSessionTTL = 60
 )
 
 // Options client configuration
@@ -42,8 +61,81 @@ type Options struct {
 	Client *Client
 	// Timeout timeout
 	Timeout time.Duration
+	// RequestTimeout timeout of the operations called without a context, the
+	// Timeout is used if it is not set
+	RequestTimeout time.Duration
 	// Heartbeat second
 	Heartbeat int
+	// LeaseStore persists the leases of ephemeral keys
+	LeaseStore LeaseStore
+	// LeaseTTL lease ttl of the temporary nodes registered by RegisterTemp
+	LeaseTTL time.Duration
+	// WatchBufferSize buffer size of every buffered watch
+	WatchBufferSize int
+	// SlowConsumerPolicy policy of buffered watches for slow consumers
+	SlowConsumerPolicy SlowConsumerPolicy
+	// SerializableReads whether Get and GetChildren use serializable reads
+	SerializableReads bool
+	// Username etcd auth username
+	Username string
+	// Password etcd auth password
+	Password string
+	// Namespace prefix of all the keys of the client
+	Namespace string
+	// AuthFailureThreshold consecutive auth failures before calling AuthFailureHandler
+	AuthFailureThreshold int
+	// AuthFailureHandler is called on repeated auth failures
+	AuthFailureHandler AuthFailureHandler
+	// Compressor compresses the values written by the client
+	Compressor gxcompress.Codec
+	// CompressThreshold values shorter than it are not compressed
+	CompressThreshold int
+	// TLSConfig tls config of the connections to etcd
+	TLSConfig *tls.Config
+	// TLSCertFile client certificate file of mTLS
+	TLSCertFile string
+	// TLSKeyFile client key file of mTLS
+	TLSKeyFile string
+	// TLSCAFile ca file verifying the etcd servers
+	TLSCAFile string
+	// ReconnectBackoff delay between the reconnect attempts after the session is lost
+	ReconnectBackoff gxtime.Backoff
+	// MaxReconnectAttempts max reconnect attempts, non-positive means no limit
+	MaxReconnectAttempts int
+	// ConnStateHandler is called when the connection state changes
+	ConnStateHandler ConnStateHandler
+	// OpObserver is called after every operation
+	OpObserver OpObserver
+	// Tracer starts a span around every operation
+	Tracer Tracer
+	// Logger logs the events of the client, the std log is used if it is nil
+	Logger gxlog.Logger
+	// RetryPolicy retries Get/Put/Delete on the transient errors, no retry if it is nil
+	RetryPolicy *RetryPolicy
+	// GRPCDialOptions extra grpc dial options of the raw client
+	GRPCDialOptions []grpc.DialOption
+	// KeepAliveTime interval of the grpc keepalive pings, no ping if it is not positive
+	KeepAliveTime time.Duration
+	// KeepAliveTimeout time waiting for the response of a keepalive ping
+	KeepAliveTimeout time.Duration
+	// MaxCallSendMsgSize max size of a request, 2MB by default
+	MaxCallSendMsgSize int
+	// MaxCallRecvMsgSize max size of a response, no limit by default
+	MaxCallRecvMsgSize int
+	// NonBlockingDial creates the client without waiting for the connection
+	NonBlockingDial bool
+	// AutoSyncInterval interval of updating the endpoints with the latest members
+	// of the cluster, no auto sync if it is not positive
+	AutoSyncInterval time.Duration
+	// ObjectCodec marshals the objects of PutObject and GetObject, JSONCodec is used if it is nil
+	ObjectCodec ObjectCodec
+	// WithoutSession creates the session on the first use of RegisterTemp or Lock
+	WithoutSession bool
+	// WatchWorkers number of the workers running the handlers of WatchFunc, the
+	// handlers run in the watch goroutines if it is not positive
+	WatchWorkers int
+	// WatchWorkerQueueSize size of the event queue of every watch worker
+	WatchWorkerQueueSize int
 }
 
 // Option will define a function of handling Options
@@ -70,9 +162,239 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithRequestTimeout sets the timeout of the operations called without a context,
+// such as Get and Create. The operations accepting a context, such as GetWithContext,
+// are bounded by the context only.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.RequestTimeout = timeout
+	}
+}
+
 // WithHeartbeat sets etcd client heartbeat
 func WithHeartbeat(heartbeat int) Option {
 	return func(opt *Options) {
 		opt.Heartbeat = heartbeat
 	}
 }
+
+// WithLeaseStore sets the store which persists the leases of ephemeral keys,
+// so that the client can reattach to the still-valid leases after restart.
+func WithLeaseStore(store LeaseStore) Option {
+	return func(opt *Options) {
+		opt.LeaseStore = store
+	}
+}
+
+// WithLeaseTTL sets the lease ttl of the temporary nodes registered by RegisterTemp,
+// which is DefaultLeaseTTL if not set
+func WithLeaseTTL(ttl time.Duration) Option {
+	return func(opt *Options) {
+		opt.LeaseTTL = ttl
+	}
+}
+
+// WithWatchBuffer sets the buffer size and slow consumer policy of buffered watches
+func WithWatchBuffer(size int, policy SlowConsumerPolicy) Option {
+	return func(opt *Options) {
+		opt.WatchBufferSize = size
+		opt.SlowConsumerPolicy = policy
+	}
+}
+
+// WithSerializableReads lets Get and GetChildren use serializable reads, which
+// are served by any member with lower latency but may return stale data
+func WithSerializableReads() Option {
+	return func(opt *Options) {
+		opt.SerializableReads = true
+	}
+}
+
+// WithAuth sets the credentials of etcd auth, which are also used to
+// re-authenticate when the auth token is expired or invalid
+func WithAuth(username, password string) Option {
+	return func(opt *Options) {
+		opt.Username = username
+		opt.Password = password
+	}
+}
+
+// WithNamespace prefixes all the keys of the client with @prefix transparently, the
+// keys of the watch events are returned without it. Include the separator in
+// @prefix, eg: "/app1/", if the keys of different namespaces should not overlap.
+func WithNamespace(prefix string) Option {
+	return func(opt *Options) {
+		opt.Namespace = prefix
+	}
+}
+
+// WithAuthFailureHandler sets the handler called when re-authentication fails
+// @threshold times in a row. A non-positive @threshold means the default threshold.
+func WithAuthFailureHandler(threshold int, handler AuthFailureHandler) Option {
+	return func(opt *Options) {
+		opt.AuthFailureThreshold = threshold
+		opt.AuthFailureHandler = handler
+	}
+}
+
+// WithCompression lets the client compress the values not shorter than @threshold
+// by @codec. Compressed values are detected by the magic header and decompressed
+// on read, so plain values written before are still readable. Clients without
+// this option never decompress values.
+func WithCompression(codec gxcompress.Codec, threshold int) Option {
+	return func(opt *Options) {
+		opt.Compressor = codec
+		opt.CompressThreshold = threshold
+	}
+}
+
+// WithTLSConfig lets the client connect to etcd by tls with @config
+func WithTLSConfig(config *tls.Config) Option {
+	return func(opt *Options) {
+		opt.TLSConfig = config
+	}
+}
+
+// WithTLSFiles lets the client connect to etcd by tls, the servers are verified
+// by @caFile, and the client certificate of mTLS is loaded from @certFile and
+// @keyFile, which are reloaded when they are modified. The certificate files
+// are optional if the servers do not verify clients. It is ignored if
+// WithTLSConfig is set.
+func WithTLSFiles(certFile, keyFile, caFile string) Option {
+	return func(opt *Options) {
+		opt.TLSCertFile = certFile
+		opt.TLSKeyFile = keyFile
+		opt.TLSCAFile = caFile
+	}
+}
+
+// WithReconnect lets the client re-dial etcd and recreate the session after the
+// session is lost, instead of stopping the client. The attempts are delayed by
+// @backoff, which is an exponential backoff from 100ms to 30s with 20% jitter if
+// nil. The client stops after @maxAttempts failed attempts, a non-positive
+// @maxAttempts means retrying until the client is closed. The watches resume on
+// the new connection from the next revision.
+func WithReconnect(backoff gxtime.Backoff, maxAttempts int) Option {
+	return func(opt *Options) {
+		if backoff == nil {
+			backoff = defaultReconnectBackoff()
+		}
+		opt.ReconnectBackoff = backoff
+		opt.MaxReconnectAttempts = maxAttempts
+	}
+}
+
+// WithConnStateHandler sets the handler called when the connection state changes
+func WithConnStateHandler(handler ConnStateHandler) Option {
+	return func(opt *Options) {
+		opt.ConnStateHandler = handler
+	}
+}
+
+// WithOpObserver sets the observer called after every operation with its latency
+// and result, which is used to export the metrics of the client
+func WithOpObserver(observer OpObserver) Option {
+	return func(opt *Options) {
+		opt.OpObserver = observer
+	}
+}
+
+// WithTracer sets the tracer which starts a span around every operation and watch
+// of the client, eg: an adapter of OpenTelemetry
+func WithTracer(tracer Tracer) Option {
+	return func(opt *Options) {
+		opt.Tracer = tracer
+	}
+}
+
+// WithLogger sets the logger of the client
+func WithLogger(logger gxlog.Logger) Option {
+	return func(opt *Options) {
+		opt.Logger = logger
+	}
+}
+
+// WithGRPCDialOptions appends @opts to the grpc dial options of the raw client
+func WithGRPCDialOptions(opts ...grpc.DialOption) Option {
+	return func(opt *Options) {
+		opt.GRPCDialOptions = append(opt.GRPCDialOptions, opts...)
+	}
+}
+
+// WithKeepAlive pings etcd every @keepAliveTime, and closes the connection if
+// the ping is not responded in @keepAliveTimeout
+func WithKeepAlive(keepAliveTime, keepAliveTimeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.KeepAliveTime = keepAliveTime
+		opt.KeepAliveTimeout = keepAliveTimeout
+	}
+}
+
+// WithMaxMsgSize sets the max sizes of the requests and the responses
+func WithMaxMsgSize(maxSendMsgSize, maxRecvMsgSize int) Option {
+	return func(opt *Options) {
+		opt.MaxCallSendMsgSize = maxSendMsgSize
+		opt.MaxCallRecvMsgSize = maxRecvMsgSize
+	}
+}
+
+// WithNonBlockingDial creates the client at once, which connects to etcd in
+// background. Valid reports whether it is ready, and WaitReady waits for it.
+func WithNonBlockingDial() Option {
+	return func(opt *Options) {
+		opt.NonBlockingDial = true
+	}
+}
+
+// WithoutSession creates the client without the session, which is created on the
+// first use of RegisterTemp or Lock, so that the clients which only read, eg: the
+// config readers, do not keep a lease alive. The connection is not monitored for
+// reconnecting until the session is created. It is ignored by WithNonBlockingDial.
+func WithoutSession() Option {
+	return func(opt *Options) {
+		opt.WithoutSession = true
+	}
+}
+
+// WithWatchWorkers runs the handlers of WatchFunc and WatchWithPrefixFunc in a pool
+// of @workers workers with queues of @queueSize events, so that a slow handler does
+// not delay the notifications of the other keys. The events of a key are handled
+// in order, while the events of different keys may be handled concurrently.
+func WithWatchWorkers(workers, queueSize int) Option {
+	return func(opt *Options) {
+		opt.WatchWorkers = workers
+		opt.WatchWorkerQueueSize = queueSize
+	}
+}
+
+// WithAutoSyncInterval updates the endpoints of the client with the members of
+// the cluster every @d, so that the replaced members are picked up
+func WithAutoSyncInterval(d time.Duration) Option {
+	return func(opt *Options) {
+		opt.AutoSyncInterval = d
+	}
+}
+
+// WithObjectCodec sets the codec of PutObject and GetObject
+func WithObjectCodec(codec ObjectCodec) Option {
+	return func(opt *Options) {
+		opt.ObjectCodec = codec
+	}
+}
+
+// WithRetry retries Get/Put/Delete on the transient errors by @policy
+func WithRetry(policy *RetryPolicy) Option {
+	return func(opt *Options) {
+		opt.RetryPolicy = policy
+	}
+}
+
+// defaultLogger writes to stderr like the std log
+var defaultLogger = gxlog.NewStdLogger(nil, false)
+
+func loggerOf(o *Options) gxlog.Logger {
+	if o == nil || o.Logger == nil {
+		return defaultLogger
+	}
+	return o.Logger
+}
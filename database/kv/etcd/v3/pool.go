@@ -0,0 +1,198 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxetcd
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// ErrClientOptionsMismatch is returned by GetOrCreate when the shared client of
+// the endpoints has been created with different options
+var ErrClientOptionsMismatch = perrors.New("etcd shared client options mismatch")
+
+var clientPool = etcdClientPool{entries: make(map[string]*poolEntry)}
+
+// etcdClientPool shares the clients which have the same endpoints
+type etcdClientPool struct {
+	sync.Mutex
+	entries map[string]*poolEntry
+}
+
+// poolEntry is the shared client of a group of endpoints. @ready is closed
+// when the dialing is done, so that only one goroutine dials for the endpoints.
+type poolEntry struct {
+	ready   chan struct{}
+	client  *Client
+	options *Options
+	err     error
+}
+
+func poolKey(endpoints []string) string {
+	eps := make([]string, len(endpoints))
+	copy(eps, endpoints)
+	sort.Strings(eps)
+	return strings.Join(eps, ",")
+}
+
+// sameOptions checks whether the options which affect the behavior of the
+// shared client are the same
+func sameOptions(a, b *Options) bool {
+	return a.Timeout == b.Timeout &&
+		a.RequestTimeout == b.RequestTimeout &&
+		a.Heartbeat == b.Heartbeat &&
+		a.LeaseTTL == b.LeaseTTL &&
+		a.Username == b.Username &&
+		a.Password == b.Password &&
+		a.Namespace == b.Namespace &&
+		a.SerializableReads == b.SerializableReads &&
+		a.WatchBufferSize == b.WatchBufferSize &&
+		a.SlowConsumerPolicy == b.SlowConsumerPolicy &&
+		a.CompressThreshold == b.CompressThreshold &&
+		codecID(a) == codecID(b) &&
+		a.TLSConfig == b.TLSConfig &&
+		a.TLSCertFile == b.TLSCertFile &&
+		a.TLSKeyFile == b.TLSKeyFile &&
+		a.TLSCAFile == b.TLSCAFile &&
+		a.KeepAliveTime == b.KeepAliveTime &&
+		a.KeepAliveTimeout == b.KeepAliveTimeout &&
+		a.MaxCallSendMsgSize == b.MaxCallSendMsgSize &&
+		a.MaxCallRecvMsgSize == b.MaxCallRecvMsgSize &&
+		a.NonBlockingDial == b.NonBlockingDial &&
+		a.WithoutSession == b.WithoutSession &&
+		a.AutoSyncInterval == b.AutoSyncInterval
+}
+
+func codecID(o *Options) int {
+	if o.Compressor == nil {
+		return -1
+	}
+	return int(o.Compressor.ID())
+}
+
+// GetOrCreate returns the shared client of @endpoints, or creates a new one
+// by @opts if it does not exist. @name only names the client when it is created.
+// ErrClientOptionsMismatch is returned if the shared client has been created
+// with different options. The caller should call Release instead of Close when
+// it does not use the client any more, and the underlying client will be closed
+// when the last user releases it.
+func GetOrCreate(name string, endpoints []string, opts ...Option) (*Client, error) {
+	options := &Options{
+		Heartbeat: 1, // default Heartbeat
+		Timeout:   DefaultDialTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	options.Name = name
+	options.Endpoints = endpoints
+
+	key := poolKey(endpoints)
+	for {
+		clientPool.Lock()
+		e, ok := clientPool.entries[key]
+		if !ok {
+			break
+		}
+		clientPool.Unlock()
+
+		// wait for the dialing goroutine
+		<-e.ready
+		if e.err != nil {
+			return nil, e.err
+		}
+
+		clientPool.Lock()
+		if clientPool.entries[key] == e && !e.client.stopped() {
+			if !sameOptions(e.options, options) {
+				clientPool.Unlock()
+				return nil, perrors.WithMessagef(ErrClientOptionsMismatch, "endpoints %s", key)
+			}
+			e.client.refs++
+			clientPool.Unlock()
+			return e.client, nil
+		}
+		if clientPool.entries[key] == e {
+			// the shared client has been stopped, create a new one
+			delete(clientPool.entries, key)
+		}
+		clientPool.Unlock()
+	}
+
+	// dial outside of the pool lock, the other callers of the same endpoints
+	// wait for the entry to be ready
+	e := &poolEntry{ready: make(chan struct{}), options: options}
+	clientPool.entries[key] = e
+	clientPool.Unlock()
+
+	c, err := newClient(options)
+
+	clientPool.Lock()
+	if err != nil {
+		e.err = err
+		delete(clientPool.entries, key)
+	} else {
+		c.poolKey = key
+		c.refs = 1
+		e.client = c
+	}
+	clientPool.Unlock()
+	close(e.ready)
+
+	return c, err
+}
+
+// GetOrCreateClient is like GetOrCreate, but the name and the endpoints are set
+// by WithName and WithEndpoints in @opts, like NewConfigClient
+func GetOrCreateClient(opts ...Option) (*Client, error) {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return GetOrCreate(options.Name, options.Endpoints, opts...)
+}
+
+// Release decreases the reference count of the shared client, and closes it
+// when the count reaches zero. For a client not created by GetOrCreate,
+// Release is the same as Close.
+func (c *Client) Release() {
+	if c == nil {
+		return
+	}
+	if c.poolKey == "" {
+		c.Close()
+		return
+	}
+
+	clientPool.Lock()
+	c.refs--
+	if c.refs > 0 {
+		clientPool.Unlock()
+		return
+	}
+	if e, ok := clientPool.entries[c.poolKey]; ok && e.client == c {
+		delete(clientPool.entries, c.poolKey)
+	}
+	clientPool.Unlock()
+
+	c.Close()
+}
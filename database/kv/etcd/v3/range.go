@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// SortTarget is the field to sort the key values by, the values are the same as clientv3.SortTarget
+type SortTarget int
+
+// the sort targets
+const (
+	SortByKey SortTarget = iota
+	SortByVersion
+	SortByCreateRevision
+	SortByModRevision
+	SortByValue
+)
+
+// SortOrder is the order of sorting
+type SortOrder int
+
+// the sort orders
+const (
+	SortAscend SortOrder = iota
+	SortDescend
+)
+
+// RangeOption will define a function of handling the options of GetRange and
+// the other reads which accept per-call options
+type RangeOption func(*rangeOptions)
+
+type rangeOptions struct {
+	sort         bool
+	target       SortTarget
+	order        SortOrder
+	limit        int64
+	serializable bool
+}
+
+// WithSort sorts the key values by @target in @order
+func WithSort(target SortTarget, order SortOrder) RangeOption {
+	return func(o *rangeOptions) {
+		o.sort = true
+		o.target = target
+		o.order = order
+	}
+}
+
+// WithLimit limits the number of the key values to @limit, no limit if it is not positive
+func WithLimit(limit int64) RangeOption {
+	return func(o *rangeOptions) {
+		o.limit = limit
+	}
+}
+
+// WithSerializable reads by a serializable read, which may be served by any member
+// and return stale data, but avoids the quorum read on the leader
+func WithSerializable() RangeOption {
+	return func(o *rangeOptions) {
+		o.serializable = true
+	}
+}
+
+func newRangeOptions(opts ...RangeOption) *rangeOptions {
+	o := &rangeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *rangeOptions) opOptions() []clientv3.OpOption {
+	var opts []clientv3.OpOption
+	if o.sort {
+		order := clientv3.SortAscend
+		if o.order == SortDescend {
+			order = clientv3.SortDescend
+		}
+		opts = append(opts, clientv3.WithSort(clientv3.SortTarget(o.target), order))
+	}
+	if o.limit > 0 {
+		opts = append(opts, clientv3.WithLimit(o.limit))
+	}
+	if o.serializable {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+	return opts
+}
+
+// PrefixRangeEnd returns the end of the range of the keys with @prefix, eg:
+// GetRange(prefix, PrefixRangeEnd(prefix)) gets all the keys with @prefix
+func PrefixRangeEnd(prefix string) string {
+	return clientv3.GetPrefixRangeEnd(prefix)
+}
+
+// GetRange gets the key values in the range [@from, @to) by @opts, eg: the latest
+// 10 entries with a prefix are got by
+//
+//	GetRange(prefix, PrefixRangeEnd(prefix), WithSort(SortByModRevision, SortDescend), WithLimit(10))
+//
+// @to "\x00" means all the keys greater than or equal to @from. The key values are
+// sorted by key in ascending order by default.
+func (c *Client) GetRange(from, to string, opts ...RangeOption) ([]KeyValue, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetRangeWithContext(ctx, from, to, opts...)
+}
+
+// GetRangeWithContext is like GetRange, but within @ctx
+func (c *Client) GetRangeWithContext(ctx context.Context, from, to string, opts ...RangeOption) ([]KeyValue, error) {
+	o := newRangeOptions(opts...)
+	kvs, _, err := c.rangeKeyValues(ctx, from, append(o.opOptions(), clientv3.WithRange(to))...)
+	return kvs, perrors.WithMessagef(err, "get range [%s, %s)", from, to)
+}
+
+// GetWithOptions gets value by @k within @ctx by @opts, eg: WithSerializable
+func (c *Client) GetWithOptions(ctx context.Context, k string, opts ...RangeOption) (string, error) {
+	v, err := c.get(ctx, k, newRangeOptions(opts...).opOptions()...)
+	return v, perrors.WithMessagef(err, "get key value (key %s)", k)
+}
+
+// GetChildrenWithOptions gets children kv list by @k within @ctx by @opts, eg: WithSerializable
+func (c *Client) GetChildrenWithOptions(ctx context.Context, k string, opts ...RangeOption) ([]string, []string, error) {
+	kList, vList, err := c.getChildren(ctx, k, newRangeOptions(opts...).opOptions()...)
+	return kList, vList, perrors.WithMessagef(err, "get key children (key %s)", k)
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"time"
+)
+
+import (
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+	gxtime "github.com/dubbogo/gost/time"
+)
+
+// ConnState is the state of the connection to etcd
+type ConnState int
+
+const (
+	// ConnDisconnected means the session is lost, the client is reconnecting if
+	// reconnect is enabled
+	ConnDisconnected ConnState = iota
+	// ConnReconnected means the client has re-dialed etcd and recreated the session
+	ConnReconnected
+	// ConnClosed means the client is stopped, it is closed or gives up reconnecting
+	ConnClosed
+	// ConnSessionRecreated means the session has expired and been recreated on the
+	// same connection, the temporary nodes have been registered again
+	ConnSessionRecreated
+	// ConnConnected means the first session of the client has been created
+	ConnConnected
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnDisconnected:
+		return "Disconnected"
+	case ConnReconnected:
+		return "Reconnected"
+	case ConnClosed:
+		return "Closed"
+	case ConnSessionRecreated:
+		return "SessionRecreated"
+	case ConnConnected:
+		return "Connected"
+	}
+	return "Unknown"
+}
+
+// ConnStateHandler is called in the session goroutine of the client when the
+// connection state changes, it should not block.
+type ConnStateHandler func(state ConnState)
+
+func defaultReconnectBackoff() gxtime.Backoff {
+	b := gxtime.NewExponentialBackoff(100*time.Millisecond, 30*time.Second)
+	b.Jitter = 0.2
+	return b
+}
+
+func (c *Client) notifyState(state ConnState) {
+	if c.options != nil && c.options.ConnStateHandler != nil {
+		c.options.ConnStateHandler(state)
+	}
+	c.publishState(state)
+}
+
+// reconnect re-dials etcd and recreates the session with backoff. It returns nil
+// if reconnect is disabled, the client is closed or all the attempts fail.
+func (c *Client) reconnect() *concurrency.Session {
+	c.notifyState(ConnDisconnected)
+	if c.options == nil || c.options.ReconnectBackoff == nil {
+		return nil
+	}
+
+	maxAttempts := c.options.MaxReconnectAttempts
+	for attempt := 1; maxAttempts <= 0 || attempt <= maxAttempts; attempt++ {
+		select {
+		case <-c.Done():
+			return nil
+		case <-time.After(c.options.ReconnectBackoff.Next(attempt)):
+		}
+
+		rawClient, err := newRawClient(c.ctx, c.options, c.tlsConfig)
+		if err != nil {
+			c.logger.Warn("etcd client reconnect error", gxlog.Any("name", c.name), gxlog.Any("attempt", attempt), gxlog.Err(err))
+			continue
+		}
+		s, err := c.newSession(rawClient)
+		if err != nil {
+			rawClient.Close()
+			c.logger.Warn("etcd client reconnect new session error", gxlog.Any("name", c.name), gxlog.Any("attempt", attempt), gxlog.Err(err))
+			continue
+		}
+
+		c.lock.Lock()
+		old := c.rawClient
+		if old == nil || c.stopped() {
+			// the client has been closed meanwhile
+			c.lock.Unlock()
+			s.Orphan()
+			rawClient.Close()
+			return nil
+		}
+		c.rawClient = rawClient
+		c.session = s
+		c.lock.Unlock()
+
+		c.reattachLeases(rawClient)
+		c.reputEphemerals(rawClient)
+		// closing the old raw client lets the watches resume on the new one
+		old.Close()
+		c.logger.Info("etcd client reconnected", gxlog.Any("name", c.name), gxlog.Any("attempts", attempt))
+		c.notifyState(ConnReconnected)
+		return s
+	}
+
+	c.logger.Error("etcd client gives up reconnecting", gxlog.Any("name", c.name), gxlog.Any("attempts", maxAttempts))
+	return nil
+}
+
+// connectLoop creates the first session of the client created by WithNonBlockingDial
+// with backoff, and keeps it until the client is stopped
+func (c *Client) connectLoop() {
+	backoff := c.options.ReconnectBackoff
+	if backoff == nil {
+		backoff = defaultReconnectBackoff()
+	}
+
+	for attempt := 1; ; attempt++ {
+		if rawClient := c.GetRawClient(); rawClient != nil {
+			s, err := c.newSession(rawClient)
+			if err == nil {
+				c.setSession(s)
+				close(c.ready)
+				c.notifyState(ConnConnected)
+				c.logger.Info("etcd client connected", gxlog.Any("name", c.name), gxlog.Any("attempts", attempt))
+				c.keepSessionLoop(s)
+				return
+			}
+			c.logger.Debug("etcd client connect error", gxlog.Any("name", c.name), gxlog.Any("attempt", attempt), gxlog.Err(err))
+		}
+
+		select {
+		case <-c.Done():
+			c.Wait.Done()
+			c.notifyState(ConnClosed)
+			return
+		case <-time.After(backoff.Next(attempt)):
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+)
+
+// DefaultRegistryRoot is the default root of the keys of the registry
+const DefaultRegistryRoot = "/services/"
+
+// ErrInvalidInstance is returned when registering an instance without service name or address
+var ErrInvalidInstance = perrors.New("invalid service instance")
+
+// ServiceInstance is an instance of a service in the registry
+type ServiceInstance struct {
+	// ID id of the instance in the service, it is "host:port" if it is empty
+	ID       string            `json:"id"`
+	Service  string            `json:"service"`
+	Host     string            `json:"host"`
+	Port     int               `json:"port"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (i *ServiceInstance) id() string {
+	if i.ID != "" {
+		return i.ID
+	}
+	return net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+// ServiceListener is called with all the instances of @service when they change
+type ServiceListener func(service string, instances []ServiceInstance)
+
+// RegistryOption will define a function of handling the options of Registry
+type RegistryOption func(*Registry)
+
+// WithRegistryRoot sets the root of the keys, the key of an instance is "root + service/id"
+func WithRegistryRoot(root string) RegistryOption {
+	return func(r *Registry) {
+		if !strings.HasSuffix(root, "/") {
+			root += "/"
+		}
+		r.root = root
+	}
+}
+
+// WithRegistryTTL sets the ttl of the leases of the instances
+func WithRegistryTTL(ttl time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.ttl = ttl
+	}
+}
+
+// Registry is a service registry built on the client. The instances are registered
+// as temporary nodes, whose leases are kept alive by the client.
+type Registry struct {
+	client *Client
+	root   string
+	ttl    time.Duration // non-positive means the default lease ttl of the client
+}
+
+// NewRegistry returns a Registry on @client
+func NewRegistry(client *Client, opts ...RegistryOption) *Registry {
+	r := &Registry{client: client, root: DefaultRegistryRoot}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Registry) servicePrefix(service string) string {
+	return r.root + service + "/"
+}
+
+func (r *Registry) instanceKey(inst *ServiceInstance) string {
+	return r.servicePrefix(inst.Service) + inst.id()
+}
+
+// Register registers @inst until it is deregistered or the client is closed
+func (r *Registry) Register(inst ServiceInstance) error {
+	if inst.Service == "" || (inst.ID == "" && inst.Host == "") {
+		return ErrInvalidInstance
+	}
+	inst.ID = inst.id()
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return perrors.WithMessagef(err, "marshal instance %s of %s", inst.ID, inst.Service)
+	}
+	_, err = r.client.RegisterTempWithTTL(r.instanceKey(&inst), string(data), r.ttl)
+	return err
+}
+
+// Deregister deletes @inst from the registry
+func (r *Registry) Deregister(inst ServiceInstance) error {
+	return r.client.UnregisterTemp(r.instanceKey(&inst))
+}
+
+// ListInstances lists the instances of @service sorted by their ids
+func (r *Registry) ListInstances(service string) ([]ServiceInstance, error) {
+	instances, _, err := r.list(service)
+	return instances, err
+}
+
+func (r *Registry) list(service string) ([]ServiceInstance, int64, error) {
+	ctx, cancel := r.client.opCtx()
+	defer cancel()
+	kvs, rev, err := r.client.rangeKeyValues(ctx, r.servicePrefix(service), clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, perrors.WithMessagef(err, "list instances of %s", service)
+	}
+
+	instances := make([]ServiceInstance, 0, len(kvs))
+	for _, kv := range kvs {
+		var inst ServiceInstance
+		if err := json.Unmarshal([]byte(kv.Value), &inst); err != nil {
+			r.client.logger.Warn("etcd registry unmarshal instance error", gxlog.Any("key", kv.Key), gxlog.Err(err))
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	return instances, rev, nil
+}
+
+// Subscribe calls @listener with the instances of @service at once, and every
+// time they change until the returned cancel function is called or the client is
+// stopped. The watch is re-established transparently when the client reconnects.
+func (r *Registry) Subscribe(service string, listener ServiceListener) (context.CancelFunc, error) {
+	instances, rev, err := r.list(service)
+	if err != nil {
+		return nil, err
+	}
+	listener(service, instances)
+
+	current := make(map[string]ServiceInstance, len(instances))
+	for _, inst := range instances {
+		current[inst.ID] = inst
+	}
+	prefix := r.servicePrefix(service)
+	ctx, cancel := context.WithCancel(r.client.ctx)
+	err = r.client.watchFunc(ctx, prefix, func(typ EventType, key, value string) {
+		switch typ {
+		case EventTypePut:
+			var inst ServiceInstance
+			if err := json.Unmarshal([]byte(value), &inst); err != nil {
+				r.client.logger.Warn("etcd registry unmarshal instance error", gxlog.Any("key", key), gxlog.Err(err))
+				return
+			}
+			current[inst.ID] = inst
+		case EventTypeDelete:
+			delete(current, strings.TrimPrefix(key, prefix))
+		}
+
+		instances := make([]ServiceInstance, 0, len(current))
+		for _, inst := range current {
+			instances = append(instances, inst)
+		}
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].ID < instances[j].ID
+		})
+		listener(service, instances)
+	}, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+	if err != nil {
+		cancel()
+		return nil, perrors.WithMessagef(err, "subscribe %s", service)
+	}
+	return cancel, nil
+}
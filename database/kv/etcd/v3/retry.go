@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"time"
+)
+
+import (
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+	gxtime "github.com/dubbogo/gost/time"
+)
+
+// RetryPolicy retries Get/Put/Delete on the transient errors
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts including the first one
+	MaxAttempts int
+	// Backoff decides the delay before every retry, no delay if it is nil
+	Backoff gxtime.Backoff
+	// Retryable classifies the errors, IsRetryable is used if it is nil
+	Retryable func(err error) bool
+}
+
+// NewRetryPolicy returns a RetryPolicy of @maxAttempts attempts, which retries
+// the errors classified by IsRetryable after an exponential backoff
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		Backoff:     gxtime.NewExponentialBackoff(50*time.Millisecond, time.Second),
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// IsRetryable returns true if @err is caused by the leader election, the timeout
+// of the server or the unavailable connection, which are likely to succeed on retry
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch rpctypes.Error(err) {
+	case rpctypes.ErrTimeout, rpctypes.ErrTimeoutDueToLeaderFail, rpctypes.ErrTimeoutDueToConnectionLost,
+		rpctypes.ErrNoLeader, rpctypes.ErrLeaderChanged, rpctypes.ErrTooManyRequests, rpctypes.ErrUnhealthy:
+		return true
+	}
+	return status.Code(err) == codes.Unavailable
+}
+
+// retryOps are the operations retried by the retry policy
+var retryOps = map[string]bool{
+	OpGet:    true,
+	OpPut:    true,
+	OpDelete: true,
+}
+
+// doWithRetry runs @fn by the retry policy of the client if @op is retryable
+func (c *Client) doWithRetry(op string, fn func(rawClient *clientv3.Client) error) error {
+	var p *RetryPolicy
+	if c.options != nil {
+		p = c.options.RetryPolicy
+	}
+	if p == nil || p.MaxAttempts <= 1 || !retryOps[op] {
+		return c.doWithAuthRetry(fn)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = c.doWithAuthRetry(fn); err == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+		c.logger.Debug("etcd client retry", gxlog.Any("name", c.name), gxlog.Any("op", op),
+			gxlog.Any("attempt", attempt), gxlog.Err(err))
+
+		var delay time.Duration
+		if p.Backoff != nil {
+			delay = p.Backoff.Next(attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-c.ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
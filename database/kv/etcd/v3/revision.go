@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// KeyValue is a key value pair with its revision metadata
+type KeyValue struct {
+	Key   string
+	Value string
+	// CreateRevision revision of the last creation of the key
+	CreateRevision int64
+	// ModRevision revision of the last modification of the key
+	ModRevision int64
+	// Version number of modifications since the key is created
+	Version int64
+	// Lease lease attached to the key, 0 if there is no lease
+	Lease int64
+}
+
+func (c *Client) toKeyValue(kv *mvccpb.KeyValue) (KeyValue, error) {
+	v, err := c.decodeValue(kv.Value)
+	if err != nil {
+		return KeyValue{}, perrors.WithMessagef(err, "decode value of key %s", kv.Key)
+	}
+	return KeyValue{
+		Key:            string(kv.Key),
+		Value:          v,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+	}, nil
+}
+
+// rangeKeyValues gets the key values of @k, and the revision of the store when they are read
+func (c *Client) rangeKeyValues(ctx context.Context, k string, opts ...clientv3.OpOption) ([]KeyValue, int64, error) {
+	var resp *clientv3.GetResponse
+	err := c.do(ctx, OpGet, k, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Get(ctx, k, c.readOpts(opts...)...)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make([]KeyValue, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		v, err := c.toKeyValue(kv)
+		if err != nil {
+			return nil, 0, err
+		}
+		kvs = append(kvs, v)
+	}
+	return kvs, resp.Header.Revision, nil
+}
+
+// GetWithRevision gets value by @k and its mod revision, which can be compared
+// to update the key optimistically
+func (c *Client) GetWithRevision(k string) (string, int64, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetWithRevisionWithContext(ctx, k)
+}
+
+// GetWithRevisionWithContext is like GetWithRevision, but within @ctx
+func (c *Client) GetWithRevisionWithContext(ctx context.Context, k string) (string, int64, error) {
+	kvs, _, err := c.rangeKeyValues(ctx, k)
+	if err == nil && len(kvs) == 0 {
+		err = ErrKVPairNotFound
+	}
+	if err != nil {
+		return "", 0, perrors.WithMessagef(err, "get key value with revision (key %s)", k)
+	}
+	return kvs[0].Value, kvs[0].ModRevision, nil
+}
+
+// GetChildrenWithRevision gets the children of @k with their revision metadata, and
+// the revision of the store when they are read. A watch starting from the next
+// revision of it misses no event after the read.
+func (c *Client) GetChildrenWithRevision(k string) ([]KeyValue, int64, error) {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.GetChildrenWithRevisionWithContext(ctx, k)
+}
+
+// GetChildrenWithRevisionWithContext is like GetChildrenWithRevision, but within @ctx
+func (c *Client) GetChildrenWithRevisionWithContext(ctx context.Context, k string) ([]KeyValue, int64, error) {
+	kvs, rev, err := c.rangeKeyValues(ctx, k, clientv3.WithPrefix())
+	if err == nil && len(kvs) == 0 {
+		err = ErrKVPairNotFound
+	}
+	if err != nil {
+		return nil, 0, perrors.WithMessagef(err, "get key children with revision (key %s)", k)
+	}
+	return kvs, rev, nil
+}
+
+// GetAndWatch gets the key values with @prefix, and watches on @prefix from the
+// next revision of the read, so that no event between the read and the watch is
+// lost. The snapshot is empty if there is no key with @prefix. The watch ends when
+// the client is closed.
+func (c *Client) GetAndWatch(prefix string) ([]KeyValue, clientv3.WatchChan, error) {
+	return c.GetAndWatchWithContext(c.ctx, prefix)
+}
+
+// GetAndWatchWithContext is like GetAndWatch, but the watch ends when @ctx is done
+// or the client is closed. The read is within the request timeout of the client.
+func (c *Client) GetAndWatchWithContext(ctx context.Context, prefix string) ([]KeyValue, clientv3.WatchChan, error) {
+	getCtx, cancel := c.opCtx()
+	kvs, rev, err := c.rangeKeyValues(getCtx, prefix, clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		return nil, nil, perrors.WithMessagef(err, "get and watch (prefix %s)", prefix)
+	}
+
+	wc, err := c.watchWithAuthRetry(ctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+	if err != nil {
+		return nil, nil, perrors.WithMessagef(err, "get and watch (prefix %s)", prefix)
+	}
+	return kvs, wc, nil
+}
@@ -0,0 +1,170 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+)
+
+// ephemeral is a temporary node registered by RegisterTemp
+type ephemeral struct {
+	value string
+	ttl   time.Duration
+}
+
+func (c *Client) trackEphemeral(k, v string, ttl time.Duration) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	c.ephemerals[k] = ephemeral{value: v, ttl: ttl}
+}
+
+func (c *Client) untrackEphemeral(k string) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+
+	delete(c.ephemerals, k)
+}
+
+// reputEphemerals registers the temporary nodes again by @rawClient if their
+// leases have expired, eg: the session has expired on a network blip
+func (c *Client) reputEphemerals(rawClient *clientv3.Client) {
+	type registration struct {
+		ephemeral
+		leaseID clientv3.LeaseID
+	}
+
+	c.leaseLock.Lock()
+	registrations := make(map[string]registration, len(c.ephemerals))
+	for k, e := range c.ephemerals {
+		registrations[k] = registration{ephemeral: e, leaseID: c.leases[k]}
+	}
+	c.leaseLock.Unlock()
+
+	for k, r := range registrations {
+		if r.leaseID != clientv3.NoLease {
+			resp, err := rawClient.TimeToLive(c.ctx, r.leaseID)
+			if err == nil && resp.TTL > 0 {
+				// the lease is still alive
+				continue
+			}
+		}
+		if _, err := c.keepAliveKV(k, r.value, r.ttl); err != nil {
+			c.logger.Warn("etcd client re-register temporary node error", gxlog.Any("name", c.name),
+				gxlog.Any("key", k), gxlog.Err(err))
+		}
+	}
+}
+
+// cleanEphemerals deletes the temporary nodes and revokes their leases by
+// @rawClient on a graceful shutdown, instead of leaving them for up to the
+// lease ttl. The leases persisted by the lease store are kept for the
+// restarted client to reattach. It returns the first error.
+//
+// The cleanup runs within its own context bounded by the timeout of the client,
+// since the client may be closed because the context of closing is done.
+func (c *Client) cleanEphemerals(rawClient *clientv3.Client) error {
+	if c.options != nil && c.options.LeaseStore != nil {
+		return nil
+	}
+
+	c.leaseLock.Lock()
+	leases := make(map[string]clientv3.LeaseID, len(c.ephemerals))
+	for k := range c.ephemerals {
+		leases[k] = c.leases[k]
+	}
+	c.ephemerals = make(map[string]ephemeral)
+	c.leaseLock.Unlock()
+
+	ctx, cancel := c.cleanupCtx()
+	defer cancel()
+	var firstErr error
+	revoked := make(map[clientv3.LeaseID]struct{}, len(leases))
+	for k, leaseID := range leases {
+		if _, err := rawClient.Delete(ctx, k); err != nil {
+			c.logger.Warn("etcd client delete temporary node error", gxlog.Any("name", c.name),
+				gxlog.Any("key", k), gxlog.Err(err))
+			if firstErr == nil {
+				firstErr = perrors.WithMessagef(err, "delete temporary node (key %s)", k)
+			}
+		}
+		if _, ok := revoked[leaseID]; ok || leaseID == clientv3.NoLease {
+			continue
+		}
+		revoked[leaseID] = struct{}{}
+		if _, err := rawClient.Revoke(ctx, leaseID); err != nil && rpctypes.Error(err) != rpctypes.ErrLeaseNotFound {
+			c.logger.Warn("etcd client revoke lease error", gxlog.Any("name", c.name),
+				gxlog.Any("key", k), gxlog.Err(err))
+			if firstErr == nil {
+				firstErr = perrors.WithMessagef(err, "revoke lease (key %s)", k)
+			}
+		}
+	}
+	return firstErr
+}
+
+// ensureSession creates the session of the client created by WithoutSession on
+// its first use, and keeps it until the client is stopped
+func (c *Client) ensureSession() error {
+	if c.options == nil || !c.options.WithoutSession || c.options.NonBlockingDial {
+		return nil
+	}
+
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	c.lock.RLock()
+	s := c.session
+	c.lock.RUnlock()
+	if s != nil {
+		return nil
+	}
+
+	rawClient := c.GetRawClient()
+	if rawClient == nil {
+		return ErrNilETCDV3Client
+	}
+	s, err := c.newSession(rawClient)
+	if err != nil {
+		return perrors.WithMessage(err, "new session with server")
+	}
+
+	c.lock.Lock()
+	if c.stopped() {
+		c.lock.Unlock()
+		s.Close()
+		return ErrClientClosed
+	}
+	c.session = s
+	// must add wg before go keep session goroutine
+	c.Wait.Add(1)
+	c.lock.Unlock()
+
+	go c.keepSessionLoop(s)
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// RestoreConfig is the config of restoring a member from a snapshot, eg:
+//
+//	RestoreConfig{
+//		SnapshotPath:   "backup.db",
+//		Name:           "m1",
+//		OutputDataDir:  "/var/lib/etcd/m1",
+//		PeerURLs:       []string{"http://10.0.0.1:2380"},
+//		InitialCluster: "m1=http://10.0.0.1:2380,m2=http://10.0.0.2:2380",
+//	}
+type RestoreConfig struct {
+	// SnapshotPath is the path of the snapshot file
+	SnapshotPath string
+	// Name is the name of the restored member
+	Name string
+	// OutputDataDir is the data dir of the restored member
+	OutputDataDir string
+	// OutputWALDir is the wal dir of the restored member, it is in OutputDataDir if empty
+	OutputWALDir string
+	// PeerURLs are the advertised peer urls of the restored member
+	PeerURLs []string
+	// InitialCluster is the initial cluster of all the restored members
+	InitialCluster string
+	// InitialClusterToken is the initial cluster token of all the restored members
+	InitialClusterToken string
+	// SkipHashCheck skips the hash check of the snapshot, which is needed for a db
+	// file copied from the data dir
+	SkipHashCheck bool
+	// Etcdutl is the path of the etcdutl binary, it is looked up in PATH if empty
+	Etcdutl string
+}
+
+// args returns the arguments of etcdutl restoring the snapshot
+func (cfg *RestoreConfig) args() []string {
+	args := []string{"snapshot", "restore", cfg.SnapshotPath}
+	add := func(flag, value string) {
+		if value != "" {
+			args = append(args, "--"+flag+"="+value)
+		}
+	}
+	add("name", cfg.Name)
+	add("data-dir", cfg.OutputDataDir)
+	add("wal-dir", cfg.OutputWALDir)
+	add("initial-advertise-peer-urls", strings.Join(cfg.PeerURLs, ","))
+	add("initial-cluster", cfg.InitialCluster)
+	add("initial-cluster-token", cfg.InitialClusterToken)
+	if cfg.SkipHashCheck {
+		args = append(args, "--skip-hash-check")
+	}
+	return args
+}
+
+// Snapshot writes the snapshot of the backend database of the member the client
+// connects to into @w
+func (c *Client) Snapshot(ctx context.Context, w io.Writer) error {
+	var rc io.ReadCloser
+	err := c.do(ctx, OpMaintenance, "", func(rawClient *clientv3.Client) (err error) {
+		rc, err = rawClient.Snapshot(ctx)
+		return err
+	})
+	if err != nil {
+		return perrors.WithMessage(err, "snapshot")
+	}
+	defer rc.Close()
+
+	// the snapshot is streamed, so the copy is not retried
+	if _, err = io.Copy(w, rc); err != nil {
+		return perrors.WithMessage(err, "write snapshot")
+	}
+	return nil
+}
+
+// RestoreFromSnapshot restores the data dir of a member from the snapshot file
+// by @cfg. It works offline, the member should be started on the data dir after
+// all the members of the cluster are restored from the same snapshot.
+//
+// The restore runs the etcdutl tool, since it is done on the server storage which
+// is not a part of the etcd client since etcd 3.5.
+func RestoreFromSnapshot(cfg RestoreConfig) error {
+	bin := cfg.Etcdutl
+	if bin == "" {
+		bin = "etcdutl"
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(bin, cfg.args()...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return perrors.WithMessagef(err, "restore from snapshot %s: %s",
+			cfg.SnapshotPath, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
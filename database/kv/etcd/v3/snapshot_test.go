@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreFromSnapshotArgs(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := path.Join(dir, "args")
+	bin := path.Join(dir, "etcdutl")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\n"
+	assert.Nil(t, os.WriteFile(bin, []byte(script), 0o755))
+
+	err := RestoreFromSnapshot(RestoreConfig{
+		SnapshotPath:   "backup.db",
+		Name:           "m1",
+		OutputDataDir:  "/var/lib/etcd/m1",
+		PeerURLs:       []string{"http://10.0.0.1:2380", "http://10.0.0.1:2381"},
+		InitialCluster: "m1=http://10.0.0.1:2380",
+		SkipHashCheck:  true,
+		Etcdutl:        bin,
+	})
+	assert.Nil(t, err)
+	args, err := os.ReadFile(argsFile)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot restore backup.db --name=m1 --data-dir=/var/lib/etcd/m1 "+
+		"--initial-advertise-peer-urls=http://10.0.0.1:2380,http://10.0.0.1:2381 "+
+		"--initial-cluster=m1=http://10.0.0.1:2380 --skip-hash-check", strings.TrimSpace(string(args)))
+
+	// the output of etcdutl is in the error
+	assert.Nil(t, os.WriteFile(bin, []byte("#!/bin/sh\necho data dir exists\nexit 1\n"), 0o755))
+	err = RestoreFromSnapshot(RestoreConfig{SnapshotPath: "backup.db", Etcdutl: bin})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "data dir exists")
+}
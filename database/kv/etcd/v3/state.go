@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"time"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+)
+
+const stateEventBufferSize = 16
+
+// StateEvent is a change of the connection state of the client
+type StateEvent struct {
+	State ConnState
+	Time  time.Time
+}
+
+// SubscribeStateChanges returns a chan of the connection state changes after the
+// subscription, eg: ConnDisconnected, ConnSessionRecreated when the session has
+// expired, and ConnReconnected. The chan is closed after ConnClosed. The events are
+// dropped if the subscriber does not keep up with the buffer of the chan.
+func (c *Client) SubscribeStateChanges() <-chan StateEvent {
+	ch := make(chan StateEvent, stateEventBufferSize)
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+	if c.stateClosed {
+		close(ch)
+		return ch
+	}
+	c.stateSubs = append(c.stateSubs, ch)
+	return ch
+}
+
+func (c *Client) publishState(state ConnState) {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if c.stateClosed {
+		return
+	}
+	event := StateEvent{State: state, Time: time.Now()}
+	for _, ch := range c.stateSubs {
+		select {
+		case ch <- event:
+		default:
+			c.logger.Warn("etcd client drops state event of slow subscriber", gxlog.Any("name", c.name),
+				gxlog.Any("state", state))
+		}
+	}
+	if state == ConnClosed {
+		c.stateClosed = true
+		for _, ch := range c.stateSubs {
+			close(ch)
+		}
+		c.stateSubs = nil
+	}
+}
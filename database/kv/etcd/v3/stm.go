@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// STM is the software transactional memory of the keys, the reads and writes in
+// it are committed atomically. The values are not compressed by the client.
+type STM = concurrency.STM
+
+// Isolation is the isolation level of STM
+type Isolation = concurrency.Isolation
+
+// the isolation levels of STM
+const (
+	// SerializableSnapshot detects the conflicts of both the reads and writes, it is the default
+	SerializableSnapshot = concurrency.SerializableSnapshot
+	// Serializable detects the conflicts of the reads
+	Serializable = concurrency.Serializable
+	// RepeatableReads reads the keys at the revision of the first read
+	RepeatableReads = concurrency.RepeatableReads
+	// ReadCommitted reads the latest committed values
+	ReadCommitted = concurrency.ReadCommitted
+)
+
+// DoSTM runs @fn in a STM of SerializableSnapshot isolation, @fn is re-run when the
+// keys read by it are modified before it is committed, so it should be idempotent.
+// The error returned by @fn aborts the STM and is returned.
+func (c *Client) DoSTM(fn func(stm STM) error) error {
+	ctx, cancel := c.opCtx()
+	defer cancel()
+	return c.DoSTMWithContext(ctx, SerializableSnapshot, fn)
+}
+
+// DoSTMWithContext is like DoSTM, but runs the STM of @iso isolation within @ctx
+func (c *Client) DoSTMWithContext(ctx context.Context, iso Isolation, fn func(stm STM) error) error {
+	err := c.do(ctx, OpTxn, "", func(rawClient *clientv3.Client) error {
+		_, err := concurrency.NewSTM(rawClient, fn, concurrency.WithAbortContext(ctx), concurrency.WithIsolation(iso))
+		return err
+	})
+	return perrors.WithMessage(err, "do stm")
+}
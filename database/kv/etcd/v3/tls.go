@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"crypto/tls"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxtls "github.com/dubbogo/gost/net/tls"
+)
+
+// loadTLSConfig returns the tls config of @options, which is built from the tls
+// files if the tls config is not set. The returned CertReloader is nil if there
+// is no client certificate to reload.
+func loadTLSConfig(options *Options) (*tls.Config, *gxtls.CertReloader, error) {
+	if options.TLSConfig != nil {
+		return options.TLSConfig, nil, nil
+	}
+	if options.TLSCAFile == "" && options.TLSCertFile == "" && options.TLSKeyFile == "" {
+		return nil, nil, nil
+	}
+	if (options.TLSCertFile == "") != (options.TLSKeyFile == "") {
+		return nil, nil, perrors.New("tls cert file and key file should be set together")
+	}
+
+	opts := []gxtls.Option{gxtls.WithCertFiles(options.TLSCertFile, options.TLSKeyFile)}
+	if options.TLSCAFile != "" {
+		opts = append(opts, gxtls.WithCAFile(options.TLSCAFile))
+	}
+	return gxtls.NewClientConfig(opts...)
+}
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gxetcd-tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCert(t, dir)
+
+	newOptions := func(opts ...Option) *Options {
+		options := &Options{}
+		for _, opt := range opts {
+			opt(options)
+		}
+		return options
+	}
+
+	config, reloader, err := loadTLSConfig(newOptions())
+	assert.Nil(t, err)
+	assert.Nil(t, config)
+	assert.Nil(t, reloader)
+
+	custom := &tls.Config{ServerName: "etcd"}
+	config, reloader, err = loadTLSConfig(newOptions(WithTLSConfig(custom), WithTLSFiles(certFile, keyFile, certFile)))
+	assert.Nil(t, err)
+	assert.Equal(t, custom, config)
+	assert.Nil(t, reloader)
+
+	config, reloader, err = loadTLSConfig(newOptions(WithTLSFiles("", "", certFile)))
+	assert.Nil(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Nil(t, reloader)
+
+	config, reloader, err = loadTLSConfig(newOptions(WithTLSFiles(certFile, keyFile, certFile)))
+	assert.Nil(t, err)
+	defer reloader.Close()
+	assert.NotNil(t, config.RootCAs)
+	cert, err := config.GetClientCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cert.Certificate))
+
+	_, _, err = loadTLSConfig(newOptions(WithTLSFiles(certFile, "", "")))
+	assert.NotNil(t, err)
+	_, _, err = loadTLSConfig(newOptions(WithTLSFiles("", "", filepath.Join(dir, "missing.pem"))))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,45 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+// Tracer starts a span around every operation and watch of the client, so that
+// the latency of etcd is visible in the distributed traces. It does not depend on
+// any tracing library, eg: an OpenTelemetry adapter starts a span named by @op
+// with the key attribute, and records @err as the result when the span ends.
+type Tracer interface {
+	// Start starts a span of @op on @key as a child of the span in @ctx, and returns
+	// the function which ends the span with the result of the operation. @key is
+	// empty if the operation is not on a key, eg: a transaction of many keys.
+	Start(ctx context.Context, op, key string) (end func(err error))
+}
+
+func nopEndSpan(error) {}
+
+func (c *Client) startSpan(ctx context.Context, op, key string) func(err error) {
+	if c.options == nil || c.options.Tracer == nil {
+		return nopEndSpan
+	}
+	if ctx == nil {
+		ctx = c.ctx
+	}
+	return c.options.Tracer.Start(ctx, op, key)
+}
@@ -0,0 +1,298 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	uatomic "go.uber.org/atomic"
+)
+
+// SlowConsumerPolicy decides what to do when the consumer of a buffered watch
+// can not keep up with the events
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerBlock blocks the watch stream until the consumer catches up
+	SlowConsumerBlock SlowConsumerPolicy = iota
+	// SlowConsumerCoalesce keeps only the latest pending event of every key
+	SlowConsumerCoalesce
+	// SlowConsumerDrop drops the events which can not be buffered
+	SlowConsumerDrop
+	// SlowConsumerCancel cancels the watch with ErrSlowConsumer
+	SlowConsumerCancel
+)
+
+const defaultWatchBufferSize = 64
+
+// ErrSlowConsumer is returned when a watch is cancelled because of a slow consumer
+var ErrSlowConsumer = perrors.New("watch consumer is too slow")
+
+// BufferedWatcher delivers watch responses through a bounded buffer
+type BufferedWatcher struct {
+	out    chan clientv3.WatchResponse
+	cancel context.CancelFunc
+	policy SlowConsumerPolicy
+
+	dropped   uatomic.Uint64
+	coalesced uatomic.Uint64
+	total     *uatomic.Uint64 // dropped events of the client, nil if the watch is not created by a client
+
+	lock sync.Mutex
+	err  error
+}
+
+// C returns the chan of watch responses, which is closed when the watch ends
+func (w *BufferedWatcher) C() <-chan clientv3.WatchResponse {
+	return w.out
+}
+
+// Dropped returns the number of the dropped events
+func (w *BufferedWatcher) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Coalesced returns the number of the events replaced by newer events of the same key
+func (w *BufferedWatcher) Coalesced() uint64 {
+	return w.coalesced.Load()
+}
+
+// Err returns the reason why the watch ends
+func (w *BufferedWatcher) Err() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
+
+// Close cancels the watch
+func (w *BufferedWatcher) Close() {
+	w.cancel()
+}
+
+func (w *BufferedWatcher) setErr(err error) {
+	w.lock.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.lock.Unlock()
+}
+
+func (w *BufferedWatcher) drop(n int) {
+	w.dropped.Add(uint64(n))
+	if w.total != nil {
+		w.total.Add(uint64(n))
+	}
+}
+
+func (w *BufferedWatcher) run(ctx context.Context, wc clientv3.WatchChan) {
+	defer close(w.out)
+
+	if w.policy == SlowConsumerCoalesce {
+		w.coalesce(ctx, wc)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.setErr(ctx.Err())
+			return
+		case resp, ok := <-wc:
+			if !ok {
+				return
+			}
+			if resp.Err() != nil {
+				// never drop the error or cancel responses
+				w.setErr(resp.Err())
+				if !w.send(ctx, resp) {
+					return
+				}
+				continue
+			}
+
+			switch w.policy {
+			case SlowConsumerDrop:
+				select {
+				case w.out <- resp:
+				default:
+					w.drop(len(resp.Events))
+				}
+			case SlowConsumerCancel:
+				select {
+				case w.out <- resp:
+				default:
+					w.drop(len(resp.Events))
+					w.setErr(ErrSlowConsumer)
+					w.cancel()
+					return
+				}
+			default:
+				if !w.send(ctx, resp) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// send blocks until @resp is delivered or the watch is cancelled
+func (w *BufferedWatcher) send(ctx context.Context, resp clientv3.WatchResponse) bool {
+	select {
+	case w.out <- resp:
+		return true
+	case <-ctx.Done():
+		w.setErr(ctx.Err())
+		return false
+	}
+}
+
+// coalesce keeps the latest pending event of every key. At most cap(w.out)
+// keys are pending, the events of the other keys are dropped.
+func (w *BufferedWatcher) coalesce(ctx context.Context, wc clientv3.WatchChan) {
+	var (
+		pending []*clientv3.Event
+		index   = make(map[string]int)
+		last    clientv3.WatchResponse
+	)
+
+	flush := func() bool {
+		if len(pending) == 0 {
+			return true
+		}
+		resp := clientv3.WatchResponse{Header: last.Header, Events: pending}
+		pending = nil
+		index = make(map[string]int)
+		return w.send(ctx, resp)
+	}
+
+	for {
+		var (
+			sendC chan clientv3.WatchResponse
+			next  clientv3.WatchResponse
+		)
+		if len(pending) > 0 {
+			sendC = w.out
+			next = clientv3.WatchResponse{Header: last.Header, Events: pending}
+		}
+
+		select {
+		case <-ctx.Done():
+			w.setErr(ctx.Err())
+			return
+		case sendC <- next:
+			pending = nil
+			index = make(map[string]int)
+		case resp, ok := <-wc:
+			if !ok {
+				flush()
+				return
+			}
+			last = resp
+			for _, e := range resp.Events {
+				key := string(e.Kv.Key)
+				if i, ok := index[key]; ok {
+					pending[i] = e
+					w.coalesced.Inc()
+					continue
+				}
+				if len(pending) >= cap(w.out) {
+					w.drop(1)
+					continue
+				}
+				index[key] = len(pending)
+				pending = append(pending, e)
+			}
+			if resp.Err() != nil {
+				// deliver the pending events before the error or cancel response
+				w.setErr(resp.Err())
+				resp.Events = nil
+				if !flush() || !w.send(ctx, resp) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Client) watchBuffered(k string, opts ...clientv3.OpOption) (*BufferedWatcher, error) {
+	size, policy := defaultWatchBufferSize, SlowConsumerBlock
+	if c.options != nil {
+		if c.options.WatchBufferSize > 0 {
+			size = c.options.WatchBufferSize
+		}
+		policy = c.options.SlowConsumerPolicy
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	wc, err := c.watchWithAuthRetry(ctx, k, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	w := newBufferedWatcher(cancel, size, policy)
+	w.total = &c.watchDropped
+	go w.run(ctx, wc)
+	return w, nil
+}
+
+func newBufferedWatcher(cancel context.CancelFunc, size int, policy SlowConsumerPolicy) *BufferedWatcher {
+	if size <= 0 {
+		size = defaultWatchBufferSize
+	}
+	return &BufferedWatcher{
+		out:    make(chan clientv3.WatchResponse, size),
+		cancel: cancel,
+		policy: policy,
+	}
+}
+
+// NewBufferedWatcher wraps the raw watch chan @wc, eg: the one returned by Watch,
+// into a bounded buffer of @size responses, so that a stalled consumer does not
+// stall the watch stream when @policy is not SlowConsumerBlock. The buffered
+// watch ends when @wc is closed, @ctx is done or the watcher is closed, the
+// context which @wc is created with should be cancelled by the caller then.
+func NewBufferedWatcher(ctx context.Context, wc clientv3.WatchChan, size int, policy SlowConsumerPolicy) *BufferedWatcher {
+	ctx, cancel := context.WithCancel(ctx)
+	w := newBufferedWatcher(cancel, size, policy)
+	go w.run(ctx, wc)
+	return w
+}
+
+// WatchBuffered watches on spec key, and delivers the events through a bounded
+// buffer which follows the slow consumer policy of the client
+func (c *Client) WatchBuffered(k string) (*BufferedWatcher, error) {
+	w, err := c.watchBuffered(k)
+	return w, perrors.WithMessagef(err, "watch buffered (key %s)", k)
+}
+
+// WatchWithPrefixBuffered watches on spec prefix, and delivers the events through
+// a bounded buffer which follows the slow consumer policy of the client
+func (c *Client) WatchWithPrefixBuffered(prefix string) (*BufferedWatcher, error) {
+	w, err := c.watchBuffered(prefix, clientv3.WithPrefix())
+	return w, perrors.WithMessagef(err, "watch prefix buffered (key %s)", prefix)
+}
+
+// WatchDroppedEvents returns the number of events dropped by all the buffered watches of the client
+func (c *Client) WatchDroppedEvents() uint64 {
+	return c.watchDropped.Load()
+}
@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxetcd
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	uatomic "go.uber.org/atomic"
+)
+
+func newTestWatcher(size int, policy SlowConsumerPolicy) (*BufferedWatcher, chan clientv3.WatchResponse, *uatomic.Uint64) {
+	var total uatomic.Uint64
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &BufferedWatcher{
+		out:    make(chan clientv3.WatchResponse, size),
+		cancel: cancel,
+		policy: policy,
+		total:  &total,
+	}
+	wc := make(chan clientv3.WatchResponse)
+	go w.run(ctx, wc)
+	return w, wc, &total
+}
+
+func putResponse(keys ...string) clientv3.WatchResponse {
+	resp := clientv3.WatchResponse{}
+	for _, k := range keys {
+		resp.Events = append(resp.Events, &clientv3.Event{
+			Type: mvccpb.PUT,
+			Kv:   &mvccpb.KeyValue{Key: []byte(k)},
+		})
+	}
+	return resp
+}
+
+func TestBufferedWatcherCoalesceBounded(t *testing.T) {
+	w, wc, total := newTestWatcher(2, SlowConsumerCoalesce)
+	defer w.Close()
+
+	// the consumer does not read, so all the events are pending
+	w.out <- clientv3.WatchResponse{}
+	w.out <- clientv3.WatchResponse{}
+	for i := 0; i < 10; i++ {
+		wc <- putResponse("k" + strconv.Itoa(i))
+	}
+	wc <- putResponse("k0")
+	close(wc)
+
+	assert.Eventually(t, func() bool { return w.Coalesced() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(8), w.Dropped())
+	assert.Equal(t, uint64(8), total.Load())
+}
+
+func TestBufferedWatcherForwardError(t *testing.T) {
+	for _, policy := range []SlowConsumerPolicy{SlowConsumerCoalesce, SlowConsumerDrop} {
+		w, wc, _ := newTestWatcher(1, policy)
+
+		wc <- putResponse("a")
+		go func() {
+			wc <- putResponse("b")
+			wc <- clientv3.WatchResponse{Canceled: true, CompactRevision: 1}
+			close(wc)
+		}()
+
+		var (
+			keys []string
+			err  error
+		)
+		timeout := time.After(3 * time.Second)
+	LOOP:
+		for {
+			select {
+			case resp, ok := <-w.C():
+				if !ok {
+					break LOOP
+				}
+				for _, e := range resp.Events {
+					keys = append(keys, string(e.Kv.Key))
+				}
+				if resp.Err() != nil {
+					err = resp.Err()
+				}
+			case <-timeout:
+				t.Fatalf("policy %d: expect the watch to end", policy)
+			}
+		}
+		assert.NotNil(t, err)
+		assert.NotNil(t, w.Err())
+		assert.Equal(t, "a", keys[0])
+		assert.Equal(t, uint64(len(keys)), 2-w.Dropped())
+	}
+}
+
+func TestNewBufferedWatcher(t *testing.T) {
+	wc := make(chan clientv3.WatchResponse)
+	w := NewBufferedWatcher(context.Background(), wc, 1, SlowConsumerDrop)
+	defer w.Close()
+
+	// the raw chan is drained although the consumer does not read
+	for i := 0; i < 3; i++ {
+		wc <- putResponse("k" + strconv.Itoa(i))
+	}
+	close(wc)
+
+	resp, ok := <-w.C()
+	assert.True(t, ok)
+	assert.Equal(t, "k0", string(resp.Events[0].Kv.Key))
+	_, ok = <-w.C()
+	assert.False(t, ok)
+	assert.Equal(t, uint64(2), w.Dropped())
+}
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	uatomic "go.uber.org/atomic"
+)
+
+// PrefixChangedHandler is called when the keys with @prefix have changed, @events
+// is the number of the events coalesced into the notification
+type PrefixChangedHandler func(prefix string, events int)
+
+// WatchWithPrefixDebounced watches the keys with @prefix, and coalesces the bursts
+// of events into one notification, which is delivered to @handler at most once
+// per @interval. An event after a quiet period is notified at once. @handler is
+// called in a goroutine until the returned cancel function is called or the client
+// is stopped, and the events happening while it runs are coalesced into the next one.
+func (c *Client) WatchWithPrefixDebounced(prefix string, interval time.Duration, handler PrefixChangedHandler) (context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	var pending uatomic.Int64
+	changed := make(chan struct{}, 1)
+	err := c.watchFunc(ctx, prefix, func(EventType, string, string) {
+		pending.Inc()
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}, clientv3.WithPrefix())
+	if err != nil {
+		cancel()
+		return nil, perrors.WithMessagef(err, "watch prefix debounced (key %s)", prefix)
+	}
+
+	go func() {
+		var last time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+
+			if wait := time.Until(last.Add(interval)); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			if n := pending.Swap(0); n > 0 {
+				last = time.Now()
+				handler(prefix, int(n))
+			}
+		}
+	}()
+	return cancel, nil
+}
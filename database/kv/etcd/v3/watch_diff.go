@@ -0,0 +1,292 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxetcd
+
+import (
+	"context"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ChildEventType is the type of a children diff event
+type ChildEventType int
+
+const (
+	// InstanceAdded means a new child appears under the prefix
+	InstanceAdded ChildEventType = iota
+	// InstanceRemoved means a child is deleted or its lease is expired
+	InstanceRemoved
+	// InstanceUpdated means the value of a child is changed
+	InstanceUpdated
+)
+
+func (t ChildEventType) String() string {
+	switch t {
+	case InstanceAdded:
+		return "InstanceAdded"
+	case InstanceRemoved:
+		return "InstanceRemoved"
+	case InstanceUpdated:
+		return "InstanceUpdated"
+	}
+	return "Unknown"
+}
+
+// ChildEvent is a semantic event derived from the raw PUT/DELETE events.
+// OldValue is empty for InstanceAdded and NewValue is empty for InstanceRemoved.
+type ChildEvent struct {
+	Type     ChildEventType
+	Key      string
+	OldValue string
+	NewValue string
+	Revision int64
+}
+
+// ChildrenDiffWatcher keeps the snapshot of the children under a prefix and
+// delivers the differences as ChildEvent
+type ChildrenDiffWatcher struct {
+	client *Client
+	prefix string
+	out    chan ChildEvent
+	cancel context.CancelFunc
+
+	lock     sync.RWMutex
+	snapshot map[string]string
+	revision int64
+	err      error
+}
+
+// C returns the chan of children events, which is closed when the watch ends
+func (w *ChildrenDiffWatcher) C() <-chan ChildEvent {
+	return w.out
+}
+
+// Snapshot returns a copy of the current children
+func (w *ChildrenDiffWatcher) Snapshot() map[string]string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	snapshot := make(map[string]string, len(w.snapshot))
+	for k, v := range w.snapshot {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+// Err returns the reason why the watch ends
+func (w *ChildrenDiffWatcher) Err() error {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.err
+}
+
+// Close cancels the watch
+func (w *ChildrenDiffWatcher) Close() {
+	w.cancel()
+}
+
+func (w *ChildrenDiffWatcher) setErr(err error) {
+	w.lock.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.lock.Unlock()
+}
+
+// list loads all the children and returns the events against the old snapshot
+func (w *ChildrenDiffWatcher) list(ctx context.Context) ([]ChildEvent, error) {
+	var resp *clientv3.GetResponse
+	err := w.client.do(ctx, OpGet, w.prefix, func(rawClient *clientv3.Client) (err error) {
+		resp, err = rawClient.Get(ctx, w.prefix, clientv3.WithPrefix())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		v, err := w.client.decodeValue(kv.Value)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "decode value of key %s", kv.Key)
+		}
+		children[string(kv.Key)] = v
+	}
+	rev := resp.Header.Revision
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	var events []ChildEvent
+	for k, v := range children {
+		old, ok := w.snapshot[k]
+		switch {
+		case !ok:
+			events = append(events, ChildEvent{Type: InstanceAdded, Key: k, NewValue: v, Revision: rev})
+		case old != v:
+			events = append(events, ChildEvent{Type: InstanceUpdated, Key: k, OldValue: old, NewValue: v, Revision: rev})
+		}
+	}
+	for k, old := range w.snapshot {
+		if _, ok := children[k]; !ok {
+			events = append(events, ChildEvent{Type: InstanceRemoved, Key: k, OldValue: old, Revision: rev})
+		}
+	}
+	w.snapshot = children
+	w.revision = rev
+	return events, nil
+}
+
+// apply updates the snapshot by @e and returns the derived event
+func (w *ChildrenDiffWatcher) apply(e *clientv3.Event) (ChildEvent, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	key := string(e.Kv.Key)
+	event := ChildEvent{Key: key, Revision: e.Kv.ModRevision}
+	old, ok := w.snapshot[key]
+	w.revision = e.Kv.ModRevision
+
+	switch e.Type {
+	case mvccpb.PUT:
+		value := string(e.Kv.Value)
+		w.snapshot[key] = value
+		if !ok {
+			event.Type, event.NewValue = InstanceAdded, value
+			return event, true
+		}
+		if old == value {
+			return event, false
+		}
+		event.Type, event.OldValue, event.NewValue = InstanceUpdated, old, value
+		return event, true
+	case mvccpb.DELETE:
+		if !ok {
+			return event, false
+		}
+		delete(w.snapshot, key)
+		event.Type, event.OldValue = InstanceRemoved, old
+		return event, true
+	}
+	return event, false
+}
+
+func (w *ChildrenDiffWatcher) send(ctx context.Context, events ...ChildEvent) bool {
+	for _, e := range events {
+		select {
+		case w.out <- e:
+		case <-ctx.Done():
+			w.setErr(ctx.Err())
+			return false
+		}
+	}
+	return true
+}
+
+// consume applies the events of @wc until it is closed or returns an error
+func (w *ChildrenDiffWatcher) consume(ctx context.Context, wc clientv3.WatchChan) bool {
+	for resp := range wc {
+		if resp.Err() != nil {
+			// the revision may be compacted, re-list to resync the snapshot
+			return true
+		}
+		for _, e := range resp.Events {
+			if event, ok := w.apply(e); ok {
+				if !w.send(ctx, event) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (w *ChildrenDiffWatcher) run(ctx context.Context, initial []ChildEvent) {
+	defer close(w.out)
+
+	if !w.send(ctx, initial...) {
+		return
+	}
+
+	for {
+		w.lock.RLock()
+		rev := w.revision
+		w.lock.RUnlock()
+
+		// the values of the events are decompressed by the client
+		wctx, wcancel := context.WithCancel(ctx)
+		wc, err := w.client.watchWithAuthRetry(wctx, w.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+		if err != nil {
+			wcancel()
+			w.setErr(err)
+			return
+		}
+		if !w.consume(ctx, wc) {
+			wcancel()
+			return
+		}
+		wcancel()
+
+		select {
+		case <-ctx.Done():
+			w.setErr(ctx.Err())
+			return
+		default:
+		}
+
+		events, err := w.list(ctx)
+		if err != nil {
+			w.setErr(err)
+			return
+		}
+		if !w.send(ctx, events...) {
+			return
+		}
+	}
+}
+
+func (c *Client) watchChildrenDiff(prefix string) (*ChildrenDiffWatcher, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	w := &ChildrenDiffWatcher{
+		client:   c,
+		prefix:   prefix,
+		out:      make(chan ChildEvent, defaultWatchBufferSize),
+		cancel:   cancel,
+		snapshot: make(map[string]string),
+	}
+
+	initial, err := w.list(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go w.run(ctx, initial)
+	return w, nil
+}
+
+// WatchChildrenDiff watches on spec prefix, and delivers InstanceAdded/InstanceRemoved/
+// InstanceUpdated events against the previous snapshot. The existing children are
+// delivered as InstanceAdded events first.
+func (c *Client) WatchChildrenDiff(prefix string) (*ChildrenDiffWatcher, error) {
+	w, err := c.watchChildrenDiff(prefix)
+	return w, perrors.WithMessagef(err, "watch children diff (key %s)", prefix)
+}
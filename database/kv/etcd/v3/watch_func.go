@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+)
+
+// EventType is the type of a watch event
+type EventType int
+
+const (
+	// EventTypePut means the key is created or updated
+	EventTypePut EventType = iota
+	// EventTypeDelete means the key is deleted or its lease is expired
+	EventTypeDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventTypePut:
+		return "Put"
+	case EventTypeDelete:
+		return "Delete"
+	}
+	return "Unknown"
+}
+
+// WatchHandler handles the watch events, @value is empty for EventTypeDelete
+type WatchHandler func(typ EventType, key, value string)
+
+// rewatchDelay delay before re-watching when a watch ends unexpectedly
+const rewatchDelay = 100 * time.Millisecond
+
+// watchFunc calls @handler with the events of @k until @ctx is done or the client
+// is stopped. The watch is re-established from the next revision of the last
+// event when it ends unexpectedly, eg: the client reconnects.
+func (c *Client) watchFunc(ctx context.Context, k string, handler WatchHandler, opts ...clientv3.OpOption) error {
+	wc, err := c.watchWithAuthRetry(ctx, k, opts...)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var rev int64
+		for {
+			for resp := range wc {
+				if resp.CompactRevision > rev {
+					// the events before the compact revision are lost
+					c.logger.Warn("etcd client watch is compacted", gxlog.Any("name", c.name), gxlog.Any("key", k), gxlog.Any("revision", resp.CompactRevision))
+					rev = resp.CompactRevision - 1
+				}
+				for _, e := range resp.Events {
+					rev = e.Kv.ModRevision
+					switch e.Type {
+					case mvccpb.PUT:
+						handler(EventTypePut, string(e.Kv.Key), string(e.Kv.Value))
+					case mvccpb.DELETE:
+						handler(EventTypeDelete, string(e.Kv.Key), "")
+					}
+				}
+				if resp.Header.Revision > rev && len(resp.Events) == 0 && resp.Err() == nil {
+					// progress notify
+					rev = resp.Header.Revision
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.Done():
+				return
+			case <-time.After(rewatchDelay):
+			}
+
+			wopts := opts
+			if rev > 0 {
+				wopts = append(opts[:len(opts):len(opts)], clientv3.WithRev(rev+1))
+			}
+			if wc, err = c.watchWithAuthRetry(ctx, k, wopts...); err != nil {
+				c.logger.Error("etcd client re-watch error", gxlog.Any("name", c.name), gxlog.Any("key", k), gxlog.Err(err))
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// WatchFunc calls @handler with the events of @k in a goroutine until the returned
+// cancel function is called or the client is stopped. The watch is re-established
+// transparently from the last seen revision.
+func (c *Client) WatchFunc(k string, handler WatchHandler) (context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	if err := c.watchFunc(ctx, k, c.dispatched(handler)); err != nil {
+		cancel()
+		return nil, perrors.WithMessagef(err, "watch func (key %s)", k)
+	}
+	return cancel, nil
+}
+
+// WatchWithPrefixFunc is like WatchFunc, but watches the keys with @prefix
+func (c *Client) WatchWithPrefixFunc(prefix string, handler WatchHandler) (context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	if err := c.watchFunc(ctx, prefix, c.dispatched(handler), clientv3.WithPrefix()); err != nil {
+		cancel()
+		return nil, perrors.WithMessagef(err, "watch prefix func (key %s)", prefix)
+	}
+	return cancel, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"context"
+)
+
+import (
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchOption will define a function of handling the options of WatchWithOption
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	prefix     bool
+	putOnly    bool
+	deleteOnly bool
+	prevKV     bool
+	rev        int64
+}
+
+// WithWatchPrefix watches all the keys with the prefix @k
+func WithWatchPrefix() WatchOption {
+	return func(o *watchOptions) {
+		o.prefix = true
+	}
+}
+
+// WithPutOnly only watches the PUT events
+func WithPutOnly() WatchOption {
+	return func(o *watchOptions) {
+		o.putOnly = true
+		o.deleteOnly = false
+	}
+}
+
+// WithDeleteOnly only watches the DELETE events
+func WithDeleteOnly() WatchOption {
+	return func(o *watchOptions) {
+		o.deleteOnly = true
+		o.putOnly = false
+	}
+}
+
+// WithPrevKV returns the previous key value of the events
+func WithPrevKV() WatchOption {
+	return func(o *watchOptions) {
+		o.prevKV = true
+	}
+}
+
+// WithStartRevision watches the events since the revision @rev, which can
+// be used to resume a watch without missing the events
+func WithStartRevision(rev int64) WatchOption {
+	return func(o *watchOptions) {
+		o.rev = rev
+	}
+}
+
+func (o *watchOptions) opOptions() []clientv3.OpOption {
+	var opts []clientv3.OpOption
+	if o.prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	if o.putOnly {
+		opts = append(opts, clientv3.WithFilterDelete())
+	}
+	if o.deleteOnly {
+		opts = append(opts, clientv3.WithFilterPut())
+	}
+	if o.prevKV {
+		opts = append(opts, clientv3.WithPrevKV())
+	}
+	if o.rev > 0 {
+		opts = append(opts, clientv3.WithRev(o.rev))
+	}
+	return opts
+}
+
+// WatchWithOption watches @k by @opts, the filtered events are dropped by the server
+func (c *Client) WatchWithOption(k string, opts ...WatchOption) (clientv3.WatchChan, error) {
+	return c.WatchWithOptionWithContext(c.ctx, k, opts...)
+}
+
+// WatchWithOptionWithContext watches @k by @opts until @ctx is done
+func (c *Client) WatchWithOptionWithContext(ctx context.Context, k string, opts ...WatchOption) (clientv3.WatchChan, error) {
+	o := &watchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return c.watchWithAuthRetry(ctx, k, o.opOptions()...)
+}
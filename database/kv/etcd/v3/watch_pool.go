@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"hash/fnv"
+)
+
+const defaultWatchWorkerQueueSize = 64
+
+// watchDispatcher runs the watch handlers in a bounded pool of workers. The events
+// of a key are always handled by the same worker, so they are handled in order,
+// while a slow handler only delays the keys of its worker.
+type watchDispatcher struct {
+	queues []chan func()
+	done   <-chan struct{}
+}
+
+// newWatchDispatcher starts @workers workers with queues of @queueSize, which
+// exit when @done is closed
+func newWatchDispatcher(workers, queueSize int, done <-chan struct{}) *watchDispatcher {
+	if queueSize <= 0 {
+		queueSize = defaultWatchWorkerQueueSize
+	}
+	d := &watchDispatcher{
+		queues: make([]chan func(), workers),
+		done:   done,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), queueSize)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+func (d *watchDispatcher) work(queue chan func()) {
+	for {
+		select {
+		case fn := <-queue:
+			fn()
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// queue returns the queue of the worker of @key
+func (d *watchDispatcher) queue(key string) chan func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return d.queues[h.Sum32()%uint32(len(d.queues))]
+}
+
+// dispatch queues @fn to the worker of @key, it blocks while the queue is full
+func (d *watchDispatcher) dispatch(key string, fn func()) {
+	select {
+	case d.queue(key) <- fn:
+	case <-d.done:
+	}
+}
+
+// dispatched returns the handler which runs @handler in the watch workers of the
+// client, or @handler itself if the watch workers are not set
+func (c *Client) dispatched(handler WatchHandler) WatchHandler {
+	d := c.dispatcher
+	if d == nil {
+		return handler
+	}
+	return func(typ EventType, key, value string) {
+		d.dispatch(key, func() {
+			handler(typ, key, value)
+		})
+	}
+}
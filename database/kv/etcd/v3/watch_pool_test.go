@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxetcd
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchDispatcher(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	c := &Client{dispatcher: newWatchDispatcher(4, 8, done)}
+
+	// find a key handled by another worker than the slow one
+	slow, fast := "slow", ""
+	for i := 0; fast == ""; i++ {
+		if k := "fast" + strconv.Itoa(i); c.dispatcher.queue(k) != c.dispatcher.queue(slow) {
+			fast = k
+		}
+	}
+
+	var (
+		lock    sync.Mutex
+		values  []string
+		release = make(chan struct{})
+		handled = make(chan string, 16)
+	)
+	handler := c.dispatched(func(typ EventType, key, value string) {
+		if key == slow && value == "0" {
+			<-release
+		}
+		lock.Lock()
+		values = append(values, key+"="+value)
+		lock.Unlock()
+		handled <- key
+	})
+
+	for i := 0; i < 3; i++ {
+		handler(EventTypePut, slow, strconv.Itoa(i))
+	}
+	handler(EventTypePut, fast, "0")
+
+	// the fast key is not delayed by the slow handler
+	select {
+	case k := <-handled:
+		assert.Equal(t, fast, k)
+	case <-time.After(time.Second):
+		t.Fatal("the fast key is delayed by the slow handler")
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-handled
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{fast + "=0", "slow=0", "slow=1", "slow=2"}, values)
+}
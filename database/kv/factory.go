@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxkv
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// URL is the parsed url selecting a backend, eg:
+// "etcd://host1:2379,host2:2379/basepath?timeout=5s"
+type URL struct {
+	// Scheme selects the backend
+	Scheme string
+	// Addrs addresses of the servers, which are separated by commas in the url
+	Addrs []string
+	// BasePath roots the keys of the client if it is not empty
+	BasePath string
+	// Params query parameters configuring the backend
+	Params url.Values
+}
+
+// Factory creates a client of a backend by @u
+type Factory func(u *URL) (Client, error)
+
+var (
+	factoriesLock sync.RWMutex
+	factories     = make(map[string]Factory)
+)
+
+// Register registers @factory of @scheme, the backends register themselves in
+// their init functions, so the application imports the backends it uses, eg:
+//
+//	import _ "github.com/dubbogo/gost/database/kv/etcd/v3"
+func Register(scheme string, factory Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	factories[strings.ToLower(scheme)] = factory
+}
+
+// NewClient creates a client of the backend selected by the scheme of @rawURL
+func NewClient(rawURL string) (Client, error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	factoriesLock.RLock()
+	factory, ok := factories[u.Scheme]
+	factoriesLock.RUnlock()
+	if !ok {
+		return nil, perrors.Errorf("kv backend %q is not registered", u.Scheme)
+	}
+	client, err := factory(u)
+	return client, perrors.WithMessagef(err, "new %s client", u.Scheme)
+}
+
+// ParseURL parses @rawURL, it accepts multiple addresses separated by commas
+// which net/url does not
+func ParseURL(rawURL string) (*URL, error) {
+	i := strings.Index(rawURL, "://")
+	if i <= 0 {
+		return nil, perrors.Errorf("kv url %q has no scheme", rawURL)
+	}
+	u := &URL{Scheme: strings.ToLower(rawURL[:i])}
+
+	rest := rawURL[i+len("://"):]
+	hosts := rest
+	if j := strings.IndexAny(rest, "/?"); j >= 0 {
+		hosts, rest = rest[:j], rest[j:]
+	} else {
+		rest = ""
+	}
+	for _, host := range strings.Split(hosts, ",") {
+		if host != "" {
+			u.Addrs = append(u.Addrs, host)
+		}
+	}
+
+	parsed, err := url.Parse(rest)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "parse kv url %q", rawURL)
+	}
+	if parsed.Path != "/" {
+		u.BasePath = parsed.Path
+	}
+	u.Params = parsed.Query()
+	return u, nil
+}
+
+// String returns the parameter @key, it is @def if @key is absent
+func (u *URL) String(key, def string) string {
+	if v := u.Params.Get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Duration parses the parameter @key, eg: "5s", it is @def if @key is absent
+func (u *URL) Duration(key string, def time.Duration) (time.Duration, error) {
+	v := u.Params.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	return d, perrors.WithMessagef(err, "kv url parameter %s", key)
+}
+
+// Int parses the parameter @key, it is @def if @key is absent
+func (u *URL) Int(key string, def int) (int, error) {
+	v := u.Params.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	return i, perrors.WithMessagef(err, "kv url parameter %s", key)
+}
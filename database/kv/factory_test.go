@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxkv
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURL(t *testing.T) {
+	u, err := ParseURL("ETCD://host1:2379,host2:2379/dubbo/base?timeout=5s&heartbeat=10")
+	assert.NoError(t, err)
+	assert.Equal(t, &URL{
+		Scheme:   "etcd",
+		Addrs:    []string{"host1:2379", "host2:2379"},
+		BasePath: "/dubbo/base",
+		Params:   url.Values{"timeout": {"5s"}, "heartbeat": {"10"}},
+	}, u)
+	timeout, err := u.Duration("timeout", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+	heartbeat, err := u.Int("heartbeat", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, heartbeat)
+	assert.Equal(t, "def", u.String("absent", "def"))
+
+	u, err = ParseURL("memory://")
+	assert.NoError(t, err)
+	assert.Empty(t, u.Addrs)
+	assert.Empty(t, u.BasePath)
+
+	u, err = ParseURL("redis://127.0.0.1:6379?db=1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:6379"}, u.Addrs)
+	_, err = u.Duration("db", 0)
+	assert.Error(t, err)
+
+	_, err = ParseURL("127.0.0.1:2379")
+	assert.Error(t, err)
+}
+
+func TestNewClient(t *testing.T) {
+	var got *URL
+	Register("fake", func(u *URL) (Client, error) {
+		got = u
+		return nil, nil
+	})
+	_, err := NewClient("fake://a:1/base")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a:1"}, got.Addrs)
+	assert.Equal(t, "/base", got.BasePath)
+
+	_, err = NewClient("absent://a:1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,420 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxkv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	// DefaultHealthCheckInterval default interval of checking the backends of FailoverClient
+	DefaultHealthCheckInterval = 5 * time.Second
+	// healthProbeKey key read by the default health checker
+	healthProbeKey = "gxkv-health-probe"
+)
+
+// ErrNoHealthyBackend is returned when none of the backends of FailoverClient is healthy
+var ErrNoHealthyBackend = perrors.New("no healthy kv backend")
+
+// HealthChecker checks whether a backend is healthy
+type HealthChecker interface {
+	// Check returns nil if @client is healthy
+	Check(ctx context.Context, client Client) error
+}
+
+// HealthCheckFunc is a function implementing HealthChecker
+type HealthCheckFunc func(ctx context.Context, client Client) error
+
+// Check calls the function
+func (f HealthCheckFunc) Check(ctx context.Context, client Client) error {
+	return f(ctx, client)
+}
+
+// getHealthChecker considers a backend healthy if it can get a key, no matter
+// whether the key exists
+func getHealthChecker(_ context.Context, client Client) error {
+	if _, err := client.Get(healthProbeKey); err != nil && perrors.Cause(err) != ErrKeyNotFound {
+		return err
+	}
+	return nil
+}
+
+// FailoverOption will define a function of handling the options of FailoverClient
+type FailoverOption func(*FailoverClient)
+
+// WithHealthChecker sets the health checker of the backends, the default one
+// gets a probe key
+func WithHealthChecker(checker HealthChecker) FailoverOption {
+	return func(c *FailoverClient) {
+		c.checker = checker
+	}
+}
+
+// WithHealthCheckInterval sets the interval of checking the backends
+func WithHealthCheckInterval(interval time.Duration) FailoverOption {
+	return func(c *FailoverClient) {
+		c.interval = interval
+	}
+}
+
+// FailoverClient routes the reads and writes to the first healthy backend in
+// the order of the backends. It checks the backends every interval and when
+// the active one fails, and after switching the backend it registers the
+// ephemeral keys again and replays the watches on the new backend, whose
+// events bring the watchers to the keys of the new backend.
+type FailoverClient struct {
+	clients  []Client
+	checker  HealthChecker
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	checkLock  sync.Mutex // serializes the health checks
+	lock       sync.Mutex
+	active     int               // index of the active backend, -1 if none is healthy
+	switched   chan struct{}     // closed and replaced when the active backend switches
+	ephemerals map[string]string // ephemeral keys registered on the active backend
+}
+
+// NewFailoverClient creates a client of @clients in priority order, it fails
+// if none of them is healthy
+func NewFailoverClient(clients []Client, opts ...FailoverOption) (*FailoverClient, error) {
+	if len(clients) == 0 {
+		return nil, ErrNoHealthyBackend
+	}
+	c := &FailoverClient{
+		clients:    clients,
+		checker:    HealthCheckFunc(getHealthChecker),
+		interval:   DefaultHealthCheckInterval,
+		active:     -1,
+		switched:   make(chan struct{}),
+		ephemerals: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	if c.check() < 0 {
+		c.cancel()
+		return nil, ErrNoHealthyBackend
+	}
+	c.wg.Add(1)
+	go c.checkLoop()
+	return c, nil
+}
+
+// Active returns the index of the active backend, it is -1 if none is healthy
+func (c *FailoverClient) Active() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.active
+}
+
+func (c *FailoverClient) checkLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.check()
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// check checks the backends in order and activates the first healthy one, and
+// returns its index
+func (c *FailoverClient) check() int {
+	c.checkLock.Lock()
+	defer c.checkLock.Unlock()
+
+	healthy := -1
+	for i, client := range c.clients {
+		ctx, cancel := context.WithTimeout(c.ctx, c.interval)
+		err := c.checker.Check(ctx, client)
+		cancel()
+		if err == nil {
+			healthy = i
+			break
+		}
+	}
+
+	c.lock.Lock()
+	if healthy == c.active {
+		c.lock.Unlock()
+		return healthy
+	}
+	c.active = healthy
+	close(c.switched)
+	c.switched = make(chan struct{})
+	ephemerals := make(map[string]string, len(c.ephemerals))
+	for k, v := range c.ephemerals {
+		ephemerals[k] = v
+	}
+	c.lock.Unlock()
+
+	if healthy >= 0 {
+		for k, v := range ephemerals {
+			_ = c.clients[healthy].RegisterEphemeral(k, v)
+		}
+	}
+	return healthy
+}
+
+// current returns the active backend and the chan closed when it switches
+func (c *FailoverClient) current() (Client, <-chan struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.active < 0 {
+		return nil, c.switched
+	}
+	return c.clients[c.active], c.switched
+}
+
+// do calls @fn with the active backend, and checks the backends and calls @fn
+// again with the new one if the backend fails and switches
+func (c *FailoverClient) do(fn func(Client) error) error {
+	client, _ := c.current()
+	if client == nil {
+		if c.check() < 0 {
+			return ErrNoHealthyBackend
+		}
+		client, _ = c.current()
+	}
+
+	err := fn(client)
+	if err == nil || perrors.Cause(err) == ErrKeyNotFound {
+		return err
+	}
+	if c.check() < 0 {
+		return err
+	}
+	if next, _ := c.current(); next != client {
+		return fn(next)
+	}
+	return err
+}
+
+// Get gets @key from the active backend
+func (c *FailoverClient) Get(key string) (value string, err error) {
+	err = c.do(func(client Client) error {
+		value, err = client.Get(key)
+		return err
+	})
+	return value, err
+}
+
+// Put puts @key to the active backend
+func (c *FailoverClient) Put(key, value string) error {
+	return c.do(func(client Client) error {
+		return client.Put(key, value)
+	})
+}
+
+// Delete deletes @key from the active backend
+func (c *FailoverClient) Delete(key string) error {
+	err := c.do(func(client Client) error {
+		return client.Delete(key)
+	})
+	if err == nil {
+		c.lock.Lock()
+		delete(c.ephemerals, key)
+		c.lock.Unlock()
+	}
+	return err
+}
+
+// List lists the key values with @prefix of the active backend
+func (c *FailoverClient) List(prefix string) (kvs []KeyValue, err error) {
+	err = c.do(func(client Client) error {
+		kvs, err = client.List(prefix)
+		return err
+	})
+	return kvs, err
+}
+
+// RegisterEphemeral registers @key to the active backend, and to the new one
+// after switching
+func (c *FailoverClient) RegisterEphemeral(key, value string) error {
+	err := c.do(func(client Client) error {
+		return client.RegisterEphemeral(key, value)
+	})
+	if err == nil {
+		c.lock.Lock()
+		c.ephemerals[key] = value
+		c.lock.Unlock()
+	}
+	return err
+}
+
+// WatchPrefix watches @prefix of the active backend, and watches the new one
+// after switching, the events of the keys which differ between the backends
+// are sent before the new events
+func (c *FailoverClient) WatchPrefix(ctx context.Context, prefix string) (<-chan Event, error) {
+	client, _ := c.current()
+	if client == nil {
+		return nil, ErrNoHealthyBackend
+	}
+	kvs, err := client.List(prefix)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "watch prefix %s", prefix)
+	}
+	known, _ := diffKeyValues(nil, kvs)
+
+	out := make(chan Event)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(out)
+		for {
+			client, switched := c.current()
+			if client != nil && !c.watch(ctx, client, switched, prefix, known, out) {
+				return
+			}
+			if !c.pause(ctx, switched) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// watch sends the events of @prefix of @client until the backend switches or
+// the watch breaks, it returns false if @ctx is done or the client is closed
+func (c *FailoverClient) watch(ctx context.Context, client Client, switched <-chan struct{},
+	prefix string, known map[string]string, out chan<- Event) bool {
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := client.WatchPrefix(watchCtx, prefix)
+	var kvs []KeyValue
+	if err == nil {
+		// list after watching so that no change is missed
+		kvs, err = client.List(prefix)
+	}
+	if err != nil {
+		return true
+	}
+
+	_, replay := diffKeyValues(known, kvs)
+	for _, event := range replay {
+		if !c.send(ctx, out, known, event) {
+			return false
+		}
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return ctx.Err() == nil && c.ctx.Err() == nil
+			}
+			if !c.send(ctx, out, known, event) {
+				return false
+			}
+		case <-switched:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-c.ctx.Done():
+			return false
+		}
+	}
+}
+
+// send sends @event to @out unless it is known, it returns false if @ctx is
+// done or the client is closed
+func (c *FailoverClient) send(ctx context.Context, out chan<- Event, known map[string]string, event Event) bool {
+	value, ok := known[event.Key]
+	if event.Type == EventPut {
+		if ok && value == event.Value {
+			return true
+		}
+		known[event.Key] = event.Value
+	} else {
+		if !ok {
+			return true
+		}
+		delete(known, event.Key)
+	}
+
+	select {
+	case out <- event:
+		return true
+	case <-ctx.Done():
+	case <-c.ctx.Done():
+	}
+	return false
+}
+
+// pause waits for the switch of the backend or the interval, it returns false
+// if @ctx is done or the client is closed
+func (c *FailoverClient) pause(ctx context.Context, switched <-chan struct{}) bool {
+	timer := time.NewTimer(c.interval)
+	defer timer.Stop()
+	select {
+	case <-switched:
+		return true
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+// Close stops checking and closes the backends
+func (c *FailoverClient) Close() {
+	c.once.Do(func() {
+		c.cancel()
+		c.wg.Wait()
+		for _, client := range c.clients {
+			client.Close()
+		}
+	})
+}
+
+// diffKeyValues returns the map of @kvs and the events from @known to @kvs
+func diffKeyValues(known map[string]string, kvs []KeyValue) (map[string]string, []Event) {
+	latest := make(map[string]string, len(kvs))
+	events := make([]Event, 0)
+	for _, kv := range kvs {
+		latest[kv.Key] = kv.Value
+		if value, ok := known[kv.Key]; !ok || value != kv.Value {
+			events = append(events, Event{Type: EventPut, Key: kv.Key, Value: kv.Value, PrevValue: value})
+		}
+	}
+	for key, value := range known {
+		if _, ok := latest[key]; !ok {
+			events = append(events, Event{Type: EventDelete, Key: key, PrevValue: value})
+		}
+	}
+	return latest, events
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxkv defines the client of the key value stores, eg: etcd and zookeeper,
+// so that the registries and config centers are written once against it.
+package gxkv
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// ErrKeyNotFound is returned when getting a key which does not exist
+var ErrKeyNotFound = perrors.New("k/v pair not found")
+
+// KeyValue is a key value pair
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// EventType is the type of a watch event
+type EventType int
+
+const (
+	// EventPut means the key is created or updated
+	EventPut EventType = iota
+	// EventDelete means the key is deleted or expired
+	EventDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventPut:
+		return "Put"
+	case EventDelete:
+		return "Delete"
+	}
+	return "Unknown"
+}
+
+// Event is a change of a key emitted by all the backends
+type Event struct {
+	Type EventType
+	Key  string
+	// Value is the new value, it is empty for EventDelete
+	Value string
+	// PrevValue is the value before the change, it is empty if the key did not
+	// exist or the backend does not know it
+	PrevValue string
+	// Revision is the revision of the store after the change, eg: the mod revision
+	// of etcd and the mzxid of zookeeper, it is 0 if the backend has no revision
+	// or does not know it, eg: the deletions of zookeeper
+	Revision int64
+}
+
+// Client is the client of a key value store
+type Client interface {
+	// Get gets the value of @key, ErrKeyNotFound is returned if it does not exist
+	Get(key string) (string, error)
+	// Put puts @key whether it exists or not
+	Put(key, value string) error
+	// Delete deletes @key
+	Delete(key string) error
+	// List lists the key values with @prefix in key order, it is empty if there is none
+	List(prefix string) ([]KeyValue, error)
+	// WatchPrefix sends the events of the keys with @prefix to the returned chan, which
+	// is closed when @ctx is done or the client is closed
+	WatchPrefix(ctx context.Context, prefix string) (<-chan Event, error)
+	// RegisterEphemeral puts @key which is deleted when the client is closed or lost
+	RegisterEphemeral(key, value string) error
+	// Close closes the client
+	Close()
+}
+
+// EphemeralLister is implemented by the clients which know the keys deleted with
+// their owners or expiring, eg: the keys with leases of etcd, so that these keys
+// are not copied as persistent ones by ReplicatedClient
+type EphemeralLister interface {
+	// ListEphemeral lists the ephemeral or expiring keys with @prefix in key order
+	ListEphemeral(prefix string) ([]string, error)
+}
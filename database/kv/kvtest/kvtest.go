@@ -0,0 +1,272 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kvtest provides the conformance tests of the gxkv clients, which a
+// backend runs against itself to stay compatible with the others, eg:
+//
+//	func TestConformance(t *testing.T) {
+//		store := NewStore()
+//		kvtest.Run(t, func() gxkv.Client { return store.NewClient() })
+//	}
+package kvtest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// DefaultTimeout default timeout of waiting for an event or a change
+const DefaultTimeout = 5 * time.Second
+
+// CASClient is a client supporting compare and swap like gxetcd.Client, the
+// concurrent CAS test is skipped if the client does not implement it
+type CASClient interface {
+	gxkv.Client
+	// CAS sets the value of @key to @newValue only if its value is @oldValue, it
+	// returns false if the value is not @oldValue or the key does not exist
+	CAS(key, oldValue, newValue string) (bool, error)
+}
+
+type options struct {
+	timeout time.Duration
+	prefix  string
+	skips   map[string]struct{}
+}
+
+// Option will define a function of handling the options of Run
+type Option func(*options)
+
+// WithTimeout sets the timeout of waiting for an event or a change, the
+// backends watching by polling may need a longer one
+func WithTimeout(timeout time.Duration) Option {
+	return func(opt *options) {
+		opt.timeout = timeout
+	}
+}
+
+// WithPrefix sets the prefix of the keys written by the tests
+func WithPrefix(prefix string) Option {
+	return func(opt *options) {
+		opt.prefix = prefix
+	}
+}
+
+// WithSkip skips the tests @names, eg: "List" for the backends listing the
+// children of a node only
+func WithSkip(names ...string) Option {
+	return func(opt *options) {
+		for _, name := range names {
+			opt.skips[name] = struct{}{}
+		}
+	}
+}
+
+// Run runs the conformance tests, @newClient creates a client of the same store
+// every call. The tests write the keys under a prefix unique to the run.
+func Run(t *testing.T, newClient func() gxkv.Client, opts ...Option) {
+	o := &options{timeout: DefaultTimeout, prefix: "/kvtest/", skips: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(o)
+	}
+	prefix := o.prefix + strconv.FormatInt(time.Now().UnixNano(), 36) + "/"
+
+	tests := []struct {
+		name string
+		fn   func(*testing.T, func() gxkv.Client, string, *options)
+	}{
+		{"GetPutDelete", testGetPutDelete},
+		{"List", testList},
+		{"WatchOrder", testWatchOrder},
+		{"Ephemeral", testEphemeral},
+		{"ConcurrentCAS", testConcurrentCAS},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := o.skips[test.name]; ok {
+				t.Skip("skipped by the backend")
+			}
+			test.fn(t, newClient, prefix+test.name+"/", o)
+		})
+	}
+}
+
+func testGetPutDelete(t *testing.T, newClient func() gxkv.Client, prefix string, _ *options) {
+	c := newClient()
+	defer c.Close()
+
+	key := prefix + "key"
+	_, err := c.Get(key)
+	assert.Equal(t, gxkv.ErrKeyNotFound, perrors.Cause(err), "get absent key")
+	require.NoError(t, c.Put(key, "1"))
+	require.NoError(t, c.Put(key, "2"))
+	v, err := c.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, "2", v)
+
+	require.NoError(t, c.Delete(key))
+	_, err = c.Get(key)
+	assert.Equal(t, gxkv.ErrKeyNotFound, perrors.Cause(err), "get deleted key")
+	assert.NoError(t, c.Delete(key), "delete absent key")
+}
+
+func testList(t *testing.T, newClient func() gxkv.Client, prefix string, _ *options) {
+	c := newClient()
+	defer c.Close()
+
+	kvs, err := c.List(prefix + "dir/")
+	require.NoError(t, err)
+	assert.Empty(t, kvs)
+
+	for _, key := range []string{"dir/c", "dir/a", "dir/b/1", "dir0", "other"} {
+		require.NoError(t, c.Put(prefix+key, key))
+	}
+	kvs, err = c.List(prefix + "dir/")
+	require.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{
+		{Key: prefix + "dir/a", Value: "dir/a"},
+		{Key: prefix + "dir/b/1", Value: "dir/b/1"},
+		{Key: prefix + "dir/c", Value: "dir/c"},
+	}, kvs, "list in key order without the siblings of the prefix")
+}
+
+// testWatchOrder checks that the events of a key arrive in order, the backends
+// watching by polling or notified by key only may skip the intermediate values
+func testWatchOrder(t *testing.T, newClient func() gxkv.Client, prefix string, o *options) {
+	c := newClient()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, prefix)
+	require.NoError(t, err)
+
+	const n = 10
+	key := prefix + "key"
+	go func() {
+		for i := 1; i <= n; i++ {
+			_ = c.Put(key, strconv.Itoa(i))
+		}
+	}()
+
+	last := 0
+	for last < n {
+		e := receive(t, events, o.timeout, "value after %d", last)
+		require.Equal(t, key, e.Key)
+		require.Equal(t, gxkv.EventPut, e.Type)
+		i, err := strconv.Atoi(e.Value)
+		require.NoError(t, err)
+		require.True(t, i > last, "value %d after %d", i, last)
+		if e.PrevValue != "" {
+			assert.Equal(t, strconv.Itoa(last), e.PrevValue, "previous value")
+		}
+		last = i
+	}
+
+	require.NoError(t, c.Delete(key))
+	e := receive(t, events, o.timeout, "delete")
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: e.PrevValue, Revision: e.Revision}, e)
+}
+
+// receive receives an event from @events within @timeout, the test fails with
+// @format and @args if there is none
+func receive(t *testing.T, events <-chan gxkv.Event, timeout time.Duration, format string, args ...interface{}) gxkv.Event {
+	select {
+	case e, ok := <-events:
+		require.True(t, ok, "watch chan closed")
+		return e
+	case <-time.After(timeout):
+		t.Fatalf("no event: "+format, args...)
+	}
+	return gxkv.Event{}
+}
+
+func testEphemeral(t *testing.T, newClient func() gxkv.Client, prefix string, o *options) {
+	owner, observer := newClient(), newClient()
+	defer observer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := observer.WatchPrefix(ctx, prefix)
+	require.NoError(t, err)
+
+	key := prefix + "ephemeral"
+	require.NoError(t, owner.RegisterEphemeral(key, "1"))
+	v, err := observer.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, "1", v)
+	e := receive(t, events, o.timeout, "register ephemeral key")
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: key, Value: "1", Revision: e.Revision}, e)
+
+	owner.Close()
+	e = receive(t, events, o.timeout, "the ephemeral key is not deleted after its client is closed")
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: e.PrevValue, Revision: e.Revision}, e)
+	_, err = observer.Get(key)
+	assert.Equal(t, gxkv.ErrKeyNotFound, perrors.Cause(err))
+}
+
+func testConcurrentCAS(t *testing.T, newClient func() gxkv.Client, prefix string, _ *options) {
+	c := newClient()
+	defer c.Close()
+	cas, ok := c.(CASClient)
+	if !ok {
+		t.Skip("the client does not support compare and swap")
+	}
+
+	const workers, increments = 4, 10
+	key := prefix + "counter"
+	require.NoError(t, cas.Put(key, "0"))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; {
+				old, err := cas.Get(key)
+				if !assert.NoError(t, err) {
+					return
+				}
+				n, _ := strconv.Atoi(old)
+				swapped, err := cas.CAS(key, old, strconv.Itoa(n+1))
+				if !assert.NoError(t, err) {
+					return
+				}
+				if swapped {
+					i++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	v, err := cas.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(workers*increments), v)
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmemory
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+func TestCachedClient(t *testing.T) {
+	store := NewStore()
+	writer := store.NewClient()
+	defer writer.Close()
+	c, err := gxkv.NewCachedClient(store.NewClient(), "/config/")
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.NoError(t, writer.Put("/config/a", "1"))
+	assert.Eventually(t, func() bool {
+		v, err := c.Get("/config/a")
+		return err == nil && v == "1"
+	}, time.Second, time.Millisecond)
+	stats := c.Stats()
+	for i := 0; i < 10; i++ {
+		v, err := c.Get("/config/a")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", v)
+	}
+	assert.Equal(t, stats.Hits+10, c.Stats().Hits)
+
+	// the absence is cached too
+	_, err = c.Get("/config/b")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	_, err = c.Get("/config/b")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	assert.Equal(t, stats.Hits+11, c.Stats().Hits)
+
+	// the changes of the other clients are applied by the watch
+	assert.NoError(t, writer.Put("/config/b", "2"))
+	assert.NoError(t, writer.Delete("/config/a"))
+	assert.Eventually(t, func() bool {
+		v, err := c.Get("/config/b")
+		_, err2 := c.Get("/config/a")
+		return err == nil && v == "2" && err2 == gxkv.ErrKeyNotFound
+	}, time.Second, time.Millisecond)
+
+	// the writes of the client itself are read at once
+	assert.NoError(t, c.Put("/config/b", "3"))
+	v, err := c.Get("/config/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", v)
+
+	// the keys out of the prefix are not cached
+	assert.NoError(t, c.Put("/other", "0"))
+	stats = c.Stats()
+	v, err = c.Get("/other")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", v)
+	assert.Equal(t, stats, c.Stats())
+	assert.True(t, c.Stats().HitRate() > 0)
+}
+
+func TestCachedClientMaxStaleness(t *testing.T) {
+	c, err := gxkv.NewCachedClient(NewClient(), "/", gxkv.WithMaxStaleness(10*time.Millisecond))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Get("/a")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	_, err = c.Get("/a")
+	assert.Equal(t, gxkv.CacheStats{Hits: 1, Misses: 1}, c.Stats())
+	time.Sleep(20 * time.Millisecond)
+	_, err = c.Get("/a")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	assert.Equal(t, gxkv.CacheStats{Hits: 1, Misses: 2}, c.Stats())
+}
@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// ErrClientClosed is returned when using a closed client
+var ErrClientClosed = perrors.New("memory kv client closed")
+
+var (
+	_ gxkv.Client          = (*Client)(nil)
+	_ gxkv.EphemeralLister = (*Client)(nil)
+)
+
+var (
+	storesLock sync.Mutex
+	stores     = make(map[string]*Store) // stores shared by the clients created by urls
+)
+
+func init() {
+	gxkv.Register("memory", newKVClient)
+}
+
+// newKVClient creates a client by @u, eg: "memory://name/basepath". The clients
+// of the same name share a store, and a client without name has its own store.
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	var store *Store
+	if len(u.Addrs) == 0 {
+		store = NewStore()
+	} else {
+		storesLock.Lock()
+		if store = stores[u.Addrs[0]]; store == nil {
+			store = NewStore()
+			stores[u.Addrs[0]] = store
+		}
+		storesLock.Unlock()
+	}
+	return gxkv.WithPrefix(store.NewClient(), u.BasePath), nil
+}
+
+// Client is a client of a Store, its ephemeral keys are deleted when it is closed
+type Client struct {
+	store  *Store
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+// NewClient creates a client of a new store
+func NewClient() *Client {
+	return NewStore().NewClient()
+}
+
+// NewClient creates a client of the store
+func (s *Store) NewClient() *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{store: s, ctx: ctx, cancel: cancel}
+}
+
+// Store returns the store of the client
+func (c *Client) Store() *Store {
+	return c.store
+}
+
+// Close deletes the ephemeral keys of the client and closes its watch chans
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.cancel()
+		c.store.deleteOwned(c)
+	})
+}
+
+func (c *Client) closed() bool {
+	return c.ctx.Err() != nil
+}
+
+// Get gets the value of @key
+func (c *Client) Get(key string) (string, error) {
+	if c.closed() {
+		return "", ErrClientClosed
+	}
+	value, ok := c.store.get(key)
+	if !ok {
+		return "", gxkv.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+// Put sets @key to @value without ttl
+func (c *Client) Put(key, value string) error {
+	return c.PutWithTTL(key, value, 0)
+}
+
+// PutWithTTL sets @key to @value which expires after @ttl, it never expires if
+// @ttl is not positive
+func (c *Client) PutWithTTL(key, value string, ttl time.Duration) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	c.store.put(key, value, ttl, nil)
+	return nil
+}
+
+// CAS sets the value of @key to @newValue without ttl only if its value is
+// @oldValue, it returns false if the value is not @oldValue or the key does
+// not exist
+func (c *Client) CAS(key, oldValue, newValue string) (bool, error) {
+	if c.closed() {
+		return false, ErrClientClosed
+	}
+	return c.store.cas(key, oldValue, newValue), nil
+}
+
+// Delete deletes @key
+func (c *Client) Delete(key string) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	c.store.delete(key)
+	return nil
+}
+
+// List lists the key values with @prefix in key order
+func (c *Client) List(prefix string) ([]gxkv.KeyValue, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+	return c.store.list(prefix), nil
+}
+
+// ListEphemeral lists the ephemeral keys and the keys with ttls with @prefix in key order
+func (c *Client) ListEphemeral(prefix string) ([]string, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+	return c.store.listEphemeral(prefix), nil
+}
+
+// WatchPrefix sends the changes of the keys with @prefix to the returned chan
+// until @ctx is done or the client is closed. The changes are queued so that a
+// slow receiver does not block the writers.
+func (c *Client) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+	return c.store.watch(ctx, c.ctx, prefix), nil
+}
+
+// RegisterEphemeral sets @key to @value, which is deleted when the client is closed
+func (c *Client) RegisterEphemeral(key, value string) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	c.store.put(key, value, 0, c)
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+func TestClient(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := c.WatchPrefix(ctx, "/services/")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Put("/services/b", "2"))
+	assert.NoError(t, c.Put("/services/a", "1"))
+	assert.NoError(t, c.Put("/other", "0"))
+	assert.NoError(t, c.Delete("/services/b"))
+	// the events are queued without a receiver
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/b", Value: "2", Revision: 1}, <-events)
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/a", Value: "1", Revision: 2}, <-events)
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "/services/b", PrevValue: "2", Revision: 4}, <-events)
+
+	v, err := c.Get("/services/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", v)
+	_, err = c.Get("/services/b")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	kvs, err := c.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/other", Value: "0"}, {Key: "/services/a", Value: "1"}}, kvs)
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestClientTTL(t *testing.T) {
+	c := NewClient()
+	defer c.Close()
+
+	events, err := c.WatchPrefix(context.Background(), "/ttl")
+	assert.NoError(t, err)
+	assert.NoError(t, c.PutWithTTL("/ttl", "1", 20*time.Millisecond))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/ttl", Value: "1", Revision: 1}, <-events)
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "/ttl", PrevValue: "1", Revision: 2}, <-events)
+	_, err = c.Get("/ttl")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+
+	// putting again cancels the ttl
+	assert.NoError(t, c.PutWithTTL("/ttl", "2", 20*time.Millisecond))
+	assert.NoError(t, c.Put("/ttl", "3"))
+	time.Sleep(40 * time.Millisecond)
+	v, err := c.Get("/ttl")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func TestClientEphemeral(t *testing.T) {
+	store := NewStore()
+	c1, c2 := store.NewClient(), store.NewClient()
+	defer c2.Close()
+
+	events, err := c2.WatchPrefix(context.Background(), "/services/")
+	assert.NoError(t, err)
+	assert.NoError(t, c1.RegisterEphemeral("/services/a", "1"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/a", Value: "1", Revision: 1}, <-events)
+
+	c1.Close()
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "/services/a", PrevValue: "1", Revision: 2}, <-events)
+	_, err = c2.Get("/services/a")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+	_, err = c1.Get("/services/a")
+	assert.Equal(t, ErrClientClosed, err)
+
+	c2.Close()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestNewClientByURL(t *testing.T) {
+	c1, err := gxkv.NewClient("memory://url-test/base")
+	assert.NoError(t, err)
+	defer c1.Close()
+	c2, err := gxkv.NewClient("memory://url-test")
+	assert.NoError(t, err)
+	defer c2.Close()
+	kvs, err := c2.List("/")
+	assert.NoError(t, err)
+	assert.Empty(t, kvs)
+
+	// the clients of the same name share a store, and the base path prefixes the keys
+	assert.NoError(t, c1.Put("/a", "1"))
+	v, err := c2.Get("/base/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", v)
+	kvs, err = c1.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/a", Value: "1"}}, kvs)
+
+	events, err := c1.WatchPrefix(context.Background(), "/")
+	assert.NoError(t, err)
+	assert.NoError(t, c2.Put("/base/b", "2"))
+	e := <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/b", Value: "2", Revision: e.Revision}, e)
+	// the named store outlives the clients
+	assert.NoError(t, c1.Delete("/a"))
+	assert.NoError(t, c1.Delete("/b"))
+}
@@ -0,0 +1,103 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmemory
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+func TestFailoverClient(t *testing.T) {
+	primary, secondary := NewClient(), NewClient()
+	var primaryDown int32
+	checker := gxkv.HealthCheckFunc(func(ctx context.Context, client gxkv.Client) error {
+		if client == primary && atomic.LoadInt32(&primaryDown) == 1 {
+			return perrors.New("down")
+		}
+		return nil
+	})
+	c, err := gxkv.NewFailoverClient([]gxkv.Client{primary, secondary},
+		gxkv.WithHealthChecker(checker), gxkv.WithHealthCheckInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, 0, c.Active())
+
+	assert.NoError(t, secondary.Put("/services/b", "2"))
+	assert.NoError(t, c.Put("/services/a", "1"))
+	assert.NoError(t, c.RegisterEphemeral("/services/e", "0"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "/services/")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Put("/services/a", "3"))
+	e := <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/a", Value: "3", PrevValue: "1", Revision: e.Revision}, e)
+
+	// the ephemeral key is registered again, and the watch replays the differences
+	atomic.StoreInt32(&primaryDown, 1)
+	received := make(map[string]gxkv.Event)
+	for len(received) < 2 {
+		e = <-events
+		e.Revision = 0
+		received[e.Key] = e
+	}
+	assert.Equal(t, map[string]gxkv.Event{
+		"/services/a": {Type: gxkv.EventDelete, Key: "/services/a", PrevValue: "3"},
+		"/services/b": {Type: gxkv.EventPut, Key: "/services/b", Value: "2"},
+	}, received)
+	assert.Equal(t, 1, c.Active())
+	v, err := secondary.Get("/services/e")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", v)
+
+	assert.NoError(t, c.Put("/services/c", "4"))
+	e = <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/c", Value: "4", Revision: e.Revision}, e)
+	_, err = primary.Get("/services/c")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+
+	// the primary is preferred after it recovers
+	atomic.StoreInt32(&primaryDown, 0)
+	assert.Eventually(t, func() bool {
+		return c.Active() == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestFailoverClientNoHealthyBackend(t *testing.T) {
+	checker := gxkv.HealthCheckFunc(func(ctx context.Context, client gxkv.Client) error {
+		return perrors.New("down")
+	})
+	_, err := gxkv.NewFailoverClient([]gxkv.Client{NewClient()}, gxkv.WithHealthChecker(checker))
+	assert.Equal(t, gxkv.ErrNoHealthyBackend, err)
+
+	// the default checker gets a probe key
+	c, err := gxkv.NewFailoverClient([]gxkv.Client{NewClient()})
+	assert.NoError(t, err)
+	c.Close()
+}
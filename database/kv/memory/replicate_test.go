@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmemory
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+func TestReplicatedClient(t *testing.T) {
+	primary, secondary := NewStore(), NewStore()
+	c := gxkv.NewReplicatedClient(primary.NewClient(), secondary.NewClient())
+	reader := secondary.NewClient()
+	defer reader.Close()
+
+	assert.NoError(t, c.Put("/a", "1"))
+	assert.NoError(t, c.RegisterEphemeral("/b", "2"))
+	assert.NoError(t, c.Put("/c", "3"))
+	assert.NoError(t, c.Delete("/c"))
+	assert.Eventually(t, func() bool {
+		return c.Stats().Mirrored == 4
+	}, time.Second, time.Millisecond)
+	kvs, err := reader.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/a", Value: "1"}, {Key: "/b", Value: "2"}}, kvs)
+
+	// the reads go to the primary
+	v, err := c.Get("/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", v)
+
+	// the ephemeral key of the secondary is deleted on close
+	c.Close()
+	kvs, err = reader.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/a", Value: "1"}}, kvs)
+}
+
+func TestReplicatedClientReconcile(t *testing.T) {
+	primary, secondary := NewClient(), NewClient()
+	assert.NoError(t, primary.Put("/a", "1"))
+	assert.NoError(t, primary.Put("/b", "2"))
+	assert.NoError(t, secondary.Put("/b", "0"))
+	assert.NoError(t, secondary.Put("/c", "3"))
+	assert.NoError(t, secondary.Put("other", "4"))
+
+	c := gxkv.NewReplicatedClient(primary, secondary, gxkv.WithMirrorQueueSize(0))
+	defer c.Close()
+	// the writes are dropped without a queue
+	assert.NoError(t, c.Put("/d", "4"))
+	assert.Equal(t, int64(1), c.Stats().Dropped)
+
+	repaired, err := c.Reconcile("/")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, repaired)
+	kvs, err := secondary.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/a", Value: "1"}, {Key: "/b", Value: "2"}, {Key: "/d", Value: "4"}}, kvs)
+	assert.Equal(t, int64(4), c.Stats().Divergences)
+
+	repaired, err = c.Reconcile("/")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, repaired)
+}
+
+func TestReplicatedClientReconcileInterval(t *testing.T) {
+	primary, secondary := NewClient(), NewClient()
+	assert.NoError(t, primary.Put("/a", "1"))
+	c := gxkv.NewReplicatedClient(primary, secondary, gxkv.WithReconcileInterval("/", 10*time.Millisecond))
+	defer c.Close()
+	assert.Eventually(t, func() bool {
+		v, err := secondary.Get("/a")
+		return err == nil && v == "1"
+	}, time.Second, time.Millisecond)
+}
+
+func TestReplicatedClientReconcileEphemeral(t *testing.T) {
+	primary, secondary := NewStore(), NewStore()
+	c := gxkv.NewReplicatedClient(primary.NewClient(), secondary.NewClient(), gxkv.WithMirrorQueueSize(0))
+	other := primary.NewClient()
+	defer other.Close()
+	reader := secondary.NewClient()
+	defer reader.Close()
+
+	assert.NoError(t, c.RegisterEphemeral("/a", "1"))
+	assert.NoError(t, other.RegisterEphemeral("/b", "2"))
+	assert.Equal(t, int64(1), c.Stats().Dropped)
+
+	// the ephemeral key of the client is repaired as an ephemeral one and the
+	// one of the other client is skipped
+	repaired, err := c.Reconcile("/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repaired)
+	kvs, err := reader.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/a", Value: "1"}}, kvs)
+
+	// the repaired key is deleted with its owner
+	c.Close()
+	kvs, err = reader.List("/")
+	assert.NoError(t, err)
+	assert.Empty(t, kvs)
+}
@@ -0,0 +1,250 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxmemory provides a process local key value store implementing the
+// gxkv client, which is for the tests, local development and single node
+// deployments.
+package gxmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+type entry struct {
+	value string
+	timer *time.Timer // expires the entry if it has a ttl
+	owner *Client     // client which registers the entry as an ephemeral one
+}
+
+// Store is a key value store in memory, its clients share the keys
+type Store struct {
+	lock     sync.Mutex
+	revision int64 // increased by every change
+	entries  map[string]*entry
+	watchers map[*watcher]struct{}
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		entries:  make(map[string]*entry),
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+func (s *Store) get(key string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return "", false
+	}
+	return e.value, true
+}
+
+// put sets @key to @value, it expires after @ttl if @ttl is positive
+func (s *Store) put(key, value string, ttl time.Duration, owner *Client) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.putLocked(key, value, ttl, owner)
+}
+
+// putLocked puts @key, the caller holds the lock
+func (s *Store) putLocked(key, value string, ttl time.Duration, owner *Client) {
+	e := &entry{value: value, owner: owner}
+	if ttl > 0 {
+		e.timer = time.AfterFunc(ttl, func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			// the entry may have been replaced before the timer fires
+			if s.entries[key] == e {
+				s.deleteLocked(key)
+			}
+		})
+	}
+	prev, _ := s.remove(key)
+	s.entries[key] = e
+	s.notify(gxkv.Event{Type: gxkv.EventPut, Key: key, Value: value, PrevValue: prev})
+}
+
+// cas puts @key if it exists and its value is @oldValue
+func (s *Store) cas(key, oldValue, newValue string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if e, ok := s.entries[key]; !ok || e.value != oldValue {
+		return false
+	}
+	s.putLocked(key, newValue, 0, nil)
+	return true
+}
+
+func (s *Store) delete(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.deleteLocked(key)
+}
+
+// deleteLocked deletes @key and notifies the watchers if it exists, the caller holds the lock
+func (s *Store) deleteLocked(key string) {
+	if prev, ok := s.remove(key); ok {
+		s.notify(gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: prev})
+	}
+}
+
+// remove removes @key without notifying, it returns the value of @key and
+// whether @key exists
+func (s *Store) remove(key string) (string, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return "", false
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	delete(s.entries, key)
+	return e.value, true
+}
+
+// deleteOwned deletes the ephemeral keys of @owner
+func (s *Store) deleteOwned(owner *Client) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, e := range s.entries {
+		if e.owner == owner {
+			s.deleteLocked(key)
+		}
+	}
+}
+
+func (s *Store) list(prefix string) []gxkv.KeyValue {
+	s.lock.Lock()
+	kvs := make([]gxkv.KeyValue, 0)
+	for key, e := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			kvs = append(kvs, gxkv.KeyValue{Key: key, Value: e.value})
+		}
+	}
+	s.lock.Unlock()
+
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs
+}
+
+// listEphemeral lists the keys with @prefix which have owners or ttls in order
+func (s *Store) listEphemeral(prefix string) []string {
+	s.lock.Lock()
+	keys := make([]string, 0)
+	for key, e := range s.entries {
+		if strings.HasPrefix(key, prefix) && (e.owner != nil || e.timer != nil) {
+			keys = append(keys, key)
+		}
+	}
+	s.lock.Unlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+// notify increases the revision and queues @event of it to the watchers of its
+// key, the caller holds the lock
+func (s *Store) notify(event gxkv.Event) {
+	s.revision++
+	event.Revision = s.revision
+	for w := range s.watchers {
+		if strings.HasPrefix(event.Key, w.prefix) {
+			w.queue(event)
+		}
+	}
+}
+
+// watcher queues the events of a prefix without blocking the writers, and
+// sends them to out in order
+type watcher struct {
+	prefix string
+	out    chan gxkv.Event
+
+	lock   sync.Mutex
+	events []gxkv.Event
+	ready  chan struct{}
+}
+
+func (s *Store) watch(ctx, clientCtx context.Context, prefix string) <-chan gxkv.Event {
+	w := &watcher{
+		prefix: prefix,
+		out:    make(chan gxkv.Event),
+		ready:  make(chan struct{}, 1),
+	}
+	s.lock.Lock()
+	s.watchers[w] = struct{}{}
+	s.lock.Unlock()
+
+	go func() {
+		defer func() {
+			s.lock.Lock()
+			delete(s.watchers, w)
+			s.lock.Unlock()
+			close(w.out)
+		}()
+		for {
+			select {
+			case <-w.ready:
+			case <-ctx.Done():
+				return
+			case <-clientCtx.Done():
+				return
+			}
+			for _, event := range w.take() {
+				select {
+				case w.out <- event:
+				case <-ctx.Done():
+					return
+				case <-clientCtx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return w.out
+}
+
+func (w *watcher) queue(event gxkv.Event) {
+	w.lock.Lock()
+	w.events = append(w.events, event)
+	w.lock.Unlock()
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) take() []gxkv.Event {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	events := w.events
+	w.events = nil
+	return events
+}
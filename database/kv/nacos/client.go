@@ -0,0 +1,270 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxnacos provides a client of the nacos config and naming services on
+// its http open api, which implements the gxkv client by the configs.
+package gxnacos
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+var (
+	// ErrNoServer is returned when creating a client without server address
+	ErrNoServer = perrors.New("no nacos server address")
+	// ErrClientClosed is returned when using a closed client
+	ErrClientClosed = perrors.New("nacos client closed")
+)
+
+// Client is a client of the nacos servers, the requests fail over to the next
+// server when the current one is unavailable
+type Client struct {
+	servers    []string
+	current    int32 // index of the server which responds last
+	options    *Options
+	httpClient *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpire time.Time
+
+	lock       sync.Mutex
+	instances  map[string]registration // registered ephemeral instances, keyed by service and address
+	ephemerals map[string]struct{}     // configs published by RegisterEphemeral
+	beating    bool                    // whether the heartbeat goroutine is running
+}
+
+// NewClient creates a client of the nacos servers @addrs, eg: "127.0.0.1:8848"
+// or "http://127.0.0.1:8848". It does not connect until the first request.
+func NewClient(addrs []string, opts ...Option) (*Client, error) {
+	if len(addrs) == 0 {
+		return nil, ErrNoServer
+	}
+
+	options := &Options{
+		Group:           DefaultGroup,
+		Timeout:         DefaultTimeout,
+		PollInterval:    DefaultPollInterval,
+		BeatInterval:    DefaultBeatInterval,
+		LongPollTimeout: defaultLongPollTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	servers := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "://") {
+			addr = "http://" + addr
+		}
+		servers = append(servers, strings.TrimSuffix(addr, "/"))
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		// the requests are bounded by their contexts, the long pollings last longer than Timeout
+		httpClient = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		servers:    servers,
+		options:    options,
+		httpClient: httpClient,
+		ctx:        ctx,
+		cancel:     cancel,
+		instances:  make(map[string]registration),
+		ephemerals: make(map[string]struct{}),
+	}, nil
+}
+
+// Close stops the client, deregisters its ephemeral instances and deletes the
+// configs published by RegisterEphemeral
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.lock.Lock()
+		instances := make([]registration, 0, len(c.instances))
+		for _, r := range c.instances {
+			instances = append(instances, r)
+		}
+		ephemerals := make([]string, 0, len(c.ephemerals))
+		for dataID := range c.ephemerals {
+			ephemerals = append(ephemerals, dataID)
+		}
+		c.lock.Unlock()
+
+		for _, r := range instances {
+			_ = c.DeregisterInstance(r.service, r.instance.IP, r.instance.Port)
+		}
+		for _, dataID := range ephemerals {
+			_ = c.DeleteConfig(dataID)
+		}
+		c.cancel()
+		c.wg.Wait()
+	})
+}
+
+func (c *Client) closed() bool {
+	return c.ctx.Err() != nil
+}
+
+// do sends the request of @method to @path of the servers with @params, and
+// returns the status and body of the response. It fails over to the next server
+// if a server is unavailable.
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, header http.Header) (int, []byte, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	if err := c.authenticate(ctx, params); err != nil {
+		return 0, nil, err
+	}
+
+	var err error
+	start := int(atomic.LoadInt32(&c.current))
+	for i := 0; i < len(c.servers); i++ {
+		index := (start + i) % len(c.servers)
+		var (
+			status int
+			body   []byte
+		)
+		status, body, err = c.send(ctx, c.servers[index]+path, method, params, header)
+		if err == nil && status < http.StatusInternalServerError {
+			atomic.StoreInt32(&c.current, int32(index))
+			return status, body, nil
+		}
+		if err == nil {
+			err = perrors.Errorf("status %d, %s", status, body)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return 0, nil, perrors.WithMessagef(err, "nacos %s %s", method, path)
+}
+
+func (c *Client) send(ctx context.Context, rawURL, method string, params url.Values, header http.Header) (int, []byte, error) {
+	var (
+		req *http.Request
+		err error
+	)
+	switch method {
+	case http.MethodPost, http.MethodPut:
+		req, err = http.NewRequest(method, rawURL, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	default:
+		req, err = http.NewRequest(method, rawURL+"?"+params.Encode(), nil)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// authenticate adds the access token to @params if the client has a username
+func (c *Client) authenticate(ctx context.Context, params url.Values) error {
+	if c.options.Username == "" {
+		return nil
+	}
+
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if c.token == "" || time.Now().After(c.tokenExpire) {
+		login := url.Values{"username": {c.options.Username}, "password": {c.options.Password}}
+		var (
+			err    error
+			status int
+			body   []byte
+		)
+		start := int(atomic.LoadInt32(&c.current))
+		for i := 0; i < len(c.servers); i++ {
+			server := c.servers[(start+i)%len(c.servers)]
+			if status, body, err = c.send(ctx, server+"/nacos/v1/auth/login", http.MethodPost, login, nil); err == nil {
+				break
+			}
+		}
+		if err == nil && status != http.StatusOK {
+			err = perrors.Errorf("status %d, %s", status, body)
+		}
+		var token struct {
+			AccessToken string `json:"accessToken"`
+			TokenTTL    int64  `json:"tokenTtl"`
+		}
+		if err == nil {
+			err = json.Unmarshal(body, &token)
+		}
+		if err != nil {
+			return perrors.WithMessage(err, "nacos login")
+		}
+		c.token = token.AccessToken
+		// refresh the token before it expires
+		c.tokenExpire = time.Now().Add(time.Duration(token.TokenTTL) * time.Second * 9 / 10)
+	}
+	params.Set("accessToken", c.token)
+	return nil
+}
+
+// reqCtx returns the context of a request within the timeout of the client
+func (c *Client) reqCtx() (context.Context, context.CancelFunc) {
+	if c.options.Timeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, c.options.Timeout)
+}
+
+// sleep waits for @d, it returns false if the client is closed or @ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxnacos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// fakeServer serves the part of the nacos open api used by the client
+type fakeServer struct {
+	lock      sync.Mutex
+	configs   map[string]string
+	instances map[string]Instance
+	beats     int
+}
+
+func newFakeServer() *httptest.Server {
+	s := &fakeServer{configs: make(map[string]string), instances: make(map[string]Instance)}
+	return httptest.NewServer(s)
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch r.URL.Path {
+	case configPath:
+		dataID := r.Form.Get("dataId")
+		switch r.Method {
+		case http.MethodGet:
+			if r.Form.Get("search") == "blur" {
+				type item struct {
+					DataID  string `json:"dataId"`
+					Content string `json:"content"`
+				}
+				items := make([]item, 0)
+				for id, content := range s.configs {
+					if strings.HasPrefix(id, strings.TrimSuffix(dataID, "*")) {
+						items = append(items, item{DataID: id, Content: content})
+					}
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"pagesAvailable": 1, "pageItems": items})
+				return
+			}
+			content, ok := s.configs[dataID]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		case http.MethodPost:
+			s.configs[dataID] = r.Form.Get("content")
+			_, _ = w.Write([]byte("true"))
+		case http.MethodDelete:
+			delete(s.configs, dataID)
+			_, _ = w.Write([]byte("true"))
+		}
+	case configListenerPath:
+		words := strings.Split(strings.TrimSuffix(r.Form.Get("Listening-Configs"), lineSeparator), wordSeparator)
+		if contentMD5(s.configs[words[0]]) != words[2] {
+			_, _ = w.Write([]byte(words[0]))
+			return
+		}
+		// a short long polling
+		s.lock.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		s.lock.Lock()
+	case instancePath:
+		key := r.Form.Get("ip") + ":" + r.Form.Get("port")
+		switch r.Method {
+		case http.MethodPost:
+			port, _ := strconv.Atoi(r.Form.Get("port"))
+			weight, _ := strconv.ParseFloat(r.Form.Get("weight"), 64)
+			s.instances[key] = Instance{IP: r.Form.Get("ip"), Port: port, Weight: weight, Healthy: true}
+		case http.MethodDelete:
+			delete(s.instances, key)
+		}
+		_, _ = w.Write([]byte("ok"))
+	case instanceBeatPath:
+		s.beats++
+		code := http.StatusOK
+		if _, ok := s.instances[r.Form.Get("ip")+":"+r.Form.Get("port")]; !ok {
+			code = codeResourceNotFound
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"code": code})
+	case instanceListPath:
+		hosts := make([]Instance, 0, len(s.instances))
+		for _, instance := range s.instances {
+			hosts = append(hosts, instance)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"hosts": hosts})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	ts := newFakeServer()
+	defer ts.Close()
+	// the first server is unavailable
+	c, err := NewClient([]string{"127.0.0.1:1", ts.URL}, WithPollInterval(20*time.Millisecond))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.GetConfig("app.yaml")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+
+	changes := make(chan string, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, c.ListenConfig(ctx, "app.yaml", func(dataID, content string) {
+		changes <- content
+	}))
+
+	assert.NoError(t, c.PublishConfig("app.yaml", "a: 1"))
+	assert.Equal(t, "a: 1", <-changes)
+	content, err := c.GetConfig("app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1", content)
+
+	assert.NoError(t, c.DeleteConfig("app.yaml"))
+	assert.Equal(t, "", <-changes)
+}
+
+func TestClientKV(t *testing.T) {
+	ts := newFakeServer()
+	defer ts.Close()
+	c, err := NewClient([]string{ts.URL}, WithPollInterval(20*time.Millisecond))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "services.")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Put("services.b", "2"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "services.b", Value: "2"}, <-events)
+	assert.NoError(t, c.RegisterEphemeral("services.a", "1"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "services.a", Value: "1"}, <-events)
+
+	kvs, err := c.List("services.")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "services.a", Value: "1"}, {Key: "services.b", Value: "2"}}, kvs)
+
+	assert.NoError(t, c.Delete("services.b"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "services.b", PrevValue: "2"}, <-events)
+
+	// the ephemeral config is deleted on close
+	c.Close()
+	_, ok := <-events
+	assert.False(t, ok)
+	c, err = NewClient([]string{ts.URL})
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Get("services.a")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+}
+
+func TestClientNaming(t *testing.T) {
+	ts := newFakeServer()
+	defer ts.Close()
+	c, err := NewClient([]string{ts.URL}, WithPollInterval(20*time.Millisecond), WithBeatInterval(20*time.Millisecond))
+	assert.NoError(t, err)
+
+	updates := make(chan []Instance, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, c.Subscribe(ctx, "demo", func(service string, instances []Instance) {
+		updates <- instances
+	}))
+	assert.Empty(t, <-updates)
+
+	assert.NoError(t, c.RegisterInstance("demo", Instance{IP: "10.0.0.1", Port: 20880}))
+	instances := <-updates
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "10.0.0.1:20880", instances[0].Addr())
+	assert.Equal(t, float64(1), instances[0].Weight)
+
+	// the instance is registered again by the heartbeat after the server loses it
+	server := ts.Config.Handler.(*fakeServer)
+	server.lock.Lock()
+	server.instances = make(map[string]Instance)
+	server.lock.Unlock()
+	assert.Eventually(t, func() bool {
+		instances, err := c.GetInstances("demo")
+		return err == nil && len(instances) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the instance is deregistered on close
+	c.Close()
+	server.lock.Lock()
+	assert.Empty(t, server.instances)
+	assert.NotZero(t, server.beats)
+	server.lock.Unlock()
+}
@@ -0,0 +1,257 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxnacos
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+const (
+	configPath         = "/nacos/v1/cs/configs"
+	configListenerPath = "/nacos/v1/cs/configs/listener"
+	configPageSize     = 100
+
+	// the separators of the Listening-Configs of the config listener
+	wordSeparator = "\x02"
+	lineSeparator = "\x01"
+)
+
+// ConfigListener is called with the new content of the config @dataID, @content
+// is empty if the config is deleted
+type ConfigListener func(dataID, content string)
+
+func (c *Client) configParams(dataID string) url.Values {
+	params := url.Values{"dataId": {dataID}, "group": {c.options.Group}}
+	if c.options.Namespace != "" {
+		params.Set("tenant", c.options.Namespace)
+	}
+	return params
+}
+
+// GetConfig gets the content of the config @dataID, gxkv.ErrKeyNotFound is
+// returned if it does not exist
+func (c *Client) GetConfig(dataID string) (string, error) {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	content, err := c.getConfig(ctx, dataID)
+	if err != nil && err != gxkv.ErrKeyNotFound {
+		return "", perrors.WithMessagef(err, "get config (data id %s)", dataID)
+	}
+	return content, err
+}
+
+func (c *Client) getConfig(ctx context.Context, dataID string) (string, error) {
+	status, body, err := c.do(ctx, http.MethodGet, configPath, c.configParams(dataID), nil)
+	if err != nil {
+		return "", err
+	}
+	switch status {
+	case http.StatusOK:
+		return string(body), nil
+	case http.StatusNotFound:
+		return "", gxkv.ErrKeyNotFound
+	}
+	return "", perrors.Errorf("status %d, %s", status, body)
+}
+
+// PublishConfig creates or updates the config @dataID with @content
+func (c *Client) PublishConfig(dataID, content string) error {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	params := c.configParams(dataID)
+	params.Set("content", content)
+	err := c.expectTrue(c.do(ctx, http.MethodPost, configPath, params, nil))
+	return perrors.WithMessagef(err, "publish config (data id %s)", dataID)
+}
+
+// DeleteConfig deletes the config @dataID, it is not an error if it does not exist
+func (c *Client) DeleteConfig(dataID string) error {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	err := c.expectTrue(c.do(ctx, http.MethodDelete, configPath, c.configParams(dataID), nil))
+	if err == nil {
+		c.lock.Lock()
+		delete(c.ephemerals, dataID)
+		c.lock.Unlock()
+	}
+	return perrors.WithMessagef(err, "delete config (data id %s)", dataID)
+}
+
+// expectTrue checks that the response is "true"
+func (c *Client) expectTrue(status int, body []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "true" {
+		return perrors.Errorf("status %d, %s", status, body)
+	}
+	return nil
+}
+
+// SearchConfigs gets the configs whose data ids have @prefix in data id order
+func (c *Client) SearchConfigs(prefix string) ([]gxkv.KeyValue, error) {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	kvs, err := c.searchConfigs(ctx, prefix)
+	return kvs, perrors.WithMessagef(err, "search configs (prefix %s)", prefix)
+}
+
+func (c *Client) searchConfigs(ctx context.Context, prefix string) ([]gxkv.KeyValue, error) {
+	kvs := make([]gxkv.KeyValue, 0)
+	for page := 1; ; page++ {
+		params := c.configParams(prefix + "*")
+		params.Set("search", "blur")
+		params.Set("pageNo", strconv.Itoa(page))
+		params.Set("pageSize", strconv.Itoa(configPageSize))
+		status, body, err := c.do(ctx, http.MethodGet, configPath, params, nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, perrors.Errorf("status %d, %s", status, body)
+		}
+
+		var result struct {
+			PagesAvailable int `json:"pagesAvailable"`
+			PageItems      []struct {
+				DataID  string `json:"dataId"`
+				Content string `json:"content"`
+			} `json:"pageItems"`
+		}
+		if err = json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+		for _, item := range result.PageItems {
+			// the blur search matches the wildcard in the prefix too
+			if strings.HasPrefix(item.DataID, prefix) {
+				kvs = append(kvs, gxkv.KeyValue{Key: item.DataID, Value: item.Content})
+			}
+		}
+		if page >= result.PagesAvailable {
+			break
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs, nil
+}
+
+func contentMD5(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListenConfig calls @listener in a goroutine when the config @dataID changes
+// until @ctx is done or the client is closed. The changes are long polled, and
+// the polling is retried after the poll interval when it fails.
+func (c *Client) ListenConfig(ctx context.Context, dataID string, listener ConfigListener) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+
+	getCtx, cancel := c.reqCtx()
+	content, err := c.getConfig(getCtx, dataID)
+	cancel()
+	if err != nil && err != gxkv.ErrKeyNotFound {
+		return perrors.WithMessagef(err, "listen config (data id %s)", dataID)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.listenLoop(ctx, dataID, content, listener)
+	}()
+	return nil
+}
+
+func (c *Client) listenLoop(ctx context.Context, dataID, content string, listener ConfigListener) {
+	for {
+		changed, err := c.pollConfig(ctx, dataID, content)
+		if err == nil && changed {
+			getCtx, cancel := c.reqCtx()
+			var latest string
+			latest, err = c.getConfig(getCtx, dataID)
+			cancel()
+			if err == gxkv.ErrKeyNotFound {
+				latest, err = "", nil
+			}
+			if err == nil && latest != content {
+				content = latest
+				listener(dataID, content)
+			}
+		}
+		if ctx.Err() != nil || c.closed() {
+			return
+		}
+		if err != nil && !c.sleep(ctx, c.options.PollInterval) {
+			return
+		}
+	}
+}
+
+// pollConfig long polls the change of @dataID whose current content is @content
+func (c *Client) pollConfig(ctx context.Context, dataID, content string) (bool, error) {
+	line := dataID + wordSeparator + c.options.Group + wordSeparator + contentMD5(content)
+	if c.options.Namespace != "" {
+		line += wordSeparator + c.options.Namespace
+	}
+	params := url.Values{"Listening-Configs": {line + lineSeparator}}
+	header := http.Header{"Long-Pulling-Timeout": {strconv.FormatInt(int64(c.options.LongPollTimeout/time.Millisecond), 10)}}
+
+	pollCtx, cancel := context.WithTimeout(ctx, c.options.LongPollTimeout+c.options.Timeout)
+	defer cancel()
+	pollCtx, stop := context.WithCancel(pollCtx)
+	defer stop()
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			stop()
+		case <-pollCtx.Done():
+		}
+	}()
+
+	status, body, err := c.do(pollCtx, http.MethodPost, configListenerPath, params, header)
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, perrors.Errorf("status %d, %s", status, body)
+	}
+	return strings.TrimSpace(string(body)) != "", nil
+}
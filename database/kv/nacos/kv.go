@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxnacos
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+var _ gxkv.Client = (*Client)(nil)
+
+func init() {
+	gxkv.Register("nacos", newKVClient)
+}
+
+// newKVClient creates a client by @u, eg:
+// "nacos://host1:8848,host2:8848/basepath?namespace=dev&group=g&timeout=3s&username=u&password=p",
+// the base path prefixes the data ids
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	timeout, err := u.Duration("timeout", DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	pollInterval, err := u.Duration("poll", DefaultPollInterval)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewClient(u.Addrs,
+		WithNamespace(u.String("namespace", "")),
+		WithGroup(u.String("group", DefaultGroup)),
+		WithTimeout(timeout),
+		WithPollInterval(pollInterval),
+		WithAuth(u.String("username", ""), u.String("password", "")),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gxkv.WithPrefix(c, u.BasePath), nil
+}
+
+// Get gets the content of the config @key
+func (c *Client) Get(key string) (string, error) {
+	return c.GetConfig(key)
+}
+
+// Put publishes the config @key with @value
+func (c *Client) Put(key, value string) error {
+	return c.PublishConfig(key, value)
+}
+
+// Delete deletes the config @key
+func (c *Client) Delete(key string) error {
+	return c.DeleteConfig(key)
+}
+
+// List lists the configs whose data ids have @prefix
+func (c *Client) List(prefix string) ([]gxkv.KeyValue, error) {
+	return c.SearchConfigs(prefix)
+}
+
+// RegisterEphemeral publishes the config @key with @value, and deletes it when
+// the client is closed. Nacos has no ephemeral config, so the config is left
+// if the client exits without Close.
+func (c *Client) RegisterEphemeral(key, value string) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	if err := c.PublishConfig(key, value); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.ephemerals[key] = struct{}{}
+	c.lock.Unlock()
+	return nil
+}
+
+// WatchPrefix polls the configs with @prefix every poll interval, and sends
+// their changes to the returned chan until @ctx is done or the client is closed
+func (c *Client) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+
+	searchCtx, cancel := c.reqCtx()
+	kvs, err := c.searchConfigs(searchCtx, prefix)
+	cancel()
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "watch prefix %s", prefix)
+	}
+	known := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		known[kv.Key] = kv.Value
+	}
+
+	out := make(chan gxkv.Event)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(out)
+		for c.sleep(ctx, c.options.PollInterval) {
+			searchCtx, cancel := c.reqCtx()
+			kvs, err := c.searchConfigs(searchCtx, prefix)
+			cancel()
+			if err != nil {
+				continue
+			}
+
+			latest := make(map[string]string, len(kvs))
+			events := make([]gxkv.Event, 0)
+			for _, kv := range kvs {
+				latest[kv.Key] = kv.Value
+				if value, ok := known[kv.Key]; !ok || value != kv.Value {
+					events = append(events, gxkv.Event{Type: gxkv.EventPut, Key: kv.Key, Value: kv.Value, PrevValue: value})
+				}
+			}
+			for key, value := range known {
+				if _, ok := latest[key]; !ok {
+					events = append(events, gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: value})
+				}
+			}
+			known = latest
+
+			for _, event := range events {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				case <-c.ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,294 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxnacos
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	instancePath     = "/nacos/v1/ns/instance"
+	instanceListPath = "/nacos/v1/ns/instance/list"
+	instanceBeatPath = "/nacos/v1/ns/instance/beat"
+
+	// codeResourceNotFound is the code of a heartbeat of an unregistered instance
+	codeResourceNotFound = 20404
+	serviceSeparator     = "@@"
+)
+
+// Instance is an instance of a nacos service
+type Instance struct {
+	IP       string            `json:"ip"`
+	Port     int               `json:"port"`
+	Weight   float64           `json:"weight"`
+	Healthy  bool              `json:"healthy"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Addr returns the address of the instance
+func (i Instance) Addr() string {
+	return net.JoinHostPort(i.IP, strconv.Itoa(i.Port))
+}
+
+// ServiceListener is called with all healthy instances of the service @service
+// when they change
+type ServiceListener func(service string, instances []Instance)
+
+type registration struct {
+	service  string
+	instance Instance
+}
+
+func registrationKey(service, ip string, port int) string {
+	return service + serviceSeparator + net.JoinHostPort(ip, strconv.Itoa(port))
+}
+
+func (c *Client) instanceParams(service, ip string, port int) url.Values {
+	params := url.Values{
+		"serviceName": {service},
+		"groupName":   {c.options.Group},
+		"ip":          {ip},
+		"port":        {strconv.Itoa(port)},
+		"ephemeral":   {"true"},
+	}
+	if c.options.Namespace != "" {
+		params.Set("namespaceId", c.options.Namespace)
+	}
+	return params
+}
+
+// RegisterInstance registers the ephemeral @instance of @service, the client
+// sends its heartbeats until it is deregistered or the client is closed
+func (c *Client) RegisterInstance(service string, instance Instance) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	if instance.Weight <= 0 {
+		instance.Weight = 1
+	}
+
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	if err := c.registerInstance(ctx, service, instance); err != nil {
+		return perrors.WithMessagef(err, "register instance (service %s, address %s)", service, instance.Addr())
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.instances[registrationKey(service, instance.IP, instance.Port)] = registration{service: service, instance: instance}
+	if !c.beating {
+		c.beating = true
+		c.wg.Add(1)
+		go c.beatLoop()
+	}
+	return nil
+}
+
+func (c *Client) registerInstance(ctx context.Context, service string, instance Instance) error {
+	params := c.instanceParams(service, instance.IP, instance.Port)
+	params.Set("weight", strconv.FormatFloat(instance.Weight, 'f', -1, 64))
+	params.Set("enable", "true")
+	params.Set("healthy", "true")
+	if len(instance.Metadata) != 0 {
+		metadata, err := json.Marshal(instance.Metadata)
+		if err != nil {
+			return err
+		}
+		params.Set("metadata", string(metadata))
+	}
+	return c.expectOK(c.do(ctx, http.MethodPost, instancePath, params, nil))
+}
+
+// DeregisterInstance deregisters the instance @ip:@port of @service
+func (c *Client) DeregisterInstance(service, ip string, port int) error {
+	c.lock.Lock()
+	delete(c.instances, registrationKey(service, ip, port))
+	c.lock.Unlock()
+
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	err := c.expectOK(c.do(ctx, http.MethodDelete, instancePath, c.instanceParams(service, ip, port), nil))
+	return perrors.WithMessagef(err, "deregister instance (service %s, address %s:%d)", service, ip, port)
+}
+
+// expectOK checks that the response is "ok"
+func (c *Client) expectOK(status int, body []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "ok" {
+		return perrors.Errorf("status %d, %s", status, body)
+	}
+	return nil
+}
+
+// beatLoop sends the heartbeats of the registered instances until the client is closed
+func (c *Client) beatLoop() {
+	defer c.wg.Done()
+	for c.sleep(c.ctx, c.options.BeatInterval) {
+		c.lock.Lock()
+		instances := make([]registration, 0, len(c.instances))
+		for _, r := range c.instances {
+			instances = append(instances, r)
+		}
+		c.lock.Unlock()
+
+		for _, r := range instances {
+			c.beat(r)
+		}
+	}
+}
+
+// beat sends a heartbeat of @r, and registers it again if the server has lost it
+func (c *Client) beat(r registration) {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+
+	beat, err := json.Marshal(map[string]interface{}{
+		"serviceName": c.options.Group + serviceSeparator + r.service,
+		"ip":          r.instance.IP,
+		"port":        r.instance.Port,
+		"weight":      r.instance.Weight,
+		"metadata":    r.instance.Metadata,
+	})
+	if err != nil {
+		return
+	}
+	params := c.instanceParams(r.service, r.instance.IP, r.instance.Port)
+	params.Set("beat", string(beat))
+	status, body, err := c.do(ctx, http.MethodPut, instanceBeatPath, params, nil)
+	if err != nil || status != http.StatusOK {
+		return
+	}
+
+	var result struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(body, &result) == nil && result.Code == codeResourceNotFound {
+		c.lock.Lock()
+		_, ok := c.instances[registrationKey(r.service, r.instance.IP, r.instance.Port)]
+		c.lock.Unlock()
+		if ok {
+			_ = c.registerInstance(ctx, r.service, r.instance)
+		}
+	}
+}
+
+// GetInstances gets the healthy instances of @service in address order
+func (c *Client) GetInstances(service string) ([]Instance, error) {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	instances, err := c.getInstances(ctx, service)
+	return instances, perrors.WithMessagef(err, "get instances (service %s)", service)
+}
+
+func (c *Client) getInstances(ctx context.Context, service string) ([]Instance, error) {
+	params := url.Values{
+		"serviceName": {service},
+		"groupName":   {c.options.Group},
+		"healthyOnly": {"true"},
+	}
+	if c.options.Namespace != "" {
+		params.Set("namespaceId", c.options.Namespace)
+	}
+	status, body, err := c.do(ctx, http.MethodGet, instanceListPath, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, perrors.Errorf("status %d, %s", status, body)
+	}
+
+	var result struct {
+		Hosts []Instance `json:"hosts"`
+	}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	instances := make([]Instance, 0, len(result.Hosts))
+	for _, host := range result.Hosts {
+		if host.Healthy {
+			instances = append(instances, host)
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Addr() < instances[j].Addr()
+	})
+	return instances, nil
+}
+
+// Subscribe polls the instances of @service every poll interval until @ctx is
+// done or the client is closed, and calls @listener in a goroutine with the
+// current instances first and then whenever they change
+func (c *Client) Subscribe(ctx context.Context, service string, listener ServiceListener) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+
+	getCtx, cancel := c.reqCtx()
+	instances, err := c.getInstances(getCtx, service)
+	cancel()
+	if err != nil {
+		return perrors.WithMessagef(err, "subscribe (service %s)", service)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		listener(service, instances)
+		for c.sleep(ctx, c.options.PollInterval) {
+			getCtx, cancel := c.reqCtx()
+			latest, err := c.getInstances(getCtx, service)
+			cancel()
+			if err != nil || sameInstances(instances, latest) {
+				continue
+			}
+			instances = latest
+			listener(service, instances)
+		}
+	}()
+	return nil
+}
+
+func sameInstances(a, b []Instance) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Addr() != b[i].Addr() || a[i].Weight != b[i].Weight || len(a[i].Metadata) != len(b[i].Metadata) {
+			return false
+		}
+		for k, v := range a[i].Metadata {
+			if b[i].Metadata[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxnacos
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultGroup default group of the configs and services
+	DefaultGroup = "DEFAULT_GROUP"
+	// DefaultTimeout default timeout of a request
+	DefaultTimeout = 3 * time.Second
+	// DefaultPollInterval default interval of polling the services and prefixes
+	DefaultPollInterval = 5 * time.Second
+	// DefaultBeatInterval default interval of the heartbeats of the ephemeral instances
+	DefaultBeatInterval = 5 * time.Second
+	// defaultLongPollTimeout timeout of a long polling of the config listener
+	defaultLongPollTimeout = 30 * time.Second
+)
+
+// Options of the nacos client
+type Options struct {
+	// Namespace namespace (tenant) of the configs and services, public by default
+	Namespace string
+	// Group group of the configs and services, DefaultGroup by default
+	Group string
+	// Timeout timeout of a request
+	Timeout time.Duration
+	// PollInterval interval of polling the services of Subscribe and the prefixes of WatchPrefix
+	PollInterval time.Duration
+	// BeatInterval interval of the heartbeats of the ephemeral instances
+	BeatInterval time.Duration
+	// LongPollTimeout timeout of a long polling of ListenConfig
+	LongPollTimeout time.Duration
+	// Username and Password authenticate the client if the server enables auth
+	Username string
+	Password string
+	// HTTPClient sends the requests, a default client is used if it is nil
+	HTTPClient *http.Client
+}
+
+// Option will define a function of handling Options
+type Option func(*Options)
+
+// WithNamespace sets the namespace of the configs and services
+func WithNamespace(namespace string) Option {
+	return func(opt *Options) {
+		opt.Namespace = namespace
+	}
+}
+
+// WithGroup sets the group of the configs and services
+func WithGroup(group string) Option {
+	return func(opt *Options) {
+		opt.Group = group
+	}
+}
+
+// WithTimeout sets the timeout of a request
+func WithTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.Timeout = timeout
+	}
+}
+
+// WithPollInterval sets the interval of polling the services and prefixes
+func WithPollInterval(interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.PollInterval = interval
+	}
+}
+
+// WithBeatInterval sets the interval of the heartbeats of the ephemeral instances
+func WithBeatInterval(interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.BeatInterval = interval
+	}
+}
+
+// WithLongPollTimeout sets the timeout of a long polling of ListenConfig
+func WithLongPollTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.LongPollTimeout = timeout
+	}
+}
+
+// WithAuth sets the username and password authenticating the client
+func WithAuth(username, password string) Option {
+	return func(opt *Options) {
+		opt.Username = username
+		opt.Password = password
+	}
+}
+
+// WithHTTPClient sets the http client sending the requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(opt *Options) {
+		opt.HTTPClient = client
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxkv
+
+import (
+	"context"
+	"strings"
+)
+
+type prefixClient struct {
+	Client
+	prefix string
+}
+
+// WithPrefix roots the keys of @client under @prefix, the keys passed to and
+// returned by the returned client have no @prefix
+func WithPrefix(client Client, prefix string) Client {
+	if prefix == "" {
+		return client
+	}
+	return &prefixClient{Client: client, prefix: prefix}
+}
+
+func (c *prefixClient) Get(key string) (string, error) {
+	return c.Client.Get(c.prefix + key)
+}
+
+func (c *prefixClient) Put(key, value string) error {
+	return c.Client.Put(c.prefix+key, value)
+}
+
+func (c *prefixClient) Delete(key string) error {
+	return c.Client.Delete(c.prefix + key)
+}
+
+func (c *prefixClient) RegisterEphemeral(key, value string) error {
+	return c.Client.RegisterEphemeral(c.prefix+key, value)
+}
+
+func (c *prefixClient) List(prefix string) ([]KeyValue, error) {
+	kvs, err := c.Client.List(c.prefix + prefix)
+	for i := range kvs {
+		kvs[i].Key = strings.TrimPrefix(kvs[i].Key, c.prefix)
+	}
+	return kvs, err
+}
+
+func (c *prefixClient) WatchPrefix(ctx context.Context, prefix string) (<-chan Event, error) {
+	events, err := c.Client.WatchPrefix(ctx, c.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		for event := range events {
+			event.Key = strings.TrimPrefix(event.Key, c.prefix)
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				// drain events until the backend closes it
+				for range events {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
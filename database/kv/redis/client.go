@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxredis provides a redis client implementing the gxkv client, it
+// speaks RESP itself so that it does not depend on a redis sdk.
+package gxredis
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// ErrClientClosed is returned when using a closed client
+var ErrClientClosed = perrors.New("redis client closed")
+
+// Client is a client of a redis server, the commands are sent on one connection
+// one by one, and the connection is dialed again after it breaks
+type Client struct {
+	addr    string
+	options *Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	connLock sync.Mutex
+	conn     *conn
+
+	lock       sync.Mutex
+	ephemerals map[string]string // ephemeral keys and their values
+	keeping    bool              // whether the goroutine refreshing the ephemeral keys is running
+}
+
+// NewClient creates a client of the redis server @addr, eg: "127.0.0.1:6379",
+// and checks the server by PING
+func NewClient(addr string, opts ...Option) (*Client, error) {
+	options := &Options{
+		Timeout:   DefaultTimeout,
+		TTL:       DefaultTTL,
+		ScanCount: DefaultScanCount,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		addr:       addr,
+		options:    options,
+		ctx:        ctx,
+		cancel:     cancel,
+		ephemerals: make(map[string]string),
+	}
+	if _, err := c.do("PING"); err != nil {
+		cancel()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close deletes the ephemeral keys and closes the client
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.lock.Lock()
+		keys := make([]string, 0, len(c.ephemerals))
+		for key := range c.ephemerals {
+			keys = append(keys, key)
+		}
+		c.lock.Unlock()
+		if len(keys) != 0 {
+			_, _ = c.do(append([]string{"DEL"}, keys...)...)
+		}
+
+		c.cancel()
+		c.connLock.Lock()
+		if c.conn != nil {
+			c.conn.close()
+			c.conn = nil
+		}
+		c.connLock.Unlock()
+		c.wg.Wait()
+	})
+}
+
+func (c *Client) closed() bool {
+	return c.ctx.Err() != nil
+}
+
+// do sends the command @args, it dials again and retries once if the
+// connection is broken
+func (c *Client) do(args ...string) (interface{}, error) {
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+
+	var err error
+	for i := 0; i < 2; i++ {
+		if c.closed() {
+			return nil, ErrClientClosed
+		}
+		if c.conn == nil {
+			if c.conn, err = dial(c.ctx, c.addr, c.options); err != nil {
+				break
+			}
+		}
+
+		var reply interface{}
+		reply, err = c.conn.do(c.options.Timeout, args...)
+		if err == nil {
+			return reply, nil
+		}
+		if _, ok := err.(Error); ok {
+			break
+		}
+		c.conn.close()
+		c.conn = nil
+	}
+	return nil, perrors.WithMessagef(err, "redis %s", args[0])
+}
+
+// sleep waits for @d, it returns false if the client is closed or @ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.ctx.Done():
+		return false
+	}
+}
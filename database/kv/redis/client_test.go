@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxredis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// fakeServer serves the commands used by the client, and publishes the
+// keyspace notifications of SET and DEL
+type fakeServer struct {
+	listener net.Listener
+
+	lock        sync.Mutex
+	values      map[string]string
+	expires     map[string]time.Time
+	subscribers map[*conn]string // subscribing connections and their patterns
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &fakeServer{
+		listener:    listener,
+		values:      make(map[string]string),
+		expires:     make(map[string]time.Time),
+		subscribers: make(map[*conn]string),
+	}
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(&conn{netConn: netConn, reader: bufio.NewReader(netConn), writer: bufio.NewWriter(netConn)})
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) close() {
+	_ = s.listener.Close()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for sub := range s.subscribers {
+		sub.close()
+	}
+}
+
+func (s *fakeServer) serve(c *conn) {
+	defer c.close()
+	for {
+		reply, err := c.receive()
+		if err != nil {
+			s.lock.Lock()
+			delete(s.subscribers, c)
+			s.lock.Unlock()
+			return
+		}
+		args := toStrings(reply)
+		s.lock.Lock()
+		s.expire()
+		s.handle(c, args)
+		_ = c.writer.Flush()
+		s.lock.Unlock()
+	}
+}
+
+func (s *fakeServer) expire() {
+	for key, deadline := range s.expires {
+		if time.Now().After(deadline) {
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+	}
+}
+
+func (s *fakeServer) handle(c *conn, args []string) {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		c.writer.WriteString("+PONG\r\n")
+	case "GET":
+		writeBulk(c, s.values, args[1])
+	case "SET":
+		s.values[args[1]] = args[2]
+		delete(s.expires, args[1])
+		if len(args) == 5 {
+			ms, _ := strconv.Atoi(args[4])
+			s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		c.writer.WriteString("+OK\r\n")
+		s.notify(args[1], "set")
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				delete(s.values, key)
+				delete(s.expires, key)
+				n++
+				s.notify(key, "del")
+			}
+		}
+		c.writer.WriteString(":" + strconv.Itoa(n) + "\r\n")
+	case "PEXPIRE":
+		if _, ok := s.values[args[1]]; !ok {
+			c.writer.WriteString(":0\r\n")
+			return
+		}
+		ms, _ := strconv.Atoi(args[2])
+		s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		c.writer.WriteString(":1\r\n")
+	case "SCAN":
+		prefix := unescapePrefix(args[3])
+		keys := make([]string, 0)
+		for key := range s.values {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		c.writer.WriteString("*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(keys)) + "\r\n")
+		for _, key := range keys {
+			writeBulk(c, map[string]string{key: key}, key)
+		}
+	case "MGET":
+		c.writer.WriteString("*" + strconv.Itoa(len(args)-1) + "\r\n")
+		for _, key := range args[1:] {
+			writeBulk(c, s.values, key)
+		}
+	case "PSUBSCRIBE":
+		s.subscribers[c] = args[1]
+		c.writer.WriteString("*3\r\n$10\r\npsubscribe\r\n$" + strconv.Itoa(len(args[1])) + "\r\n" + args[1] + "\r\n:1\r\n")
+	default:
+		c.writer.WriteString("-ERR unknown command\r\n")
+	}
+}
+
+func (s *fakeServer) notify(key, operation string) {
+	channel := "__keyspace@0__:" + key
+	for sub, pattern := range s.subscribers {
+		if strings.HasPrefix(channel, unescapePrefix(pattern)) {
+			_ = sub.send("pmessage", pattern, channel, operation)
+		}
+	}
+}
+
+func writeBulk(c *conn, values map[string]string, key string) {
+	value, ok := values[key]
+	if !ok {
+		c.writer.WriteString("$-1\r\n")
+		return
+	}
+	c.writer.WriteString("$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n")
+}
+
+func unescapePrefix(pattern string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(pattern, "*"), `\`, "")
+}
+
+func testKV(t *testing.T, opts ...Option) {
+	s := newFakeServer(t)
+	defer s.close()
+	c, err := NewClient(s.addr(), opts...)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "/services/")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Put("/services/b", "2"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/b", Value: "2"}, <-events)
+	assert.NoError(t, c.RegisterEphemeral("/services/a", "1"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/a", Value: "1"}, <-events)
+	assert.NoError(t, c.Put("/other", "0"))
+
+	v, err := c.Get("/services/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", v)
+	kvs, err := c.List("/services/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/services/a", Value: "1"}, {Key: "/services/b", Value: "2"}}, kvs)
+
+	assert.NoError(t, c.Delete("/services/b"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "/services/b", PrevValue: "2"}, <-events)
+	_, err = c.Get("/services/b")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+
+	// the ephemeral key outlives its ttl by the refreshing, and is deleted on close
+	time.Sleep(150 * time.Millisecond)
+	_, err = c.Get("/services/a")
+	assert.NoError(t, err)
+	c.Close()
+	_, ok := <-events
+	assert.False(t, ok)
+
+	c, err = NewClient(s.addr())
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Get("/services/a")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+}
+
+func TestClientKV(t *testing.T) {
+	testKV(t, WithTTL(60*time.Millisecond))
+}
+
+func TestClientKVPollWatch(t *testing.T) {
+	testKV(t, WithTTL(60*time.Millisecond), WithPollWatch(10*time.Millisecond))
+}
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, `/a\*b\?c\[d\]`, escapeGlob("/a*b?c[d]"))
+}
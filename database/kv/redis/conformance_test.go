@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxredis
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+	"github.com/dubbogo/gost/database/kv/kvtest"
+)
+
+func TestConformance(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.close()
+	kvtest.Run(t, func() gxkv.Client {
+		c, err := NewClient(s.addr())
+		assert.NoError(t, err)
+		return c
+	})
+	kvtest.Run(t, func() gxkv.Client {
+		c, err := NewClient(s.addr(), WithPollWatch(10*time.Millisecond))
+		assert.NoError(t, err)
+		return c
+	})
+}
@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxredis
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// Error is an error reply of the redis server
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// conn is a connection speaking RESP, the replies are decoded to string, int64,
+// []interface{}, nil for the null replies, or Error
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+}
+
+// dial connects to @addr, then authenticates and selects the database
+func dial(ctx context.Context, addr string, options *Options) (*conn, error) {
+	dialer := net.Dialer{Timeout: options.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "dial redis %s", addr)
+	}
+	c := &conn{
+		netConn: netConn,
+		reader:  bufio.NewReader(netConn),
+		writer:  bufio.NewWriter(netConn),
+	}
+	if options.Password != "" {
+		if _, err = c.do(options.Timeout, "AUTH", options.Password); err != nil {
+			c.close()
+			return nil, perrors.WithMessage(err, "redis AUTH")
+		}
+	}
+	if options.DB != 0 {
+		if _, err = c.do(options.Timeout, "SELECT", strconv.Itoa(options.DB)); err != nil {
+			c.close()
+			return nil, perrors.WithMessage(err, "redis SELECT")
+		}
+	}
+	return c, nil
+}
+
+func (c *conn) close() {
+	_ = c.netConn.Close()
+}
+
+// do sends the command @args and reads its reply within @timeout, the Error
+// reply is returned as the error
+func (c *conn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	if timeout > 0 {
+		_ = c.netConn.SetDeadline(time.Now().Add(timeout))
+		defer c.netConn.SetDeadline(time.Time{})
+	}
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	reply, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// send writes the command @args as an array of bulk strings
+func (c *conn) send(args ...string) error {
+	c.writer.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		c.writer.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n")
+		c.writer.WriteString(arg)
+		c.writer.WriteString("\r\n")
+	}
+	return c.writer.Flush()
+}
+
+// receive reads a reply
+func (c *conn) receive() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, perrors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(c.reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		replies := make([]interface{}, n)
+		for i := range replies {
+			if replies[i], err = c.receive(); err != nil {
+				return nil, err
+			}
+		}
+		return replies, nil
+	}
+	return nil, perrors.Errorf("redis: unknown reply %q", line)
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", perrors.Errorf("redis: bad line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,412 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxredis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+var _ gxkv.Client = (*Client)(nil)
+
+func init() {
+	gxkv.Register("redis", newKVClient)
+}
+
+// newKVClient creates a client of the first address of @u, eg:
+// "redis://127.0.0.1:6379/basepath?db=1&password=p&timeout=3s&ttl=10s&poll=1s",
+// the base path prefixes the keys, and poll makes it watch by polling
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	if len(u.Addrs) == 0 {
+		return nil, perrors.New("no redis address")
+	}
+	db, err := u.Int("db", 0)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := u.Duration("timeout", DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := u.Duration("ttl", DefaultTTL)
+	if err != nil {
+		return nil, err
+	}
+	pollInterval, err := u.Duration("poll", 0)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewClient(u.Addrs[0],
+		WithDB(db),
+		WithPassword(u.String("password", "")),
+		WithTimeout(timeout),
+		WithTTL(ttl),
+		WithPollWatch(pollInterval),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gxkv.WithPrefix(c, u.BasePath), nil
+}
+
+// Get gets the value of @key
+func (c *Client) Get(key string) (string, error) {
+	reply, err := c.do("GET", key)
+	if err != nil {
+		return "", perrors.WithMessagef(err, "get key %s", key)
+	}
+	if reply == nil {
+		return "", gxkv.ErrKeyNotFound
+	}
+	return reply.(string), nil
+}
+
+// Put sets @key to @value without ttl
+func (c *Client) Put(key, value string) error {
+	_, err := c.do("SET", key, value)
+	return perrors.WithMessagef(err, "put key %s", key)
+}
+
+// Delete deletes @key
+func (c *Client) Delete(key string) error {
+	c.lock.Lock()
+	delete(c.ephemerals, key)
+	c.lock.Unlock()
+	_, err := c.do("DEL", key)
+	return perrors.WithMessagef(err, "delete key %s", key)
+}
+
+// List scans the keys with @prefix and gets their values in key order
+func (c *Client) List(prefix string) ([]gxkv.KeyValue, error) {
+	values := make(map[string]string)
+	cursor := "0"
+	for {
+		reply, err := c.do("SCAN", cursor, "MATCH", escapeGlob(prefix)+"*", "COUNT", strconv.Itoa(c.options.ScanCount))
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "list prefix %s", prefix)
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return nil, perrors.Errorf("list prefix %s: bad SCAN reply %v", prefix, reply)
+		}
+		cursor, _ = page[0].(string)
+		keys := toStrings(page[1])
+
+		if len(keys) != 0 {
+			if reply, err = c.do(append([]string{"MGET"}, keys...)...); err != nil {
+				return nil, perrors.WithMessagef(err, "list prefix %s", prefix)
+			}
+			vs, _ := reply.([]interface{})
+			for i, v := range vs {
+				// the key is deleted after it is scanned
+				if s, ok := v.(string); ok && i < len(keys) {
+					values[keys[i]] = s
+				}
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	// SCAN may return a key more than once, so the pairs are collected by a map
+	kvs := make([]gxkv.KeyValue, 0, len(values))
+	for k, v := range values {
+		kvs = append(kvs, gxkv.KeyValue{Key: k, Value: v})
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs, nil
+}
+
+// RegisterEphemeral sets @key to @value with the ttl of the options, and
+// refreshes the ttl until the key is deleted or the client is closed
+func (c *Client) RegisterEphemeral(key, value string) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	if err := c.setEphemeral(key, value); err != nil {
+		return perrors.WithMessagef(err, "register ephemeral key %s", key)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.keeping {
+		c.keeping = true
+		c.wg.Add(1)
+		go c.keepEphemerals()
+	}
+	c.ephemerals[key] = value
+	return nil
+}
+
+func (c *Client) setEphemeral(key, value string) error {
+	_, err := c.do("SET", key, value, "PX", strconv.FormatInt(int64(c.options.TTL/time.Millisecond), 10))
+	return err
+}
+
+// keepEphemerals refreshes the ttl of the ephemeral keys every TTL/3, and sets
+// the keys again if they have expired. It exits when there is no ephemeral key.
+func (c *Client) keepEphemerals() {
+	defer c.wg.Done()
+	ttl := strconv.FormatInt(int64(c.options.TTL/time.Millisecond), 10)
+	for c.sleep(c.ctx, c.options.TTL/3) {
+		c.lock.Lock()
+		if len(c.ephemerals) == 0 {
+			c.keeping = false
+			c.lock.Unlock()
+			return
+		}
+		ephemerals := make(map[string]string, len(c.ephemerals))
+		for k, v := range c.ephemerals {
+			ephemerals[k] = v
+		}
+		c.lock.Unlock()
+
+		for key, value := range ephemerals {
+			reply, err := c.do("PEXPIRE", key, ttl)
+			if err == nil && reply == int64(0) {
+				_ = c.setEphemeral(key, value)
+			}
+		}
+	}
+}
+
+// WatchPrefix sends the changes of the keys with @prefix to the returned chan
+// until @ctx is done or the client is closed. It subscribes the keyspace
+// notifications, which needs the server to enable them, eg:
+// "notify-keyspace-events KA", and lists the keys after resubscribing to send
+// the changes missed while the connection is broken. It polls the keys instead
+// with WithPollWatch. The events have no revision.
+func (c *Client) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+	if c.options.PollInterval > 0 {
+		return c.pollPrefix(ctx, prefix)
+	}
+
+	pattern := "__keyspace@" + strconv.Itoa(c.options.DB) + "__:" + escapeGlob(prefix) + "*"
+	sub, err := c.subscribe(pattern)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "watch prefix %s", prefix)
+	}
+	// list after subscribing so that no change is missed
+	kvs, err := c.List(prefix)
+	if err != nil {
+		sub.close()
+		return nil, perrors.WithMessagef(err, "watch prefix %s", prefix)
+	}
+	known, _ := diff(nil, kvs)
+
+	out := make(chan gxkv.Event)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(out)
+		for {
+			c.receiveNotifications(ctx, sub, known, out)
+			for {
+				if !c.sleep(ctx, retryInterval) {
+					return
+				}
+				if sub, err = c.subscribe(pattern); err != nil {
+					continue
+				}
+				if kvs, err = c.List(prefix); err != nil {
+					sub.close()
+					continue
+				}
+				break
+			}
+
+			var events []gxkv.Event
+			known, events = diff(known, kvs)
+			if !c.send(ctx, out, events...) {
+				sub.close()
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// subscribe dials a connection subscribing @pattern
+func (c *Client) subscribe(pattern string) (*conn, error) {
+	sub, err := dial(c.ctx, c.addr, c.options)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = sub.do(c.options.Timeout, "PSUBSCRIBE", pattern); err != nil {
+		sub.close()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// receiveNotifications converts the keyspace notifications received by @sub to
+// events of the @known keys until @sub breaks, @ctx is done or the client is closed
+func (c *Client) receiveNotifications(ctx context.Context, sub *conn, known map[string]string, out chan<- gxkv.Event) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.ctx.Done():
+		case <-done:
+		}
+		sub.close()
+	}()
+
+	for {
+		reply, err := sub.receive()
+		if err != nil {
+			return
+		}
+		message := toStrings(reply)
+		// pmessage, pattern, channel, operation
+		if len(message) != 4 || message[0] != "pmessage" {
+			continue
+		}
+		key := message[2][strings.Index(message[2], ":")+1:]
+
+		prev, existed := known[key]
+		event := gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: prev}
+		switch message[3] {
+		case "expire", "persist":
+			// the ttl changes only
+			continue
+		case "del", "expired", "evicted", "rename_from":
+		default:
+			value, err := c.Get(key)
+			if err == nil {
+				event.Type, event.Value = gxkv.EventPut, value
+			} else if err != gxkv.ErrKeyNotFound {
+				continue
+			}
+		}
+
+		if event.Type == gxkv.EventPut {
+			if existed && prev == event.Value {
+				continue
+			}
+			known[key] = event.Value
+		} else {
+			if !existed {
+				continue
+			}
+			delete(known, key)
+		}
+		if !c.send(ctx, out, event) {
+			return
+		}
+	}
+}
+
+// send sends @events to @out, it returns false if @ctx is done or the client is closed
+func (c *Client) send(ctx context.Context, out chan<- gxkv.Event, events ...gxkv.Event) bool {
+	for _, event := range events {
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return false
+		case <-c.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// diff returns the map of @kvs and the events from @known to @kvs
+func diff(known map[string]string, kvs []gxkv.KeyValue) (map[string]string, []gxkv.Event) {
+	latest := make(map[string]string, len(kvs))
+	events := make([]gxkv.Event, 0)
+	for _, kv := range kvs {
+		latest[kv.Key] = kv.Value
+		if value, ok := known[kv.Key]; !ok || value != kv.Value {
+			events = append(events, gxkv.Event{Type: gxkv.EventPut, Key: kv.Key, Value: kv.Value, PrevValue: value})
+		}
+	}
+	for key, value := range known {
+		if _, ok := latest[key]; !ok {
+			events = append(events, gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: value})
+		}
+	}
+	return latest, events
+}
+
+// pollPrefix lists the keys with @prefix every poll interval and sends their changes
+func (c *Client) pollPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	kvs, err := c.List(prefix)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "watch prefix %s", prefix)
+	}
+	known, _ := diff(nil, kvs)
+
+	out := make(chan gxkv.Event)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(out)
+		for c.sleep(ctx, c.options.PollInterval) {
+			kvs, err := c.List(prefix)
+			if err != nil {
+				continue
+			}
+			var events []gxkv.Event
+			if known, events = diff(known, kvs); !c.send(ctx, out, events...) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// escapeGlob escapes the special characters of the glob patterns of redis in @s
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\^-`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toStrings(reply interface{}) []string {
+	replies, _ := reply.([]interface{})
+	strs := make([]string, 0, len(replies))
+	for _, r := range replies {
+		s, _ := r.(string)
+		strs = append(strs, s)
+	}
+	return strs
+}
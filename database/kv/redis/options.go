@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxredis
+
+import (
+	"time"
+)
+
+const (
+	// DefaultTimeout default timeout of dialing and a command
+	DefaultTimeout = 3 * time.Second
+	// DefaultTTL default ttl of the ephemeral keys
+	DefaultTTL = 10 * time.Second
+	// DefaultScanCount default count hint of a SCAN
+	DefaultScanCount = 100
+	// retryInterval interval of resubscribing the keyspace notifications
+	retryInterval = time.Second
+)
+
+// Options of the redis client
+type Options struct {
+	// DB index of the database
+	DB int
+	// Password authenticates the client if it is not empty
+	Password string
+	// Timeout timeout of dialing and a command
+	Timeout time.Duration
+	// TTL ttl of the ephemeral keys, they are refreshed every TTL/3
+	TTL time.Duration
+	// ScanCount count hint of a SCAN of List
+	ScanCount int
+	// PollInterval makes WatchPrefix poll the keys every interval instead of
+	// subscribing the keyspace notifications if it is positive
+	PollInterval time.Duration
+}
+
+// Option will define a function of handling Options
+type Option func(*Options)
+
+// WithDB sets the index of the database
+func WithDB(db int) Option {
+	return func(opt *Options) {
+		opt.DB = db
+	}
+}
+
+// WithPassword sets the password authenticating the client
+func WithPassword(password string) Option {
+	return func(opt *Options) {
+		opt.Password = password
+	}
+}
+
+// WithTimeout sets the timeout of dialing and a command
+func WithTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.Timeout = timeout
+	}
+}
+
+// WithTTL sets the ttl of the ephemeral keys
+func WithTTL(ttl time.Duration) Option {
+	return func(opt *Options) {
+		opt.TTL = ttl
+	}
+}
+
+// WithScanCount sets the count hint of a SCAN
+func WithScanCount(count int) Option {
+	return func(opt *Options) {
+		opt.ScanCount = count
+	}
+}
+
+// WithPollWatch makes WatchPrefix poll the keys every @interval, it is for the
+// servers which do not enable the keyspace notifications
+func WithPollWatch(interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.PollInterval = interval
+	}
+}
@@ -0,0 +1,310 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxkv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+// DefaultMirrorQueueSize default size of the queue of the writes to mirror
+const DefaultMirrorQueueSize = 1024
+
+type mirrorOp int
+
+const (
+	mirrorPut mirrorOp = iota
+	mirrorDelete
+	mirrorEphemeral
+)
+
+type mirrorWrite struct {
+	op    mirrorOp
+	key   string
+	value string
+}
+
+// ReplicationOption will define a function of handling the options of ReplicatedClient
+type ReplicationOption func(*ReplicatedClient)
+
+// WithMirrorQueueSize sets the size of the queue of the writes to mirror, the
+// writes are dropped when it is full and repaired by the reconciliation
+func WithMirrorQueueSize(size int) ReplicationOption {
+	return func(c *ReplicatedClient) {
+		c.queueSize = size
+	}
+}
+
+// WithReconcileInterval reconciles the keys with @prefix every @interval
+func WithReconcileInterval(prefix string, interval time.Duration) ReplicationOption {
+	return func(c *ReplicatedClient) {
+		c.reconcilePrefix = prefix
+		c.reconcileInterval = interval
+	}
+}
+
+// ReplicationStats is the statistics of the mirroring of a ReplicatedClient
+type ReplicationStats struct {
+	// Mirrored writes applied to the secondary
+	Mirrored int64
+	// Failed writes failing on the secondary
+	Failed int64
+	// Dropped writes dropped because the queue is full
+	Dropped int64
+	// Divergences keys repaired by the reconciliations
+	Divergences int64
+}
+
+// ReplicatedClient writes to the primary client and mirrors the successful
+// writes to the secondary one asynchronously, eg: from etcd to zookeeper
+// during a migration. The reads and watches go to the primary. The keys which
+// the mirroring misses are repaired by Reconcile.
+type ReplicatedClient struct {
+	Client
+	secondary Client
+
+	queueSize         int
+	reconcilePrefix   string
+	reconcileInterval time.Duration
+
+	queue  chan mirrorWrite
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	lock       sync.Mutex
+	ephemerals map[string]struct{} // keys registered as ephemeral ones by the client
+
+	mirrored    atomic.Int64
+	failed      atomic.Int64
+	dropped     atomic.Int64
+	divergences atomic.Int64
+}
+
+// NewReplicatedClient creates a client writing to @primary and mirroring to @secondary
+func NewReplicatedClient(primary, secondary Client, opts ...ReplicationOption) *ReplicatedClient {
+	c := &ReplicatedClient{
+		Client:     primary,
+		secondary:  secondary,
+		queueSize:  DefaultMirrorQueueSize,
+		ephemerals: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.queue = make(chan mirrorWrite, c.queueSize)
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	c.wg.Add(1)
+	go c.mirror()
+	if c.reconcileInterval > 0 {
+		c.wg.Add(1)
+		go c.reconcileLoop()
+	}
+	return c
+}
+
+// Put puts @key to the primary and mirrors it
+func (c *ReplicatedClient) Put(key, value string) error {
+	if err := c.Client.Put(key, value); err != nil {
+		return err
+	}
+	c.setEphemeral(key, false)
+	c.enqueue(mirrorWrite{op: mirrorPut, key: key, value: value})
+	return nil
+}
+
+// Delete deletes @key from the primary and mirrors it
+func (c *ReplicatedClient) Delete(key string) error {
+	if err := c.Client.Delete(key); err != nil {
+		return err
+	}
+	c.setEphemeral(key, false)
+	c.enqueue(mirrorWrite{op: mirrorDelete, key: key})
+	return nil
+}
+
+// RegisterEphemeral registers @key to the primary and mirrors it as an
+// ephemeral key of the secondary
+func (c *ReplicatedClient) RegisterEphemeral(key, value string) error {
+	if err := c.Client.RegisterEphemeral(key, value); err != nil {
+		return err
+	}
+	c.setEphemeral(key, true)
+	c.enqueue(mirrorWrite{op: mirrorEphemeral, key: key, value: value})
+	return nil
+}
+
+func (c *ReplicatedClient) setEphemeral(key string, ephemeral bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if ephemeral {
+		c.ephemerals[key] = struct{}{}
+	} else {
+		delete(c.ephemerals, key)
+	}
+}
+
+func (c *ReplicatedClient) isEphemeral(key string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.ephemerals[key]
+	return ok
+}
+
+func (c *ReplicatedClient) enqueue(w mirrorWrite) {
+	select {
+	case c.queue <- w:
+	default:
+		c.dropped.Inc()
+	}
+}
+
+// mirror applies the queued writes to the secondary until the client is closed
+func (c *ReplicatedClient) mirror() {
+	defer c.wg.Done()
+	for {
+		select {
+		case w := <-c.queue:
+			var err error
+			switch w.op {
+			case mirrorPut:
+				err = c.secondary.Put(w.key, w.value)
+			case mirrorDelete:
+				err = c.secondary.Delete(w.key)
+			case mirrorEphemeral:
+				err = c.secondary.RegisterEphemeral(w.key, w.value)
+			}
+			if err != nil {
+				c.failed.Inc()
+			} else {
+				c.mirrored.Inc()
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *ReplicatedClient) reconcileLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.reconcileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_, _ = c.Reconcile(c.reconcilePrefix)
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Reconcile makes the keys with @prefix of the secondary the same as the
+// primary, and returns the number of the keys repaired. The keys written
+// during the reconciliation may be repaired by the next one.
+//
+// The ephemeral keys registered by the client are repaired as ephemeral ones.
+// The other ephemeral keys of the primary are left to the clients registering
+// them if the primary is an EphemeralLister, otherwise they are repaired as
+// persistent ones.
+func (c *ReplicatedClient) Reconcile(prefix string) (int, error) {
+	primary, err := c.Client.List(prefix)
+	if err != nil {
+		return 0, perrors.WithMessagef(err, "reconcile prefix %s: list primary", prefix)
+	}
+	foreign := make(map[string]struct{})
+	if lister, ok := c.Client.(EphemeralLister); ok {
+		keys, err := lister.ListEphemeral(prefix)
+		if err != nil {
+			return 0, perrors.WithMessagef(err, "reconcile prefix %s: list primary ephemeral keys", prefix)
+		}
+		for _, key := range keys {
+			foreign[key] = struct{}{}
+		}
+	}
+	secondary, err := c.secondary.List(prefix)
+	if err != nil {
+		return 0, perrors.WithMessagef(err, "reconcile prefix %s: list secondary", prefix)
+	}
+	values := make(map[string]string, len(secondary))
+	for _, kv := range secondary {
+		values[kv.Key] = kv.Value
+	}
+
+	repaired := 0
+	for _, kv := range primary {
+		value, ok := values[kv.Key]
+		delete(values, kv.Key)
+		if ok && value == kv.Value {
+			continue
+		}
+		if c.isEphemeral(kv.Key) {
+			err = c.secondary.RegisterEphemeral(kv.Key, kv.Value)
+		} else if _, ok = foreign[kv.Key]; ok {
+			// mirrored by the client registering it
+			continue
+		} else {
+			err = c.secondary.Put(kv.Key, kv.Value)
+		}
+		if err != nil {
+			break
+		}
+		repaired++
+	}
+	for key := range values {
+		if err != nil {
+			break
+		}
+		if err = c.secondary.Delete(key); err == nil {
+			repaired++
+		}
+	}
+	c.divergences.Add(int64(repaired))
+	return repaired, perrors.WithMessagef(err, "reconcile prefix %s", prefix)
+}
+
+// Stats returns the statistics of the mirroring
+func (c *ReplicatedClient) Stats() ReplicationStats {
+	return ReplicationStats{
+		Mirrored:    c.mirrored.Load(),
+		Failed:      c.failed.Load(),
+		Dropped:     c.dropped.Load(),
+		Divergences: c.divergences.Load(),
+	}
+}
+
+// Close stops mirroring and closes both clients, the writes left in the queue
+// are dropped
+func (c *ReplicatedClient) Close() {
+	c.once.Do(func() {
+		c.cancel()
+		c.wg.Wait()
+		c.Client.Close()
+		c.secondary.Close()
+	})
+}
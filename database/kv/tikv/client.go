@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxtikv implements the gxkv client over the raw kv api of tikv. It
+// depends on an interface of the raw client instead of the tikv sdk, so the
+// application adapts the rawkv client of the sdk version it uses.
+package gxtikv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	// DefaultTimeout default timeout of a request
+	DefaultTimeout = 3 * time.Second
+	// DefaultScanLimit default number of the pairs of a scan
+	DefaultScanLimit = 256
+	// DefaultPollInterval default interval of polling the prefixes of WatchPrefix
+	DefaultPollInterval = time.Second
+	// DefaultTTL default ttl of the ephemeral keys
+	DefaultTTL = 10 * time.Second
+)
+
+// ErrClientClosed is returned when using a closed client
+var ErrClientClosed = perrors.New("tikv client closed")
+
+// RawClient is the raw kv api of tikv, Get returns nil without error if the key does not exist
+type RawClient interface {
+	Get(ctx context.Context, key []byte) ([]byte, error)
+	Put(ctx context.Context, key, value []byte) error
+	Delete(ctx context.Context, key []byte) error
+	// Scan scans at most @limit pairs in [@startKey, @endKey), @endKey is unbounded if it is empty
+	Scan(ctx context.Context, startKey, endKey []byte, limit int) (keys [][]byte, values [][]byte, err error)
+	Close() error
+}
+
+// TTLRawClient is the raw client supporting ttl, which needs the tikv servers
+// to enable the ttl of the raw kv. The ephemeral keys expire after the client
+// exits without Close if the raw client supports ttl.
+type TTLRawClient interface {
+	RawClient
+	// PutWithTTL puts the pair which expires after @ttl seconds
+	PutWithTTL(ctx context.Context, key, value []byte, ttl uint64) error
+}
+
+// Options of the tikv client
+type Options struct {
+	// Timeout timeout of a request
+	Timeout time.Duration
+	// ScanLimit number of the pairs of a scan
+	ScanLimit int
+	// PollInterval interval of polling the prefixes of WatchPrefix
+	PollInterval time.Duration
+	// TTL ttl of the ephemeral keys if the raw client supports ttl, they are refreshed every TTL/3
+	TTL time.Duration
+}
+
+// Option will define a function of handling Options
+type Option func(*Options)
+
+// WithTimeout sets the timeout of a request
+func WithTimeout(timeout time.Duration) Option {
+	return func(opt *Options) {
+		opt.Timeout = timeout
+	}
+}
+
+// WithScanLimit sets the number of the pairs of a scan
+func WithScanLimit(limit int) Option {
+	return func(opt *Options) {
+		opt.ScanLimit = limit
+	}
+}
+
+// WithPollInterval sets the interval of polling the prefixes of WatchPrefix
+func WithPollInterval(interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.PollInterval = interval
+	}
+}
+
+// WithTTL sets the ttl of the ephemeral keys
+func WithTTL(ttl time.Duration) Option {
+	return func(opt *Options) {
+		opt.TTL = ttl
+	}
+}
+
+// Client is a kv client over the raw kv api of tikv
+type Client struct {
+	raw     RawClient
+	options *Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	lock       sync.Mutex
+	ephemerals map[string]string // ephemeral keys and their values
+	keeping    bool              // whether the goroutine refreshing the ephemeral keys is running
+}
+
+// NewClient creates a client over @raw
+func NewClient(raw RawClient, opts ...Option) *Client {
+	options := &Options{
+		Timeout:      DefaultTimeout,
+		ScanLimit:    DefaultScanLimit,
+		PollInterval: DefaultPollInterval,
+		TTL:          DefaultTTL,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		raw:        raw,
+		options:    options,
+		ctx:        ctx,
+		cancel:     cancel,
+		ephemerals: make(map[string]string),
+	}
+}
+
+// Close deletes the ephemeral keys and closes the raw client
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.lock.Lock()
+		keys := make([]string, 0, len(c.ephemerals))
+		for key := range c.ephemerals {
+			keys = append(keys, key)
+		}
+		c.lock.Unlock()
+		for _, key := range keys {
+			ctx, cancel := c.reqCtx()
+			_ = c.raw.Delete(ctx, []byte(key))
+			cancel()
+		}
+
+		c.cancel()
+		c.wg.Wait()
+		_ = c.raw.Close()
+	})
+}
+
+func (c *Client) closed() bool {
+	return c.ctx.Err() != nil
+}
+
+// reqCtx returns the context of a request within the timeout of the client
+func (c *Client) reqCtx() (context.Context, context.CancelFunc) {
+	if c.options.Timeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, c.options.Timeout)
+}
+
+// sleep waits for @d, it returns false if the client is closed or @ctx is done
+func (c *Client) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxtikv
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+// fakeRawClient is a raw client in memory, fakeTTLRawClient supports ttl over it
+type fakeRawClient struct {
+	lock    sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRawClient() *fakeRawClient {
+	return &fakeRawClient{values: make(map[string]string), expires: make(map[string]time.Time)}
+}
+
+func (f *fakeRawClient) expire() {
+	for key, deadline := range f.expires {
+		if time.Now().After(deadline) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+	}
+}
+
+func (f *fakeRawClient) Get(_ context.Context, key []byte) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.expire()
+	if value, ok := f.values[string(key)]; ok {
+		return []byte(value), nil
+	}
+	return nil, nil
+}
+
+func (f *fakeRawClient) Put(_ context.Context, key, value []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.values[string(key)] = string(value)
+	delete(f.expires, string(key))
+	return nil
+}
+
+func (f *fakeRawClient) Delete(_ context.Context, key []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.values, string(key))
+	delete(f.expires, string(key))
+	return nil
+}
+
+func (f *fakeRawClient) Scan(_ context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.expire()
+	keys := make([]string, 0)
+	for key := range f.values {
+		if bytes.Compare([]byte(key), startKey) >= 0 && (len(endKey) == 0 || bytes.Compare([]byte(key), endKey) < 0) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	ks, vs := make([][]byte, 0, len(keys)), make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		ks = append(ks, []byte(key))
+		vs = append(vs, []byte(f.values[key]))
+	}
+	return ks, vs, nil
+}
+
+func (f *fakeRawClient) Close() error {
+	return nil
+}
+
+type fakeTTLRawClient struct {
+	*fakeRawClient
+}
+
+func (f fakeTTLRawClient) PutWithTTL(_ context.Context, key, value []byte, ttl uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.values[string(key)] = string(value)
+	f.expires[string(key)] = time.Now().Add(time.Duration(ttl) * time.Second)
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	raw := newFakeRawClient()
+	c := NewClient(raw, WithScanLimit(2), WithPollInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "/services/")
+	assert.NoError(t, err)
+
+	for _, key := range []string{"/services/c", "/services/a", "/services/b", "/services0", "/other"} {
+		assert.NoError(t, c.Put(key, key))
+	}
+	received := map[string]gxkv.Event{}
+	for len(received) < 3 {
+		e := <-events
+		received[e.Key] = e
+	}
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/services/b", Value: "/services/b"}, received["/services/b"])
+
+	// the scans are paged by the limit
+	keys, values, err := c.GetChildren("/services/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/services/a", "/services/b", "/services/c"}, keys)
+	assert.Equal(t, keys, values)
+
+	assert.NoError(t, c.Delete("/services/b"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "/services/b", PrevValue: "/services/b"}, <-events)
+	_, err = c.Get("/services/b")
+	assert.Equal(t, gxkv.ErrKeyNotFound, err)
+
+	assert.NoError(t, c.RegisterEphemeral("/services/e", "1"))
+	c.Close()
+	// the ephemeral key is deleted on close
+	v, err := raw.Get(context.Background(), []byte("/services/e"))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestClientTTL(t *testing.T) {
+	raw := fakeTTLRawClient{newFakeRawClient()}
+	c := NewClient(raw, WithTTL(time.Second))
+	assert.NoError(t, c.RegisterEphemeral("/e", "1"))
+
+	// the ttl is refreshed
+	time.Sleep(1500 * time.Millisecond)
+	v, err := c.Get("/e")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", v)
+	c.Close()
+}
+
+func TestNewKVClient(t *testing.T) {
+	SetDialer(nil)
+	_, err := gxkv.NewClient("tikv://pd:2379")
+	assert.Error(t, err)
+
+	raw := newFakeRawClient()
+	var addrs []string
+	SetDialer(func(a []string) (RawClient, error) {
+		addrs = a
+		return raw, nil
+	})
+	defer SetDialer(nil)
+	_, err = gxkv.NewClient("tikv://pd:2379?scan=x")
+	assert.Error(t, err)
+
+	c, err := gxkv.NewClient("tikv://pd1:2379,pd2:2379/base?scan=1")
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, []string{"pd1:2379", "pd2:2379"}, addrs)
+	assert.NoError(t, c.Put("/a", "1"))
+	assert.NoError(t, c.Put("/b", "2"))
+	value, err := raw.Get(context.Background(), []byte("/base/a"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+	kvs, err := c.List("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/a", Value: "1"}, {Key: "/b", Value: "2"}}, kvs)
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("/b"), prefixEnd([]byte("/a")))
+	assert.Equal(t, []byte{'a' + 1}, prefixEnd([]byte{'a', 0xff}))
+	assert.Equal(t, []byte{}, prefixEnd([]byte{0xff}))
+}
@@ -0,0 +1,294 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxtikv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+var _ gxkv.Client = (*Client)(nil)
+
+func init() {
+	gxkv.Register("tikv", newKVClient)
+}
+
+// Dialer creates a raw client of the pd servers @addrs
+type Dialer func(addrs []string) (RawClient, error)
+
+var (
+	dialerLock sync.RWMutex
+	dialer     Dialer
+)
+
+// SetDialer sets the dialer of the raw clients of the "tikv" urls, which adapts
+// the rawkv client of the tikv sdk the application uses, eg:
+//
+//	gxtikv.SetDialer(func(addrs []string) (gxtikv.RawClient, error) {
+//		return rawkv.NewClientWithOpts(context.Background(), addrs)
+//	})
+func SetDialer(d Dialer) {
+	dialerLock.Lock()
+	defer dialerLock.Unlock()
+	dialer = d
+}
+
+// newKVClient creates a client over the raw client of the dialer, eg:
+// "tikv://pd1:2379,pd2:2379/basepath?timeout=3s&scan=256&poll=1s&ttl=10s",
+// the base path prefixes the keys
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	dialerLock.RLock()
+	dial := dialer
+	dialerLock.RUnlock()
+	if dial == nil {
+		return nil, perrors.New("no tikv dialer, set it by gxtikv.SetDialer")
+	}
+	if len(u.Addrs) == 0 {
+		return nil, perrors.New("no tikv pd address")
+	}
+	timeout, err := u.Duration("timeout", DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	scanLimit, err := u.Int("scan", DefaultScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	pollInterval, err := u.Duration("poll", DefaultPollInterval)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := u.Duration("ttl", DefaultTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := dial(u.Addrs)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "dial tikv %v", u.Addrs)
+	}
+	c := NewClient(raw,
+		WithTimeout(timeout),
+		WithScanLimit(scanLimit),
+		WithPollInterval(pollInterval),
+		WithTTL(ttl),
+	)
+	return gxkv.WithPrefix(c, u.BasePath), nil
+}
+
+// Get gets the value of @key
+func (c *Client) Get(key string) (string, error) {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	value, err := c.raw.Get(ctx, []byte(key))
+	if err != nil {
+		return "", perrors.WithMessagef(err, "get key %s", key)
+	}
+	if value == nil {
+		return "", gxkv.ErrKeyNotFound
+	}
+	return string(value), nil
+}
+
+// Put puts @key whether it exists or not
+func (c *Client) Put(key, value string) error {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	return perrors.WithMessagef(c.raw.Put(ctx, []byte(key), []byte(value)), "put key %s", key)
+}
+
+// Delete deletes @key
+func (c *Client) Delete(key string) error {
+	c.lock.Lock()
+	delete(c.ephemerals, key)
+	c.lock.Unlock()
+
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	return perrors.WithMessagef(c.raw.Delete(ctx, []byte(key)), "delete key %s", key)
+}
+
+// List scans the key values with @prefix in key order
+func (c *Client) List(prefix string) ([]gxkv.KeyValue, error) {
+	kvs := make([]gxkv.KeyValue, 0)
+	start, end := []byte(prefix), prefixEnd([]byte(prefix))
+	for {
+		ctx, cancel := c.reqCtx()
+		keys, values, err := c.raw.Scan(ctx, start, end, c.options.ScanLimit)
+		cancel()
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "list prefix %s", prefix)
+		}
+		for i := range keys {
+			kvs = append(kvs, gxkv.KeyValue{Key: string(keys[i]), Value: string(values[i])})
+		}
+		if len(keys) < c.options.ScanLimit {
+			return kvs, nil
+		}
+		// continue after the last key
+		start = append(append([]byte{}, keys[len(keys)-1]...), 0)
+	}
+}
+
+// GetChildren gets the keys and values with @prefix in key order
+func (c *Client) GetChildren(prefix string) ([]string, []string, error) {
+	kvs, err := c.List(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, values := make([]string, 0, len(kvs)), make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		keys = append(keys, kv.Key)
+		values = append(values, kv.Value)
+	}
+	return keys, values, nil
+}
+
+// RegisterEphemeral puts @key which is deleted when the client is closed. If
+// the raw client supports ttl, the key is put with the ttl of the options and
+// refreshed every TTL/3, otherwise the key is left if the client exits
+// without Close.
+func (c *Client) RegisterEphemeral(key, value string) error {
+	if c.closed() {
+		return ErrClientClosed
+	}
+	if err := c.putEphemeral(key, value); err != nil {
+		return perrors.WithMessagef(err, "register ephemeral key %s", key)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ephemerals[key] = value
+	if _, ok := c.raw.(TTLRawClient); ok && !c.keeping {
+		c.keeping = true
+		c.wg.Add(1)
+		go c.keepEphemerals()
+	}
+	return nil
+}
+
+func (c *Client) putEphemeral(key, value string) error {
+	ctx, cancel := c.reqCtx()
+	defer cancel()
+	if raw, ok := c.raw.(TTLRawClient); ok {
+		return raw.PutWithTTL(ctx, []byte(key), []byte(value), uint64((c.options.TTL+time.Second-1)/time.Second))
+	}
+	return c.raw.Put(ctx, []byte(key), []byte(value))
+}
+
+// keepEphemerals puts the ephemeral keys with ttl every TTL/3, it exits when
+// there is no ephemeral key
+func (c *Client) keepEphemerals() {
+	defer c.wg.Done()
+	for c.sleep(c.ctx, c.options.TTL/3) {
+		c.lock.Lock()
+		if len(c.ephemerals) == 0 {
+			c.keeping = false
+			c.lock.Unlock()
+			return
+		}
+		ephemerals := make(map[string]string, len(c.ephemerals))
+		for k, v := range c.ephemerals {
+			ephemerals[k] = v
+		}
+		c.lock.Unlock()
+
+		for key, value := range ephemerals {
+			_ = c.putEphemeral(key, value)
+		}
+	}
+}
+
+// WatchPrefix polls the keys with @prefix every poll interval, since the raw
+// kv api has no watch, and sends their changes to the returned chan until @ctx
+// is done or the client is closed. The events have no revision.
+func (c *Client) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	if c.closed() {
+		return nil, ErrClientClosed
+	}
+	kvs, err := c.List(prefix)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "watch prefix %s", prefix)
+	}
+	known := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		known[kv.Key] = kv.Value
+	}
+
+	out := make(chan gxkv.Event)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(out)
+		for c.sleep(ctx, c.options.PollInterval) {
+			kvs, err := c.List(prefix)
+			if err != nil {
+				continue
+			}
+
+			latest := make(map[string]string, len(kvs))
+			events := make([]gxkv.Event, 0)
+			for _, kv := range kvs {
+				latest[kv.Key] = kv.Value
+				if value, ok := known[kv.Key]; !ok || value != kv.Value {
+					events = append(events, gxkv.Event{Type: gxkv.EventPut, Key: kv.Key, Value: kv.Value, PrevValue: value})
+				}
+			}
+			for key, value := range known {
+				if _, ok := latest[key]; !ok {
+					events = append(events, gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: value})
+				}
+			}
+			known = latest
+
+			for _, event := range events {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				case <-c.ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// prefixEnd returns the end of the range of the keys with @prefix, it is empty
+// if the range is unbounded
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{}
+}
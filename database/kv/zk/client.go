@@ -30,6 +30,11 @@ import (
 	perrors "github.com/pkg/errors"
 )
 
+import (
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+	gxerrors "github.com/dubbogo/gost/errors"
+)
+
 var (
 	// ErrNilZkClientConn no conn error
 	ErrNilZkClientConn = perrors.New("zookeeper Client{conn} is nil")
@@ -37,6 +42,8 @@ var (
 	ErrNilChildren = perrors.Errorf("has none children")
 	// ErrNilNode no node error
 	ErrNilNode = perrors.Errorf("node does not exist")
+	// ErrZkClientOptionsMismatch is returned when the shared client has been created with different options
+	ErrZkClientOptionsMismatch = perrors.New("zookeeper shared client options mismatch")
 )
 
 var (
@@ -60,6 +67,9 @@ type ZookeeperClient struct {
 	eventRegistryLock sync.RWMutex
 	zkEventHandler    ZkEventHandler
 	Session           <-chan zk.Event
+	compressor        gxcompress.Codec
+	compressThreshold int
+	chroot            string
 }
 
 type zookeeperClientPool struct {
@@ -109,18 +119,10 @@ func initZookeeperClientPool() {
 	zkClientPool.zkClient = make(map[string]*ZookeeperClient)
 }
 
-// NewZookeeperClient will create a ZookeeperClient
+// NewZookeeperClient will create a ZookeeperClient. The shared clients are
+// keyed by @name and chroot, and ErrZkClientOptionsMismatch is returned if the
+// shared client has been created with another compression.
 func NewZookeeperClient(name string, zkAddrs []string, share bool, opts ...zkClientOption) (*ZookeeperClient, error) {
-	if share {
-		clientPoolOnce.Do(initZookeeperClientPool)
-		zkClientPool.Lock()
-		defer zkClientPool.Unlock()
-		if zkClient, ok := zkClientPool.zkClient[name]; ok {
-			zkClient.activeNumber++
-			return zkClient, nil
-		}
-
-	}
 	newZkClient := &ZookeeperClient{
 		name:           name,
 		ZkAddrs:        zkAddrs,
@@ -134,17 +136,47 @@ func NewZookeeperClient(name string, zkAddrs []string, share bool, opts ...zkCli
 	for _, opt := range opts {
 		opt(newZkClient)
 	}
+
+	if share {
+		clientPoolOnce.Do(initZookeeperClientPool)
+		zkClientPool.Lock()
+		defer zkClientPool.Unlock()
+		if zkClient, ok := zkClientPool.zkClient[newZkClient.poolKey()]; ok {
+			if !zkClient.sameCompression(newZkClient) {
+				return nil, perrors.WithMessagef(ErrZkClientOptionsMismatch, "zk client %s", name)
+			}
+			zkClient.activeNumber++
+			return zkClient, nil
+		}
+	}
+
 	err := newZkClient.createZookeeperConn()
 	if err != nil {
 		return nil, err
 	}
 	newZkClient.activeNumber++
 	if share {
-		zkClientPool.zkClient[name] = newZkClient
+		zkClientPool.zkClient[newZkClient.poolKey()] = newZkClient
 	}
 	return newZkClient, nil
 }
 
+// poolKey returns the key of the shared client
+func (z *ZookeeperClient) poolKey() string {
+	return z.name + "@" + z.chroot
+}
+
+// sameCompression checks whether @other compresses the values in the same way
+func (z *ZookeeperClient) sameCompression(other *ZookeeperClient) bool {
+	if (z.compressor == nil) != (other.compressor == nil) {
+		return false
+	}
+	if z.compressor == nil {
+		return true
+	}
+	return z.compressor.ID() == other.compressor.ID() && z.compressThreshold == other.compressThreshold
+}
+
 // nolint
 func (z *ZookeeperClient) createZookeeperConn() error {
 	var err error
@@ -259,6 +291,7 @@ func (z *ZookeeperClient) RegisterEvent(zkPath string, event *chan struct{}) {
 		return
 	}
 
+	zkPath = z.realPath(zkPath)
 	z.eventRegistryLock.Lock()
 	defer z.eventRegistryLock.Unlock()
 	a := z.eventRegistry[zkPath]
@@ -272,6 +305,7 @@ func (z *ZookeeperClient) UnregisterEvent(zkPath string, event *chan struct{}) {
 		return
 	}
 
+	zkPath = z.realPath(zkPath)
 	z.eventRegistryLock.Lock()
 	defer z.eventRegistryLock.Unlock()
 	infoList, ok := z.eventRegistry[zkPath]
@@ -318,7 +352,10 @@ func (z *ZookeeperClient) CreateWithValue(basePath string, value []byte) error {
 	if conn == nil {
 		return perrors.WithMessagef(err, "zk.Create(path:%s)", basePath)
 	}
-	for _, str := range strings.Split(basePath, "/")[1:] {
+	if value, err = z.encodeValue(value); err != nil {
+		return perrors.WithMessagef(err, "zk.Create(path:%s)", basePath)
+	}
+	for _, str := range strings.Split(z.realPath(basePath), "/")[1:] {
 		tmpPath = path.Join(tmpPath, "/", str)
 		_, err = conn.Create(tmpPath, value, 0, zk.WorldACL(zk.PermAll))
 
@@ -346,8 +383,11 @@ func (z *ZookeeperClient) CreateTempWithValue(basePath string, value []byte) err
 	if conn == nil {
 		return perrors.WithMessagef(err, "zk.Create(path:%s)", basePath)
 	}
+	if value, err = z.encodeValue(value); err != nil {
+		return perrors.WithMessagef(err, "zk.Create(path:%s)", basePath)
+	}
 
-	pathSlice := strings.Split(basePath, "/")[1:]
+	pathSlice := strings.Split(z.realPath(basePath), "/")[1:]
 	length := len(pathSlice)
 	for i, str := range pathSlice {
 		tmpPath = path.Join(tmpPath, "/", str)
@@ -375,11 +415,21 @@ func (z *ZookeeperClient) Delete(basePath string) error {
 	err := ErrNilZkClientConn
 	conn := z.getConn()
 	if conn != nil {
-		err = conn.Delete(basePath, -1)
+		err = conn.Delete(z.realPath(basePath), -1)
 	}
 	return perrors.WithMessagef(err, "Delete(basePath:%s)", basePath)
 }
 
+// DeleteNodes deletes @basePaths one by one. The others are still deleted if some of them fail,
+// and the returned *gxerrors.MultiError holds the errors and the number of deleted paths.
+func (z *ZookeeperClient) DeleteNodes(basePaths ...string) error {
+	errs := gxerrors.NewMultiError()
+	for _, basePath := range basePaths {
+		errs.Add(z.Delete(basePath))
+	}
+	return errs.ErrorOrNil()
+}
+
 // RegisterTemp registers temporary node by @basePath and @node
 func (z *ZookeeperClient) RegisterTemp(basePath string, node string) (string, error) {
 	var (
@@ -392,14 +442,14 @@ func (z *ZookeeperClient) RegisterTemp(basePath string, node string) (string, er
 	zkPath = path.Join(basePath) + "/" + node
 	conn := z.getConn()
 	if conn != nil {
-		tmpPath, err = conn.Create(zkPath, []byte(""), zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+		tmpPath, err = conn.Create(z.realPath(zkPath), []byte(""), zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
 	}
 
 	if err != nil {
 		return zkPath, perrors.WithStack(err)
 	}
 
-	return tmpPath, nil
+	return z.userPath(tmpPath), nil
 }
 
 // RegisterTempSeq register temporary sequence node by @basePath and @data
@@ -409,11 +459,15 @@ func (z *ZookeeperClient) RegisterTempSeq(basePath string, data []byte) (string,
 		tmpPath string
 	)
 
+	if data, err = z.encodeValue(data); err != nil {
+		return "", perrors.WithStack(err)
+	}
+
 	err = ErrNilZkClientConn
 	conn := z.getConn()
 	if conn != nil {
 		tmpPath, err = conn.Create(
-			path.Join(basePath)+"/",
+			path.Join(z.realPath(basePath))+"/",
 			data,
 			zk.FlagEphemeral|zk.FlagSequence,
 			zk.WorldACL(zk.PermAll),
@@ -423,7 +477,7 @@ func (z *ZookeeperClient) RegisterTempSeq(basePath string, data []byte) (string,
 	if err != nil && err != zk.ErrNodeExists {
 		return "", perrors.WithStack(err)
 	}
-	return tmpPath, nil
+	return z.userPath(tmpPath), nil
 }
 
 // GetChildrenW gets children watch by @path
@@ -438,7 +492,7 @@ func (z *ZookeeperClient) GetChildrenW(path string) ([]string, <-chan zk.Event,
 	err = ErrNilZkClientConn
 	conn := z.getConn()
 	if conn != nil {
-		children, stat, watcher, err = conn.ChildrenW(path)
+		children, stat, watcher, err = conn.ChildrenW(z.realPath(path))
 	}
 
 	if err != nil {
@@ -457,7 +511,7 @@ func (z *ZookeeperClient) GetChildrenW(path string) ([]string, <-chan zk.Event,
 		return nil, nil, ErrNilChildren
 	}
 
-	return children, watcher.EvtCh, nil
+	return children, z.userEvents(watcher.EvtCh), nil
 }
 
 // GetChildren gets children by @path
@@ -471,7 +525,7 @@ func (z *ZookeeperClient) GetChildren(path string) ([]string, error) {
 	err = ErrNilZkClientConn
 	conn := z.getConn()
 	if conn != nil {
-		children, stat, err = conn.Children(path)
+		children, stat, err = conn.Children(z.realPath(path))
 	}
 
 	if err != nil {
@@ -501,7 +555,7 @@ func (z *ZookeeperClient) ExistW(zkPath string) (<-chan zk.Event, error) {
 	err = ErrNilZkClientConn
 	conn := z.getConn()
 	if conn != nil {
-		exist, _, watcher, err = conn.ExistsW(zkPath)
+		exist, _, watcher, err = conn.ExistsW(z.realPath(zkPath))
 	}
 
 	if err != nil {
@@ -511,17 +565,73 @@ func (z *ZookeeperClient) ExistW(zkPath string) (<-chan zk.Event, error) {
 		return nil, perrors.Errorf("zkClient{%s} App zk path{%s} does not exist.", z.name, zkPath)
 	}
 
-	return watcher.EvtCh, nil
+	return z.userEvents(watcher.EvtCh), nil
 }
 
 // GetContent gets content by @zkPath
 func (z *ZookeeperClient) GetContent(zkPath string) ([]byte, *zk.Stat, error) {
-	return z.Conn.Get(zkPath)
+	content, stat, err := z.Conn.Get(z.realPath(zkPath))
+	if err != nil || z.compressor == nil {
+		return content, stat, err
+	}
+	content, err = gxcompress.Decode(content)
+	return content, stat, err
 }
 
 // SetContent set content of zkPath
 func (z *ZookeeperClient) SetContent(zkPath string, content []byte, version int32) (*zk.Stat, error) {
-	return z.Conn.Set(zkPath, content, version)
+	content, err := z.encodeValue(content)
+	if err != nil {
+		return nil, err
+	}
+	return z.Conn.Set(z.realPath(zkPath), content, version)
+}
+
+// realPath returns the path in zookeeper of @zkPath, which is rooted under the chroot
+func (z *ZookeeperClient) realPath(zkPath string) string {
+	if z.chroot == "" {
+		return zkPath
+	}
+	return path.Join(z.chroot, zkPath)
+}
+
+// userPath strips the chroot from the path @zkPath in zookeeper
+func (z *ZookeeperClient) userPath(zkPath string) string {
+	if z.chroot == "" || (zkPath != z.chroot && !strings.HasPrefix(zkPath, z.chroot+"/")) {
+		return zkPath
+	}
+	if zkPath = strings.TrimPrefix(zkPath, z.chroot); zkPath == "" {
+		return "/"
+	}
+	return zkPath
+}
+
+// userEvents strips the chroot from the paths of the events of watch chan @ch
+func (z *ZookeeperClient) userEvents(ch <-chan zk.Event) <-chan zk.Event {
+	if z.chroot == "" {
+		return ch
+	}
+
+	// the watch chan of zookeeper delivers only one event and then it is closed
+	out := make(chan zk.Event, 1)
+	go func() {
+		defer close(out)
+		for event := range ch {
+			event.Path = z.userPath(event.Path)
+			out <- event
+		}
+	}()
+	return out
+}
+
+// GetChroot gets the chroot of zookeeper Client
+func (z *ZookeeperClient) GetChroot() string {
+	return z.chroot
+}
+
+// encodeValue compresses @value if the client has a compressor
+func (z *ZookeeperClient) encodeValue(value []byte) ([]byte, error) {
+	return gxcompress.Encode(z.compressor, z.compressThreshold, value)
 }
 
 // getConn gets zookeeper connection safely
@@ -551,7 +661,7 @@ func (z *ZookeeperClient) Close() {
 		z.activeNumber--
 		if z.activeNumber == 0 {
 			z.Conn.Close()
-			delete(zkClientPool.zkClient, z.name)
+			delete(zkClientPool.zkClient, z.poolKey())
 		}
 	} else {
 		z.Lock()
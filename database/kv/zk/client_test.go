@@ -18,7 +18,10 @@
 package gxzookeeper
 
 import (
+	"compress/gzip"
+	"errors"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,6 +31,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+import (
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+	gxerrors "github.com/dubbogo/gost/errors"
+)
+
 func verifyEventStateOrder(t *testing.T, c <-chan zk.Event, expectedStates []zk.State, source string) {
 	for _, state := range expectedStates {
 		for {
@@ -73,10 +81,22 @@ func Test_getZookeeperClient(t *testing.T) {
 	if client3 == client4 {
 		t.Fatalf("NewZookeeperClient failed")
 	}
+
+	// the shared clients of different chroots are different
+	client5, err := NewZookeeperClient("test1", address, true, WithZkTimeOut(3*time.Second), WithChroot("/env"))
+	assert.Nil(t, err)
+	assert.True(t, client5 != client1)
+	assert.Equal(t, "/env", client5.GetChroot())
+	// the shared client can not be reused with another compression
+	_, err = NewZookeeperClient("test1", address, true, WithZkTimeOut(3*time.Second),
+		WithZkCompression(gxcompress.NewSnappyCodec(), 1024))
+	assert.True(t, errors.Is(err, ErrZkClientOptionsMismatch))
+
 	client1.Close()
 	client2.Close()
 	client3.Close()
 	client4.Close()
+	client5.Close()
 	tc.Stop()
 }
 
@@ -170,6 +190,30 @@ func TestCreateDelete(t *testing.T) {
 	// verifyEventOrder(t, event, []zk.EventType{zk.EventNodeCreated}, "event channel")
 }
 
+func TestDeleteNodes(t *testing.T) {
+	ts, z, event, err := NewMockZookeeperClient("test", 15*time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		_ = ts.Stop()
+	}()
+
+	states := []zk.State{zk.StateConnecting, zk.StateConnected, zk.StateHasSession}
+	verifyEventStateOrder(t, event, states, "event channel")
+	assert.NoError(t, z.Create("/test1/a"))
+	assert.NoError(t, z.Create("/test1/b"))
+
+	err = z.DeleteNodes("/test1/a", "/test1/missing", "/test1/b")
+	me, ok := err.(*gxerrors.MultiError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, me.Len())
+	assert.True(t, errors.Is(err, zk.ErrNoNode))
+	succeed, total := me.Succeed()
+	assert.Equal(t, 2, succeed)
+	assert.Equal(t, 3, total)
+
+	assert.NoError(t, z.DeleteNodes("/test1"))
+}
+
 func TestRegisterTemp(t *testing.T) {
 	ts, z, event, err := NewMockZookeeperClient("test", 15*time.Second)
 	assert.NoError(t, err)
@@ -203,6 +247,101 @@ func TestRegisterTempSeq(t *testing.T) {
 	verifyEventStateOrder(t, event, states, "event channel")
 }
 
+func TestCompressContent(t *testing.T) {
+	ts, z, event, err := NewMockZookeeperClient("test", 15*time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		_ = ts.Stop()
+		assert.Nil(t, err)
+	}()
+	states := []zk.State{zk.StateConnecting, zk.StateConnected, zk.StateHasSession}
+	verifyEventStateOrder(t, event, states, "event channel")
+
+	err = z.CreateWithValue("/test1/test2", []byte("plain"))
+	assert.NoError(t, err)
+
+	// binary values which look like compressed are kept as is without compression
+	binary := []byte{0x00, 'G', 'X', 0x01, 0xff}
+	_, err = z.SetContent("/test1/test2", binary, -1)
+	assert.NoError(t, err)
+	content, _, err := z.GetContent("/test1/test2")
+	assert.NoError(t, err)
+	assert.Equal(t, binary, content)
+
+	WithZkCompression(gxcompress.NewGzipCodec(gzip.BestSpeed), 16)(z)
+	value := []byte(strings.Repeat("dubbo-go", 64))
+	_, err = z.SetContent("/test1/test2", value, -1)
+	assert.NoError(t, err)
+
+	raw, _, err := z.Conn.Get("/test1/test2")
+	assert.NoError(t, err)
+	assert.True(t, gxcompress.IsCompressed(raw))
+	assert.True(t, len(raw) < len(value))
+
+	content, _, err = z.GetContent("/test1/test2")
+	assert.NoError(t, err)
+	assert.Equal(t, value, content)
+}
+
+func TestChroot(t *testing.T) {
+	ts, z, event, err := NewMockZookeeperClient("test", 15*time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		_ = ts.Stop()
+		assert.Nil(t, err)
+	}()
+	states := []zk.State{zk.StateConnecting, zk.StateConnected, zk.StateHasSession}
+	verifyEventStateOrder(t, event, states, "event channel")
+
+	WithChroot("dev/")(z)
+	assert.Equal(t, "/dev", z.GetChroot())
+
+	err = z.CreateWithValue("/test1/test2", []byte("hello"))
+	assert.NoError(t, err)
+	tmpPath, err := z.RegisterTemp("/test1/test2", "test3")
+	assert.NoError(t, err)
+	assert.Equal(t, "/test1/test2/test3", tmpPath)
+
+	children, err := z.GetChildren("/test1/test2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test3"}, children)
+	content, _, err := z.GetContent("/test1/test2")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), content)
+
+	// the paths of the watch events are stripped as well
+	_, childEvents, err := z.GetChildrenW("/test1/test2")
+	assert.NoError(t, err)
+	existEvents, err := z.ExistW("/test1/test2")
+	assert.NoError(t, err)
+	_, err = z.RegisterTemp("/test1/test2", "test4")
+	assert.NoError(t, err)
+	select {
+	case e := <-childEvents:
+		assert.Equal(t, "/test1/test2", e.Path)
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the children event of /test1/test2")
+	}
+	_, err = z.SetContent("/test1/test2", []byte("world"), -1)
+	assert.NoError(t, err)
+	select {
+	case e := <-existEvents:
+		assert.Equal(t, "/test1/test2", e.Path)
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect the data event of /test1/test2")
+	}
+
+	exist, _, err := z.Conn.Exists("/dev/test1/test2/test3")
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	exist, _, err = z.Conn.Exists("/test1")
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	err = z.Delete("/test1/test2/test3")
+	assert.NoError(t, err)
+}
+
 func Test_UnregisterEvent(t *testing.T) {
 	client := &ZookeeperClient{}
 	client.eventRegistry = make(map[string][]*chan struct{})
@@ -211,3 +350,12 @@ func Test_UnregisterEvent(t *testing.T) {
 	client.eventRegistry["test"] = array
 	client.UnregisterEvent("test", new(chan struct{}))
 }
+
+func TestUserPath(t *testing.T) {
+	client := &ZookeeperClient{}
+	WithChroot("/a")(client)
+	assert.Equal(t, "/", client.userPath("/a"))
+	assert.Equal(t, "/b", client.userPath("/a/b"))
+	assert.Equal(t, "/ab/c", client.userPath("/ab/c"))
+	assert.Equal(t, "/c", client.userPath("/c"))
+}
@@ -0,0 +1,376 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxzookeeper
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/go-zookeeper/zk"
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+)
+
+// watchRetryInterval interval of listing again when a watch of WatchPrefix fails
+const watchRetryInterval = time.Second
+
+var (
+	_ gxkv.Client          = (*ZookeeperClient)(nil)
+	_ gxkv.EphemeralLister = (*ZookeeperClient)(nil)
+)
+
+func init() {
+	gxkv.Register("zookeeper", newKVClient)
+	gxkv.Register("zk", newKVClient)
+}
+
+// newKVClient creates a client by @u, eg: "zookeeper://host1:2181,host2:2181/chroot?timeout=15s"
+func newKVClient(u *gxkv.URL) (gxkv.Client, error) {
+	timeout, err := u.Duration("timeout", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return NewZookeeperClient(u.String("name", "kv"), u.Addrs, false, WithZkTimeOut(timeout), WithChroot(u.BasePath))
+}
+
+// decodeValue decompresses @value if the client has a compressor
+func (z *ZookeeperClient) decodeValue(value []byte) ([]byte, error) {
+	if z.compressor == nil {
+		return value, nil
+	}
+	return gxcompress.Decode(value)
+}
+
+// Get gets the value of the node @key, gxkv.ErrKeyNotFound is returned if it does not exist
+func (z *ZookeeperClient) Get(key string) (string, error) {
+	conn := z.getConn()
+	if conn == nil {
+		return "", perrors.WithMessagef(ErrNilZkClientConn, "zk.Get(path:%s)", key)
+	}
+	content, _, err := conn.Get(z.realPath(key))
+	if err == zk.ErrNoNode {
+		err = gxkv.ErrKeyNotFound
+	}
+	if err == nil {
+		content, err = z.decodeValue(content)
+	}
+	if err != nil {
+		return "", perrors.WithMessagef(err, "zk.Get(path:%s)", key)
+	}
+	return string(content), nil
+}
+
+// Put sets the value of the node @key, which is created with its parents if it does not exist
+func (z *ZookeeperClient) Put(key, value string) error {
+	err := z.put(key, value, 0)
+	return perrors.WithMessagef(err, "zk.Put(path:%s)", key)
+}
+
+// RegisterEphemeral creates the ephemeral node @key with its parents, it is deleted
+// when the session of the client is closed or expired
+func (z *ZookeeperClient) RegisterEphemeral(key, value string) error {
+	err := z.put(key, value, zk.FlagEphemeral)
+	return perrors.WithMessagef(err, "zk.RegisterEphemeral(path:%s)", key)
+}
+
+func (z *ZookeeperClient) put(key, value string, flags int32) error {
+	conn := z.getConn()
+	if conn == nil {
+		return ErrNilZkClientConn
+	}
+	data, err := z.encodeValue([]byte(value))
+	if err != nil {
+		return err
+	}
+
+	realPath := z.realPath(key)
+	if flags == 0 {
+		if _, err = conn.Set(realPath, data, -1); err != zk.ErrNoNode {
+			return err
+		}
+	}
+	if parent := path.Dir(key); parent != "/" && parent != "." {
+		if err = z.Create(parent); err != nil {
+			return err
+		}
+	}
+	_, err = conn.Create(realPath, data, flags, zk.WorldACL(zk.PermAll))
+	if err == zk.ErrNodeExists {
+		// created meanwhile, or the ephemeral node is registered again
+		_, err = conn.Set(realPath, data, -1)
+	}
+	return err
+}
+
+// List lists the children of the node @prefix with their values in key order, it
+// is empty if the node does not exist
+func (z *ZookeeperClient) List(prefix string) ([]gxkv.KeyValue, error) {
+	prefix = prefixNode(prefix)
+	conn := z.getConn()
+	if conn == nil {
+		return nil, perrors.WithMessagef(ErrNilZkClientConn, "zk.List(path:%s)", prefix)
+	}
+	children, _, err := conn.Children(z.realPath(prefix))
+	if err == zk.ErrNoNode {
+		return []gxkv.KeyValue{}, nil
+	}
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "zk.List(path:%s)", prefix)
+	}
+
+	sort.Strings(children)
+	kvs := make([]gxkv.KeyValue, 0, len(children))
+	for _, child := range children {
+		key := path.Join(prefix, child)
+		value, err := z.Get(key)
+		if perrors.Cause(err) == gxkv.ErrKeyNotFound {
+			// deleted meanwhile
+			continue
+		}
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "zk.List(path:%s)", prefix)
+		}
+		kvs = append(kvs, gxkv.KeyValue{Key: key, Value: value})
+	}
+	return kvs, nil
+}
+
+// ListEphemeral lists the ephemeral children of the node @prefix in key order
+func (z *ZookeeperClient) ListEphemeral(prefix string) ([]string, error) {
+	prefix = prefixNode(prefix)
+	conn := z.getConn()
+	if conn == nil {
+		return nil, perrors.WithMessagef(ErrNilZkClientConn, "zk.ListEphemeral(path:%s)", prefix)
+	}
+	children, _, err := conn.Children(z.realPath(prefix))
+	if err == zk.ErrNoNode {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "zk.ListEphemeral(path:%s)", prefix)
+	}
+
+	sort.Strings(children)
+	keys := make([]string, 0)
+	for _, child := range children {
+		key := path.Join(prefix, child)
+		exist, stat, err := conn.Exists(z.realPath(key))
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "zk.ListEphemeral(path:%s)", prefix)
+		}
+		if exist && stat.EphemeralOwner != 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// WatchPrefix sends the changes of the children of the node @prefix to the returned
+// chan, which is closed when @ctx is done. The one-shot watches of zookeeper are
+// registered again after they fire or the session is recovered.
+func (z *ZookeeperClient) WatchPrefix(ctx context.Context, prefix string) (<-chan gxkv.Event, error) {
+	prefix = prefixNode(prefix)
+	if z.getConn() == nil {
+		return nil, perrors.WithMessagef(ErrNilZkClientConn, "zk.WatchPrefix(path:%s)", prefix)
+	}
+
+	w := &prefixWatcher{
+		client:  z,
+		prefix:  prefix,
+		ctx:     ctx,
+		out:     make(chan gxkv.Event),
+		fired:   make(chan string),
+		known:   make(map[string]node),
+		watched: make(map[string]struct{}),
+	}
+	go w.loop()
+	return w.out, nil
+}
+
+// prefixNode returns the node of @prefix without the trailing "/", eg: the node
+// of "/services/" is "/services"
+func prefixNode(prefix string) string {
+	if len(prefix) > 1 {
+		return strings.TrimSuffix(prefix, "/")
+	}
+	return prefix
+}
+
+// node is the value of a child and the zxid of its last modification
+type node struct {
+	value string
+	mzxid int64
+}
+
+// prefixWatcher watches the children of a node and their values
+type prefixWatcher struct {
+	client  *ZookeeperClient
+	prefix  string
+	ctx     context.Context
+	out     chan gxkv.Event
+	fired   chan string         // the child whose watch fires, empty for the watch of the node
+	known   map[string]node     // child key -> node
+	watched map[string]struct{} // children whose data watches are pending, empty for the node
+}
+
+func (w *prefixWatcher) loop() {
+	defer close(w.out)
+
+	for first := true; ; first = false {
+		// get the reconnect chan before listing, so a recovery during listing is not missed
+		reconnect := w.client.Reconnect()
+
+		var retry <-chan time.Time
+		current, err := w.list()
+		if err == nil {
+			if !first && !w.diff(current) {
+				return
+			}
+			w.known = current
+		} else {
+			retry = time.After(watchRetryInterval)
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case child := <-w.fired:
+			delete(w.watched, child)
+		case <-retry:
+		case <-reconnect:
+			// the watches may be lost with the session
+			w.watched = make(map[string]struct{})
+		}
+	}
+}
+
+// list lists the children with their values, and sets the watches of the node and
+// the children which are not watched
+func (w *prefixWatcher) list() (map[string]node, error) {
+	conn := w.client.getConn()
+	if conn == nil {
+		return nil, ErrNilZkClientConn
+	}
+
+	var (
+		realPath = w.client.realPath(w.prefix)
+		children []string
+		watcher  *zk.Watcher
+		err      error
+	)
+	if _, ok := w.watched[""]; ok {
+		children, _, err = conn.Children(realPath)
+	} else if children, _, watcher, err = conn.ChildrenW(realPath); err == zk.ErrNoNode {
+		// wait for the creation of the node
+		var exist bool
+		if exist, _, watcher, err = conn.ExistsW(realPath); err == nil && exist {
+			// created meanwhile
+			return w.list()
+		}
+	}
+	if watcher != nil {
+		w.watched[""] = struct{}{}
+		w.forward(watcher.EvtCh, "")
+	}
+	if err != nil && err != zk.ErrNoNode {
+		return nil, err
+	}
+
+	current := make(map[string]node, len(children))
+	for _, child := range children {
+		var (
+			key     = path.Join(w.prefix, child)
+			content []byte
+			stat    *zk.Stat
+		)
+		if _, ok := w.watched[child]; ok {
+			content, stat, err = conn.Get(w.client.realPath(key))
+		} else {
+			content, stat, watcher, err = conn.GetW(w.client.realPath(key))
+			if err == nil {
+				w.watched[child] = struct{}{}
+				w.forward(watcher.EvtCh, child)
+			}
+		}
+		if err == zk.ErrNoNode {
+			// deleted meanwhile, the watch of the node fires
+			continue
+		}
+		if err == nil {
+			content, err = w.client.decodeValue(content)
+		}
+		if err != nil {
+			return nil, err
+		}
+		current[key] = node{value: string(content), mzxid: stat.Mzxid}
+	}
+	return current, nil
+}
+
+// forward notifies the loop with @child when the watch chan @ch fires
+func (w *prefixWatcher) forward(ch <-chan zk.Event, child string) {
+	go func() {
+		select {
+		case <-ch:
+		case <-w.ctx.Done():
+			return
+		}
+		select {
+		case w.fired <- child:
+		case <-w.ctx.Done():
+		}
+	}()
+}
+
+// diff sends the events from the known children to @current, a child is changed if it
+// is modified since listed, even with the same value. It returns false if ctx is done.
+func (w *prefixWatcher) diff(current map[string]node) bool {
+	var events []gxkv.Event
+	for key, n := range current {
+		if old, ok := w.known[key]; !ok || old.mzxid != n.mzxid || old.value != n.value {
+			events = append(events, gxkv.Event{Type: gxkv.EventPut, Key: key, Value: n.value, PrevValue: old.value, Revision: n.mzxid})
+		}
+	}
+	for key, old := range w.known {
+		if _, ok := current[key]; !ok {
+			// zookeeper does not tell the zxid of a deletion
+			events = append(events, gxkv.Event{Type: gxkv.EventDelete, Key: key, PrevValue: old.value})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Key < events[j].Key
+	})
+
+	for _, e := range events {
+		select {
+		case w.out <- e:
+		case <-w.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
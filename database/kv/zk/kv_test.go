@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxzookeeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxkv "github.com/dubbogo/gost/database/kv"
+)
+
+func TestKVClient(t *testing.T) {
+	ts, z, _, err := NewMockZookeeperClient("test", 15*time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		_ = ts.Stop()
+	}()
+	var c gxkv.Client = z
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchPrefix(ctx, "/kv/services")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Put("/kv/services/b", "2"))
+	e := <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/kv/services/b", Value: "2", Revision: e.Revision}, e)
+	assert.NoError(t, c.RegisterEphemeral("/kv/services/a", "1"))
+	prev := e.Revision
+	e = <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/kv/services/a", Value: "1", Revision: e.Revision}, e)
+	assert.True(t, e.Revision > prev)
+	assert.NoError(t, c.Put("/kv/services/b", "3"))
+	e = <-events
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventPut, Key: "/kv/services/b", Value: "3", PrevValue: "2", Revision: e.Revision}, e)
+	assert.True(t, e.Revision > 0)
+
+	v, err := c.Get("/kv/services/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", v)
+	kvs, err := c.List("/kv/services")
+	assert.NoError(t, err)
+	assert.Equal(t, []gxkv.KeyValue{{Key: "/kv/services/a", Value: "1"}, {Key: "/kv/services/b", Value: "3"}}, kvs)
+
+	assert.NoError(t, c.Delete("/kv/services/b"))
+	assert.Equal(t, gxkv.Event{Type: gxkv.EventDelete, Key: "/kv/services/b", PrevValue: "3"}, <-events)
+	_, err = c.Get("/kv/services/b")
+	assert.Equal(t, gxkv.ErrKeyNotFound, perrors.Cause(err))
+	kvs, err = c.List("/kv/none")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(kvs))
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestPrefixWatcherDiff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &prefixWatcher{
+		prefix: "/kv/services",
+		ctx:    ctx,
+		out:    make(chan gxkv.Event, 8),
+		known: map[string]node{
+			"/kv/services/a": {value: "1", mzxid: 10},
+			"/kv/services/b": {value: "2", mzxid: 11},
+			"/kv/services/c": {value: "3", mzxid: 12},
+			"/kv/services/d": {value: "4", mzxid: 13},
+		},
+	}
+
+	current := map[string]node{
+		"/kv/services/a": {value: "1", mzxid: 10}, // unchanged
+		"/kv/services/b": {value: "2", mzxid: 20}, // set to the same value
+		"/kv/services/c": {value: "5", mzxid: 21}, // updated
+		"/kv/services/e": {value: "6", mzxid: 22}, // created
+	}
+	assert.True(t, w.diff(current))
+	close(w.out)
+
+	var events []gxkv.Event
+	for e := range w.out {
+		events = append(events, e)
+	}
+	assert.Equal(t, []gxkv.Event{
+		{Type: gxkv.EventPut, Key: "/kv/services/b", Value: "2", PrevValue: "2", Revision: 20},
+		{Type: gxkv.EventPut, Key: "/kv/services/c", Value: "5", PrevValue: "3", Revision: 21},
+		{Type: gxkv.EventDelete, Key: "/kv/services/d", PrevValue: "4"},
+		{Type: gxkv.EventPut, Key: "/kv/services/e", Value: "6", Revision: 22},
+	}, events)
+
+	// the diff stops when ctx is done
+	w.out = make(chan gxkv.Event)
+	cancel()
+	assert.False(t, w.diff(map[string]node{}))
+}
+
+func TestPrefixNode(t *testing.T) {
+	assert.Equal(t, "/kv/services", prefixNode("/kv/services/"))
+	assert.Equal(t, "/kv/services", prefixNode("/kv/services"))
+	assert.Equal(t, "/", prefixNode("/"))
+}
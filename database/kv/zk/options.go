@@ -18,6 +18,7 @@
 package gxzookeeper
 
 import (
+	"path"
 	"time"
 )
 
@@ -25,6 +26,10 @@ import (
 	"github.com/dubbogo/go-zookeeper/zk"
 )
 
+import (
+	gxcompress "github.com/dubbogo/gost/encoding/compress"
+)
+
 // nolint
 type options struct {
 	ZkName string
@@ -57,3 +62,25 @@ func WithZkTimeOut(t time.Duration) zkClientOption {
 		opt.Timeout = t
 	}
 }
+
+// WithZkCompression lets zk Client compress the node values not shorter than
+// @threshold by @codec. Compressed values are detected by the magic header and
+// decompressed by GetContent. Clients without this option never decompress values.
+func WithZkCompression(codec gxcompress.Codec, threshold int) zkClientOption {
+	return func(opt *ZookeeperClient) {
+		opt.compressor = codec
+		opt.compressThreshold = threshold
+	}
+}
+
+// WithChroot roots all the paths used by zk Client under @prefix, so that
+// multiple environments can share a zookeeper ensemble.
+func WithChroot(prefix string) zkClientOption {
+	return func(opt *ZookeeperClient) {
+		prefix = path.Clean("/" + prefix)
+		if prefix == "/" {
+			prefix = ""
+		}
+		opt.chroot = prefix
+	}
+}
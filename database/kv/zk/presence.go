@@ -0,0 +1,259 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxzookeeper
+
+import (
+	"log"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/go-zookeeper/zk"
+	perrors "github.com/pkg/errors"
+)
+
+const defaultPresenceRetryInterval = time.Second
+
+// ErrPresenceConflict means the id is registered by another live session
+var ErrPresenceConflict = perrors.New("presence id is registered by another session")
+
+// PresenceHandler is called when a member joins or leaves
+type PresenceHandler func(member string)
+
+// PresenceOption will define a function of handling PresenceTracker
+type PresenceOption func(*PresenceTracker)
+
+// WithJoinHandler sets the handler called when a member joins
+func WithJoinHandler(handler PresenceHandler) PresenceOption {
+	return func(p *PresenceTracker) {
+		p.onJoin = handler
+	}
+}
+
+// WithLeaveHandler sets the handler called when a member leaves
+func WithLeaveHandler(handler PresenceHandler) PresenceOption {
+	return func(p *PresenceTracker) {
+		p.onLeave = handler
+	}
+}
+
+// WithPresenceRetryInterval sets the interval of re-listing the members when
+// the children watch can not be set
+func WithPresenceRetryInterval(interval time.Duration) PresenceOption {
+	return func(p *PresenceTracker) {
+		p.retryInterval = interval
+	}
+}
+
+// PresenceTracker registers this instance as an ephemeral node under a path,
+// and keeps a membership view of all the instances under the path.
+// The ephemeral node is registered again after the zk session is recovered.
+type PresenceTracker struct {
+	client        *ZookeeperClient
+	path          string
+	id            string
+	data          []byte
+	onJoin        PresenceHandler
+	onLeave       PresenceHandler
+	retryInterval time.Duration
+
+	session int64 // the session which registered the ephemeral node last time
+
+	lock    sync.RWMutex
+	members map[string]struct{}
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewPresenceTracker returns a PresenceTracker which registers @id with @data
+// under @basePath by @client
+func NewPresenceTracker(client *ZookeeperClient, basePath, id string, data []byte, opts ...PresenceOption) *PresenceTracker {
+	p := &PresenceTracker{
+		client:        client,
+		path:          basePath,
+		id:            id,
+		data:          data,
+		retryInterval: defaultPresenceRetryInterval,
+		members:       make(map[string]struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Start registers this instance and starts to track the members. ErrPresenceConflict
+// is returned if the id is registered by another live session.
+func (p *PresenceTracker) Start() error {
+	if err := p.client.Create(p.path); err != nil {
+		return perrors.WithMessagef(err, "create presence path %s", p.path)
+	}
+	if err := p.register(); err != nil {
+		return err
+	}
+
+	p.wg.Add(1)
+	go p.loop()
+	return nil
+}
+
+// Members returns the sorted members
+func (p *PresenceTracker) Members() []string {
+	p.lock.RLock()
+	members := make([]string, 0, len(p.members))
+	for m := range p.members {
+		members = append(members, m)
+	}
+	p.lock.RUnlock()
+
+	sort.Strings(members)
+	return members
+}
+
+// Close stops tracking and removes the ephemeral node of this instance
+func (p *PresenceTracker) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+
+		err = p.client.Delete(path.Join(p.path, p.id))
+		if perrors.Cause(err) == zk.ErrNoNode {
+			err = nil
+		}
+	})
+	return err
+}
+
+func (p *PresenceTracker) register() error {
+	conn := p.client.getConn()
+	if conn == nil {
+		return perrors.WithMessagef(ErrNilZkClientConn, "register presence %s", p.id)
+	}
+
+	nodePath := path.Join(p.path, p.id)
+	err := p.client.CreateTempWithValue(nodePath, p.data)
+	if perrors.Cause(err) != zk.ErrNodeExists {
+		if err == nil {
+			p.session = conn.SessionID()
+		}
+		return perrors.WithMessagef(err, "register presence %s", p.id)
+	}
+
+	_, stat, err := p.client.GetContent(nodePath)
+	if err != nil && perrors.Cause(err) != zk.ErrNoNode {
+		return perrors.WithMessagef(err, "register presence %s", p.id)
+	}
+	if err == nil {
+		switch stat.EphemeralOwner {
+		case conn.SessionID():
+			p.session = stat.EphemeralOwner
+			return nil
+		case p.session:
+			// the node is left by the expired session of this instance, which is
+			// removed by zookeeper later. Replace it by the node of the current session.
+			err = conn.Delete(p.client.realPath(nodePath), stat.Version)
+			if err != nil && err != zk.ErrNoNode {
+				return perrors.WithMessagef(err, "remove stale presence %s", p.id)
+			}
+		default:
+			return perrors.WithMessagef(ErrPresenceConflict, "register presence %s", p.id)
+		}
+	}
+	err = p.client.CreateTempWithValue(nodePath, p.data)
+	if err == nil {
+		p.session = conn.SessionID()
+	}
+	return perrors.WithMessagef(err, "register presence %s", p.id)
+}
+
+func (p *PresenceTracker) loop() {
+	defer p.wg.Done()
+
+	for {
+		// get the reconnect chan before listing, so a recovery during listing is not missed
+		reconnect := p.client.Reconnect()
+
+		var retry <-chan time.Time
+		children, watch, err := p.client.GetChildrenW(p.path)
+		switch {
+		case err == nil:
+			p.update(children)
+		case perrors.Cause(err) == ErrNilChildren:
+			// no watch is set on an empty path, list again later
+			p.update(nil)
+			retry = time.After(p.retryInterval)
+		default:
+			log.Printf("zk presence tracker of %s get children error: %v", p.path, err)
+			retry = time.After(p.retryInterval)
+		}
+
+		select {
+		case <-p.done:
+			return
+		case <-watch:
+		case <-retry:
+		case <-reconnect:
+			// the session may be expired and the ephemeral node is lost
+			if err := p.register(); err != nil {
+				log.Printf("zk presence tracker of %s error: %v", p.path, err)
+			}
+		}
+	}
+}
+
+// update replaces the members by @children and calls the handlers
+func (p *PresenceTracker) update(children []string) {
+	current := make(map[string]struct{}, len(children))
+	for _, c := range children {
+		current[c] = struct{}{}
+	}
+
+	var joined, left []string
+	p.lock.Lock()
+	for m := range current {
+		if _, ok := p.members[m]; !ok {
+			joined = append(joined, m)
+		}
+	}
+	for m := range p.members {
+		if _, ok := current[m]; !ok {
+			left = append(left, m)
+		}
+	}
+	p.members = current
+	p.lock.Unlock()
+
+	sort.Strings(joined)
+	sort.Strings(left)
+	if p.onJoin != nil {
+		for _, m := range joined {
+			p.onJoin(m)
+		}
+	}
+	if p.onLeave != nil {
+		for _, m := range left {
+			p.onLeave(m)
+		}
+	}
+}
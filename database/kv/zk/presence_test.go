@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxzookeeper
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/go-zookeeper/zk"
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	ts, z, event, err := NewMockZookeeperClient("test", 15*time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		_ = ts.Stop()
+	}()
+	states := []zk.State{zk.StateConnecting, zk.StateConnected, zk.StateHasSession}
+	verifyEventStateOrder(t, event, states, "event channel")
+
+	var (
+		lock   sync.Mutex
+		joined []string
+		left   []string
+	)
+	p := NewPresenceTracker(z, "/test/presence", "node1", []byte("1"),
+		WithJoinHandler(func(member string) {
+			lock.Lock()
+			joined = append(joined, member)
+			lock.Unlock()
+		}),
+		WithLeaveHandler(func(member string) {
+			lock.Lock()
+			left = append(left, member)
+			lock.Unlock()
+		}),
+		WithPresenceRetryInterval(100*time.Millisecond))
+	assert.NoError(t, p.Start())
+
+	_, z2, _, err := NewMockZookeeperClient("test2", 15*time.Second, WithTestCluster(ts))
+	assert.NoError(t, err)
+	p2 := NewPresenceTracker(z2, "/test/presence", "node2", []byte("2"))
+	assert.NoError(t, p2.Start())
+
+	assert.Eventually(t, func() bool {
+		return len(p.Members()) == 2
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, []string{"node1", "node2"}, p.Members())
+
+	assert.NoError(t, p2.Close())
+	assert.Eventually(t, func() bool {
+		return len(p.Members()) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+
+	lock.Lock()
+	assert.Equal(t, []string{"node1", "node2"}, joined)
+	assert.Equal(t, []string{"node2"}, left)
+	lock.Unlock()
+
+	assert.NoError(t, p.Close())
+	z2.Close()
+}
+
+func TestPresenceTrackerReplaceStaleNode(t *testing.T) {
+	ts, z, event, err := NewMockZookeeperClient("test", 15*time.Second)
+	assert.NoError(t, err)
+	defer func() {
+		_ = ts.Stop()
+	}()
+	states := []zk.State{zk.StateConnecting, zk.StateConnected, zk.StateHasSession}
+	verifyEventStateOrder(t, event, states, "event channel")
+
+	// the node registered by another live session is not taken over
+	_, z2, _, err := NewMockZookeeperClient("test2", 15*time.Second, WithTestCluster(ts))
+	assert.NoError(t, err)
+	defer z2.Close()
+	assert.NoError(t, z2.CreateTempWithValue("/test/stale/node1", []byte("old")))
+
+	p := NewPresenceTracker(z, "/test/stale", "node1", []byte("new"))
+	assert.Equal(t, ErrPresenceConflict, perrors.Cause(p.Start()))
+	data, _, err := z.GetContent("/test/stale/node1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("old"), data)
+
+	// the node left by the previous session of this instance is replaced
+	p.session = z2.getConn().SessionID()
+	assert.NoError(t, p.register())
+	defer p.Close()
+
+	data, stat, err := z.GetContent("/test/stale/node1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new"), data)
+	assert.Equal(t, z.getConn().SessionID(), stat.EphemeralOwner)
+
+	// registering again is a no-op for the node of the current session
+	assert.NoError(t, p.register())
+}
@@ -0,0 +1,238 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package gxcompress provides value codecs which compress large values with
+// a magic header, so compressed and plain values can be told apart on read.
+package gxcompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"github.com/golang/snappy"
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxbytes "github.com/dubbogo/gost/bytes"
+)
+
+// HeaderLen is the length of the magic header of compressed values
+const HeaderLen = 4
+
+const (
+	// GzipID is the id of the gzip codec
+	GzipID byte = 1
+	// SnappyID is the id of the snappy codec
+	SnappyID byte = 2
+)
+
+// DefaultMaxDecompressedSize is the default limit of decompressed values,
+// which protects the readers from decompression bombs
+const DefaultMaxDecompressedSize = 16 << 20
+
+// magic is the prefix of compressed values. Text values such as json and
+// url never start with a zero byte.
+var magic = [HeaderLen - 1]byte{0x00, 'G', 'X'}
+
+var (
+	// ErrUnknownCodec is returned when a value is compressed by an unregistered codec
+	ErrUnknownCodec = perrors.New("unknown compress codec")
+	// ErrDecompressedTooLarge is returned when a decompressed value exceeds the limit
+	ErrDecompressedTooLarge = perrors.New("decompressed value is too large")
+)
+
+// Codec compresses and decompresses values
+type Codec interface {
+	// ID identifies the codec in the magic header, it must be unique
+	ID() byte
+	Compress(data []byte) ([]byte, error)
+	// Decompress decompresses @data, and returns ErrDecompressedTooLarge if the
+	// decompressed value is longer than @limit. A non-positive @limit means no limit.
+	Decompress(data []byte, limit int) ([]byte, error)
+}
+
+var (
+	codecLock sync.RWMutex
+	codecs    = map[byte]Codec{}
+
+	maxDecompressedSize int64 = DefaultMaxDecompressedSize
+)
+
+func init() {
+	RegisterCodec(NewGzipCodec(gzip.DefaultCompression))
+	RegisterCodec(NewSnappyCodec())
+}
+
+// SetMaxDecompressedSize sets the limit of the values decompressed by Decode.
+// A non-positive @size means no limit.
+func SetMaxDecompressedSize(size int) {
+	atomic.StoreInt64(&maxDecompressedSize, int64(size))
+}
+
+// RegisterCodec registers @codec for decoding, such as a snappy codec
+func RegisterCodec(codec Codec) {
+	codecLock.Lock()
+	codecs[codec.ID()] = codec
+	codecLock.Unlock()
+}
+
+// GetCodec returns the registered codec by @id
+func GetCodec(id byte) (Codec, bool) {
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+	codec, ok := codecs[id]
+	return codec, ok
+}
+
+// IsCompressed checks whether @data starts with the magic header
+func IsCompressed(data []byte) bool {
+	return len(data) >= HeaderLen && bytes.Equal(data[:HeaderLen-1], magic[:])
+}
+
+// Encode compresses @data by @codec and prepends the magic header. @data is
+// returned as is if @codec is nil or the length of @data is less than @threshold.
+func Encode(codec Codec, threshold int, data []byte) ([]byte, error) {
+	if codec == nil || len(data) < threshold {
+		return data, nil
+	}
+
+	compressed, err := codec.Compress(data)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "compress by codec %d", codec.ID())
+	}
+	out := make([]byte, 0, HeaderLen+len(compressed))
+	out = append(out, magic[:]...)
+	out = append(out, codec.ID())
+	return append(out, compressed...), nil
+}
+
+// Decode decompresses @data if it starts with the magic header, otherwise
+// @data is returned as is. The decompressed value is limited by SetMaxDecompressedSize.
+func Decode(data []byte) ([]byte, error) {
+	return DecodeWithLimit(data, int(atomic.LoadInt64(&maxDecompressedSize)))
+}
+
+// DecodeWithLimit is the same as Decode, but the decompressed value is limited
+// by @limit. A non-positive @limit means no limit.
+func DecodeWithLimit(data []byte, limit int) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+
+	codec, ok := GetCodec(data[HeaderLen-1])
+	if !ok {
+		return nil, perrors.WithMessagef(ErrUnknownCodec, "codec id %d", data[HeaderLen-1])
+	}
+	out, err := codec.Decompress(data[HeaderLen:], limit)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "decompress by codec %d", codec.ID())
+	}
+	return out, nil
+}
+
+// GzipCodec compresses values by gzip
+type GzipCodec struct {
+	level int
+}
+
+// NewGzipCodec returns a gzip codec with compression @level
+func NewGzipCodec(level int) *GzipCodec {
+	return &GzipCodec{level: level}
+}
+
+// ID returns GzipID
+func (c *GzipCodec) ID() byte {
+	return GzipID
+}
+
+// Compress compresses @data by gzip
+func (c *GzipCodec) Compress(data []byte) ([]byte, error) {
+	buf := gxbytes.GetBytesBuffer()
+	defer gxbytes.PutBytesBuffer(buf)
+
+	w, err := gzip.NewWriterLevel(buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// Decompress decompresses @data by gzip
+func (c *GzipCodec) Decompress(data []byte, limit int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if limit <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	// read one more byte to tell whether the value exceeds the limit
+	out, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > limit {
+		return nil, perrors.WithMessagef(ErrDecompressedTooLarge, "limit %d", limit)
+	}
+	return out, nil
+}
+
+// SnappyCodec compresses values by snappy, which is faster than gzip but
+// compresses less
+type SnappyCodec struct{}
+
+// NewSnappyCodec returns a snappy codec
+func NewSnappyCodec() *SnappyCodec {
+	return &SnappyCodec{}
+}
+
+// ID returns SnappyID
+func (c *SnappyCodec) ID() byte {
+	return SnappyID
+}
+
+// Compress compresses @data by snappy
+func (c *SnappyCodec) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+// Decompress decompresses @data by snappy
+func (c *SnappyCodec) Decompress(data []byte, limit int) ([]byte, error) {
+	// the decoded length is in the snappy header, check it before allocating
+	n, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && n > limit {
+		return nil, perrors.WithMessagef(ErrDecompressedTooLarge, "length %d, limit %d", n, limit)
+	}
+	return snappy.Decode(nil, data)
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxcompress
+
+import (
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	codec := NewGzipCodec(gzip.BestCompression)
+	data := []byte(strings.Repeat("hello, dubbogo ", 100))
+
+	for _, c := range []Codec{codec, NewSnappyCodec()} {
+		out, err := Encode(c, 1024, data)
+		assert.Nil(t, err)
+		assert.True(t, IsCompressed(out))
+		assert.Equal(t, c.ID(), out[HeaderLen-1])
+		assert.True(t, len(out) < len(data))
+
+		plain, err := Decode(out)
+		assert.Nil(t, err)
+		assert.Equal(t, data, plain)
+	}
+
+	// short values are not compressed
+	out, err := Encode(codec, 1024, []byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), out)
+
+	// nil codec
+	out, err = Encode(nil, 0, data)
+	assert.Nil(t, err)
+	assert.Equal(t, data, out)
+}
+
+func TestDecodePlain(t *testing.T) {
+	for _, v := range [][]byte{nil, []byte(""), []byte("{}"), []byte("dubbo://127.0.0.1:20000"), {0x00, 'G'}} {
+		out, err := Decode(v)
+		assert.Nil(t, err)
+		assert.Equal(t, v, out)
+	}
+
+	_, err := Decode([]byte{0x00, 'G', 'X', 0xff, 1, 2})
+	assert.Equal(t, ErrUnknownCodec, perrors.Cause(err))
+
+	_, err = Decode([]byte{0x00, 'G', 'X', GzipID, 1, 2})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeLimit(t *testing.T) {
+	// a small value which decompresses into 1MB
+	data := make([]byte, 1<<20)
+	for _, c := range []Codec{NewGzipCodec(gzip.BestCompression), NewSnappyCodec()} {
+		out, err := Encode(c, 0, data)
+		assert.Nil(t, err)
+
+		_, err = DecodeWithLimit(out, 1<<10)
+		assert.Equal(t, ErrDecompressedTooLarge, perrors.Cause(err))
+
+		plain, err := DecodeWithLimit(out, 1<<20)
+		assert.Nil(t, err)
+		assert.Equal(t, data, plain)
+
+		SetMaxDecompressedSize(1 << 10)
+		_, err = Decode(out)
+		assert.Equal(t, ErrDecompressedTooLarge, perrors.Cause(err))
+		SetMaxDecompressedSize(0)
+		plain, err = Decode(out)
+		assert.Nil(t, err)
+		assert.Equal(t, data, plain)
+		SetMaxDecompressedSize(DefaultMaxDecompressedSize)
+	}
+}
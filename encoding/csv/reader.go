@@ -0,0 +1,572 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxcsv provides a streaming reader of csv and delimited line records,
+// whose buffers are acquired from the gxbytes pool, so huge files are read with
+// bounded memory and little allocation.
+package gxcsv
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxbytes "github.com/dubbogo/gost/bytes"
+)
+
+const (
+	defaultReadBufferSize = 16 << 10
+	defaultMaxRecordSize  = 1 << 20
+)
+
+var (
+	// ErrRecordTooLarge is returned when a record exceeds the max record size
+	ErrRecordTooLarge = perrors.New("csv record too large")
+	// ErrBareQuote is returned when a quote appears in an unquoted field
+	ErrBareQuote = perrors.New("bare quote in unquoted field")
+	// ErrQuote is returned when a quoted field is not closed or followed by other characters
+	ErrQuote = perrors.New("extraneous or missing quote in quoted field")
+	// ErrFieldIndex is returned when the field index is out of range
+	ErrFieldIndex = perrors.New("field index out of range")
+)
+
+type readerOptions struct {
+	comma         byte
+	comment       byte
+	quote         bool
+	hasHeader     bool
+	bufferSize    int
+	maxRecordSize int
+}
+
+// ReaderOption is optional settings for Reader
+type ReaderOption func(*readerOptions)
+
+// WithComma sets the field delimiter, which is ',' by default
+func WithComma(comma byte) ReaderOption {
+	return func(o *readerOptions) {
+		o.comma = comma
+	}
+}
+
+// WithComment skips the lines beginning with @comment
+func WithComment(comment byte) ReaderOption {
+	return func(o *readerOptions) {
+		o.comment = comment
+	}
+}
+
+// WithoutQuote treats quotes as normal characters, which is used to read plain
+// delimited lines, such as tab separated values
+func WithoutQuote() ReaderOption {
+	return func(o *readerOptions) {
+		o.quote = false
+	}
+}
+
+// WithHeader takes the first record as the header, the fields can be looked up by name then
+func WithHeader() ReaderOption {
+	return func(o *readerOptions) {
+		o.hasHeader = true
+	}
+}
+
+// WithBufferSize sets the size of the read buffer
+func WithBufferSize(size int) ReaderOption {
+	return func(o *readerOptions) {
+		o.bufferSize = size
+	}
+}
+
+// WithMaxRecordSize sets the max bytes of the fields of a record, which bounds the
+// memory used by the reader. ErrRecordTooLarge is returned for larger records.
+func WithMaxRecordSize(size int) ReaderOption {
+	return func(o *readerOptions) {
+		o.maxRecordSize = size
+	}
+}
+
+// Reader reads records from an io.Reader one by one. It is not safe for concurrent use.
+type Reader struct {
+	readerOptions
+
+	r          io.Reader
+	bufp       *[]byte // read buffer, the unread bytes are buf[start:end]
+	buf        []byte
+	start, end int
+	eof        bool
+	line       int // the current line number
+
+	datap  *[]byte // the unquoted fields of current record
+	record Record
+
+	header  []string
+	indexes map[string]int
+}
+
+// NewReader returns a Reader reading from @r. Release it after use.
+func NewReader(r io.Reader, opts ...ReaderOption) *Reader {
+	rd := &Reader{
+		readerOptions: readerOptions{
+			comma:         ',',
+			quote:         true,
+			bufferSize:    defaultReadBufferSize,
+			maxRecordSize: defaultMaxRecordSize,
+		},
+		r: r,
+	}
+	for _, opt := range opts {
+		opt(&rd.readerOptions)
+	}
+	if rd.bufferSize < 1 {
+		rd.bufferSize = defaultReadBufferSize
+	}
+	if rd.maxRecordSize < 1 {
+		rd.maxRecordSize = defaultMaxRecordSize
+	}
+
+	rd.bufp = gxbytes.AcquireBytes(rd.bufferSize)
+	rd.buf = (*rd.bufp)[:rd.bufferSize]
+	rd.datap = gxbytes.AcquireBytes(minDataSize)
+	rd.record.reader = rd
+	return rd
+}
+
+const minDataSize = 512
+
+// Release puts the buffers back to the pool. The reader and the records read
+// by it should not be used after that.
+func (r *Reader) Release() {
+	if r.bufp != nil {
+		gxbytes.ReleaseBytes(r.bufp)
+		r.bufp, r.buf = nil, nil
+	}
+	if r.datap != nil {
+		gxbytes.ReleaseBytes(r.datap)
+		r.datap = nil
+	}
+	r.record.data = nil
+}
+
+// Header returns the header read by WithHeader
+func (r *Reader) Header() ([]string, error) {
+	if err := r.readHeader(); err != nil {
+		return nil, err
+	}
+	return r.header, nil
+}
+
+func (r *Reader) readHeader() error {
+	if !r.hasHeader || r.indexes != nil {
+		return nil
+	}
+
+	rec, err := r.read()
+	if err != nil {
+		return perrors.WithMessage(err, "read header")
+	}
+	r.header = rec.Strings()
+	r.indexes = make(map[string]int, len(r.header))
+	for i, name := range r.header {
+		if _, ok := r.indexes[name]; !ok {
+			r.indexes[name] = i
+		}
+	}
+	return nil
+}
+
+// Read returns the next record, which is valid until the next Read, and io.EOF
+// if there is no more record. Empty lines and comment lines are skipped.
+func (r *Reader) Read() (*Record, error) {
+	if err := r.readHeader(); err != nil {
+		return nil, err
+	}
+	return r.read()
+}
+
+func (r *Reader) read() (*Record, error) {
+	if r.bufp == nil {
+		return nil, perrors.New("csv reader released")
+	}
+
+	for {
+		ok, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &r.record, nil
+		}
+	}
+}
+
+// fill reads more bytes into the empty buffer, it returns io.EOF if there is no more byte
+func (r *Reader) fill() error {
+	if r.eof {
+		return io.EOF
+	}
+
+	r.start, r.end = 0, 0
+	for {
+		n, err := r.r.Read(r.buf)
+		r.end = n
+		if err == io.EOF {
+			r.eof = true
+			if n == 0 {
+				return io.EOF
+			}
+			return nil
+		}
+		if err != nil {
+			return perrors.WithStack(err)
+		}
+		if n > 0 {
+			return nil
+		}
+	}
+}
+
+func (r *Reader) nextByte() (byte, error) {
+	if r.start == r.end {
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	c := r.buf[r.start]
+	r.start++
+	return c, nil
+}
+
+// append appends @p to the fields of current record
+func (r *Reader) append(p ...byte) error {
+	data := r.record.data
+	if len(data)+len(p) > r.maxRecordSize {
+		return perrors.WithMessagef(ErrRecordTooLarge, "line %d", r.line)
+	}
+	if len(data)+len(p) > cap(data) {
+		// grow by acquiring a double sized buffer from the pool
+		size := 2 * cap(data)
+		if size < len(data)+len(p) {
+			size = len(data) + len(p)
+		}
+		ndatap := gxbytes.AcquireBytes(size)
+		ndata := append((*ndatap)[:0], data...)
+		gxbytes.ReleaseBytes(r.datap)
+		r.datap, data = ndatap, ndata
+	}
+	r.record.data = append(data, p...)
+	return nil
+}
+
+// endField marks the end of current field
+func (r *Reader) endField() {
+	r.record.ends = append(r.record.ends, len(r.record.data))
+}
+
+// readRecord reads the fields of a record, it returns false if a line is skipped
+func (r *Reader) readRecord() (bool, error) {
+	r.record.data = (*r.datap)[:0]
+	r.record.ends = r.record.ends[:0]
+	r.line++
+	r.record.line = r.line
+
+	c, err := r.nextByte()
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case c == '\n':
+		return false, nil
+	case c == '\r':
+		if c, err = r.nextByte(); err == nil && c == '\n' {
+			return false, nil
+		}
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if err == nil {
+			r.start--
+		}
+		// a single carriage return is a field
+		if err = r.append('\r'); err != nil {
+			return false, err
+		}
+	case r.comment != 0 && c == r.comment:
+		return false, r.skipLine()
+	default:
+		r.start--
+	}
+
+	for {
+		var last bool
+		c, err = r.nextByte()
+		switch {
+		case err == io.EOF:
+			// the record ends with an empty field
+			r.endField()
+			return true, nil
+		case err != nil:
+			return false, err
+		case r.quote && c == '"':
+			last, err = r.readQuotedField()
+		default:
+			r.start--
+			last, err = r.readField()
+		}
+		if err != nil {
+			return false, err
+		}
+		r.endField()
+		if last {
+			return true, nil
+		}
+	}
+}
+
+func (r *Reader) skipLine() error {
+	for {
+		if r.start == r.end {
+			if err := r.fill(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+		if i := bytes.IndexByte(r.buf[r.start:r.end], '\n'); i >= 0 {
+			r.start += i + 1
+			return nil
+		}
+		r.start = r.end
+	}
+}
+
+// readField reads an unquoted field, it returns true if it is the last field of the record
+func (r *Reader) readField() (bool, error) {
+	fieldStart := len(r.record.data)
+	for {
+		if r.start == r.end {
+			if err := r.fill(); err != nil {
+				if err == io.EOF {
+					return true, nil
+				}
+				return false, err
+			}
+		}
+
+		chunk := r.buf[r.start:r.end]
+		i := r.indexSpecial(chunk)
+		if i < 0 {
+			if err := r.append(chunk...); err != nil {
+				return false, err
+			}
+			r.start = r.end
+			continue
+		}
+
+		if err := r.append(chunk[:i]...); err != nil {
+			return false, err
+		}
+		r.start += i + 1
+		switch chunk[i] {
+		case r.comma:
+			return false, nil
+		case '\n':
+			// trim the carriage return of CRLF
+			if n := len(r.record.data); n > fieldStart && r.record.data[n-1] == '\r' {
+				r.record.data = r.record.data[:n-1]
+			}
+			return true, nil
+		default:
+			return false, perrors.WithMessagef(ErrBareQuote, "line %d field %d", r.line, len(r.record.ends)+1)
+		}
+	}
+}
+
+// indexSpecial returns the index of the first comma, newline or quote in @p
+func (r *Reader) indexSpecial(p []byte) int {
+	for i, c := range p {
+		if c == r.comma || c == '\n' || (r.quote && c == '"') {
+			return i
+		}
+	}
+	return -1
+}
+
+// readQuotedField reads a quoted field whose leading quote has been read,
+// it returns true if it is the last field of the record
+func (r *Reader) readQuotedField() (bool, error) {
+	line := r.line
+	for {
+		c, err := r.nextByte()
+		if err == io.EOF {
+			return false, perrors.WithMessagef(ErrQuote, "line %d field %d", line, len(r.record.ends)+1)
+		}
+		if err != nil {
+			return false, err
+		}
+		if c != '"' {
+			if c == '\n' {
+				// the field spans multiple lines
+				r.line++
+			}
+			if err = r.append(c); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		c, err = r.nextByte()
+		switch {
+		case err == io.EOF:
+			return true, nil
+		case err != nil:
+			return false, err
+		case c == '"':
+			// escaped quote
+			if err = r.append('"'); err != nil {
+				return false, err
+			}
+		case c == r.comma:
+			return false, nil
+		case c == '\n':
+			return true, nil
+		case c == '\r':
+			if c, err = r.nextByte(); err == nil && c == '\n' {
+				return true, nil
+			}
+			if err == io.EOF {
+				return true, nil
+			}
+			return false, perrors.WithMessagef(ErrQuote, "line %d field %d", line, len(r.record.ends)+1)
+		default:
+			return false, perrors.WithMessagef(ErrQuote, "line %d field %d", line, len(r.record.ends)+1)
+		}
+	}
+}
+
+// Record is a record read by Reader, it is valid until the next Read
+type Record struct {
+	reader *Reader
+	data   []byte // the fields of the record
+	ends   []int  // the end offsets of the fields in data
+	line   int
+}
+
+// Len returns the number of the fields
+func (rec *Record) Len() int {
+	return len(rec.ends)
+}
+
+// Line returns the line number where the record starts
+func (rec *Record) Line() int {
+	return rec.line
+}
+
+// Bytes returns the field @i, which refers to the buffer of the reader and is
+// only valid until the next Read. It returns nil if @i is out of range.
+func (rec *Record) Bytes(i int) []byte {
+	if i < 0 || i >= len(rec.ends) {
+		return nil
+	}
+	start := 0
+	if i > 0 {
+		start = rec.ends[i-1]
+	}
+	return rec.data[start:rec.ends[i]]
+}
+
+// String returns the field @i as a string, or "" if @i is out of range
+func (rec *Record) String(i int) string {
+	return string(rec.Bytes(i))
+}
+
+// Strings returns a copy of all the fields
+func (rec *Record) Strings() []string {
+	fields := make([]string, len(rec.ends))
+	for i := range fields {
+		fields[i] = rec.String(i)
+	}
+	return fields
+}
+
+// Index returns the index of the field named @name in the header, or -1 if
+// there is no such field or no header
+func (rec *Record) Index(name string) int {
+	if i, ok := rec.reader.indexes[name]; ok {
+		return i
+	}
+	return -1
+}
+
+// Get returns the field named @name in the header, or "" if there is no such field
+func (rec *Record) Get(name string) string {
+	return rec.String(rec.Index(name))
+}
+
+func (rec *Record) field(i int) ([]byte, error) {
+	if i < 0 || i >= len(rec.ends) {
+		return nil, perrors.WithMessagef(ErrFieldIndex, "line %d field %d", rec.line, i)
+	}
+	return rec.Bytes(i), nil
+}
+
+// Int parses the field @i as an int64
+func (rec *Record) Int(i int) (int64, error) {
+	b, err := rec.field(i)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(string(b), 10, 64)
+	return v, perrors.WithMessagef(err, "line %d field %d", rec.line, i)
+}
+
+// Uint parses the field @i as an uint64
+func (rec *Record) Uint(i int) (uint64, error) {
+	b, err := rec.field(i)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(string(b), 10, 64)
+	return v, perrors.WithMessagef(err, "line %d field %d", rec.line, i)
+}
+
+// Float parses the field @i as a float64
+func (rec *Record) Float(i int) (float64, error) {
+	b, err := rec.field(i)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(string(b), 64)
+	return v, perrors.WithMessagef(err, "line %d field %d", rec.line, i)
+}
+
+// Bool parses the field @i as a bool, which accepts the values of strconv.ParseBool
+func (rec *Record) Bool(i int) (bool, error) {
+	b, err := rec.field(i)
+	if err != nil {
+		return false, err
+	}
+	v, err := strconv.ParseBool(string(b))
+	return v, perrors.WithMessagef(err, "line %d field %d", rec.line, i)
+}
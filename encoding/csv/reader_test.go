@@ -0,0 +1,180 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxcsv
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, r *Reader) [][]string {
+	var records [][]string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		assert.Nil(t, err)
+		if err != nil {
+			return records
+		}
+		records = append(records, rec.Strings())
+	}
+}
+
+func TestReader(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		opts  []ReaderOption
+		want  [][]string
+	}{
+		{name: "simple", input: "a,b,c\n1,2,3\n", want: [][]string{{"a", "b", "c"}, {"1", "2", "3"}}},
+		{name: "no trailing newline", input: "a,b\nc,d", want: [][]string{{"a", "b"}, {"c", "d"}}},
+		{name: "empty fields", input: ",a,\n", want: [][]string{{"", "a", ""}}},
+		{name: "crlf", input: "a,b\r\n\r\nc,d\r\n", want: [][]string{{"a", "b"}, {"c", "d"}}},
+		{name: "empty lines", input: "\n\na\n\n", want: [][]string{{"a"}}},
+		{
+			name:  "quoted",
+			input: "\"a,b\",\"say \"\"hi\"\"\",\"multi\nline\"\r\n\"\",x\n",
+			want:  [][]string{{"a,b", "say \"hi\"", "multi\nline"}, {"", "x"}},
+		},
+		{name: "comment", input: "#a,b\nc,d\n#e", opts: []ReaderOption{WithComment('#')}, want: [][]string{{"c", "d"}}},
+		{
+			name:  "tab without quote",
+			input: "a\t\"b\tc\"\n",
+			opts:  []ReaderOption{WithComma('\t'), WithoutQuote()},
+			want:  [][]string{{"a", "\"b", "c\""}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(c.input), c.opts...)
+			defer r.Release()
+			assert.Equal(t, c.want, readAll(t, r))
+
+			// the result should not depend on how the input is split
+			opts := append([]ReaderOption{WithBufferSize(1)}, c.opts...)
+			r = NewReader(iotest.OneByteReader(strings.NewReader(c.input)), opts...)
+			defer r.Release()
+			assert.Equal(t, c.want, readAll(t, r))
+		})
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		opts  []ReaderOption
+		err   error
+	}{
+		{name: "bare quote", input: "a,b\"c\n", err: ErrBareQuote},
+		{name: "unclosed quote", input: "a,\"bc\n", err: ErrQuote},
+		{name: "extraneous quote", input: "\"a\"b,c\n", err: ErrQuote},
+		{name: "too large", input: "a,0123456789\n", opts: []ReaderOption{WithMaxRecordSize(8)}, err: ErrRecordTooLarge},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(c.input), c.opts...)
+			defer r.Release()
+			_, err := r.Read()
+			assert.Equal(t, c.err, perrors.Cause(err))
+			assert.Contains(t, err.Error(), "line 1")
+		})
+	}
+}
+
+func TestReaderLargeFile(t *testing.T) {
+	const n = 10000
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString("rule,")
+		sb.WriteString(strings.Repeat("x", i%100))
+		sb.WriteString(",42\n")
+	}
+
+	r := NewReader(strings.NewReader(sb.String()), WithBufferSize(64), WithMaxRecordSize(128))
+	defer r.Release()
+	count := 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, 3, rec.Len())
+		assert.Equal(t, count%100, len(rec.Bytes(1)))
+		assert.Equal(t, count+1, rec.Line())
+		count++
+	}
+	assert.Equal(t, n, count)
+}
+
+func TestRecordAccessors(t *testing.T) {
+	input := "name,weight,port,ratio,enabled\nfoo,-3,8080,0.5,true\nbar,x,1,2,no\n"
+	r := NewReader(strings.NewReader(input), WithHeader())
+	defer r.Release()
+
+	header, err := r.Header()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name", "weight", "port", "ratio", "enabled"}, header)
+
+	rec, err := r.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rec.Line())
+	assert.Equal(t, "foo", rec.Get("name"))
+	assert.Equal(t, -1, rec.Index("missing"))
+	assert.Equal(t, "", rec.Get("missing"))
+	i, err := rec.Int(rec.Index("weight"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-3), i)
+	u, err := rec.Uint(2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(8080), u)
+	f, err := rec.Float(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, f)
+	b, err := rec.Bool(4)
+	assert.Nil(t, err)
+	assert.True(t, b)
+	_, err = rec.Int(5)
+	assert.Equal(t, ErrFieldIndex, perrors.Cause(err))
+	assert.Nil(t, rec.Bytes(-1))
+
+	rec, err = r.Read()
+	assert.Nil(t, err)
+	_, err = rec.Int(1)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "line 3 field 1")
+	_, err = rec.Bool(4)
+	assert.NotNil(t, err)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,212 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxtlv
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const tagName = "tlv"
+
+// Marshal encodes the exported fields of struct @v which have a `tlv:"<tag>"` field tag.
+// Supported field types are bool, integers, floats, string, []byte and nested structs.
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, perrors.Errorf("tlv: can not marshal %T", v)
+	}
+	return marshalStruct(rv)
+}
+
+func marshalStruct(rv reflect.Value) ([]byte, error) {
+	var (
+		dst []byte
+		rt  = rv.Type()
+	)
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok, err := fieldTag(field)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			// a nil pointer is encoded as absent
+			continue
+		}
+		value, err := marshalValue(fv)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "tlv: field %s", field.Name)
+		}
+		if dst, err = AppendTLV(dst, tag, value); err != nil {
+			return nil, perrors.WithMessagef(err, "tlv: field %s", field.Name)
+		}
+	}
+	return dst, nil
+}
+
+func marshalValue(fv reflect.Value) ([]byte, error) {
+	switch fv.Kind() {
+	case reflect.Bool:
+		if fv.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64Bytes(uint64(fv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uint64Bytes(fv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return uint64Bytes(math.Float64bits(fv.Float())), nil
+	case reflect.String:
+		return []byte(fv.String()), nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			return fv.Bytes(), nil
+		}
+	case reflect.Struct:
+		return marshalStruct(fv)
+	case reflect.Ptr:
+		if fv.Elem().Kind() == reflect.Struct {
+			return marshalStruct(fv.Elem())
+		}
+	}
+	return nil, perrors.Errorf("unsupported type %s", fv.Type())
+}
+
+// Unmarshal decodes @data into struct pointer @v according to the `tlv` field tags.
+// The records whose tag does not match any field are ignored.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return perrors.Errorf("tlv: can not unmarshal into %T", v)
+	}
+	return unmarshalStruct(data, rv.Elem())
+}
+
+func unmarshalStruct(data []byte, rv reflect.Value) error {
+	records, err := DecodeAll(data)
+	if err != nil {
+		return err
+	}
+
+	rt := rv.Type()
+	fields := make(map[uint16]int, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok, err := fieldTag(rt.Field(i))
+		if err != nil {
+			return err
+		}
+		if ok {
+			fields[tag] = i
+		}
+	}
+
+	for _, r := range records {
+		idx, ok := fields[r.Tag]
+		if !ok {
+			continue
+		}
+		if err := unmarshalValue(r.Value, rv.Field(idx)); err != nil {
+			return perrors.WithMessagef(err, "tlv: field %s", rt.Field(idx).Name)
+		}
+	}
+	return nil
+}
+
+func unmarshalValue(value []byte, fv reflect.Value) error {
+	switch fv.Kind() {
+	case reflect.Bool:
+		if len(value) != 1 {
+			return ErrShortBuffer
+		}
+		fv.SetBool(value[0] != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(value) != 8 {
+			return ErrShortBuffer
+		}
+		v := int64(binary.BigEndian.Uint64(value))
+		if fv.OverflowInt(v) {
+			return perrors.Errorf("value %d overflows %s", v, fv.Type())
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if len(value) != 8 {
+			return ErrShortBuffer
+		}
+		v := binary.BigEndian.Uint64(value)
+		if fv.OverflowUint(v) {
+			return perrors.Errorf("value %d overflows %s", v, fv.Type())
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		if len(value) != 8 {
+			return ErrShortBuffer
+		}
+		fv.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(value)))
+	case reflect.String:
+		fv.SetString(string(value))
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.Uint8 {
+			return perrors.Errorf("unsupported type %s", fv.Type())
+		}
+		fv.SetBytes(append([]byte(nil), value...))
+	case reflect.Struct:
+		return unmarshalStruct(value, fv)
+	case reflect.Ptr:
+		if fv.Type().Elem().Kind() != reflect.Struct {
+			return perrors.Errorf("unsupported type %s", fv.Type())
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return unmarshalStruct(value, fv.Elem())
+	default:
+		return perrors.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+func uint64Bytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func fieldTag(field reflect.StructField) (uint16, bool, error) {
+	name, ok := field.Tag.Lookup(tagName)
+	if !ok || name == "-" || field.PkgPath != "" {
+		return 0, false, nil
+	}
+	tag, err := strconv.ParseUint(name, 10, 16)
+	if err != nil {
+		return 0, false, perrors.Errorf("tlv: illegal tag %q of field %s", name, field.Name)
+	}
+	return uint16(tag), true, nil
+}
@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxtlv provides a tag-length-value encoder/decoder.
+// The tag is encoded as uint16 and the length as uint32, both in big endian.
+package gxtlv
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxbytes "github.com/dubbogo/gost/bytes"
+)
+
+const (
+	// HeaderLen is the length of tag and length
+	HeaderLen = 6
+	// DefaultMaxValueLen is the default max length of a value
+	DefaultMaxValueLen = 16 << 20
+)
+
+var (
+	// ErrValueTooLarge is returned when the value length exceeds the limit
+	ErrValueTooLarge = perrors.New("tlv value too large")
+	// ErrShortBuffer is returned when the data is not a complete tlv
+	ErrShortBuffer = perrors.New("tlv short buffer")
+
+	// maxEncodeLen is the max length of a value which the uint32 length can hold
+	maxEncodeLen uint64 = math.MaxUint32
+)
+
+// Record is a decoded tlv
+type Record struct {
+	Tag   uint16
+	Value []byte
+
+	bufp *[]byte // pooled buffer which holds Value
+}
+
+// Release returns the buffer of @r to the bytes pool. @r.Value should not be used after Release.
+func (r *Record) Release() {
+	if r.bufp != nil {
+		gxbytes.ReleaseBytes(r.bufp)
+		r.bufp = nil
+	}
+	r.Value = nil
+}
+
+// Children decodes @r.Value as nested tlv records
+func (r *Record) Children() ([]*Record, error) {
+	return DecodeAll(r.Value)
+}
+
+// AppendTLV appends a tlv to @dst. ErrValueTooLarge is returned if the length
+// of @value does not fit in uint32.
+func AppendTLV(dst []byte, tag uint16, value []byte) ([]byte, error) {
+	header, err := encodeHeader(tag, value)
+	if err != nil {
+		return dst, err
+	}
+	dst = append(dst, header[:]...)
+	return append(dst, value...), nil
+}
+
+func encodeHeader(tag uint16, value []byte) ([HeaderLen]byte, error) {
+	var header [HeaderLen]byte
+	if uint64(len(value)) > maxEncodeLen {
+		return header, perrors.WithMessagef(ErrValueTooLarge, "tag %d length %d", tag, len(value))
+	}
+	binary.BigEndian.PutUint16(header[:2], tag)
+	binary.BigEndian.PutUint32(header[2:], uint32(len(value)))
+	return header, nil
+}
+
+// DecodeAll decodes all the tlv records in @data. The values refer to @data.
+func DecodeAll(data []byte) ([]*Record, error) {
+	var records []*Record
+	for len(data) > 0 {
+		if len(data) < HeaderLen {
+			return nil, ErrShortBuffer
+		}
+		tag := binary.BigEndian.Uint16(data[:2])
+		length := binary.BigEndian.Uint32(data[2:HeaderLen])
+		if uint64(len(data)-HeaderLen) < uint64(length) {
+			return nil, ErrShortBuffer
+		}
+		end := HeaderLen + int(length)
+		records = append(records, &Record{Tag: tag, Value: data[HeaderLen:end]})
+		data = data[end:]
+	}
+	return records, nil
+}
+
+// Encoder writes tlv records to an io.Writer
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an Encoder writing to @w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes a tlv
+func (e *Encoder) Encode(tag uint16, value []byte) error {
+	header, err := encodeHeader(tag, value)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(header[:]); err != nil {
+		return perrors.WithStack(err)
+	}
+	_, err = e.w.Write(value)
+	return perrors.WithStack(err)
+}
+
+// EncodeNested writes a tlv whose value consists of the records written by @f
+func (e *Encoder) EncodeNested(tag uint16, f func(e *Encoder) error) error {
+	buf := gxbytes.GetBytesBuffer()
+	defer gxbytes.PutBytesBuffer(buf)
+
+	if err := f(NewEncoder(buf)); err != nil {
+		return err
+	}
+	return e.Encode(tag, buf.Bytes())
+}
+
+// Decoder reads tlv records from an io.Reader
+type Decoder struct {
+	r           io.Reader
+	maxValueLen uint32
+	header      [HeaderLen]byte
+}
+
+// NewDecoder returns a Decoder reading from @r. The values longer than
+// @maxValueLen will be rejected, and a non-positive @maxValueLen means DefaultMaxValueLen.
+func NewDecoder(r io.Reader, maxValueLen int) *Decoder {
+	if maxValueLen <= 0 {
+		maxValueLen = DefaultMaxValueLen
+	}
+	return &Decoder{r: r, maxValueLen: uint32(maxValueLen)}
+}
+
+// Decode reads the next tlv. The value is held by a pooled buffer, so the caller
+// should call Record.Release when it is no longer used. io.EOF is returned
+// when there is no more record.
+func (d *Decoder) Decode() (*Record, error) {
+	if _, err := io.ReadFull(d.r, d.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, ErrShortBuffer
+		}
+		return nil, err
+	}
+
+	tag := binary.BigEndian.Uint16(d.header[:2])
+	length := binary.BigEndian.Uint32(d.header[2:])
+	if length > d.maxValueLen {
+		return nil, perrors.WithMessagef(ErrValueTooLarge, "tag %d length %d", tag, length)
+	}
+
+	bufp := gxbytes.AcquireBytes(int(length))
+	// the bytes beyond the max slot of the pool are returned with zero length
+	*bufp = (*bufp)[:length]
+	if _, err := io.ReadFull(d.r, *bufp); err != nil {
+		gxbytes.ReleaseBytes(bufp)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrShortBuffer
+		}
+		return nil, err
+	}
+	return &Record{Tag: tag, Value: *bufp, bufp: bufp}, nil
+}
+
+// Encode encodes @records as a tlv stream
+func Encode(records ...*Record) ([]byte, error) {
+	var (
+		dst []byte
+		err error
+	)
+	for _, r := range records {
+		if dst, err = AppendTLV(dst, r.Tag, r.Value); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxtlv
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City string `tlv:"1"`
+	Zip  uint32 `tlv:"2"`
+}
+
+type user struct {
+	Name    string   `tlv:"1"`
+	Age     int      `tlv:"2"`
+	Score   float64  `tlv:"3"`
+	Admin   bool     `tlv:"4"`
+	Avatar  []byte   `tlv:"5"`
+	Home    address  `tlv:"6"`
+	Office  *address `tlv:"7"`
+	Ignored string
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	assert.Nil(t, e.Encode(1, []byte("hello")))
+	assert.Nil(t, e.EncodeNested(2, func(e *Encoder) error {
+		if err := e.Encode(3, []byte("a")); err != nil {
+			return err
+		}
+		return e.Encode(4, []byte("bc"))
+	}))
+
+	d := NewDecoder(&buf, 0)
+	r, err := d.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(1), r.Tag)
+	assert.Equal(t, "hello", string(r.Value))
+	r.Release()
+
+	r, err = d.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(2), r.Tag)
+	children, err := r.Children()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(children))
+	assert.Equal(t, uint16(4), children[1].Tag)
+	assert.Equal(t, "bc", string(children[1].Value))
+	r.Release()
+
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+
+	data, err := AppendTLV(nil, 1, []byte("hello"))
+	assert.Nil(t, err)
+	_, err = NewDecoder(bytes.NewReader(data), 2).Decode()
+	assert.NotNil(t, err)
+	_, err = NewDecoder(bytes.NewReader([]byte{0, 1, 0}), 0).Decode()
+	assert.Equal(t, ErrShortBuffer, err)
+}
+
+func TestDecodeLargeValue(t *testing.T) {
+	// larger than the max slot of the bytes pool
+	large := bytes.Repeat([]byte("x"), 100<<10)
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	assert.Nil(t, e.Encode(1, large))
+	assert.Nil(t, e.Encode(2, []byte("next")))
+
+	d := NewDecoder(&buf, 1<<20)
+	r, err := d.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, large, r.Value)
+	r.Release()
+
+	r, err = d.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(2), r.Tag)
+	assert.Equal(t, "next", string(r.Value))
+	r.Release()
+}
+
+func TestMarshal(t *testing.T) {
+	u := user{
+		Name:    "alex",
+		Age:     -18,
+		Score:   99.5,
+		Admin:   true,
+		Avatar:  []byte{1, 2, 3},
+		Home:    address{City: "hz", Zip: 310000},
+		Office:  &address{City: "bj"},
+		Ignored: "ignored",
+	}
+	data, err := Marshal(&u)
+	assert.Nil(t, err)
+
+	var got user
+	assert.Nil(t, Unmarshal(data, &got))
+	u.Ignored = ""
+	assert.Equal(t, u, got)
+
+	// a nil pointer is absent
+	u.Office = nil
+	data, err = Marshal(&u)
+	assert.Nil(t, err)
+	got = user{}
+	assert.Nil(t, Unmarshal(data, &got))
+	assert.Nil(t, got.Office)
+	assert.Equal(t, u, got)
+
+	_, err = Marshal(1)
+	assert.NotNil(t, err)
+	assert.NotNil(t, Unmarshal(data, got))
+}
+
+func TestEncodeOverflow(t *testing.T) {
+	// the small ints are decoded from 8 bytes, the values out of range are errors
+	type small struct {
+		I8  int8   `tlv:"1"`
+		U16 uint16 `tlv:"2"`
+	}
+	data, err := Marshal(&struct {
+		I8 int64 `tlv:"1"`
+	}{I8: 128})
+	assert.Nil(t, err)
+	assert.NotNil(t, Unmarshal(data, &small{}))
+	data, err = Marshal(&struct {
+		U16 uint64 `tlv:"2"`
+	}{U16: 1 << 16})
+	assert.Nil(t, err)
+	assert.NotNil(t, Unmarshal(data, &small{}))
+	data, err = Marshal(&struct {
+		I8 int64 `tlv:"1"`
+	}{I8: -128})
+	assert.Nil(t, err)
+	var got small
+	assert.Nil(t, Unmarshal(data, &got))
+	assert.Equal(t, int8(-128), got.I8)
+
+	// the length of a value must fit in uint32
+	old := maxEncodeLen
+	maxEncodeLen = 4
+	defer func() {
+		maxEncodeLen = old
+	}()
+	_, err = AppendTLV(nil, 1, []byte("hello"))
+	assert.Equal(t, ErrValueTooLarge, perrors.Cause(err))
+	_, err = Encode(&Record{Tag: 1, Value: []byte("hi")}, &Record{Tag: 2, Value: []byte("hello")})
+	assert.Equal(t, ErrValueTooLarge, perrors.Cause(err))
+	assert.Equal(t, ErrValueTooLarge, perrors.Cause(NewEncoder(io.Discard).Encode(1, []byte("hello"))))
+	_, err = Marshal(&address{City: "hangzhou"})
+	assert.Equal(t, ErrValueTooLarge, perrors.Cause(err))
+}
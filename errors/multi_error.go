@@ -0,0 +1,191 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxerrors provides error helpers
+package gxerrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiError collects errors from parallel operations. It is safe for concurrent use.
+// A nil *MultiError has no errors.
+type MultiError struct {
+	lock    sync.RWMutex
+	errs    []error
+	total   int
+	succeed int
+}
+
+// NewMultiError returns a MultiError which holds @errs. The nil errors are ignored.
+func NewMultiError(errs ...error) *MultiError {
+	m := &MultiError{}
+	for _, err := range errs {
+		m.Add(err)
+	}
+	return m
+}
+
+// Add appends @err. A nil @err is counted as a success. The errors and the
+// counts of a nested *MultiError are merged into @m, and adding @m to itself
+// changes nothing since @m already holds them. Add on a nil *MultiError is a no-op.
+func (m *MultiError) Add(err error) {
+	if m == nil {
+		return
+	}
+
+	if me, ok := err.(*MultiError); ok && me != nil {
+		if me == m {
+			return
+		}
+		// take the snapshot before locking @m, so that @me and @m adding each
+		// other concurrently do not deadlock
+		errs := me.Errors()
+		succeed, total := me.Succeed()
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		m.errs = append(m.errs, errs...)
+		m.succeed += succeed
+		m.total += total
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.total++
+	if err == nil {
+		m.succeed++
+		return
+	}
+	m.errs = append(m.errs, err)
+}
+
+// Errors returns a copy of the collected errors
+func (m *MultiError) Errors() []error {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	errs := make([]error, len(m.errs))
+	copy(errs, m.errs)
+	return errs
+}
+
+// Len returns the number of the collected errors
+func (m *MultiError) Len() int {
+	if m == nil {
+		return 0
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.errs)
+}
+
+// Succeed returns how many operations succeed and how many operations have been reported,
+// which is used for partial-success reporting.
+func (m *MultiError) Succeed() (succeed int, total int) {
+	if m == nil {
+		return 0, 0
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.succeed, m.total
+}
+
+// ErrorOrNil returns nil if there is no error, otherwise returns @m itself.
+// It should be used as the return value of functions which return error.
+func (m *MultiError) ErrorOrNil() error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Error implements error interface
+func (m *MultiError) Error() string {
+	errs := m.Errors()
+	switch len(errs) {
+	case 0:
+		return ""
+	case 1:
+		return errs[0].Error()
+	}
+
+	var b strings.Builder
+	succeed, total := m.Succeed()
+	fmt.Fprintf(&b, "%d errors occurred", len(errs))
+	if total > len(errs) {
+		fmt.Fprintf(&b, " (%d/%d succeed)", succeed, total)
+	}
+	b.WriteString(":")
+	for i, err := range errs {
+		fmt.Fprintf(&b, "\n\t* #%d: %s", i+1, strings.Replace(err.Error(), "\n", "\n\t  ", -1))
+	}
+	return b.String()
+}
+
+// Is reports whether any of the collected errors matches @target
+func (m *MultiError) Is(target error) bool {
+	for _, err := range m.Errors() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first collected error which matches @target
+func (m *MultiError) As(target interface{}) bool {
+	for _, err := range m.Errors() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Append appends @errs to @err, and returns nil if there is no error at all.
+// If @err is a *MultiError, @errs are appended to it, otherwise a new MultiError is created.
+// It returns error rather than *MultiError to avoid the typed nil trap:
+//
+//	var err error
+//	for _, k := range keys {
+//		err = gxerrors.Append(err, client.Delete(k))
+//	}
+//	return err
+func Append(err error, errs ...error) error {
+	m, ok := err.(*MultiError)
+	if !ok || m == nil {
+		m = NewMultiError()
+		if err != nil {
+			m.Add(err)
+		}
+	}
+	for _, e := range errs {
+		m.Add(e)
+	}
+	return m.ErrorOrNil()
+}
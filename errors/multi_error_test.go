@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxerrors
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type myError struct {
+	code int
+}
+
+func (e *myError) Error() string {
+	return "my error"
+}
+
+func TestMultiError(t *testing.T) {
+	var m *MultiError
+	assert.Equal(t, 0, m.Len())
+	assert.Nil(t, NewMultiError(nil, nil).ErrorOrNil())
+
+	m = NewMultiError()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch i {
+			case 3:
+				m.Add(perrors.WithMessage(os.ErrNotExist, "open file"))
+			case 7:
+				m.Add(&myError{code: 7})
+			default:
+				m.Add(nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	err := m.ErrorOrNil()
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, m.Len())
+	succeed, total := m.Succeed()
+	assert.Equal(t, 8, succeed)
+	assert.Equal(t, 10, total)
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.False(t, errors.Is(err, os.ErrExist))
+	var me *myError
+	assert.True(t, errors.As(err, &me))
+	assert.Equal(t, 7, me.code)
+
+	assert.True(t, strings.HasPrefix(err.Error(), "2 errors occurred (8/10 succeed):"))
+}
+
+func TestAppend(t *testing.T) {
+	var err error
+	err = Append(err, nil)
+	// no typed nil
+	assert.True(t, err == nil)
+
+	first := errors.New("first")
+	err = Append(first, errors.New("second"), nil)
+	m, ok := err.(*MultiError)
+	assert.True(t, ok)
+	assert.Equal(t, 2, m.Len())
+
+	err = Append(err, NewMultiError(errors.New("third"), errors.New("fourth"), nil))
+	assert.Equal(t, 4, m.Len())
+	// the counts of the nested MultiError are merged
+	succeed, total := m.Succeed()
+	assert.Equal(t, 2, succeed)
+	assert.Equal(t, 6, total)
+	assert.True(t, errors.Is(err, first))
+	assert.Equal(t, "first", NewMultiError(first).Error())
+
+	// append to itself changes nothing
+	err = Append(m, m)
+	assert.Equal(t, 4, m.Len())
+	succeed, total = m.Succeed()
+	assert.Equal(t, 2, succeed)
+	assert.Equal(t, 6, total)
+
+	// a nil *MultiError is valid
+	var nm *MultiError
+	nm.Add(first)
+	assert.Equal(t, 0, nm.Len())
+	assert.Nil(t, nm.ErrorOrNil())
+}
@@ -0,0 +1,276 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxbytes "github.com/dubbogo/gost/bytes"
+)
+
+const defaultAsyncQueueSize = 1024
+
+var (
+	// ErrWriterClosed is returned when writing to a closed AsyncWriter
+	ErrWriterClosed = perrors.New("async writer closed")
+	// ErrSyncTimeout is returned when the buffered records are not flushed in time
+	ErrSyncTimeout = perrors.New("sync timeout")
+
+	// FatalSyncTimeout is the max time to wait for flushing buffered records
+	// before the process exits on fatal paths
+	FatalSyncTimeout = 3 * time.Second
+
+	// exit is replaced in tests
+	exit = os.Exit
+)
+
+// Syncer is a logger or writer which buffers records, Sync flushes all of them.
+// Syncers registered by RegisterSyncer are flushed before the process exits by Fatal or Exit.
+type Syncer interface {
+	Sync() error
+}
+
+// cancelableSyncer is a Syncer which stops waiting for the flush when @cancel is closed
+type cancelableSyncer interface {
+	syncUntil(cancel <-chan struct{}) error
+}
+
+var (
+	syncersLock sync.Mutex
+	syncers     = make(map[Syncer]struct{})
+)
+
+// RegisterSyncer lets @s be flushed on fatal paths
+func RegisterSyncer(s Syncer) {
+	syncersLock.Lock()
+	syncers[s] = struct{}{}
+	syncersLock.Unlock()
+}
+
+// UnregisterSyncer removes @s registered by RegisterSyncer
+func UnregisterSyncer(s Syncer) {
+	syncersLock.Lock()
+	delete(syncers, s)
+	syncersLock.Unlock()
+}
+
+// SyncAll flushes all registered syncers concurrently, and waits for @timeout at most.
+// It is supposed to be called by shutdown hooks.
+func SyncAll(timeout time.Duration) error {
+	syncersLock.Lock()
+	list := make([]Syncer, 0, len(syncers))
+	for s := range syncers {
+		list = append(list, s)
+	}
+	syncersLock.Unlock()
+
+	errCh := make(chan error, len(list))
+	cancel := make(chan struct{})
+	for _, s := range list {
+		go func(s Syncer) {
+			if cs, ok := s.(cancelableSyncer); ok {
+				errCh <- cs.syncUntil(cancel)
+				return
+			}
+			errCh <- syncTarget(s)
+		}(s)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var firstErr error
+	for range list {
+		select {
+		case err := <-errCh:
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		case <-timer.C:
+			// stop the syncers waiting for full queues
+			close(cancel)
+			return ErrSyncTimeout
+		}
+	}
+	return firstErr
+}
+
+// syncTarget syncs @w if it is a Syncer. Syncing a terminal or pipe, such as
+// os.Stderr, fails with EINVAL, which is not an error of flushing logs.
+func syncTarget(w interface{}) error {
+	s, ok := w.(Syncer)
+	if !ok {
+		return nil
+	}
+	err := s.Sync()
+	if f, ok := w.(*os.File); ok && errors.Is(err, syscall.EINVAL) {
+		if info, serr := f.Stat(); serr == nil && !info.Mode().IsRegular() {
+			return nil
+		}
+	}
+	return err
+}
+
+// Exit flushes all registered syncers within FatalSyncTimeout, and then exits with @code
+func Exit(code int) {
+	if err := SyncAll(FatalSyncTimeout); err != nil {
+		fmt.Fprintf(os.Stderr, "gxlog: flush logs before exit error: %v\n", err)
+	}
+	exit(code)
+}
+
+// Fatal prints the message like CFatal, and then exits after flushing all registered syncers
+func Fatal(format string, args ...interface{}) {
+	CEPrintfln(BRed, format, args...)
+	Exit(1)
+}
+
+// FlushOnPanic flushes all registered syncers within FatalSyncTimeout if the goroutine panics,
+// and then panics again. It should be deferred at the top of the main and long-lived goroutines:
+//
+//	defer gxlog.FlushOnPanic()
+func FlushOnPanic() {
+	if r := recover(); r != nil {
+		if err := SyncAll(FatalSyncTimeout); err != nil {
+			fmt.Fprintf(os.Stderr, "gxlog: flush logs on panic error: %v\n", err)
+		}
+		panic(r)
+	}
+}
+
+type asyncRecord struct {
+	bufp *[]byte
+	sync chan error // not nil for a sync request
+}
+
+// AsyncWriter writes records to the underlying writer in a background goroutine,
+// so the callers are not blocked by slow disks. Records are never dropped: Write
+// blocks if the queue is full. AsyncWriter registers itself by RegisterSyncer.
+type AsyncWriter struct {
+	w     io.Writer
+	queue chan asyncRecord
+
+	lock   sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to @w, @queueSize records can be buffered at most
+func NewAsyncWriter(w io.Writer, queueSize int) *AsyncWriter {
+	if queueSize < 1 {
+		queueSize = defaultAsyncQueueSize
+	}
+	a := &AsyncWriter{
+		w:     w,
+		queue: make(chan asyncRecord, queueSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	RegisterSyncer(a)
+	return a
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	for record := range a.queue {
+		if record.sync != nil {
+			record.sync <- syncTarget(a.w)
+			continue
+		}
+		// there is no way to return the error to the caller, just like log.Logger
+		_, _ = a.w.Write(*record.bufp)
+		gxbytes.ReleaseBytes(record.bufp)
+	}
+}
+
+// Write copies @p and queues it, the error of the underlying writer is ignored
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	bufp := gxbytes.AcquireBytes(len(p))
+	*bufp = append((*bufp)[:0], p...)
+
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.closed {
+		gxbytes.ReleaseBytes(bufp)
+		return 0, ErrWriterClosed
+	}
+	a.queue <- asyncRecord{bufp: bufp}
+	return len(p), nil
+}
+
+// Sync blocks until all records written before are flushed to the underlying writer,
+// and syncs the underlying writer if it is a Syncer, such as *os.File
+func (a *AsyncWriter) Sync() error {
+	return a.syncUntil(nil)
+}
+
+func (a *AsyncWriter) syncUntil(cancel <-chan struct{}) error {
+	ch := make(chan error, 1)
+
+	a.lock.RLock()
+	if a.closed {
+		a.lock.RUnlock()
+		select {
+		case <-a.done:
+			return syncTarget(a.w)
+		case <-cancel:
+			return ErrSyncTimeout
+		}
+	}
+	select {
+	case a.queue <- asyncRecord{sync: ch}:
+		a.lock.RUnlock()
+	case <-cancel:
+		a.lock.RUnlock()
+		return ErrSyncTimeout
+	}
+
+	select {
+	case err := <-ch:
+		return err
+	case <-cancel:
+		return ErrSyncTimeout
+	}
+}
+
+// Close flushes the buffered records and stops the background goroutine
+func (a *AsyncWriter) Close() error {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.lock.Unlock()
+
+	UnregisterSyncer(a)
+	<-a.done
+	return syncTarget(a.w)
+}
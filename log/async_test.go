@@ -0,0 +1,190 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxlog
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// slowWriter sleeps before every write
+type slowWriter struct {
+	lock   sync.Mutex
+	buf    bytes.Buffer
+	delay  time.Duration
+	synced int
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) Sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.synced++
+	return nil
+}
+
+func (w *slowWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	w := &slowWriter{delay: time.Millisecond}
+	a := NewAsyncWriter(w, 4)
+
+	line := []byte("hello\n")
+	for i := 0; i < 10; i++ {
+		n, err := a.Write(line)
+		assert.Nil(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	// the record is copied
+	line[0] = 'j'
+
+	assert.Nil(t, a.Sync())
+	assert.Equal(t, 10, bytes.Count([]byte(w.String()), []byte("hello\n")))
+	assert.Equal(t, 1, w.synced)
+
+	_, _ = a.Write([]byte("bye\n"))
+	assert.Nil(t, a.Close())
+	assert.Contains(t, w.String(), "bye\n")
+	assert.Nil(t, a.Close())
+	assert.Equal(t, 2, w.synced)
+	// the underlying writer is still synced after close
+	assert.Nil(t, a.Sync())
+	assert.Equal(t, 3, w.synced)
+	_, err := a.Write(line)
+	assert.Equal(t, ErrWriterClosed, err)
+}
+
+func TestSyncAll(t *testing.T) {
+	fast := NewAsyncWriter(&slowWriter{}, 0)
+	defer fast.Close()
+	slow := &slowWriter{delay: 200 * time.Millisecond}
+	a := NewAsyncWriter(slow, 0)
+
+	_, _ = fast.Write([]byte("fast\n"))
+	_, _ = a.Write([]byte("slow\n"))
+	assert.Equal(t, ErrSyncTimeout, SyncAll(10*time.Millisecond))
+	assert.Nil(t, SyncAll(time.Second))
+	assert.Equal(t, "slow\n", slow.String())
+
+	// closed writers are unregistered
+	assert.Nil(t, a.Close())
+	syncersLock.Lock()
+	_, ok := syncers[a]
+	syncersLock.Unlock()
+	assert.False(t, ok)
+}
+
+func TestSyncCancel(t *testing.T) {
+	block := make(chan struct{})
+	w := &blockWriter{block: block}
+	a := NewAsyncWriter(w, 1)
+	defer a.Close()
+	defer close(block)
+
+	// the writer is blocked and the queue is full
+	_, _ = a.Write([]byte("1\n"))
+	_, _ = a.Write([]byte("2\n"))
+
+	cancel := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.syncUntil(cancel)
+	}()
+	close(cancel)
+	select {
+	case err := <-errCh:
+		assert.Equal(t, ErrSyncTimeout, err)
+	case <-time.After(time.Second):
+		t.Fatal("sync should return when it is cancelled")
+	}
+}
+
+func TestSyncTarget(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	// syncing a pipe is not an error
+	assert.Nil(t, syncTarget(w))
+	assert.Nil(t, syncTarget(&bytes.Buffer{}))
+}
+
+// blockWriter blocks every write until block is closed
+type blockWriter struct {
+	block chan struct{}
+}
+
+func (w *blockWriter) Write(p []byte) (int, error) {
+	<-w.block
+	return len(p), nil
+}
+
+func TestExit(t *testing.T) {
+	code := -1
+	oldExit := exit
+	exit = func(c int) {
+		code = c
+	}
+	defer func() {
+		exit = oldExit
+	}()
+
+	w := &slowWriter{delay: 10 * time.Millisecond}
+	a := NewAsyncWriter(w, 0)
+	defer a.Close()
+
+	_, _ = a.Write([]byte("last words\n"))
+	Fatal("fatal error")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "last words\n", w.String())
+}
+
+func TestFlushOnPanic(t *testing.T) {
+	w := &slowWriter{delay: 10 * time.Millisecond}
+	a := NewAsyncWriter(w, 0)
+	defer a.Close()
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer FlushOnPanic()
+		_, _ = a.Write([]byte("before panic\n"))
+		panic("boom")
+	})
+	assert.Equal(t, "before panic\n", w.String())
+
+	// nothing happens without panic
+	func() {
+		defer FlushOnPanic()
+	}()
+}
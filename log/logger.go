@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxlog
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Field is a key value pair of the structured log
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any returns a field of @key and @value
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err returns the field of the error @err keyed by "error"
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// Logger is the structured logger used by the gost components, it is easy to
+// be adapted to zap, logrus and other loggers.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+type stdLogger struct {
+	l     *log.Logger
+	debug bool
+}
+
+// NewStdLogger returns a Logger writing to @l with the format "[LEVEL] msg key=value ...".
+// It writes to stderr if @l is nil, and drops the debug logs if @debug is false.
+func NewStdLogger(l *log.Logger, debug bool) Logger {
+	if l == nil {
+		l = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &stdLogger{l: l, debug: debug}
+}
+
+func (s *stdLogger) output(level, msg string, fields []Field) {
+	var b strings.Builder
+	b.WriteString("[")
+	b.WriteString(level)
+	b.WriteString("] ")
+	b.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	s.l.Print(b.String())
+}
+
+func (s *stdLogger) Debug(msg string, fields ...Field) {
+	if s.debug {
+		s.output("DEBUG", msg, fields)
+	}
+}
+
+func (s *stdLogger) Info(msg string, fields ...Field) {
+	s.output("INFO", msg, fields)
+}
+
+func (s *stdLogger) Warn(msg string, fields ...Field) {
+	s.output("WARN", msg, fields)
+}
+
+func (s *stdLogger) Error(msg string, fields ...Field) {
+	s.output("ERROR", msg, fields)
+}
+
+type nopLogger struct{}
+
+// NewNopLogger returns a Logger dropping all logs
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...Field) {}
+func (nopLogger) Info(string, ...Field)  {}
+func (nopLogger) Warn(string, ...Field)  {}
+func (nopLogger) Error(string, ...Field) {}
@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxlog
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(log.New(&buf, "", 0), false)
+
+	l.Debug("hidden")
+	assert.Equal(t, 0, buf.Len())
+
+	l.Info("connected", Any("name", "etcd"), Any("attempt", 2))
+	assert.Equal(t, "[INFO] connected name=etcd attempt=2\n", buf.String())
+
+	buf.Reset()
+	l.Error("failed", Err(errors.New("timeout")))
+	assert.Equal(t, "[ERROR] failed error=timeout\n", buf.String())
+
+	buf.Reset()
+	l = NewStdLogger(log.New(&buf, "", 0), true)
+	l.Debug("shown")
+	assert.Equal(t, "[DEBUG] shown\n", buf.String())
+
+	NewNopLogger().Warn("dropped", Any("k", "v"))
+}
@@ -0,0 +1,298 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmath
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	defaultHistogramPrecision = 7
+	maxHistogramPrecision     = 16
+)
+
+// ErrHistogramMismatch is returned when merging histograms of different layouts
+var ErrHistogramMismatch = perrors.New("histograms of different precision or max value")
+
+type histogramOptions struct {
+	precision int
+	maxValue  int64
+}
+
+// HistogramOption is optional settings for Histogram
+type HistogramOption func(*histogramOptions)
+
+// WithPrecision sets the number of significant bits kept for the recorded values,
+// the relative error of the percentiles is less than 1/2^(precision-1). It is 7
+// by default, which means the error is less than 1.6%.
+func WithPrecision(precision int) HistogramOption {
+	return func(o *histogramOptions) {
+		o.precision = precision
+	}
+}
+
+// WithMaxValue sets the max trackable value, larger values are recorded as it.
+// A smaller max value saves the memory of the buckets.
+func WithMaxValue(v int64) HistogramOption {
+	return func(o *histogramOptions) {
+		o.maxValue = v
+	}
+}
+
+// Histogram records non-negative values, such as latencies, into log-linear
+// buckets like HdrHistogram, and estimates the percentiles of them. It is
+// lock free, and histograms of the same layout can be merged.
+type Histogram struct {
+	precision int
+	half      int64 // half of the sub buckets of a bucket
+	maxValue  int64
+	counts    []uint64
+
+	count uint64
+	sum   int64
+	min   int64
+	max   int64
+}
+
+// NewHistogram returns an empty Histogram
+func NewHistogram(opts ...HistogramOption) *Histogram {
+	o := histogramOptions{
+		precision: defaultHistogramPrecision,
+		maxValue:  math.MaxInt64,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.precision < 1 {
+		o.precision = 1
+	}
+	if o.precision > maxHistogramPrecision {
+		o.precision = maxHistogramPrecision
+	}
+	if o.maxValue < 1 {
+		o.maxValue = math.MaxInt64
+	}
+
+	h := &Histogram{
+		precision: o.precision,
+		half:      1 << (o.precision - 1),
+		maxValue:  o.maxValue,
+		min:       math.MaxInt64,
+	}
+	h.counts = make([]uint64, h.index(o.maxValue)+1)
+	return h
+}
+
+// index returns the bucket index of @v. The values less than 2^precision have
+// their own buckets, the larger ones are grouped by the highest @precision bits.
+func (h *Histogram) index(v int64) int {
+	shift := bits.Len64(uint64(v)) - h.precision
+	if shift <= 0 {
+		return int(v)
+	}
+	top := v >> uint(shift)
+	return int(int64(shift+1)*h.half + top - h.half)
+}
+
+// highest returns the highest value of the bucket @idx
+func (h *Histogram) highest(idx int) int64 {
+	if int64(idx) < 2*h.half {
+		return int64(idx)
+	}
+	shift := int64(idx)/h.half - 1
+	top := int64(idx)%h.half + h.half
+	upper := uint64(top+1)<<uint(shift) - 1
+	if upper > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(upper)
+}
+
+// Record records the value @v, negative values are recorded as 0
+func (h *Histogram) Record(v int64) {
+	h.RecordN(v, 1)
+}
+
+// RecordDuration records the duration @d in nanoseconds
+func (h *Histogram) RecordDuration(d time.Duration) {
+	h.RecordN(int64(d), 1)
+}
+
+// RecordN records the value @v for @n times
+func (h *Histogram) RecordN(v int64, n uint64) {
+	if n == 0 {
+		return
+	}
+	if v < 0 {
+		v = 0
+	}
+	if v > h.maxValue {
+		v = h.maxValue
+	}
+
+	atomic.AddUint64(&h.counts[h.index(v)], n)
+	atomic.AddInt64(&h.sum, v*int64(n))
+	atomic.AddUint64(&h.count, n)
+	h.updateMin(v)
+	h.updateMax(v)
+}
+
+func (h *Histogram) updateMin(v int64) {
+	for {
+		min := atomic.LoadInt64(&h.min)
+		if v >= min || atomic.CompareAndSwapInt64(&h.min, min, v) {
+			return
+		}
+	}
+}
+
+func (h *Histogram) updateMax(v int64) {
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if v <= max || atomic.CompareAndSwapInt64(&h.max, max, v) {
+			return
+		}
+	}
+}
+
+// Count returns the number of the recorded values
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// Sum returns the sum of the recorded values
+func (h *Histogram) Sum() int64 {
+	return atomic.LoadInt64(&h.sum)
+}
+
+// Min returns the min recorded value, or 0 if nothing is recorded
+func (h *Histogram) Min() int64 {
+	if h.Count() == 0 {
+		return 0
+	}
+	return atomic.LoadInt64(&h.min)
+}
+
+// Max returns the max recorded value
+func (h *Histogram) Max() int64 {
+	return atomic.LoadInt64(&h.max)
+}
+
+// Mean returns the mean of the recorded values
+func (h *Histogram) Mean() float64 {
+	count := h.Count()
+	if count == 0 {
+		return 0
+	}
+	return float64(h.Sum()) / float64(count)
+}
+
+// Percentile returns the estimated value at the percentile @p, which is in [0, 100],
+// e.g. Percentile(99.9) returns the p999. It returns 0 if nothing is recorded.
+func (h *Histogram) Percentile(p float64) int64 {
+	return h.Percentiles(p)[0]
+}
+
+// Percentiles returns the values at the percentiles @ps by one scan of the buckets
+func (h *Histogram) Percentiles(ps ...float64) []int64 {
+	values := make([]int64, len(ps))
+	counts := make([]uint64, len(h.counts))
+	var total uint64
+	for i := range h.counts {
+		counts[i] = atomic.LoadUint64(&h.counts[i])
+		total += counts[i]
+	}
+	if total == 0 {
+		return values
+	}
+
+	min, max := h.Min(), h.Max()
+	for i, p := range ps {
+		if p < 0 {
+			p = 0
+		}
+		if p > 100 {
+			p = 100
+		}
+		rank := uint64(math.Ceil(p / 100 * float64(total)))
+		if rank == 0 {
+			rank = 1
+		}
+
+		var cum uint64
+		for idx, c := range counts {
+			cum += c
+			if cum >= rank {
+				values[i] = h.highest(idx)
+				break
+			}
+		}
+		// the bucket bounds are coarser than the recorded extremes
+		if values[i] > max {
+			values[i] = max
+		}
+		if values[i] < min {
+			values[i] = min
+		}
+	}
+	return values
+}
+
+// Merge adds the values recorded by @other to h, the two histograms should be
+// created with the same precision and max value.
+func (h *Histogram) Merge(other *Histogram) error {
+	if other == nil || other == h {
+		return nil
+	}
+	if h.precision != other.precision || h.maxValue != other.maxValue {
+		return perrors.WithStack(ErrHistogramMismatch)
+	}
+
+	count := other.Count()
+	if count == 0 {
+		return nil
+	}
+	for i := range other.counts {
+		if c := atomic.LoadUint64(&other.counts[i]); c > 0 {
+			atomic.AddUint64(&h.counts[i], c)
+		}
+	}
+	atomic.AddInt64(&h.sum, other.Sum())
+	atomic.AddUint64(&h.count, count)
+	h.updateMin(other.Min())
+	h.updateMax(other.Max())
+	return nil
+}
+
+// Reset clears the recorded values
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		atomic.StoreUint64(&h.counts[i], 0)
+	}
+	atomic.StoreUint64(&h.count, 0)
+	atomic.StoreInt64(&h.sum, 0)
+	atomic.StoreInt64(&h.min, math.MaxInt64)
+	atomic.StoreInt64(&h.max, 0)
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxmath
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramIndex(t *testing.T) {
+	h := NewHistogram(WithPrecision(4))
+	last := -1
+	for v := int64(0); v < 1<<16; v++ {
+		idx := h.index(v)
+		assert.True(t, idx == last || idx == last+1, "value %d", v)
+		assert.True(t, v <= h.highest(idx), "value %d", v)
+		if idx > 0 {
+			assert.True(t, v > h.highest(idx-1), "value %d", v)
+		}
+		last = idx
+	}
+	assert.Equal(t, int64(math.MaxInt64), h.highest(h.index(math.MaxInt64)))
+	assert.Equal(t, len(h.counts)-1, h.index(math.MaxInt64))
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	h := NewHistogram()
+	assert.Equal(t, int64(0), h.Percentile(99))
+	assert.Equal(t, int64(0), h.Min())
+
+	values := make([]int64, 0, 100000)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < cap(values); i++ {
+		v := int64(r.ExpFloat64() * float64(time.Millisecond))
+		values = append(values, v)
+		h.Record(v)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	assert.Equal(t, uint64(len(values)), h.Count())
+	assert.Equal(t, values[0], h.Min())
+	assert.Equal(t, values[len(values)-1], h.Max())
+	assert.Equal(t, values[len(values)-1], h.Percentile(100))
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		want := values[int(math.Ceil(p/100*float64(len(values))))-1]
+		got := h.Percentile(p)
+		assert.True(t, DeltaCompareFloat64(float64(want), float64(got), float64(want)/64), "p%v want %d got %d", p, want, got)
+	}
+}
+
+func TestHistogramRecord(t *testing.T) {
+	h := NewHistogram(WithMaxValue(1000))
+	h.Record(-5)
+	h.RecordN(10, 3)
+	h.RecordN(20, 0)
+	h.RecordDuration(time.Hour)
+
+	assert.Equal(t, uint64(5), h.Count())
+	assert.Equal(t, int64(1030), h.Sum())
+	assert.Equal(t, int64(0), h.Min())
+	assert.Equal(t, int64(1000), h.Max())
+	assert.Equal(t, 206.0, h.Mean())
+	assert.Equal(t, []int64{0, 10, 10, 1000}, h.Percentiles(0, 50, 80, 100))
+
+	h.Reset()
+	assert.Equal(t, uint64(0), h.Count())
+	assert.Equal(t, int64(0), h.Max())
+	assert.Equal(t, 0.0, h.Mean())
+}
+
+func TestHistogramMerge(t *testing.T) {
+	h1, h2 := NewHistogram(), NewHistogram()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for v := int64(1); v <= 1000; v++ {
+				if i%2 == 0 {
+					h1.Record(v)
+				} else {
+					h2.Record(v + 1000)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Nil(t, h1.Merge(h2))
+	assert.Nil(t, h1.Merge(h1))
+	assert.Nil(t, h1.Merge(nil))
+	assert.Equal(t, uint64(4000), h1.Count())
+	assert.Equal(t, int64(1), h1.Min())
+	assert.Equal(t, int64(2000), h1.Max())
+	assert.True(t, DeltaCompareFloat64(1000, float64(h1.Percentile(50)), 1000.0/64))
+
+	err := h1.Merge(NewHistogram(WithPrecision(3)))
+	assert.Equal(t, ErrHistogramMismatch, perrors.Cause(err))
+}
+
+func BenchmarkHistogramRecord(b *testing.B) {
+	h := NewHistogram()
+	b.RunParallel(func(pb *testing.PB) {
+		v := int64(0)
+		for pb.Next() {
+			v++
+			h.Record(v)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxnet
+
+import (
+	"context"
+	"net"
+)
+
+import (
+	gxtime "github.com/dubbogo/gost/time"
+)
+
+// RateLimitedConn limits the read and write bandwidth of a net.Conn in bytes per second.
+// A bucket can be shared by many connections to limit their total bandwidth.
+type RateLimitedConn struct {
+	net.Conn
+	readBucket  *gxtime.TokenBucket
+	writeBucket *gxtime.TokenBucket
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewRateLimitedConn wraps @conn, its read bandwidth is limited by @readBucket and its write
+// bandwidth is limited by @writeBucket. A nil bucket means unlimited.
+func NewRateLimitedConn(conn net.Conn, readBucket, writeBucket *gxtime.TokenBucket) *RateLimitedConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RateLimitedConn{
+		Conn:        conn,
+		readBucket:  readBucket,
+		writeBucket: writeBucket,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Read reads at most a burst of bytes, and then waits until the bytes are paid
+func (c *RateLimitedConn) Read(p []byte) (int, error) {
+	if c.readBucket == nil {
+		return c.Conn.Read(p)
+	}
+
+	if burst := c.readBucket.Burst(); int64(len(p)) > burst {
+		p = p[:burst]
+	}
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		if waitErr := c.readBucket.Wait(c.ctx, int64(n)); waitErr != nil && err == nil {
+			err = net.ErrClosed
+		}
+	}
+	return n, err
+}
+
+// Write splits @p into chunks of a burst, and waits for the tokens before writing every chunk
+func (c *RateLimitedConn) Write(p []byte) (int, error) {
+	if c.writeBucket == nil {
+		return c.Conn.Write(p)
+	}
+
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if burst := c.writeBucket.Burst(); int64(len(chunk)) > burst {
+			chunk = chunk[:burst]
+		}
+		if err := c.writeBucket.Wait(c.ctx, int64(len(chunk))); err != nil {
+			return written, net.ErrClosed
+		}
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close closes the connection and wakes up the blocked readers and writers
+func (c *RateLimitedConn) Close() error {
+	c.cancel()
+	return c.Conn.Close()
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxnet
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxtime "github.com/dubbogo/gost/time"
+)
+
+func TestRateLimitedConnWrite(t *testing.T) {
+	c1, c2 := net.Pipe()
+	// 1000 bytes per second, the first 100 bytes are free
+	conn := NewRateLimitedConn(c1, nil, gxtime.NewTokenBucket(1000, 100))
+	defer conn.Close()
+	defer c2.Close()
+
+	go io.Copy(io.Discard, c2)
+
+	start := time.Now()
+	n, err := conn.Write(make([]byte, 300))
+	assert.Nil(t, err)
+	assert.Equal(t, 300, n)
+	assert.True(t, time.Since(start) >= 150*time.Millisecond, time.Since(start))
+}
+
+func TestRateLimitedConnRead(t *testing.T) {
+	c1, c2 := net.Pipe()
+	conn := NewRateLimitedConn(c1, gxtime.NewTokenBucket(1000, 100), nil)
+	defer c2.Close()
+
+	go c2.Write(make([]byte, 300))
+
+	start := time.Now()
+	buf := make([]byte, 300)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	// a read never exceeds the burst
+	assert.Equal(t, 100, n)
+	_, err = io.ReadFull(conn, buf[n:])
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 150*time.Millisecond, time.Since(start))
+
+	// the blocked reader is woken up by Close
+	go c2.Write(make([]byte, 100))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		conn.Close()
+	}()
+	start = time.Now()
+	n, err = conn.Read(buf[:100])
+	assert.Equal(t, 100, n)
+	assert.Equal(t, net.ErrClosed, err)
+	assert.True(t, time.Since(start) < 80*time.Millisecond, time.Since(start))
+}
+
+func TestRateLimitedConnSharedBucket(t *testing.T) {
+	bucket := gxtime.NewTokenBucket(1000, 100)
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		c1, c2 := net.Pipe()
+		go io.Copy(io.Discard, c2)
+		conn := NewRateLimitedConn(c1, nil, bucket)
+		_, err := conn.Write(make([]byte, 150))
+		assert.Nil(t, err)
+		conn.Close()
+		c2.Close()
+	}
+	assert.True(t, time.Since(start) >= 150*time.Millisecond, time.Since(start))
+}
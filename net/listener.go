@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxnet
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// DrainingListener tracks the connections accepted by a net.Listener. Shutdown stops
+// accepting new connections and waits for the accepted ones to be closed by their owners.
+type DrainingListener struct {
+	net.Listener
+
+	lock     sync.Mutex
+	conns    map[*drainingConn]struct{}
+	draining bool
+	idle     chan struct{} // closed when all connections are closed during draining
+}
+
+// NewDrainingListener wraps @l
+func NewDrainingListener(l net.Listener) *DrainingListener {
+	return &DrainingListener{
+		Listener: l,
+		conns:    make(map[*drainingConn]struct{}),
+		idle:     make(chan struct{}),
+	}
+}
+
+// Accept waits for and returns the next connection, it returns net.ErrClosed after Shutdown
+func (l *DrainingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.draining {
+		conn.Close()
+		return nil, net.ErrClosed
+	}
+	c := &drainingConn{Conn: conn, l: l}
+	l.conns[c] = struct{}{}
+	return c, nil
+}
+
+// ActiveConns returns the number of accepted connections which are not closed
+func (l *DrainingListener) ActiveConns() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return len(l.conns)
+}
+
+func (l *DrainingListener) remove(c *drainingConn) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.conns, c)
+	if l.draining && len(l.conns) == 0 {
+		l.closeIdle()
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (l *DrainingListener) closeIdle() {
+	select {
+	case <-l.idle:
+	default:
+		close(l.idle)
+	}
+}
+
+// Shutdown closes the listener, and waits for all accepted connections to be closed.
+// The remaining connections are closed forcibly when @ctx is done, and ctx.Err() is returned.
+func (l *DrainingListener) Shutdown(ctx context.Context) error {
+	l.lock.Lock()
+	l.draining = true
+	if len(l.conns) == 0 {
+		l.closeIdle()
+	}
+	l.lock.Unlock()
+
+	err := l.Listener.Close()
+	select {
+	case <-l.idle:
+		return err
+	case <-ctx.Done():
+	}
+
+	l.lock.Lock()
+	conns := make([]*drainingConn, 0, len(l.conns))
+	for c := range l.conns {
+		conns = append(conns, c)
+	}
+	l.lock.Unlock()
+	for _, c := range conns {
+		c.Close()
+	}
+	return ctx.Err()
+}
+
+type drainingConn struct {
+	net.Conn
+	l    *DrainingListener
+	once sync.Once
+}
+
+func (c *drainingConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.l.remove(c)
+	})
+	return err
+}
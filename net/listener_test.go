@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxnet
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainingListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	l := NewDrainingListener(ln)
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	var clients []net.Conn
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		assert.Nil(t, err)
+		clients = append(clients, c)
+		defer c.Close()
+	}
+	c1, c2 := <-accepted, <-accepted
+	assert.Equal(t, 2, l.ActiveConns())
+
+	// the first connection is closed by its owner during draining
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c1.Close()
+		c1.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Shutdown(ctx))
+	// the second connection is closed forcibly
+	assert.Equal(t, 0, l.ActiveConns())
+	_, err = c2.Write([]byte("x"))
+	assert.NotNil(t, err)
+
+	_, err = net.Dial("tcp", ln.Addr().String())
+	assert.NotNil(t, err)
+}
+
+func TestDrainingListenerIdle(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	l := NewDrainingListener(ln)
+
+	go func() {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err == nil {
+			c.Close()
+		}
+	}()
+	conn, err := l.Accept()
+	assert.Nil(t, err)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		conn.Close()
+	}()
+
+	start := time.Now()
+	assert.Nil(t, l.Shutdown(context.Background()))
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
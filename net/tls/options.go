@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtls
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// Options tls configuration. The certificate is hot-reloaded by polling the
+// modification time of its files every ReloadInterval, or on SIGHUP if
+// ReloadOnSIGHUP is set. It is never reloaded automatically if neither is set.
+type Options struct {
+	// CertFile path of the PEM encoded certificate
+	CertFile string
+	// KeyFile path of the PEM encoded private key
+	KeyFile string
+	// CAFile path of the PEM encoded CA certificates to verify the peer
+	CAFile string
+	// ServerName used by clients to verify the hostname of the server
+	ServerName string
+	// InsecureSkipVerify whether clients skip verifying the server certificate
+	InsecureSkipVerify bool
+	// ClientAuth server policy of client certificates
+	ClientAuth tls.ClientAuthType
+	// MinVersion min tls version, tls 1.2 by default
+	MinVersion uint16
+	// CipherSuites cipher suites of tls 1.2, secure AEAD suites by default
+	CipherSuites []uint16
+	// ReloadInterval interval of checking the certificate files, no polling if it is not positive
+	ReloadInterval time.Duration
+	// ReloadOnSIGHUP whether to reload the certificate on SIGHUP
+	ReloadOnSIGHUP bool
+	// ReloadErrorHandler is called with the errors of automatic reloading, they are logged by default
+	ReloadErrorHandler func(err error)
+}
+
+// Option will define a function of handling Options
+type Option func(*Options)
+
+// WithCertFiles sets the certificate and private key files
+func WithCertFiles(certFile, keyFile string) Option {
+	return func(opt *Options) {
+		opt.CertFile = certFile
+		opt.KeyFile = keyFile
+	}
+}
+
+// WithCAFile sets the CA file to verify the peer
+func WithCAFile(caFile string) Option {
+	return func(opt *Options) {
+		opt.CAFile = caFile
+	}
+}
+
+// WithServerName sets the server name verified by clients
+func WithServerName(name string) Option {
+	return func(opt *Options) {
+		opt.ServerName = name
+	}
+}
+
+// WithInsecureSkipVerify lets clients skip verifying the server certificate
+func WithInsecureSkipVerify() Option {
+	return func(opt *Options) {
+		opt.InsecureSkipVerify = true
+	}
+}
+
+// WithClientAuth sets the server policy of client certificates
+func WithClientAuth(auth tls.ClientAuthType) Option {
+	return func(opt *Options) {
+		opt.ClientAuth = auth
+	}
+}
+
+// WithMinVersion sets the min tls version
+func WithMinVersion(version uint16) Option {
+	return func(opt *Options) {
+		opt.MinVersion = version
+	}
+}
+
+// WithCipherSuites sets the cipher suites of tls 1.2
+func WithCipherSuites(suites ...uint16) Option {
+	return func(opt *Options) {
+		opt.CipherSuites = suites
+	}
+}
+
+// WithReloadInterval polls the certificate files every @interval, and reloads
+// the certificate when they are modified
+func WithReloadInterval(interval time.Duration) Option {
+	return func(opt *Options) {
+		opt.ReloadInterval = interval
+	}
+}
+
+// WithReloadOnSIGHUP reloads the certificate when receiving SIGHUP
+func WithReloadOnSIGHUP() Option {
+	return func(opt *Options) {
+		opt.ReloadOnSIGHUP = true
+	}
+}
+
+// WithReloadErrorHandler sets the handler of the errors of automatic reloading,
+// the current certificate is kept when an error occurs
+func WithReloadErrorHandler(handler func(err error)) Option {
+	return func(opt *Options) {
+		opt.ReloadErrorHandler = handler
+	}
+}
@@ -0,0 +1,284 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package gxtls builds tls configurations whose certificates can be hot-reloaded
+package gxtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxlog "github.com/dubbogo/gost/log"
+)
+
+var defaultCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// NewServerConfig returns a server tls config. The certificate files are required,
+// and the returned CertReloader must be closed when the config is not used any more.
+func NewServerConfig(opts ...Option) (*tls.Config, *CertReloader, error) {
+	options := newOptions(opts...)
+	if options.CertFile == "" || options.KeyFile == "" {
+		return nil, nil, perrors.New("server tls config requires cert file and key file")
+	}
+
+	config := baseConfig(options)
+	config.ClientAuth = options.ClientAuth
+	if options.CAFile != "" {
+		pool, err := loadCertPool(options.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		config.ClientCAs = pool
+		if config.ClientAuth == tls.NoClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	reloader, err := NewCertReloader(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.GetCertificate = reloader.GetCertificate
+	return config, reloader, nil
+}
+
+// NewClientConfig returns a client tls config. The certificate files are optional,
+// they are required only if the server verifies client certificates.
+// The returned CertReloader is nil if there is no certificate files.
+func NewClientConfig(opts ...Option) (*tls.Config, *CertReloader, error) {
+	options := newOptions(opts...)
+
+	config := baseConfig(options)
+	config.ServerName = options.ServerName
+	config.InsecureSkipVerify = options.InsecureSkipVerify
+	if options.CAFile != "" {
+		pool, err := loadCertPool(options.CAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if options.CertFile == "" || options.KeyFile == "" {
+		return config, nil, nil
+	}
+	reloader, err := NewCertReloader(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.GetClientCertificate = reloader.GetClientCertificate
+	return config, reloader, nil
+}
+
+func newOptions(opts ...Option) *Options {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+func baseConfig(options *Options) *tls.Config {
+	config := &tls.Config{
+		MinVersion:   options.MinVersion,
+		CipherSuites: options.CipherSuites,
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	if len(config.CipherSuites) == 0 {
+		config.CipherSuites = defaultCipherSuites
+	}
+	return config
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "read ca file %s", caFile)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, perrors.Errorf("no certificate found in ca file %s", caFile)
+	}
+	return pool, nil
+}
+
+// CertReloader holds a certificate which is reloaded when its files are
+// modified or the process receives SIGHUP
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	onError  func(err error)
+
+	cert    atomic.Value // *tls.Certificate
+	lock    sync.Mutex
+	modTime time.Time
+
+	sigCh chan os.Signal
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewCertReloader loads the certificate files of @options and starts to watch them
+func NewCertReloader(options *Options) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: options.CertFile,
+		keyFile:  options.KeyFile,
+		onError:  options.ReloadErrorHandler,
+		done:     make(chan struct{}),
+	}
+	if r.onError == nil {
+		r.onError = r.logError
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	var tick <-chan time.Time
+	if options.ReloadInterval > 0 {
+		ticker := time.NewTicker(options.ReloadInterval)
+		go func() {
+			<-r.done
+			ticker.Stop()
+		}()
+		tick = ticker.C
+	}
+	if options.ReloadOnSIGHUP {
+		r.sigCh = make(chan os.Signal, 1)
+		signal.Notify(r.sigCh, syscall.SIGHUP)
+	}
+	if tick != nil || r.sigCh != nil {
+		go r.watch(tick)
+	}
+	return r, nil
+}
+
+func (r *CertReloader) watch(tick <-chan time.Time) {
+	for {
+		var err error
+		select {
+		case <-r.done:
+			return
+		case <-tick:
+			err = r.reloadIfModified()
+		case <-r.sigCh:
+			err = r.Reload()
+		}
+		if err != nil {
+			r.onError(err)
+		}
+	}
+}
+
+func (r *CertReloader) logError(err error) {
+	gxlog.CError("gost/CertReloader reload certificate %s error: %v", r.certFile, err)
+}
+
+func (r *CertReloader) lastModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *CertReloader) reloadIfModified() error {
+	modTime, err := r.lastModTime()
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	modified := !modTime.Equal(r.modTime)
+	r.lock.Unlock()
+	if !modified {
+		return nil
+	}
+	return r.Reload()
+}
+
+// Reload loads the certificate files. The current certificate is kept if they are invalid.
+func (r *CertReloader) Reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	modTime, err := r.lastModTime()
+	if err != nil {
+		return perrors.WithMessage(err, "stat certificate files")
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return perrors.WithMessagef(err, "load certificate %s", r.certFile)
+	}
+	r.cert.Store(&cert)
+	r.modTime = modTime
+	return nil
+}
+
+// Certificate returns the current certificate
+func (r *CertReloader) Certificate() *tls.Certificate {
+	return r.cert.Load().(*tls.Certificate)
+}
+
+// GetCertificate is used as tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate is used as tls.Config.GetClientCertificate
+func (r *CertReloader) GetClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// Close stops watching the certificate files
+func (r *CertReloader) Close() {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		close(r.done)
+		if r.sigCh != nil {
+			signal.Stop(r.sigCh)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCert writes a self-signed certificate for localhost into @dir
+func writeCert(t *testing.T, dir string, serial int64) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600)
+	assert.Nil(t, err)
+	return certFile, keyFile
+}
+
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) *x509.Certificate {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Server(c1, serverConfig).Handshake()
+	}()
+	client := tls.Client(c2, clientConfig)
+	assert.Nil(t, client.Handshake())
+	assert.Nil(t, <-errCh)
+	return client.ConnectionState().PeerCertificates[0]
+}
+
+func TestNewServerConfig(t *testing.T) {
+	_, _, err := NewServerConfig()
+	assert.NotNil(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, 1)
+
+	serverConfig, reloader, err := NewServerConfig(WithCertFiles(certFile, keyFile))
+	assert.Nil(t, err)
+	defer reloader.Close()
+	assert.Equal(t, uint16(tls.VersionTLS12), serverConfig.MinVersion)
+	assert.Equal(t, defaultCipherSuites, serverConfig.CipherSuites)
+
+	clientConfig, clientReloader, err := NewClientConfig(WithCAFile(certFile), WithServerName("localhost"))
+	assert.Nil(t, err)
+	assert.Nil(t, clientReloader)
+	assert.Equal(t, int64(1), handshake(t, serverConfig, clientConfig).SerialNumber.Int64())
+
+	// invalid files keep the current certificate
+	err = ioutil.WriteFile(certFile, []byte("invalid"), 0o600)
+	assert.Nil(t, err)
+	assert.NotNil(t, reloader.Reload())
+	assert.Equal(t, int64(1), handshake(t, serverConfig, clientConfig).SerialNumber.Int64())
+
+	writeCert(t, dir, 2)
+	assert.Nil(t, reloader.Reload())
+	clientConfig, _, err = NewClientConfig(WithCAFile(certFile), WithServerName("localhost"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), handshake(t, serverConfig, clientConfig).SerialNumber.Int64())
+}
+
+func TestMutualTLS(t *testing.T) {
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	serverCert, serverKey := writeCert(t, serverDir, 1)
+	clientCert, clientKey := writeCert(t, clientDir, 2)
+
+	serverConfig, serverReloader, err := NewServerConfig(WithCertFiles(serverCert, serverKey), WithCAFile(clientCert))
+	assert.Nil(t, err)
+	defer serverReloader.Close()
+	assert.Equal(t, tls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+
+	clientConfig, clientReloader, err := NewClientConfig(WithCertFiles(clientCert, clientKey),
+		WithCAFile(serverCert), WithServerName("localhost"))
+	assert.Nil(t, err)
+	defer clientReloader.Close()
+	handshake(t, serverConfig, clientConfig)
+
+	// the client without certificate is rejected
+	clientConfig, _, err = NewClientConfig(WithInsecureSkipVerify())
+	assert.Nil(t, err)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	go func() {
+		tls.Client(c2, clientConfig).Handshake()
+		c2.Close()
+	}()
+	assert.NotNil(t, tls.Server(c1, serverConfig).Handshake())
+}
+
+func TestCertReloaderPolling(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, 1)
+
+	_, reloader, err := NewServerConfig(WithCertFiles(certFile, keyFile), WithReloadInterval(10*time.Millisecond))
+	assert.Nil(t, err)
+	defer reloader.Close()
+	assert.Equal(t, int64(1), serial(t, reloader))
+
+	writeCert(t, dir, 2)
+	// make sure the modification time changes on coarse grained file systems
+	future := time.Now().Add(time.Second)
+	assert.Nil(t, os.Chtimes(certFile, future, future))
+	assert.Eventually(t, func() bool {
+		return serial(t, reloader) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestCertReloaderErrorHandler(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, 1)
+
+	errCh := make(chan error, 1)
+	_, reloader, err := NewServerConfig(WithCertFiles(certFile, keyFile), WithReloadInterval(10*time.Millisecond),
+		WithReloadErrorHandler(func(err error) {
+			select {
+			case errCh <- err:
+			default:
+			}
+		}))
+	assert.Nil(t, err)
+	defer reloader.Close()
+
+	assert.Nil(t, ioutil.WriteFile(certFile, []byte("invalid"), 0o600))
+	future := time.Now().Add(time.Second)
+	assert.Nil(t, os.Chtimes(certFile, future, future))
+	select {
+	case err = <-errCh:
+		assert.NotNil(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expect the reload error")
+	}
+	// the current certificate is kept
+	assert.Equal(t, int64(1), serial(t, reloader))
+}
+
+func serial(t *testing.T, r *CertReloader) int64 {
+	cert, err := x509.ParseCertificate(r.Certificate().Certificate[0])
+	assert.Nil(t, err)
+	return cert.SerialNumber.Int64()
+}
@@ -0,0 +1,274 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxos encapsulates some golang.os functions
+package gxos
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	defaultHookTimeout = 3 * time.Second
+)
+
+var (
+	// ErrShutdownHookTimeout is returned when a hook does not finish in its timeout
+	ErrShutdownHookTimeout = perrors.New("shutdown hook timeout")
+
+	defaultShutdownManager = NewShutdownManager()
+)
+
+// Shutdowner is a resource which can be shut down gracefully before @ctx is done,
+// such as gxsync.GracefulTaskPool and gxnet.DrainingListener
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ShutdownHookFunc is invoked when the process is shutting down.
+// @ctx will be done when the hook's timeout elapses.
+type ShutdownHookFunc func(ctx context.Context) error
+
+type shutdownHook struct {
+	name    string
+	order   int
+	timeout time.Duration
+	fn      ShutdownHookFunc
+}
+
+// ShutdownManager runs registered hooks in order when the process receives
+// a termination signal or when Shutdown is called.
+type ShutdownManager struct {
+	lock  sync.Mutex
+	hooks []*shutdownHook
+
+	signals []os.Signal
+	sigCh   chan os.Signal
+
+	ctx    context.Context    // done when shutdown begins
+	cancel context.CancelFunc // cancel the ctx
+	once   sync.Once
+	exit   chan struct{} // closed when all hooks have been run
+	err    error
+}
+
+// NewShutdownManager returns a ShutdownManager which listens on @signals.
+// SIGTERM and SIGINT are used if @signals is empty.
+func NewShutdownManager(signals ...os.Signal) *ShutdownManager {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ShutdownManager{
+		signals: signals,
+		ctx:     ctx,
+		cancel:  cancel,
+		exit:    make(chan struct{}),
+	}
+}
+
+// AddHook registers a hook named @name. Hooks with smaller @order run first,
+// and hooks with the same @order run in registration order. A non-positive
+// @timeout means the default hook timeout.
+func (m *ShutdownManager) AddHook(name string, order int, timeout time.Duration, fn ShutdownHookFunc) {
+	if fn == nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.hooks = append(m.hooks, &shutdownHook{
+		name:    name,
+		order:   order,
+		timeout: timeout,
+		fn:      fn,
+	})
+}
+
+// AddCloser registers @closer as a hook, such as the Close method of a task pool.
+// If @closer does not return in @timeout, the hook is regarded as timeout and
+// @closer keeps running in the background.
+func (m *ShutdownManager) AddCloser(name string, order int, timeout time.Duration, closer func()) {
+	if closer == nil {
+		return
+	}
+
+	m.AddHook(name, order, timeout, func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			closer()
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// AddShutdowner registers the Shutdown method of @s as a hook, the hook's context is passed to it
+func (m *ShutdownManager) AddShutdowner(name string, order int, timeout time.Duration, s Shutdowner) {
+	if s == nil {
+		return
+	}
+	m.AddHook(name, order, timeout, s.Shutdown)
+}
+
+// Listen starts to listen on the termination signals. Shutdown will be triggered
+// when receiving one of them.
+func (m *ShutdownManager) Listen() {
+	m.lock.Lock()
+	if m.sigCh != nil {
+		m.lock.Unlock()
+		return
+	}
+	m.sigCh = make(chan os.Signal, 1)
+	signal.Notify(m.sigCh, m.signals...)
+	m.lock.Unlock()
+
+	go func() {
+		select {
+		case sig := <-m.sigCh:
+			log.Printf("gost/ShutdownManager get signal %s, shutdown now.", sig)
+			m.Shutdown()
+		case <-m.ctx.Done():
+		}
+	}()
+}
+
+// Shutdown runs all hooks in order and returns the first hook error.
+// It is safe to call Shutdown many times, the hooks will be run only once.
+func (m *ShutdownManager) Shutdown() error {
+	m.once.Do(func() {
+		m.cancel()
+
+		m.lock.Lock()
+		if m.sigCh != nil {
+			signal.Stop(m.sigCh)
+		}
+		hooks := make([]*shutdownHook, len(m.hooks))
+		copy(hooks, m.hooks)
+		m.lock.Unlock()
+
+		sort.SliceStable(hooks, func(i, j int) bool {
+			return hooks[i].order < hooks[j].order
+		})
+		for _, hook := range hooks {
+			if err := runHook(hook); err != nil {
+				log.Printf("gost/ShutdownManager run hook %s error: %v", hook.name, err)
+				if m.err == nil {
+					m.err = err
+				}
+			}
+		}
+		close(m.exit)
+	})
+
+	<-m.exit
+	return m.err
+}
+
+func runHook(hook *shutdownHook) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hook.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- perrors.Errorf("shutdown hook %s panic: %v", hook.name, r)
+			}
+		}()
+		done <- hook.fn(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		return perrors.WithMessagef(err, "shutdown hook %s", hook.name)
+	case <-ctx.Done():
+		return perrors.WithMessagef(ErrShutdownHookTimeout, "shutdown hook %s", hook.name)
+	}
+}
+
+// Context returns a context which will be done when shutdown begins
+func (m *ShutdownManager) Context() context.Context {
+	return m.ctx
+}
+
+// Done returns a chan which will be closed when shutdown begins
+func (m *ShutdownManager) Done() <-chan struct{} {
+	return m.ctx.Done()
+}
+
+// Wait blocks until all hooks have been run
+func (m *ShutdownManager) Wait() error {
+	<-m.exit
+	return m.err
+}
+
+// AddShutdownHook registers a hook to the default ShutdownManager
+func AddShutdownHook(name string, order int, timeout time.Duration, fn ShutdownHookFunc) {
+	defaultShutdownManager.AddHook(name, order, timeout, fn)
+}
+
+// AddShutdownCloser registers a closer to the default ShutdownManager
+func AddShutdownCloser(name string, order int, timeout time.Duration, closer func()) {
+	defaultShutdownManager.AddCloser(name, order, timeout, closer)
+}
+
+// AddShutdowner registers a Shutdowner to the default ShutdownManager
+func AddShutdowner(name string, order int, timeout time.Duration, s Shutdowner) {
+	defaultShutdownManager.AddShutdowner(name, order, timeout, s)
+}
+
+// ListenShutdownSignal lets the default ShutdownManager listen on SIGTERM/SIGINT
+func ListenShutdownSignal() {
+	defaultShutdownManager.Listen()
+}
+
+// Shutdown runs the hooks of the default ShutdownManager
+func Shutdown() error {
+	return defaultShutdownManager.Shutdown()
+}
+
+// Context returns the context of the default ShutdownManager
+func Context() context.Context {
+	return defaultShutdownManager.Context()
+}
+
+// Done returns a chan which will be closed when the default ShutdownManager begins to shutdown
+func Done() <-chan struct{} {
+	return defaultShutdownManager.Done()
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxos
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxnet "github.com/dubbogo/gost/net"
+	gxsync "github.com/dubbogo/gost/sync"
+)
+
+func TestShutdownManagerOrder(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		order []string
+	)
+	record := func(name string) ShutdownHookFunc {
+		return func(ctx context.Context) error {
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+			return nil
+		}
+	}
+
+	m := NewShutdownManager()
+	m.AddHook("c", 2, 0, record("c"))
+	m.AddHook("a", 1, 0, record("a"))
+	m.AddHook("b", 1, 0, record("b"))
+	m.AddCloser("d", 3, 0, func() { _ = record("d")(nil) })
+
+	select {
+	case <-m.Done():
+		t.Fatal("manager should not be done before shutdown")
+	default:
+	}
+
+	assert.Nil(t, m.Shutdown())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order)
+	assert.NotNil(t, m.Context().Err())
+
+	// hooks run only once
+	assert.Nil(t, m.Shutdown())
+	assert.Equal(t, 4, len(order))
+}
+
+func TestShutdownManagerTimeout(t *testing.T) {
+	var called bool
+
+	m := NewShutdownManager()
+	m.AddHook("slow", 1, 100*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	m.AddHook("panic", 2, 0, func(ctx context.Context) error {
+		panic("hello")
+	})
+	m.AddHook("next", 3, 0, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	err := m.Shutdown()
+	assert.Equal(t, ErrShutdownHookTimeout, perrors.Cause(err))
+	assert.True(t, called)
+}
+
+func TestShutdownManagerShutdowner(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	listener := gxnet.NewDrainingListener(ln)
+	pool := gxsync.NewTaskPoolSimple(2)
+
+	finished := make(chan struct{})
+	pool.AddTaskAlways(func() {
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+	})
+
+	m := NewShutdownManager()
+	m.AddShutdowner("listener", 1, time.Second, listener)
+	m.AddShutdowner("pool", 2, time.Second, pool.(gxsync.GracefulTaskPool))
+	assert.Nil(t, m.Shutdown())
+
+	// the running task finishes before the pool is shut down
+	select {
+	case <-finished:
+	default:
+		t.Fatal("task pool should wait for the running task")
+	}
+	assert.True(t, pool.IsClosed())
+	_, err = listener.Accept()
+	assert.NotNil(t, err)
+
+	// the hook fails if the task does not finish in time
+	pool = gxsync.NewTaskPoolSimple(1)
+	pool.AddTaskAlways(func() {
+		time.Sleep(200 * time.Millisecond)
+	})
+	m = NewShutdownManager()
+	m.AddShutdowner("pool", 1, 20*time.Millisecond, pool.(gxsync.GracefulTaskPool))
+	assert.NotNil(t, m.Shutdown())
+}
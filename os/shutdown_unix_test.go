@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxos
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownManagerSignal(t *testing.T) {
+	m := NewShutdownManager(syscall.SIGUSR1)
+	m.AddHook("signal", 1, 0, func(ctx context.Context) error {
+		return nil
+	})
+	m.Listen()
+
+	assert.Nil(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	select {
+	case <-m.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("shutdown should be triggered by signal")
+	}
+	assert.Nil(t, m.Wait())
+}
@@ -0,0 +1,282 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxpath provides an Ant-style path matcher.
+//
+// The mapping matches paths using the following rules:
+//
+//	? matches one character in a path segment
+//	* matches zero or more characters in a path segment
+//	** matches zero or more path segments
+//	{name} matches a path segment and captures it as a variable named "name"
+//	{name:regex} matches a path segment by regex and captures it
+package gxpath
+
+import (
+	"regexp"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+const (
+	// Separator is the path separator
+	Separator = "/"
+
+	doubleWildcard = "**"
+)
+
+// Pattern is a compiled Ant-style pattern, which is safe for concurrent use
+type Pattern struct {
+	raw      string
+	segments []*segment
+}
+
+type segment struct {
+	literal string         // the segment has no wildcard if regex is nil
+	regex   *regexp.Regexp // compiled segment
+	vars    []string       // the names of the capture groups, "" means wildcard
+	any     bool           // **
+}
+
+// MatchResult is the captures of a successful match
+type MatchResult struct {
+	// Vars holds the {name} variables
+	Vars map[string]string
+	// Wildcards holds the parts matched by * and **, in order
+	Wildcards []string
+}
+
+// Compile parses an Ant-style pattern
+func Compile(pattern string) (*Pattern, error) {
+	p := &Pattern{raw: pattern}
+	for _, s := range splitPath(pattern) {
+		seg, err := compileSegment(s)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "compile pattern %s", pattern)
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// MustCompile is like Compile but panics if the pattern can not be parsed
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Match reports whether @path matches @pattern
+func Match(pattern, path string) (bool, error) {
+	p, err := Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return p.Match(path), nil
+}
+
+// IsPattern reports whether @s contains any wildcard
+func IsPattern(s string) bool {
+	return strings.ContainsAny(s, "*?{")
+}
+
+func splitPath(path string) []string {
+	var segments []string
+	for _, s := range strings.Split(path, Separator) {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+func compileSegment(s string) (*segment, error) {
+	if s == doubleWildcard {
+		return &segment{any: true}, nil
+	}
+	if !IsPattern(s) {
+		return &segment{literal: s}, nil
+	}
+
+	var (
+		b    strings.Builder
+		vars []string
+	)
+	b.WriteString("^")
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '?':
+			b.WriteString(".")
+		case '*':
+			b.WriteString("(.*)")
+			vars = append(vars, "")
+		case '{':
+			end := closingBrace(s[i:])
+			if end < 0 {
+				return nil, perrors.Errorf("unclosed variable in segment %s", s)
+			}
+			name, expr := s[i+1:i+end], ".*"
+			if idx := strings.IndexByte(name, ':'); idx >= 0 {
+				name, expr = name[:idx], name[idx+1:]
+			}
+			if name == "" {
+				return nil, perrors.Errorf("empty variable name in segment %s", s)
+			}
+			b.WriteString("(" + expr + ")")
+			vars = append(vars, name)
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	regex, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, err
+	}
+	if regex.NumSubexp() != len(vars) {
+		return nil, perrors.Errorf("capture groups are not allowed in variable regex of segment %s", s)
+	}
+	return &segment{regex: regex, vars: vars}, nil
+}
+
+// closingBrace returns the index of the brace closing the variable at the head
+// of @s, the braces of the regex quantifiers such as {3} are nested in it
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// String returns the raw pattern
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Match reports whether @path matches the pattern
+func (p *Pattern) Match(path string) bool {
+	return p.match(splitPath(path), 0, 0, nil)
+}
+
+// MatchCapture reports whether @path matches the pattern and returns the captures
+func (p *Pattern) MatchCapture(path string) (*MatchResult, bool) {
+	result := &MatchResult{Vars: make(map[string]string)}
+	if !p.match(splitPath(path), 0, 0, result) {
+		return nil, false
+	}
+	return result, true
+}
+
+// MatchPrefix reports whether @path may be a prefix of some paths which match the pattern.
+// It is used to filter the KV prefixes to subscribe.
+func (p *Pattern) MatchPrefix(path string) bool {
+	parts := splitPath(path)
+	for i, part := range parts {
+		if i >= len(p.segments) {
+			return false
+		}
+		seg := p.segments[i]
+		if seg.any {
+			return true
+		}
+		if !seg.matchSegment(part, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Pattern) match(parts []string, pi, si int, result *MatchResult) bool {
+	for si < len(p.segments) {
+		seg := p.segments[si]
+		if seg.any {
+			// try to let ** match as few segments as possible
+			for n := pi; n <= len(parts); n++ {
+				var sub *MatchResult
+				if result != nil {
+					sub = result.clone()
+					sub.Wildcards = append(sub.Wildcards, strings.Join(parts[pi:n], Separator))
+				}
+				if p.match(parts, n, si+1, sub) {
+					if result != nil {
+						*result = *sub
+					}
+					return true
+				}
+			}
+			return false
+		}
+
+		if pi >= len(parts) || !seg.matchSegment(parts[pi], result) {
+			return false
+		}
+		pi++
+		si++
+	}
+	return pi == len(parts)
+}
+
+func (s *segment) matchSegment(part string, result *MatchResult) bool {
+	if s.regex == nil {
+		return s.literal == part
+	}
+
+	groups := s.regex.FindStringSubmatch(part)
+	if groups == nil {
+		return false
+	}
+	if result != nil {
+		for i, name := range s.vars {
+			if name == "" {
+				result.Wildcards = append(result.Wildcards, groups[i+1])
+			} else {
+				result.Vars[name] = groups[i+1]
+			}
+		}
+	}
+	return true
+}
+
+func (r *MatchResult) clone() *MatchResult {
+	c := &MatchResult{
+		Vars:      make(map[string]string, len(r.Vars)),
+		Wildcards: make([]string, len(r.Wildcards), len(r.Wildcards)+1),
+	}
+	for k, v := range r.Vars {
+		c.Vars[k] = v
+	}
+	copy(c.Wildcards, r.Wildcards)
+	return c
+}
@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxpath
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/dubbo/**/providers/*", "/dubbo/org.apache.Foo/providers/dubbo%3A%2F%2F1.2.3.4", true},
+		{"/dubbo/**/providers/*", "/dubbo/a/b/c/providers/x", true},
+		{"/dubbo/**/providers/*", "/dubbo/providers/x", true},
+		{"/dubbo/**/providers/*", "/dubbo/a/consumers/x", false},
+		{"/dubbo/**/providers/*", "/dubbo/a/providers", false},
+		{"/dubbo/*/providers", "/dubbo/a/b/providers", false},
+		{"/dubbo/?oo", "/dubbo/foo", true},
+		{"/dubbo/?oo", "/dubbo/fooo", false},
+		{"/dubbo/*.xml", "/dubbo/a.xml", true},
+		{"/dubbo/*.xml", "/dubbo/a.json", false},
+		{"/**", "/any/thing", true},
+		{"dubbo/config", "/dubbo/config/", true},
+		{"/dubbo/{app:[a-z]+}", "/dubbo/app1", false},
+		{"/dubbo/{id:[0-9]{3}}", "/dubbo/123", true},
+		{"/dubbo/{id:[0-9]{3}}", "/dubbo/1234", false},
+		{"/dubbo/{id:[0-9]{2,3}}-{name}", "/dubbo/12-foo", true},
+	}
+
+	for _, tt := range tests {
+		ok, err := Match(tt.pattern, tt.path)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.match, ok, "pattern %s path %s", tt.pattern, tt.path)
+	}
+
+	_, err := Compile("/dubbo/{app")
+	assert.NotNil(t, err)
+	_, err = Compile("/dubbo/{app:(a)}")
+	assert.NotNil(t, err)
+	_, err = Compile("/dubbo/{id:[0-9]{3}")
+	assert.NotNil(t, err)
+}
+
+func TestMatchCapture(t *testing.T) {
+	p := MustCompile("/dubbo/**/{service}/providers/*.{ext:json|xml}")
+	result, ok := p.MatchCapture("/dubbo/group/v1/org.Foo/providers/config.json")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"service": "org.Foo", "ext": "json"}, result.Vars)
+	assert.Equal(t, []string{"group/v1", "config"}, result.Wildcards)
+
+	_, ok = p.MatchCapture("/dubbo/org.Foo/providers/config.yaml")
+	assert.False(t, ok)
+}
+
+func TestMatchPrefix(t *testing.T) {
+	p := MustCompile("/dubbo/*/providers/*")
+	assert.True(t, p.MatchPrefix("/dubbo"))
+	assert.True(t, p.MatchPrefix("/dubbo/org.Foo/providers"))
+	assert.False(t, p.MatchPrefix("/dubbo/org.Foo/consumers"))
+	assert.False(t, p.MatchPrefix("/dubbo/org.Foo/providers/a/b"))
+	assert.True(t, MustCompile("/dubbo/**").MatchPrefix("/dubbo/a/b/c"))
+	assert.True(t, IsPattern("/a/*"))
+	assert.False(t, IsPattern("/a/b"))
+}
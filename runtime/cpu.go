@@ -0,0 +1,341 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxruntime
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// userHZ is the clock ticks per second of /proc/[pid]/stat, it is 100 on all mainstream linux
+const userHZ = 100
+
+var (
+	procSelfStatPath   = "/proc/self/stat"
+	procSelfCgroupPath = "/proc/self/cgroup"
+	cgroupRootPath     = "/sys/fs/cgroup"
+)
+
+// CPUUsage is the accumulated cpu usage of current process
+type CPUUsage struct {
+	// Time is when the usage is read
+	Time time.Time
+	// Total is the user and system cpu time of current process
+	Total time.Duration
+	// NrThrottled is the number of periods in which the cgroup is throttled
+	NrThrottled uint64
+	// Throttled is the total time the cgroup is throttled
+	Throttled time.Duration
+	// Limit is the cpu cores current process can use, it is the cgroup quota
+	// in a container, or the cpu number otherwise
+	Limit float64
+}
+
+// ReadCPUUsage reads the cpu usage of current process from /proc and the cgroup cpu.stat.
+// The throttled fields are zero if there is no cgroup cpu controller.
+func ReadCPUUsage() (CPUUsage, error) {
+	usage := CPUUsage{
+		Time:  time.Now(),
+		Limit: float64(runtime.NumCPU()),
+	}
+	total, err := readProcessCPUTime(procSelfStatPath)
+	if err != nil {
+		return usage, err
+	}
+	usage.Total = total
+
+	for _, dir := range cgroupCPUDirs() {
+		stat, err := readKeyValues(filepath.Join(dir, "cpu.stat"))
+		if err != nil {
+			continue
+		}
+		usage.NrThrottled = stat["nr_throttled"]
+		if v, ok := stat["throttled_usec"]; ok {
+			usage.Throttled = time.Duration(v) * time.Microsecond
+		} else {
+			usage.Throttled = time.Duration(stat["throttled_time"])
+		}
+		if limit, ok := readCgroupCPULimit(dir); ok && limit < usage.Limit {
+			usage.Limit = limit
+		}
+		break
+	}
+	return usage, nil
+}
+
+// cgroupCPUDirs returns the candidate cpu controller directories of the cgroup
+// of current process, which is read from /proc/self/cgroup. The cgroup roots
+// are the last candidates, they are the cgroup of the container when the
+// cgroup namespace is used.
+func cgroupCPUDirs() []string {
+	var dirs []string
+	if content, err := ioutil.ReadFile(procSelfCgroupPath); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			// hierarchy-ID:controller-list:cgroup-path
+			fields := strings.SplitN(line, ":", 3)
+			if len(fields) != 3 {
+				continue
+			}
+			if fields[0] == "0" && fields[1] == "" {
+				// cgroup v2 puts all controllers in the same directory
+				dirs = append(dirs, filepath.Join(cgroupRootPath, fields[2]))
+				continue
+			}
+			for _, controller := range strings.Split(fields[1], ",") {
+				if controller == "cpu" {
+					// cgroup v1 mounts the controller to cpu/ or a joint directory like cpu,cpuacct/
+					dirs = append(dirs,
+						filepath.Join(cgroupRootPath, "cpu", fields[2]),
+						filepath.Join(cgroupRootPath, fields[1], fields[2]))
+					break
+				}
+			}
+		}
+	}
+	return append(dirs, cgroupRootPath, filepath.Join(cgroupRootPath, "cpu"))
+}
+
+// readProcessCPUTime returns utime + stime of /proc/[pid]/stat
+func readProcessCPUTime(path string) (time.Duration, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	// the command name in parentheses may contain spaces
+	stat := string(content)
+	idx := strings.LastIndexByte(stat, ')')
+	if idx < 0 {
+		return 0, perrors.Errorf("illegal stat file %s", path)
+	}
+	// fields after the command name start from the 3rd field: state,
+	// utime and stime are the 14th and 15th fields
+	fields := strings.Fields(stat[idx+1:])
+	if len(fields) < 13 {
+		return 0, perrors.Errorf("illegal stat file %s", path)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, perrors.WithStack(err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, perrors.WithStack(err)
+	}
+	return time.Duration(utime+stime) * time.Second / userHZ, nil
+}
+
+func readKeyValues(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := parseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values, scanner.Err()
+}
+
+// readCgroupCPULimit returns the cpu cores of the cgroup quota, false if there is no quota
+func readCgroupCPULimit(dir string) (float64, bool) {
+	// cgroup v2: "$MAX $PERIOD" or "max $PERIOD"
+	if content, err := ioutil.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+		fields := strings.Fields(string(content))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, false
+		}
+		quota, err1 := strconv.ParseFloat(fields[0], 64)
+		period, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil || quota <= 0 || period <= 0 {
+			return 0, false
+		}
+		return quota / period, true
+	}
+
+	// cgroup v1: the quota is -1 if there is no limit
+	quota, err := readUint(filepath.Join(dir, "cpu.cfs_quota_us"))
+	if err != nil || quota == 0 {
+		return 0, false
+	}
+	period, err := readUint(filepath.Join(dir, "cpu.cfs_period_us"))
+	if err != nil || period == 0 {
+		return 0, false
+	}
+	return float64(quota) / float64(period), true
+}
+
+// CPUStat is the cpu usage of current process during a sampling interval
+type CPUStat struct {
+	// Time is the end of the interval
+	Time time.Time
+	// Interval is the length of the interval
+	Interval time.Duration
+	// Cores is the average cpu cores used in the interval
+	Cores float64
+	// Utilization is Cores / Limit, in [0, 1] except for measuring errors
+	Utilization float64
+	// Limit is the cpu cores current process can use
+	Limit float64
+	// NrThrottled is the number of throttled periods in the interval
+	NrThrottled uint64
+	// Throttled is the throttled time in the interval
+	Throttled time.Duration
+}
+
+// CPUStatBetween calculates the cpu stat between two usages
+func CPUStatBetween(prev, cur CPUUsage) CPUStat {
+	stat := CPUStat{
+		Time:     cur.Time,
+		Interval: cur.Time.Sub(prev.Time),
+		Limit:    cur.Limit,
+	}
+	if stat.Interval <= 0 {
+		return stat
+	}
+	if cur.Total > prev.Total {
+		stat.Cores = float64(cur.Total-prev.Total) / float64(stat.Interval)
+	}
+	if stat.Limit > 0 {
+		stat.Utilization = stat.Cores / stat.Limit
+	}
+	if cur.NrThrottled > prev.NrThrottled {
+		stat.NrThrottled = cur.NrThrottled - prev.NrThrottled
+	}
+	if cur.Throttled > prev.Throttled {
+		stat.Throttled = cur.Throttled - prev.Throttled
+	}
+	return stat
+}
+
+// CPUSampler samples the cpu usage of current process periodically
+type CPUSampler struct {
+	interval time.Duration
+	ch       chan CPUStat
+
+	lock   sync.RWMutex
+	latest CPUStat
+	err    error
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewCPUSampler starts to sample the cpu usage every @interval
+func NewCPUSampler(interval time.Duration) (*CPUSampler, error) {
+	if interval <= 0 {
+		return nil, perrors.Errorf("illegal sampling interval %s", interval)
+	}
+	prev, err := ReadCPUUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &CPUSampler{
+		interval: interval,
+		ch:       make(chan CPUStat, 1),
+		done:     make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run(prev)
+	return s, nil
+}
+
+func (s *CPUSampler) run(prev CPUUsage) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := ReadCPUUsage()
+		if err != nil {
+			s.lock.Lock()
+			s.err = err
+			s.lock.Unlock()
+			continue
+		}
+		stat := CPUStatBetween(prev, cur)
+		prev = cur
+
+		s.lock.Lock()
+		s.latest = stat
+		s.err = nil
+		s.lock.Unlock()
+
+		// keep the latest stat only if the consumer is slow
+		select {
+		case <-s.ch:
+		default:
+		}
+		s.ch <- stat
+	}
+}
+
+// C returns the channel of the stats, the stale stat is dropped if it is not consumed in time
+func (s *CPUSampler) C() <-chan CPUStat {
+	return s.ch
+}
+
+// Latest returns the latest stat, it is zero before the first sampling
+func (s *CPUSampler) Latest() CPUStat {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.latest
+}
+
+// Err returns the error of the latest sampling
+func (s *CPUSampler) Err() error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.err
+}
+
+// Close stops sampling
+func (s *CPUSampler) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+}
@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxruntime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeCPUFiles(t *testing.T, files map[string]string) {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	}
+
+	oldStat, oldSelfCgroup, oldCgroup := procSelfStatPath, procSelfCgroupPath, cgroupRootPath
+	procSelfStatPath, procSelfCgroupPath, cgroupRootPath =
+		filepath.Join(dir, "stat"), filepath.Join(dir, "self_cgroup"), filepath.Join(dir, "cgroup")
+	t.Cleanup(func() {
+		procSelfStatPath, procSelfCgroupPath, cgroupRootPath = oldStat, oldSelfCgroup, oldCgroup
+	})
+}
+
+const fakeProcStat = "42 (my app) S 1 42 42 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 8 0 100 1000 100 0\n"
+
+func TestReadCPUUsageCgroupV1(t *testing.T) {
+	fakeCPUFiles(t, map[string]string{
+		"stat":                         fakeProcStat,
+		"cgroup/cpu/cpu.stat":          "nr_periods 10\nnr_throttled 3\nthrottled_time 2000000\n",
+		"cgroup/cpu/cpu.cfs_quota_us":  "50000\n",
+		"cgroup/cpu/cpu.cfs_period_us": "100000\n",
+	})
+
+	usage, err := ReadCPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, 3*time.Second, usage.Total)
+	assert.Equal(t, uint64(3), usage.NrThrottled)
+	assert.Equal(t, 2*time.Millisecond, usage.Throttled)
+	assert.Equal(t, 0.5, usage.Limit)
+}
+
+func TestReadCPUUsageCgroupV2(t *testing.T) {
+	fakeCPUFiles(t, map[string]string{
+		"stat":            fakeProcStat,
+		"cgroup/cpu.stat": "usage_usec 100\nnr_throttled 5\nthrottled_usec 300\n",
+		"cgroup/cpu.max":  "max 100000\n",
+	})
+
+	usage, err := ReadCPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), usage.NrThrottled)
+	assert.Equal(t, 300*time.Microsecond, usage.Throttled)
+	assert.Equal(t, float64(runtime.NumCPU()), usage.Limit)
+}
+
+func TestReadCPUUsageNestedCgroup(t *testing.T) {
+	// the cgroup of current process is not the root
+	fakeCPUFiles(t, map[string]string{
+		"stat":                          fakeProcStat,
+		"self_cgroup":                   "0::/kubepods/pod1\n",
+		"cgroup/cpu.stat":               "nr_throttled 1\n",
+		"cgroup/kubepods/pod1/cpu.stat": "nr_throttled 7\nthrottled_usec 300\n",
+		"cgroup/kubepods/pod1/cpu.max":  "200000 100000\n",
+	})
+	usage, err := ReadCPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), usage.NrThrottled)
+	if runtime.NumCPU() > 2 {
+		assert.Equal(t, 2.0, usage.Limit)
+	}
+
+	fakeCPUFiles(t, map[string]string{
+		"stat":                                   fakeProcStat,
+		"self_cgroup":                            "5:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n",
+		"cgroup/cpu,cpuacct/docker/abc/cpu.stat": "nr_throttled 9\nthrottled_time 1000\n",
+		"cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  "-1\n",
+		"cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": "100000\n",
+	})
+	usage, err = ReadCPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(9), usage.NrThrottled)
+	assert.Equal(t, float64(runtime.NumCPU()), usage.Limit)
+}
+
+func TestReadCPUUsageWithoutCgroup(t *testing.T) {
+	fakeCPUFiles(t, map[string]string{"stat": fakeProcStat})
+
+	usage, err := ReadCPUUsage()
+	assert.Nil(t, err)
+	assert.Equal(t, 3*time.Second, usage.Total)
+	assert.Equal(t, uint64(0), usage.NrThrottled)
+
+	fakeCPUFiles(t, map[string]string{"stat": "illegal"})
+	_, err = ReadCPUUsage()
+	assert.NotNil(t, err)
+}
+
+func TestCPUStatBetween(t *testing.T) {
+	now := time.Now()
+	prev := CPUUsage{Time: now, Total: time.Second, NrThrottled: 1, Throttled: time.Millisecond, Limit: 2}
+	cur := CPUUsage{Time: now.Add(time.Second), Total: 2 * time.Second, NrThrottled: 4, Throttled: 3 * time.Millisecond, Limit: 2}
+
+	stat := CPUStatBetween(prev, cur)
+	assert.Equal(t, time.Second, stat.Interval)
+	assert.Equal(t, 1.0, stat.Cores)
+	assert.Equal(t, 0.5, stat.Utilization)
+	assert.Equal(t, uint64(3), stat.NrThrottled)
+	assert.Equal(t, 2*time.Millisecond, stat.Throttled)
+}
+
+func TestCPUSampler(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("cpu sampler reads /proc")
+	}
+
+	_, err := NewCPUSampler(0)
+	assert.NotNil(t, err)
+
+	s, err := NewCPUSampler(50 * time.Millisecond)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	var stat CPUStat
+	select {
+	case stat = <-s.C():
+	case <-time.After(time.Second):
+		t.Fatal("no cpu stat is sampled")
+	}
+	assert.Nil(t, s.Err())
+	assert.True(t, stat.Interval > 0)
+	assert.True(t, stat.Limit > 0)
+	assert.True(t, stat.Cores >= 0)
+	assert.False(t, s.Latest().Time.IsZero())
+}
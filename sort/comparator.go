@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxsort
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Ordered is the constraint of the types supporting the < operator
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// Comparator returns a negative number if a < b, zero if a == b, and a positive
+// number if a > b.
+type Comparator[T any] func(a, b T) int
+
+// Natural returns the comparator of the natural order
+func Natural[T Ordered]() Comparator[T] {
+	return func(a, b T) int {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	}
+}
+
+// By returns the comparator ordering by the key extracted by @key, e.g.
+// By(func(p Provider) int { return p.Weight })
+func By[T any, K Ordered](key func(T) K) Comparator[T] {
+	natural := Natural[K]()
+	return func(a, b T) int {
+		return natural(key(a), key(b))
+	}
+}
+
+// Reverse returns the comparator of the reverse order of @cmp
+func Reverse[T any](cmp Comparator[T]) Comparator[T] {
+	return func(a, b T) int {
+		return cmp(b, a)
+	}
+}
+
+// Compose returns the comparator ordering by @cmps one by one, the next comparator
+// is used only if the elements are equal by the previous ones.
+func Compose[T any](cmps ...Comparator[T]) Comparator[T] {
+	return func(a, b T) int {
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+// Then returns the comparator ordering by @next if the elements are equal by c
+func (c Comparator[T]) Then(next Comparator[T]) Comparator[T] {
+	return Compose(c, next)
+}
+
+// SortFunc sorts @s by @cmp, it is not stable
+func SortFunc[T any](s []T, cmp Comparator[T]) {
+	sort.Slice(s, func(i, j int) bool {
+		return cmp(s[i], s[j]) < 0
+	})
+}
+
+// SortStableFunc sorts @s by @cmp, the equal elements keep their original order
+func SortStableFunc[T any](s []T, cmp Comparator[T]) {
+	sort.SliceStable(s, func(i, j int) bool {
+		return cmp(s[i], s[j]) < 0
+	})
+}
+
+// IsSortedFunc checks whether @s is sorted by @cmp
+func IsSortedFunc[T any](s []T, cmp Comparator[T]) bool {
+	for i := 1; i < len(s); i++ {
+		if cmp(s[i], s[i-1]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Search searches @target in @s sorted by @cmp. It returns the index of the first
+// element not less than @target, and whether the element equals to @target.
+func Search[T any](s []T, target T, cmp Comparator[T]) (int, bool) {
+	i := sort.Search(len(s), func(i int) bool {
+		return cmp(s[i], target) >= 0
+	})
+	return i, i < len(s) && cmp(s[i], target) == 0
+}
+
+// Floor returns the index of the last element not greater than @target in @s
+// sorted by @cmp, or false if there is no such element.
+func Floor[T any](s []T, target T, cmp Comparator[T]) (int, bool) {
+	i := sort.Search(len(s), func(i int) bool {
+		return cmp(s[i], target) > 0
+	})
+	return i - 1, i > 0
+}
+
+// Ceiling returns the index of the first element not less than @target in @s
+// sorted by @cmp, or false if there is no such element.
+func Ceiling[T any](s []T, target T, cmp Comparator[T]) (int, bool) {
+	i, _ := Search(s, target, cmp)
+	return i, i < len(s)
+}
+
+// TopK returns the first @k elements of @s in the order of @cmp without sorting
+// the whole slice, e.g. TopK(providers, 3, Reverse(By(weight))) returns the 3
+// heaviest providers. @s is not modified, and the result is sorted by @cmp.
+func TopK[T any](s []T, k int, cmp Comparator[T]) []T {
+	if k <= 0 {
+		return nil
+	}
+	if k >= len(s) {
+		top := append([]T(nil), s...)
+		SortStableFunc(top, cmp)
+		return top
+	}
+
+	// keep the k first elements by a max heap, whose top is the last of them
+	h := &boundedHeap[T]{items: append(make([]T, 0, k), s[:k]...), cmp: cmp}
+	heap.Init(h)
+	for _, v := range s[k:] {
+		if cmp(v, h.items[0]) < 0 {
+			h.items[0] = v
+			heap.Fix(h, 0)
+		}
+	}
+
+	top := h.items
+	SortStableFunc(top, cmp)
+	return top
+}
+
+type boundedHeap[T any] struct {
+	items []T
+	cmp   Comparator[T]
+}
+
+func (h *boundedHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *boundedHeap[T]) Less(i, j int) bool {
+	return h.cmp(h.items[i], h.items[j]) > 0
+}
+
+func (h *boundedHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *boundedHeap[T]) Push(x interface{}) {
+	h.items = append(h.items, x.(T))
+}
+
+func (h *boundedHeap[T]) Pop() interface{} {
+	n := len(h.items)
+	x := h.items[n-1]
+	h.items = h.items[:n-1]
+	return x
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxsort
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+type provider struct {
+	name   string
+	weight int
+	zone   string
+}
+
+func TestComparator(t *testing.T) {
+	providers := []provider{
+		{"a", 10, "hz"},
+		{"b", 20, "sh"},
+		{"c", 10, "sh"},
+		{"d", 20, "hz"},
+		{"e", 10, "hz"},
+	}
+
+	SortStableFunc(providers, Reverse(By(func(p provider) int { return p.weight })))
+	names := func() []string {
+		var s []string
+		for _, p := range providers {
+			s = append(s, p.name)
+		}
+		return s
+	}
+	assert.Equal(t, []string{"b", "d", "a", "c", "e"}, names())
+
+	cmp := By(func(p provider) string { return p.zone }).Then(By(func(p provider) int { return p.weight }))
+	SortFunc(providers, Compose(cmp, By(func(p provider) string { return p.name })))
+	assert.Equal(t, []string{"a", "e", "d", "c", "b"}, names())
+	assert.True(t, IsSortedFunc(providers, cmp))
+	assert.False(t, IsSortedFunc(providers, Reverse(cmp)))
+}
+
+func TestSearch(t *testing.T) {
+	s := []int{1, 3, 3, 5, 7}
+	cmp := Natural[int]()
+
+	cases := []struct {
+		target       int
+		search       int
+		found        bool
+		floor        int
+		floorFound   bool
+		ceiling      int
+		ceilingFound bool
+	}{
+		{0, 0, false, -1, false, 0, true},
+		{1, 0, true, 0, true, 0, true},
+		{3, 1, true, 2, true, 1, true},
+		{4, 3, false, 2, true, 3, true},
+		{7, 4, true, 4, true, 4, true},
+		{8, 5, false, 4, true, 5, false},
+	}
+	for _, c := range cases {
+		i, ok := Search(s, c.target, cmp)
+		assert.Equal(t, c.search, i, "search %d", c.target)
+		assert.Equal(t, c.found, ok, "search %d", c.target)
+		i, ok = Floor(s, c.target, cmp)
+		assert.Equal(t, c.floor, i, "floor %d", c.target)
+		assert.Equal(t, c.floorFound, ok, "floor %d", c.target)
+		i, ok = Ceiling(s, c.target, cmp)
+		assert.Equal(t, c.ceiling, i, "ceiling %d", c.target)
+		assert.Equal(t, c.ceilingFound, ok, "ceiling %d", c.target)
+	}
+
+	_, ok := Floor(nil, 1, cmp)
+	assert.False(t, ok)
+}
+
+func TestTopK(t *testing.T) {
+	s := rand.New(rand.NewSource(1)).Perm(1000)
+	origin := append([]int(nil), s...)
+
+	top := TopK(s, 5, Reverse(Natural[int]()))
+	assert.Equal(t, []int{999, 998, 997, 996, 995}, top)
+	assert.Equal(t, origin, s)
+
+	assert.Nil(t, TopK(s, 0, Natural[int]()))
+	all := TopK(s, 2000, Natural[int]())
+	assert.True(t, sort.IntsAreSorted(all))
+	assert.Len(t, all, 1000)
+	assert.Equal(t, origin, s)
+}
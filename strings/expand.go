@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxstrings
+
+import (
+	"os"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+var (
+	// ErrUnresolvedPlaceholder means a placeholder without default value can not be resolved
+	ErrUnresolvedPlaceholder = perrors.New("unresolved placeholder")
+	// ErrPlaceholderCycle means the value of a placeholder refers to itself directly or indirectly
+	ErrPlaceholderCycle = perrors.New("placeholder cycle")
+	// ErrBadPlaceholder means a placeholder is not closed
+	ErrBadPlaceholder = perrors.New("bad placeholder")
+)
+
+// Resolver returns the value of @key, false if @key is not found
+type Resolver func(key string) (string, bool)
+
+// MapResolver resolves keys from @m
+func MapResolver(m map[string]string) Resolver {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+// EnvResolver resolves keys from the environment variables
+func EnvResolver() Resolver {
+	return os.LookupEnv
+}
+
+// ChainResolver resolves keys by @resolvers in order, the first found value wins
+func ChainResolver(resolvers ...Resolver) Resolver {
+	return func(key string) (string, bool) {
+		for _, r := range resolvers {
+			if v, ok := r(key); ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+// Expand replaces the placeholders of @s with the values returned by @resolver:
+//   - ${key} is replaced by the value of key, it is an error if key is not found
+//   - ${key:default} is replaced by default if key is not found
+//   - placeholders may be nested in keys and default values, e.g. ${db.${env}.url:${db.url}}
+//   - resolved values are expanded again, and a value referring to itself is an error
+//   - $${ is an escaped ${
+func Expand(s string, resolver Resolver) (string, error) {
+	return expand(s, resolver, nil)
+}
+
+// ExpandEnv expands @s with the environment variables
+func ExpandEnv(s string) (string, error) {
+	return Expand(s, EnvResolver())
+}
+
+// expand expands @s, @stack is the keys being resolved
+func expand(s string, resolver Resolver, stack []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := closingBrace(s, i+2)
+		if end < 0 {
+			return "", perrors.WithMessagef(ErrBadPlaceholder, "%q is not closed", s[i:])
+		}
+		value, err := expandPlaceholder(s[i+2:end], resolver, stack)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		i = end + 1
+	}
+	return b.String(), nil
+}
+
+func expandPlaceholder(body string, resolver Resolver, stack []string) (string, error) {
+	keyPart, defaultPart, hasDefault := body, "", false
+	if idx := topLevelColon(body); idx >= 0 {
+		keyPart, defaultPart, hasDefault = body[:idx], body[idx+1:], true
+	}
+
+	key, err := expand(keyPart, resolver, stack)
+	if err != nil {
+		return "", err
+	}
+	for i, k := range stack {
+		if k == key {
+			chain := append(append([]string(nil), stack[i:]...), key)
+			return "", perrors.WithMessagef(ErrPlaceholderCycle, "%s", strings.Join(chain, " -> "))
+		}
+	}
+
+	if value, ok := resolver(key); ok {
+		return expand(value, resolver, append(stack, key))
+	}
+	if hasDefault {
+		return expand(defaultPart, resolver, stack)
+	}
+	return "", perrors.WithMessagef(ErrUnresolvedPlaceholder, "${%s}", key)
+}
+
+// closingBrace returns the index of the brace closing the placeholder whose body starts at @start
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// topLevelColon returns the index of the first colon out of nested placeholders
+func topLevelColon(body string) int {
+	depth := 0
+	for i := 0; i < len(body); i++ {
+		switch {
+		case strings.HasPrefix(body[i:], "${"):
+			depth++
+			i++
+		case body[i] == '}':
+			depth--
+		case body[i] == ':' && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxstrings
+
+import (
+	"testing"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand(t *testing.T) {
+	resolver := MapResolver(map[string]string{
+		"host":         "127.0.0.1",
+		"port":         "20880",
+		"addr":         "${host}:${port}",
+		"env":          "test",
+		"db.test.url":  "mysql://${addr}/test",
+		"empty":        "",
+		"self":         "${self}",
+		"a":            "${b}",
+		"b":            "x${c}",
+		"c":            "${a}",
+		"default.port": "8080",
+	})
+
+	cases := []struct {
+		in, out string
+	}{
+		{"", ""},
+		{"no placeholder", "no placeholder"},
+		{"${host}", "127.0.0.1"},
+		{"dubbo://${addr}/svc", "dubbo://127.0.0.1:20880/svc"},
+		{"${db.${env}.url}", "mysql://127.0.0.1:20880/test"},
+		{"${missing:default}", "default"},
+		{"${missing:}", ""},
+		{"${empty:default}", ""},
+		{"${missing:${default.port}}", "8080"},
+		{"${missing:${missing2:http://${host}}}", "http://127.0.0.1"},
+		{"$${host}", "${host}"},
+		{"$host {host} $", "$host {host} $"},
+	}
+	for _, c := range cases {
+		out, err := Expand(c.in, resolver)
+		assert.Nil(t, err, c.in)
+		assert.Equal(t, c.out, out, c.in)
+	}
+
+	_, err := Expand("${missing}", resolver)
+	assert.Equal(t, ErrUnresolvedPlaceholder, perrors.Cause(err))
+	assert.Contains(t, err.Error(), "${missing}")
+
+	_, err = Expand("${self}", resolver)
+	assert.Equal(t, ErrPlaceholderCycle, perrors.Cause(err))
+
+	_, err = Expand("${a}", resolver)
+	assert.Equal(t, ErrPlaceholderCycle, perrors.Cause(err))
+	assert.Contains(t, err.Error(), "a -> b -> c -> a")
+
+	_, err = Expand("${host", resolver)
+	assert.Equal(t, ErrBadPlaceholder, perrors.Cause(err))
+	_, err = Expand("${a:${b}", resolver)
+	assert.Equal(t, ErrBadPlaceholder, perrors.Cause(err))
+}
+
+func TestChainResolver(t *testing.T) {
+	t.Setenv("GOST_EXPAND_TEST", "env")
+	resolver := ChainResolver(MapResolver(map[string]string{"GOST_EXPAND_TEST": "map"}), EnvResolver())
+	out, err := Expand("${GOST_EXPAND_TEST}", resolver)
+	assert.Nil(t, err)
+	assert.Equal(t, "map", out)
+
+	out, err = ExpandEnv("${GOST_EXPAND_TEST}-${GOST_EXPAND_MISSING:none}")
+	assert.Nil(t, err)
+	assert.Equal(t, "env-none", out)
+}
@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLimiterInitial      = 20
+	defaultLimiterMin          = 1
+	defaultLimiterMax          = 1000
+	defaultLimiterBackoff      = 0.9
+	defaultLimiterTolerance    = 2.0
+	defaultLimiterProbeSamples = 1000
+)
+
+type adaptiveLimiterOptions struct {
+	initial      int
+	min          int
+	max          int
+	backoff      float64 // ratio of the limit after a decrease
+	tolerance    float64 // latency above minRTT*tolerance is regarded as overload
+	probeSamples int     // minRTT is reset after the samples to follow the latency drift
+}
+
+// AdaptiveLimiterOption is optional settings for adaptive limiter
+type AdaptiveLimiterOption func(*adaptiveLimiterOptions)
+
+// WithLimiterLimits sets the @initial limit and the range [@min, @max] of the limit
+func WithLimiterLimits(initial, min, max int) AdaptiveLimiterOption {
+	return func(o *adaptiveLimiterOptions) {
+		o.initial = initial
+		o.min = min
+		o.max = max
+	}
+}
+
+// WithLimiterBackoff sets the @ratio of the limit after overload is detected, in (0, 1)
+func WithLimiterBackoff(ratio float64) AdaptiveLimiterOption {
+	return func(o *adaptiveLimiterOptions) {
+		o.backoff = ratio
+	}
+}
+
+// WithLimiterTolerance sets the latency @tolerance, a latency greater than
+// the min latency multiplied by @tolerance is regarded as overload
+func WithLimiterTolerance(tolerance float64) AdaptiveLimiterOption {
+	return func(o *adaptiveLimiterOptions) {
+		o.tolerance = tolerance
+	}
+}
+
+func (o *adaptiveLimiterOptions) validate() {
+	if o.min < 1 {
+		o.min = defaultLimiterMin
+	}
+	if o.max < o.min {
+		o.max = defaultLimiterMax
+		if o.max < o.min {
+			o.max = o.min
+		}
+	}
+	if o.initial < o.min || o.initial > o.max {
+		o.initial = o.min
+		if defaultLimiterInitial > o.min && defaultLimiterInitial < o.max {
+			o.initial = defaultLimiterInitial
+		}
+	}
+	if o.backoff <= 0 || o.backoff >= 1 {
+		o.backoff = defaultLimiterBackoff
+	}
+	if o.tolerance < 1 {
+		o.tolerance = defaultLimiterTolerance
+	}
+	if o.probeSamples < 1 {
+		o.probeSamples = defaultLimiterProbeSamples
+	}
+}
+
+// AdaptiveLimiter limits the concurrency of calls to a dependency, and adjusts
+// the limit by AIMD: the limit increases by one on every fast successful call
+// while the limiter is busy, and decreases multiplicatively on a failed call
+// or call whose latency exceeds the tolerance of the min latency. The limit
+// decreases at most once per window, that is, the calls started before the
+// last decrease do not decrease it again.
+type AdaptiveLimiter struct {
+	adaptiveLimiterOptions
+
+	lock     sync.Mutex
+	limit    float64
+	inflight int
+	minRTT   time.Duration
+	samples  int
+	notify   chan struct{} // closed and renewed when a slot is released
+
+	lastDecrease time.Time
+}
+
+// NewAdaptiveLimiter returns an adaptive concurrency limiter
+func NewAdaptiveLimiter(opts ...AdaptiveLimiterOption) *AdaptiveLimiter {
+	l := &AdaptiveLimiter{
+		notify: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&l.adaptiveLimiterOptions)
+	}
+	l.validate()
+	l.limit = float64(l.initial)
+	return l
+}
+
+// TryAcquire acquires a slot without blocking
+func (l *AdaptiveLimiter) TryAcquire() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.inflight >= l.currentLimit() {
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+// Acquire blocks until a slot is acquired or @ctx is done
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.lock.Lock()
+		if l.inflight < l.currentLimit() {
+			l.inflight++
+			l.lock.Unlock()
+			return nil
+		}
+		notify := l.notify
+		l.lock.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release releases a slot with the @latency of the call. @ok is false if the
+// call failed because of overload, such as timeout or rejection.
+func (l *AdaptiveLimiter) Release(latency time.Duration, ok bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	busy := l.inflight*2 >= l.currentLimit()
+	l.inflight--
+
+	l.samples++
+	if l.samples >= l.probeSamples {
+		l.samples = 0
+		l.minRTT = 0
+	}
+	if ok && latency > 0 && (l.minRTT == 0 || latency < l.minRTT) {
+		l.minRTT = latency
+	}
+
+	switch {
+	case !ok || float64(latency) > float64(l.minRTT)*l.tolerance:
+		now := time.Now()
+		if now.Add(-latency).Before(l.lastDecrease) {
+			// the call was measured under the limit before the last decrease
+			break
+		}
+		l.lastDecrease = now
+		l.limit = math.Max(float64(l.min), l.limit*l.backoff)
+	case busy:
+		l.limit = math.Min(float64(l.max), l.limit+1)
+	}
+
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// Do runs @fn in a slot, and releases the slot with the latency of @fn.
+// The error of @fn is regarded as overload if @isOverload returns true for it.
+// The slot is released as a failed call if @fn panics.
+func (l *AdaptiveLimiter) Do(ctx context.Context, fn func() error, isOverload func(error) bool) error {
+	if err := l.Acquire(ctx); err != nil {
+		return err
+	}
+
+	var (
+		start = time.Now()
+		ok    bool
+	)
+	defer func() {
+		l.Release(time.Since(start), ok)
+	}()
+	err := fn()
+	ok = err == nil || isOverload == nil || !isOverload(err)
+	return err
+}
+
+// Limit returns the current limit
+func (l *AdaptiveLimiter) Limit() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.currentLimit()
+}
+
+// Inflight returns the number of acquired slots
+func (l *AdaptiveLimiter) Inflight() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.inflight
+}
+
+// NOTICE: need to get the lock before calling this method
+func (l *AdaptiveLimiter) currentLimit() int {
+	return int(l.limit)
+}
@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(4, 2, 8), WithLimiterBackoff(0.5))
+	assert.Equal(t, 4, l.Limit())
+
+	// increase while busy and fast
+	for i := 0; i < 4; i++ {
+		assert.True(t, l.TryAcquire())
+	}
+	assert.False(t, l.TryAcquire())
+	for i := 0; i < 4; i++ {
+		l.Release(time.Millisecond, true)
+	}
+	assert.Equal(t, 6, l.Limit())
+
+	// not busy, keep the limit
+	assert.True(t, l.TryAcquire())
+	l.Release(time.Millisecond, true)
+	assert.Equal(t, 6, l.Limit())
+
+	// decrease on slow calls and failures
+	assert.True(t, l.TryAcquire())
+	l.Release(10*time.Millisecond, true)
+	assert.Equal(t, 3, l.Limit())
+	// the call started after the last decrease
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, l.TryAcquire())
+	l.Release(time.Millisecond, false)
+	assert.Equal(t, 2, l.Limit())
+	assert.Equal(t, 0, l.Inflight())
+}
+
+func TestAdaptiveLimiterDecreaseOncePerWindow(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(16, 1, 16), WithLimiterBackoff(0.5))
+
+	// a burst of failures of the concurrent calls decreases the limit once
+	for i := 0; i < 8; i++ {
+		assert.True(t, l.TryAcquire())
+	}
+	for i := 0; i < 8; i++ {
+		l.Release(10*time.Millisecond, false)
+	}
+	assert.Equal(t, 8, l.Limit())
+}
+
+func TestAdaptiveLimiterDoPanic(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(1, 1, 1))
+	assert.Panics(t, func() {
+		_ = l.Do(context.Background(), func() error { panic("oops") }, nil)
+	})
+	assert.Equal(t, 0, l.Inflight())
+	assert.True(t, l.TryAcquire())
+}
+
+func TestAdaptiveLimiterAcquire(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(1, 1, 1))
+	assert.Nil(t, l.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Acquire(ctx))
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.Nil(t, l.Acquire(context.Background()))
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	l.Release(time.Millisecond, true)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("acquire should succeed after release")
+	}
+
+	errOverload := errors.New("overload")
+	l.Release(time.Millisecond, true)
+	err := l.Do(context.Background(), func() error { return errOverload }, func(err error) bool {
+		return err == errOverload
+	})
+	assert.Equal(t, errOverload, err)
+	assert.Equal(t, 0, l.Inflight())
+}
+
+func TestTaskPoolWithLimiter(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(2, 2, 2))
+	p := NewTaskPool(WithTaskPoolTaskPoolSize(8), WithTaskPoolLimiter(l))
+	defer p.Close()
+
+	var (
+		wg      sync.WaitGroup
+		running int32
+		max     int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		p.AddTask(func() {
+			defer wg.Done()
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&max) <= 2)
+}
+
+func TestTaskPoolWithLimiterClose(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(1, 1, 1))
+	p := NewTaskPool(WithTaskPoolTaskPoolSize(2), WithTaskPoolLimiter(l))
+
+	// hold the only slot, the queued task waits for the limiter
+	assert.True(t, l.TryAcquire())
+	var ran int32
+	p.AddTask(func() {
+		atomic.StoreInt32(&ran, 1)
+	})
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&ran))
+
+	done := make(chan struct{})
+	go func() {
+		p.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("closing the pool should stop waiting for the limiter")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ran))
+	assert.Equal(t, 1, l.Inflight())
+}
+
+func TestTaskPoolWithLimiterOverflow(t *testing.T) {
+	l := NewAdaptiveLimiter(WithLimiterLimits(1, 1, 1))
+	p := NewTaskPool(WithTaskPoolTaskPoolSize(1), WithTaskPoolTaskQueueLength(1), WithTaskPoolLimiter(l))
+	defer p.Close()
+
+	// hold the only slot, the overflowed tasks wait for the limiter as well
+	assert.True(t, l.TryAcquire())
+	var ran int32
+	for i := 0; i < 5; i++ {
+		p.AddTaskAlways(func() {
+			atomic.AddInt32(&ran, 1)
+		})
+		p.AddTaskBalance(func() {
+			atomic.AddInt32(&ran, 1)
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&ran))
+
+	l.Release(time.Millisecond, true)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&ran) == 10 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Mutex is a sync.Mutex with a name. It records contention and detects potential
+// deadlocks when lock debugging is enabled by EnableLockDebug, and costs nothing more
+// than sync.Mutex otherwise. The zero value is an unlocked mutex without a name.
+type Mutex struct {
+	name string
+	mu   sync.Mutex
+}
+
+// NewMutex returns a mutex named @name, mutexes with the same name are
+// regarded as the same lock class when checking the lock order
+func NewMutex(name string) *Mutex {
+	return &Mutex{name: name}
+}
+
+// Name returns the name of the mutex
+func (m *Mutex) Name() string {
+	return lockName(m.name, m)
+}
+
+// Lock locks m
+func (m *Mutex) Lock() {
+	if !lockDebugEnabled() {
+		m.mu.Lock()
+		return
+	}
+	_ = debugAcquire(m, m.Name(), true, m.mu.TryLock, func() error {
+		m.mu.Lock()
+		return nil
+	})
+}
+
+// TryLock tries to lock m and reports whether it succeeded
+func (m *Mutex) TryLock() bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	if lockDebugEnabled() {
+		debugAcquired(m, m.Name(), 0, false)
+	}
+	return true
+}
+
+// Unlock unlocks m
+func (m *Mutex) Unlock() {
+	if lockDebugEnabled() {
+		debugRelease(m)
+	}
+	m.mu.Unlock()
+}
+
+// Semaphore is a counting semaphore with a name. Like Mutex, it records contention and
+// detects potential deadlocks when lock debugging is enabled.
+type Semaphore struct {
+	name   string
+	tokens chan struct{}
+}
+
+// NewSemaphore returns a semaphore named @name which admits @n holders at most
+func NewSemaphore(name string, n int) *Semaphore {
+	if n < 1 {
+		n = 1
+	}
+	return &Semaphore{
+		name:   name,
+		tokens: make(chan struct{}, n),
+	}
+}
+
+// Name returns the name of the semaphore
+func (s *Semaphore) Name() string {
+	return lockName(s.name, s)
+}
+
+// Acquire blocks until the semaphore is acquired or @ctx is done
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	wait := func() error {
+		select {
+		case s.tokens <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if !lockDebugEnabled() {
+		return wait()
+	}
+	return debugAcquire(s, s.Name(), false, s.tryAcquire, wait)
+}
+
+func (s *Semaphore) tryAcquire() bool {
+	select {
+	case s.tokens <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// TryAcquire tries to acquire the semaphore and reports whether it succeeded
+func (s *Semaphore) TryAcquire() bool {
+	if !s.tryAcquire() {
+		return false
+	}
+	if lockDebugEnabled() {
+		debugAcquired(s, s.Name(), 0, false)
+	}
+	return true
+}
+
+// Release releases the semaphore, it panics if the semaphore is not acquired
+func (s *Semaphore) Release() {
+	if lockDebugEnabled() {
+		debugRelease(s)
+	}
+	select {
+	case <-s.tokens:
+	default:
+		panic("gxsync: release of unacquired semaphore")
+	}
+}
+
+// Holders returns the number of current holders
+func (s *Semaphore) Holders() int {
+	return len(s.tokens)
+}
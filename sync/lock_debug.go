@@ -0,0 +1,497 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultLockWaitThreshold = 5 * time.Second
+	allStacksBufSize         = 1 << 20
+)
+
+// LockReportKind is the kind of a lock problem
+type LockReportKind int
+
+const (
+	// LockLongWait means a goroutine waits for a lock longer than the threshold
+	LockLongWait LockReportKind = iota
+	// LockOrderCycle means locks are acquired in inconsistent orders, which may deadlock
+	LockOrderCycle
+)
+
+// String returns the name of the kind
+func (k LockReportKind) String() string {
+	switch k {
+	case LockLongWait:
+		return "long wait"
+	case LockOrderCycle:
+		return "lock order cycle"
+	default:
+		return "unknown"
+	}
+}
+
+// LockReport describes a lock problem found in debug mode
+type LockReport struct {
+	Kind LockReportKind
+	// Lock is the name of the lock being acquired
+	Lock string
+	// Goroutine is the id of the goroutine acquiring the lock
+	Goroutine int64
+	// Wait is how long the goroutine has waited, for LockLongWait
+	Wait time.Duration
+	// Cycle is the lock names of the cycle whose first one equals the last one, for LockOrderCycle
+	Cycle []string
+	// HolderStacks are the stacks where the current holders acquired the lock
+	HolderStacks []string
+	// Stack is the stack of the goroutine acquiring the lock for LockOrderCycle,
+	// or the stacks of all goroutines for LockLongWait
+	Stack string
+}
+
+// String formats the report
+func (r LockReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s on lock %q by goroutine %d", r.Kind, r.Lock, r.Goroutine)
+	if r.Kind == LockLongWait {
+		fmt.Fprintf(&b, " after %s", r.Wait)
+	}
+	if len(r.Cycle) > 0 {
+		fmt.Fprintf(&b, ", cycle: %s", strings.Join(r.Cycle, " -> "))
+	}
+	for _, stack := range r.HolderStacks {
+		fmt.Fprintf(&b, "\nholder acquired the lock at:\n%s", stack)
+	}
+	if r.Stack != "" {
+		fmt.Fprintf(&b, "\n%s", r.Stack)
+	}
+	return b.String()
+}
+
+// LockStat is the statistics of a lock class, i.e. the locks with the same name
+type LockStat struct {
+	Name         string
+	Acquisitions uint64
+	// Contentions is the number of acquisitions which had to wait
+	Contentions uint64
+	TotalWait   time.Duration
+	MaxWait     time.Duration
+	TotalHold   time.Duration
+	MaxHold     time.Duration
+}
+
+// LockWaiter is a goroutine waiting for a lock
+type LockWaiter struct {
+	Lock      string
+	Goroutine int64
+	Wait      time.Duration
+}
+
+type lockDebugOptions struct {
+	waitThreshold time.Duration
+	handler       func(LockReport)
+}
+
+// LockDebugOption is optional settings for lock debugging
+type LockDebugOption func(*lockDebugOptions)
+
+// WithLockWaitThreshold reports the goroutines waiting for a lock longer than @d
+func WithLockWaitThreshold(d time.Duration) LockDebugOption {
+	return func(o *lockDebugOptions) {
+		o.waitThreshold = d
+	}
+}
+
+// WithLockReportHandler sets the handler of the reports, the reports are logged by default.
+// The handler is called without holding any lock of the debugger.
+func WithLockReportHandler(handler func(LockReport)) LockDebugOption {
+	return func(o *lockDebugOptions) {
+		o.handler = handler
+	}
+}
+
+type heldLock struct {
+	lock  interface{}
+	name  string
+	at    time.Time
+	stack []byte
+}
+
+type lockWaiting struct {
+	name  string
+	since time.Time
+}
+
+type lockDebugger struct {
+	lock     sync.Mutex
+	options  lockDebugOptions
+	stats    map[string]*LockStat
+	held     map[int64][]heldLock // goroutine id -> locks held by the goroutine
+	waiting  map[int64]lockWaiting
+	order    map[string]map[string]struct{} // lock name -> names of locks acquired while holding it
+	reported map[string]struct{}            // reported cycles
+}
+
+var (
+	lockDebugFlag int32
+	debugger      = &lockDebugger{}
+)
+
+func lockDebugEnabled() bool {
+	return atomic.LoadInt32(&lockDebugFlag) == 1
+}
+
+// EnableLockDebug enables the debug mode of Mutex and Semaphore, the statistics are reset.
+// It is expensive, and is supposed to be used for diagnosing stalls.
+func EnableLockDebug(opts ...LockDebugOption) {
+	options := lockDebugOptions{
+		waitThreshold: defaultLockWaitThreshold,
+		handler: func(r LockReport) {
+			log.Printf("gost/lock %s", r)
+		},
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	debugger.lock.Lock()
+	debugger.options = options
+	debugger.stats = make(map[string]*LockStat)
+	debugger.held = make(map[int64][]heldLock)
+	debugger.waiting = make(map[int64]lockWaiting)
+	debugger.order = make(map[string]map[string]struct{})
+	debugger.reported = make(map[string]struct{})
+	debugger.lock.Unlock()
+
+	atomic.StoreInt32(&lockDebugFlag, 1)
+}
+
+// DisableLockDebug disables the debug mode, the statistics are kept
+func DisableLockDebug() {
+	atomic.StoreInt32(&lockDebugFlag, 0)
+}
+
+// LockStats returns the statistics of all lock classes sorted by name
+func LockStats() []LockStat {
+	debugger.lock.Lock()
+	defer debugger.lock.Unlock()
+
+	stats := make([]LockStat, 0, len(debugger.stats))
+	for _, stat := range debugger.stats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// LockWaiters returns the goroutines waiting for locks, the longest waiter first
+func LockWaiters() []LockWaiter {
+	debugger.lock.Lock()
+	defer debugger.lock.Unlock()
+
+	now := time.Now()
+	waiters := make([]LockWaiter, 0, len(debugger.waiting))
+	for gid, w := range debugger.waiting {
+		waiters = append(waiters, LockWaiter{Lock: w.name, Goroutine: gid, Wait: now.Sub(w.since)})
+	}
+	sort.Slice(waiters, func(i, j int) bool {
+		return waiters[i].Wait > waiters[j].Wait
+	})
+	return waiters
+}
+
+func lockName(name string, lock interface{}) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T(%p)", lock, lock)
+}
+
+// goroutineID parses the id from the first line of the stack: "goroutine 18 [running]:"
+func goroutineID() int64 {
+	var buf [64]byte
+	line := buf[:runtime.Stack(buf[:], false)]
+	line = bytes.TrimPrefix(line, []byte("goroutine "))
+	if idx := bytes.IndexByte(line, ' '); idx > 0 {
+		line = line[:idx]
+	}
+	id, _ := strconv.ParseInt(string(line), 10, 64)
+	return id
+}
+
+func currentStack() []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// debugAcquire acquires @lock by @try first, and by @wait if it is contended.
+// @exclusive means the lock can not be held twice, re-acquiring it by its holder is a deadlock.
+func debugAcquire(lock interface{}, name string, exclusive bool, try func() bool, wait func() error) error {
+	gid := goroutineID()
+	debugger.checkOrder(gid, lock, name, exclusive)
+
+	if try() {
+		debugAcquired(lock, name, 0, false)
+		return nil
+	}
+
+	start := time.Now()
+	debugger.lock.Lock()
+	debugger.waiting[gid] = lockWaiting{name: name, since: start}
+	threshold := debugger.options.waitThreshold
+	debugger.lock.Unlock()
+
+	var timer *time.Timer
+	if threshold > 0 {
+		timer = time.AfterFunc(threshold, func() {
+			debugger.reportLongWait(gid, lock, name, start)
+		})
+	}
+	err := wait()
+	if timer != nil {
+		timer.Stop()
+	}
+
+	debugger.lock.Lock()
+	delete(debugger.waiting, gid)
+	debugger.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	debugAcquired(lock, name, time.Since(start), true)
+	return nil
+}
+
+func debugAcquired(lock interface{}, name string, wait time.Duration, contended bool) {
+	gid := goroutineID()
+	stack := currentStack()
+
+	debugger.lock.Lock()
+	defer debugger.lock.Unlock()
+
+	if debugger.held == nil {
+		return
+	}
+	stat := debugger.stat(name)
+	stat.Acquisitions++
+	if contended {
+		stat.Contentions++
+		stat.TotalWait += wait
+		if wait > stat.MaxWait {
+			stat.MaxWait = wait
+		}
+	}
+	debugger.held[gid] = append(debugger.held[gid], heldLock{lock: lock, name: name, at: time.Now(), stack: stack})
+}
+
+// debugRelease forgets the holder of @lock. A lock may be released by a goroutine other
+// than its holder, so the holder of the current goroutine is preferred, and any holder otherwise.
+func debugRelease(lock interface{}) {
+	gid := goroutineID()
+
+	debugger.lock.Lock()
+	defer debugger.lock.Unlock()
+
+	if debugger.held == nil {
+		return
+	}
+	if debugger.releaseHeld(gid, lock) {
+		return
+	}
+	for holder := range debugger.held {
+		if debugger.releaseHeld(holder, lock) {
+			return
+		}
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (d *lockDebugger) releaseHeld(gid int64, lock interface{}) bool {
+	held := d.held[gid]
+	for i := len(held) - 1; i >= 0; i-- {
+		if held[i].lock != lock {
+			continue
+		}
+		hold := time.Since(held[i].at)
+		stat := d.stat(held[i].name)
+		stat.TotalHold += hold
+		if hold > stat.MaxHold {
+			stat.MaxHold = hold
+		}
+
+		held = append(held[:i], held[i+1:]...)
+		if len(held) == 0 {
+			delete(d.held, gid)
+		} else {
+			d.held[gid] = held
+		}
+		return true
+	}
+	return false
+}
+
+// NOTICE: need to get the lock before calling this method
+func (d *lockDebugger) stat(name string) *LockStat {
+	stat, ok := d.stats[name]
+	if !ok {
+		stat = &LockStat{Name: name}
+		d.stats[name] = stat
+	}
+	return stat
+}
+
+// NOTICE: need to get the lock before calling this method
+func (d *lockDebugger) holderStacks(lock interface{}) []string {
+	var stacks []string
+	for _, held := range d.held {
+		for _, h := range held {
+			if h.lock == lock {
+				stacks = append(stacks, string(h.stack))
+			}
+		}
+	}
+	return stacks
+}
+
+// checkOrder records the order from every lock held by goroutine @gid to @name,
+// and reports the cycles of the order
+func (d *lockDebugger) checkOrder(gid int64, lock interface{}, name string, exclusive bool) {
+	var reports []LockReport
+
+	d.lock.Lock()
+	if d.held == nil {
+		d.lock.Unlock()
+		return
+	}
+	for _, h := range d.held[gid] {
+		var cycle []string
+		if h.lock == lock {
+			if exclusive {
+				cycle = []string{name, name}
+			}
+		} else if h.name != name {
+			if _, ok := d.order[h.name]; !ok {
+				d.order[h.name] = make(map[string]struct{})
+			}
+			d.order[h.name][name] = struct{}{}
+			if path := d.orderPath(name, h.name); path != nil {
+				cycle = append([]string{h.name}, path...)
+			}
+		}
+		if cycle == nil {
+			continue
+		}
+
+		key := cycleKey(cycle)
+		if _, ok := d.reported[key]; ok {
+			continue
+		}
+		d.reported[key] = struct{}{}
+		reports = append(reports, LockReport{
+			Kind:         LockOrderCycle,
+			Lock:         name,
+			Goroutine:    gid,
+			Cycle:        cycle,
+			HolderStacks: d.holderStacks(h.lock),
+			Stack:        string(currentStack()),
+		})
+	}
+	handler := d.options.handler
+	d.lock.Unlock()
+
+	for _, r := range reports {
+		handler(r)
+	}
+}
+
+// orderPath returns the lock names from @from to @to in the order graph, nil if @to is unreachable
+// NOTICE: need to get the lock before calling this method
+func (d *lockDebugger) orderPath(from, to string) []string {
+	visited := map[string]struct{}{from: {}}
+	var dfs func(name string) []string
+	dfs = func(name string) []string {
+		if name == to {
+			return []string{name}
+		}
+		for next := range d.order[name] {
+			if _, ok := visited[next]; ok {
+				continue
+			}
+			visited[next] = struct{}{}
+			if path := dfs(next); path != nil {
+				return append([]string{name}, path...)
+			}
+		}
+		return nil
+	}
+	return dfs(from)
+}
+
+// cycleKey identifies a cycle regardless of its start point
+func cycleKey(cycle []string) string {
+	names := append([]string(nil), cycle[:len(cycle)-1]...)
+	min := 0
+	for i := range names {
+		if names[i] < names[min] {
+			min = i
+		}
+	}
+	return strings.Join(append(names[min:], names[:min]...), "->")
+}
+
+func (d *lockDebugger) reportLongWait(gid int64, lock interface{}, name string, start time.Time) {
+	buf := make([]byte, allStacksBufSize)
+	stacks := string(buf[:runtime.Stack(buf, true)])
+
+	d.lock.Lock()
+	if w, ok := d.waiting[gid]; !ok || w.since != start {
+		// the lock has been acquired
+		d.lock.Unlock()
+		return
+	}
+	report := LockReport{
+		Kind:         LockLongWait,
+		Lock:         name,
+		Goroutine:    gid,
+		Wait:         time.Since(start),
+		HolderStacks: d.holderStacks(lock),
+		Stack:        stacks,
+	}
+	handler := d.options.handler
+	d.lock.Unlock()
+
+	handler(report)
+}
@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+type reportRecorder struct {
+	lock    sync.Mutex
+	reports []LockReport
+}
+
+func (r *reportRecorder) handle(report LockReport) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func (r *reportRecorder) get() []LockReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]LockReport(nil), r.reports...)
+}
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	assert.True(t, id > 0)
+
+	ch := make(chan int64)
+	go func() {
+		ch <- goroutineID()
+	}()
+	assert.NotEqual(t, id, <-ch)
+}
+
+func TestLockDebugStats(t *testing.T) {
+	recorder := &reportRecorder{}
+	EnableLockDebug(WithLockReportHandler(recorder.handle), WithLockWaitThreshold(20*time.Millisecond))
+	defer DisableLockDebug()
+
+	m := NewMutex("stats")
+	m.Lock()
+	acquired := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(acquired)
+		m.Unlock()
+	}()
+
+	assert.Eventually(t, func() bool {
+		waiters := LockWaiters()
+		return len(waiters) == 1 && waiters[0].Lock == "stats"
+	}, time.Second, time.Millisecond)
+	// the waiter is reported with the stack of the holder
+	assert.Eventually(t, func() bool {
+		return len(recorder.get()) == 1
+	}, time.Second, time.Millisecond)
+	report := recorder.get()[0]
+	assert.Equal(t, LockLongWait, report.Kind)
+	assert.Equal(t, "stats", report.Lock)
+	assert.Len(t, report.HolderStacks, 1)
+	assert.Contains(t, report.HolderStacks[0], "TestLockDebugStats")
+	assert.Contains(t, report.String(), "long wait")
+
+	m.Unlock()
+	<-acquired
+	assert.Empty(t, LockWaiters())
+
+	stats := LockStats()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "stats", stats[0].Name)
+	assert.Equal(t, uint64(2), stats[0].Acquisitions)
+	assert.Equal(t, uint64(1), stats[0].Contentions)
+	assert.True(t, stats[0].MaxWait >= 20*time.Millisecond)
+	assert.True(t, stats[0].MaxHold >= 20*time.Millisecond)
+}
+
+func TestLockDebugOrderCycle(t *testing.T) {
+	recorder := &reportRecorder{}
+	EnableLockDebug(WithLockReportHandler(recorder.handle))
+	defer DisableLockDebug()
+
+	a, b := NewMutex("a"), NewMutex("b")
+	s := NewSemaphore("s", 2)
+
+	a.Lock()
+	b.Lock()
+	b.Unlock()
+	a.Unlock()
+	assert.Empty(t, recorder.get())
+
+	// b -> s -> a closes the cycle a -> b -> s -> a without deadlocking in this run
+	b.Lock()
+	assert.Nil(t, s.Acquire(context.Background()))
+	s.Release()
+	b.Unlock()
+	assert.Nil(t, s.Acquire(context.Background()))
+	a.Lock()
+	a.Unlock()
+	s.Release()
+
+	reports := recorder.get()
+	assert.Len(t, reports, 1)
+	assert.Equal(t, LockOrderCycle, reports[0].Kind)
+	assert.Equal(t, []string{"s", "a", "b", "s"}, reports[0].Cycle)
+	assert.Contains(t, reports[0].Stack, "TestLockDebugOrderCycle")
+
+	// the same cycle is reported once
+	assert.Nil(t, s.Acquire(context.Background()))
+	a.Lock()
+	a.Unlock()
+	s.Release()
+	assert.Len(t, recorder.get(), 1)
+}
+
+func TestLockDebugSelfDeadlock(t *testing.T) {
+	recorder := &reportRecorder{}
+	EnableLockDebug(WithLockReportHandler(recorder.handle), WithLockWaitThreshold(0))
+	defer DisableLockDebug()
+
+	m := NewMutex("self")
+	m.Lock()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		// unlocked by another goroutine
+		m.Unlock()
+	}()
+	m.Lock()
+	m.Unlock()
+
+	reports := recorder.get()
+	assert.Len(t, reports, 1)
+	assert.Equal(t, []string{"self", "self"}, reports[0].Cycle)
+
+	// a semaphore can be acquired twice by the same goroutine
+	s := NewSemaphore("twice", 2)
+	assert.Nil(t, s.Acquire(context.Background()))
+	assert.Nil(t, s.Acquire(context.Background()))
+	s.Release()
+	s.Release()
+	assert.Len(t, recorder.get(), 1)
+}
+
+func TestCycleKey(t *testing.T) {
+	assert.Equal(t, cycleKey([]string{"b", "c", "a", "b"}), cycleKey([]string{"a", "b", "c", "a"}))
+	assert.NotEqual(t, cycleKey([]string{"a", "c", "b", "a"}), cycleKey([]string{"a", "b", "c", "a"}))
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutex(t *testing.T) {
+	var (
+		m     Mutex
+		wg    sync.WaitGroup
+		count int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Lock()
+				count++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10000, count)
+
+	assert.True(t, m.TryLock())
+	assert.False(t, m.TryLock())
+	m.Unlock()
+
+	assert.Equal(t, "db", NewMutex("db").Name())
+	assert.Contains(t, m.Name(), "Mutex")
+}
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore("conn", 2)
+	assert.Equal(t, "conn", s.Name())
+	assert.True(t, s.TryAcquire())
+	assert.Nil(t, s.Acquire(context.Background()))
+	assert.False(t, s.TryAcquire())
+	assert.Equal(t, 2, s.Holders())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Acquire(ctx))
+
+	s.Release()
+	s.Release()
+	assert.Equal(t, 0, s.Holders())
+	assert.Panics(t, s.Release)
+}
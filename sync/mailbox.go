@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"errors"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+const defaultMailboxBatch = 64
+
+var (
+	// ErrMailboxClosed is returned when sending to a closed mailbox
+	ErrMailboxClosed = errors.New("mailbox closed")
+	// ErrMailboxFull is returned when the pending messages reach the mailbox capacity
+	ErrMailboxFull = errors.New("mailbox full")
+)
+
+type mailboxOptions struct {
+	capacity int // max pending messages, no limit if it is not positive
+	batch    int // max messages processed in one scheduling
+}
+
+// MailboxOption is optional settings for mailbox
+type MailboxOption func(*mailboxOptions)
+
+// WithMailboxCapacity sets the max number of pending messages
+func WithMailboxCapacity(capacity int) MailboxOption {
+	return func(o *mailboxOptions) {
+		o.capacity = capacity
+	}
+}
+
+// WithMailboxBatch sets the max number of messages processed in one scheduling,
+// after which the mailbox yields the worker to other mailboxes
+func WithMailboxBatch(batch int) MailboxOption {
+	return func(o *mailboxOptions) {
+		o.batch = batch
+	}
+}
+
+// Mailbox is an actor: a message queue of an entity with a single logical
+// consumer which handles the messages serially in sending order.
+// Mailboxes do not own goroutines, they are scheduled on a shared task pool
+// only when they have pending messages, so a huge number of mailboxes, such as
+// one per connection, cost little.
+type Mailbox[T any] struct {
+	mailboxOptions
+
+	pool    GenericTaskPool
+	handler func(msg T)
+
+	lock      sync.Mutex
+	queue     []T
+	head      int
+	scheduled bool
+	closed    bool
+	done      chan struct{}
+}
+
+// NewMailbox returns a mailbox which handles messages by @handler on @pool
+func NewMailbox[T any](pool GenericTaskPool, handler func(msg T), opts ...MailboxOption) *Mailbox[T] {
+	m := &Mailbox[T]{
+		mailboxOptions: mailboxOptions{batch: defaultMailboxBatch},
+		pool:           pool,
+		handler:        handler,
+		done:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&m.mailboxOptions)
+	}
+	if m.batch < 1 {
+		m.batch = defaultMailboxBatch
+	}
+	return m
+}
+
+// Send puts @msg into the mailbox. If the pool is closed while the mailbox is
+// idle, the mailbox is closed and ErrMailboxClosed is returned.
+func (m *Mailbox[T]) Send(msg T) error {
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return ErrMailboxClosed
+	}
+	if m.capacity > 0 && len(m.queue)-m.head >= m.capacity {
+		m.lock.Unlock()
+		return ErrMailboxFull
+	}
+	if !m.scheduled && m.pool.IsClosed() {
+		// no worker will handle the messages, and nothing is pending
+		m.closed = true
+		close(m.done)
+		m.lock.Unlock()
+		return ErrMailboxClosed
+	}
+	m.queue = append(m.queue, msg)
+	schedule := !m.scheduled
+	m.scheduled = true
+	m.lock.Unlock()
+
+	if schedule {
+		m.pool.AddTaskAlways(m.process)
+	}
+	return nil
+}
+
+// Len returns the number of pending messages
+func (m *Mailbox[T]) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.queue) - m.head
+}
+
+// Close stops receiving messages. The pending messages are still handled,
+// and Done is closed after all of them have been handled.
+func (m *Mailbox[T]) Close() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return
+	}
+	m.closed = true
+	if !m.scheduled {
+		close(m.done)
+	}
+}
+
+// Done returns a chan which is closed when the mailbox is closed and drained
+func (m *Mailbox[T]) Done() <-chan struct{} {
+	return m.done
+}
+
+// pop returns the next message, or marks the mailbox unscheduled if it is empty
+func (m *Mailbox[T]) pop() (msg T, ok bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.head == len(m.queue) {
+		m.queue = m.queue[:0]
+		m.head = 0
+		m.scheduled = false
+		if m.closed {
+			close(m.done)
+		}
+		return msg, false
+	}
+
+	msg = m.queue[m.head]
+	var zero T
+	m.queue[m.head] = zero
+	m.head++
+	// release the consumed space of a long queue
+	if m.head > 1024 && m.head*2 > len(m.queue) {
+		m.queue = append(m.queue[:0], m.queue[m.head:]...)
+		m.head = 0
+	}
+	return msg, true
+}
+
+func (m *Mailbox[T]) process() {
+	for i := 0; ; i++ {
+		// a closed pool drops the tasks, so drain the mailbox on current worker
+		if i >= m.batch && !m.pool.IsClosed() {
+			// yield the worker, the mailbox is still scheduled
+			m.pool.AddTaskAlways(m.process)
+			return
+		}
+		msg, ok := m.pop()
+		if !ok {
+			return
+		}
+		m.handle(msg)
+	}
+}
+
+func (m *Mailbox[T]) handle(msg T) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("gost/Mailbox handler panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	m.handler(msg)
+}
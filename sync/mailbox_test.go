@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailboxOrder(t *testing.T) {
+	pool := NewTaskPool(WithTaskPoolTaskPoolSize(4), WithTaskPoolTaskQueueNumber(2))
+	defer pool.Close()
+
+	var (
+		running int32
+		got     []int
+	)
+	m := NewMailbox(pool, func(msg int) {
+		// messages of a mailbox are handled serially
+		if atomic.AddInt32(&running, 1) != 1 {
+			t.Error("mailbox handler is running concurrently")
+		}
+		got = append(got, msg)
+		atomic.AddInt32(&running, -1)
+	}, WithMailboxBatch(3))
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, m.Send(i))
+	}
+	m.Close()
+	assert.Equal(t, ErrMailboxClosed, m.Send(100))
+
+	select {
+	case <-m.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("mailbox should be drained")
+	}
+	assert.Equal(t, 100, len(got))
+	for i := range got {
+		assert.Equal(t, i, got[i])
+	}
+}
+
+func TestMailboxMany(t *testing.T) {
+	pool := NewTaskPool(WithTaskPoolTaskPoolSize(8))
+	defer pool.Close()
+
+	var (
+		wg    sync.WaitGroup
+		total int64
+	)
+	mailboxes := make([]*Mailbox[int64], 1000)
+	for i := range mailboxes {
+		mailboxes[i] = NewMailbox(pool, func(msg int64) {
+			atomic.AddInt64(&total, msg)
+			wg.Done()
+		})
+	}
+	for i := 0; i < 10; i++ {
+		for _, m := range mailboxes {
+			wg.Add(1)
+			assert.Nil(t, m.Send(1))
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, int64(10000), atomic.LoadInt64(&total))
+}
+
+func TestMailboxCapacityAndPanic(t *testing.T) {
+	pool := NewTaskPool(WithTaskPoolTaskPoolSize(1))
+	defer pool.Close()
+
+	block := make(chan struct{})
+	var handled int32
+	m := NewMailbox(pool, func(msg int) {
+		if msg == 0 {
+			<-block
+		}
+		if msg == 1 {
+			panic("hello")
+		}
+		atomic.AddInt32(&handled, 1)
+	}, WithMailboxCapacity(2))
+
+	assert.Nil(t, m.Send(0))
+	assert.Eventually(t, func() bool { return m.Len() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, m.Send(1))
+	assert.Nil(t, m.Send(2))
+	assert.Equal(t, ErrMailboxFull, m.Send(3))
+	close(block)
+
+	m.Close()
+	<-m.Done()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&handled))
+}
+
+func TestMailboxClosedPool(t *testing.T) {
+	pool := NewTaskPoolSimple(1)
+
+	block := make(chan struct{})
+	var handled int32
+	m := NewMailbox(pool, func(msg int) {
+		if msg == 0 {
+			<-block
+		}
+		atomic.AddInt32(&handled, 1)
+	}, WithMailboxBatch(2))
+
+	assert.Nil(t, m.Send(0))
+	assert.Eventually(t, func() bool { return m.Len() == 0 }, time.Second, 10*time.Millisecond)
+	for i := 1; i < 6; i++ {
+		assert.Nil(t, m.Send(i))
+	}
+	go pool.Close()
+	assert.Eventually(t, pool.IsClosed, time.Second, 10*time.Millisecond)
+	close(block)
+
+	// the pending messages are drained beyond the batch size
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&handled) == 6 }, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return m.Send(6) == ErrMailboxClosed }, time.Second, 10*time.Millisecond)
+	<-m.Done()
+}
@@ -35,6 +35,7 @@ type TaskPoolOptions struct {
 	tQLen      int // task queue length. buffer size per queue
 	tQNumber   int // task queue number. number of queue
 	tQPoolSize int // task pool size. number of workers
+	limiter    *AdaptiveLimiter
 }
 
 func (o *TaskPoolOptions) validate() {
@@ -77,3 +78,11 @@ func WithTaskPoolTaskQueueNumber(number int) TaskPoolOption {
 		o.tQNumber = number
 	}
 }
+
+// WithTaskPoolLimiter limits the concurrency of running tasks by @limiter,
+// the latency of every task is fed back to @limiter and a panic is regarded as overload
+func WithTaskPoolLimiter(limiter *AdaptiveLimiter) TaskPoolOption {
+	return func(o *TaskPoolOptions) {
+		o.limiter = limiter
+	}
+}
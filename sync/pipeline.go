@@ -0,0 +1,256 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// Pipeline runs composable stages connected by bounded chans. The first error
+// returned by a stage cancels the context of the pipeline, which stops all the
+// stages, and is returned by Wait.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	pool   GenericTaskPool
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewPipeline returns a pipeline whose stages run on @pool. Every stage holds a
+// worker until its input is exhausted, so @pool must have more workers than the
+// stages running at the same time, counting every worker of PipelineFanOut and
+// every input of PipelineFanIn. A goroutine is started for every stage if @pool is nil.
+func NewPipeline(ctx context.Context, pool GenericTaskPool) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{
+		ctx:    ctx,
+		cancel: cancel,
+		pool:   pool,
+	}
+}
+
+// Context returns the context of the pipeline, which is done when the pipeline fails
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait waits for all the stages and returns the first error
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	return p.err
+}
+
+// Fail cancels the pipeline with @err
+func (p *Pipeline) Fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// run runs the stage @fn, a panic of @fn fails the pipeline
+func (p *Pipeline) run(fn func()) {
+	p.wg.Add(1)
+	stage := func() {
+		defer p.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				p.Fail(perrors.Errorf("pipeline stage panic: %v", r))
+			}
+		}()
+		fn()
+	}
+	if p.pool == nil {
+		goSafely(stage)
+		return
+	}
+	p.pool.AddTaskAlways(stage)
+}
+
+// send sends @v to @out unless the pipeline is cancelled
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// PipelineSource emits @items into the pipeline through a chan with @buffer size
+func PipelineSource[T any](p *Pipeline, buffer int, items ...T) <-chan T {
+	return PipelineGenerate(p, buffer, func(ctx context.Context, emit func(T) bool) error {
+		for _, item := range items {
+			if !emit(item) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// PipelineGenerate emits the items generated by @gen into the pipeline. @emit returns
+// false if the pipeline is cancelled, then @gen should return.
+func PipelineGenerate[T any](p *Pipeline, buffer int, gen func(ctx context.Context, emit func(T) bool) error) <-chan T {
+	out := make(chan T, buffer)
+	p.run(func() {
+		defer close(out)
+		err := gen(p.ctx, func(v T) bool {
+			return send(p.ctx, out, v)
+		})
+		if err != nil {
+			p.Fail(err)
+		}
+	})
+	return out
+}
+
+// PipelineMap converts every item of @in by @fn
+func PipelineMap[T, R any](p *Pipeline, in <-chan T, buffer int, fn func(ctx context.Context, v T) (R, error)) <-chan R {
+	out := make(chan R, buffer)
+	p.run(func() {
+		defer close(out)
+		mapLoop(p, in, out, fn)
+	})
+	return out
+}
+
+func mapLoop[T, R any](p *Pipeline, in <-chan T, out chan<- R, fn func(ctx context.Context, v T) (R, error)) {
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case v, ok := <-in:
+			if !ok {
+				return
+			}
+			r, err := fn(p.ctx, v)
+			if err != nil {
+				p.Fail(err)
+				return
+			}
+			if !send(p.ctx, out, r) {
+				return
+			}
+		}
+	}
+}
+
+// PipelineFilter keeps the items of @in for which @fn returns true
+func PipelineFilter[T any](p *Pipeline, in <-chan T, buffer int, fn func(ctx context.Context, v T) (bool, error)) <-chan T {
+	out := make(chan T, buffer)
+	p.run(func() {
+		defer close(out)
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				keep, err := fn(p.ctx, v)
+				if err != nil {
+					p.Fail(err)
+					return
+				}
+				if keep && !send(p.ctx, out, v) {
+					return
+				}
+			}
+		}
+	})
+	return out
+}
+
+// PipelineFanOut converts the items of @in by @n concurrent @fn, the output order is
+// not guaranteed
+func PipelineFanOut[T, R any](p *Pipeline, in <-chan T, n, buffer int, fn func(ctx context.Context, v T) (R, error)) <-chan R {
+	if n < 1 {
+		n = 1
+	}
+
+	out := make(chan R, buffer)
+	// the last exiting worker closes @out, no worker is held to wait for the others
+	remaining := int32(n)
+	for i := 0; i < n; i++ {
+		p.run(func() {
+			defer func() {
+				if atomic.AddInt32(&remaining, -1) == 0 {
+					close(out)
+				}
+			}()
+			mapLoop(p, in, out, fn)
+		})
+	}
+	return out
+}
+
+// PipelineFanIn merges all the items of @ins into one chan
+func PipelineFanIn[T any](p *Pipeline, buffer int, ins ...<-chan T) <-chan T {
+	out := make(chan T, buffer)
+	if len(ins) == 0 {
+		close(out)
+		return out
+	}
+
+	// the last exiting worker closes @out, no worker is held to wait for the others
+	remaining := int32(len(ins))
+	for _, in := range ins {
+		in := in
+		p.run(func() {
+			defer func() {
+				if atomic.AddInt32(&remaining, -1) == 0 {
+					close(out)
+				}
+			}()
+			for {
+				select {
+				case <-p.ctx.Done():
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					if !send(p.ctx, out, v) {
+						return
+					}
+				}
+			}
+		})
+	}
+	return out
+}
+
+// PipelineCollect drains @in and returns all the items with the error of the pipeline
+func PipelineCollect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var items []T
+	for v := range in {
+		items = append(items, v)
+	}
+	return items, p.Wait()
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	for _, pool := range []GenericTaskPool{nil, NewTaskPool(WithTaskPoolTaskPoolSize(16))} {
+		p := NewPipeline(context.Background(), pool)
+
+		src := PipelineSource(p, 4, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+		even := PipelineFilter(p, src, 4, func(ctx context.Context, v int) (bool, error) {
+			return v%2 == 0, nil
+		})
+		squares := PipelineFanOut(p, even, 3, 4, func(ctx context.Context, v int) (int, error) {
+			return v * v, nil
+		})
+		strs := PipelineMap(p, squares, 4, func(ctx context.Context, v int) (string, error) {
+			return strconv.Itoa(v), nil
+		})
+		others := PipelineSource(p, 0, "x")
+
+		items, err := PipelineCollect(p, PipelineFanIn(p, 2, strs, others))
+		assert.Nil(t, err)
+		sort.Strings(items)
+		assert.Equal(t, []string{"100", "16", "36", "4", "64", "x"}, items)
+
+		if pool != nil {
+			pool.Close()
+		}
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	errStop := errors.New("stop")
+	p := NewPipeline(context.Background(), nil)
+
+	src := PipelineGenerate(p, 0, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+	})
+	out := PipelineMap(p, src, 0, func(ctx context.Context, v int) (int, error) {
+		if v == 5 {
+			return 0, errStop
+		}
+		return v, nil
+	})
+
+	items, err := PipelineCollect(p, out)
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, items)
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPipeline(ctx, nil)
+
+	src := PipelineGenerate(p, 0, func(ctx context.Context, emit func(int) bool) error {
+		for emit(1) {
+		}
+		return nil
+	})
+	<-src
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range src {
+		}
+		assert.Nil(t, p.Wait())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("pipeline should stop after cancel")
+	}
+}
+
+func TestPipelineSmallPool(t *testing.T) {
+	// exactly one worker for every stage
+	pool := NewTaskPool(WithTaskPoolTaskPoolSize(4), WithTaskPoolTaskQueueNumber(1),
+		WithTaskPoolTaskQueueLength(16))
+	defer pool.Close()
+	p := NewPipeline(context.Background(), pool)
+
+	src := PipelineSource(p, 0, 1, 2, 3, 4)
+	squares := PipelineFanOut(p, src, 2, 0, func(ctx context.Context, v int) (int, error) {
+		return v * v, nil
+	})
+	out := PipelineFanIn(p, 0, squares)
+
+	done := make(chan struct{})
+	go func() {
+		items, err := PipelineCollect(p, out)
+		assert.Nil(t, err)
+		sort.Ints(items)
+		assert.Equal(t, []int{1, 4, 9, 16}, items)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("pipeline should not need extra workers")
+	}
+}
+
+func TestPipelinePanic(t *testing.T) {
+	p := NewPipeline(context.Background(), nil)
+
+	src := PipelineSource(p, 0, 1, 2, 3)
+	out := PipelineFanOut(p, src, 2, 0, func(ctx context.Context, v int) (int, error) {
+		if v == 2 {
+			panic("bad item")
+		}
+		return v, nil
+	})
+
+	_, err := PipelineCollect(p, out)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "bad item")
+	assert.NotNil(t, p.Context().Err())
+}
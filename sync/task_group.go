@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	gxerrors "github.com/dubbogo/gost/errors"
+)
+
+// TaskGroup runs a group of tasks which may fail on a task pool, and collects the errors
+// of all tasks instead of the first one
+type TaskGroup struct {
+	pool GenericTaskPool
+	wg   sync.WaitGroup
+	errs *gxerrors.MultiError
+}
+
+// NewTaskGroup returns a TaskGroup running tasks on @pool, every task runs in its own
+// goroutine if @pool is nil
+func NewTaskGroup(pool GenericTaskPool) *TaskGroup {
+	return &TaskGroup{
+		pool: pool,
+		errs: gxerrors.NewMultiError(),
+	}
+}
+
+// Go runs @fn in the group, a panic of @fn is collected as an error
+func (g *TaskGroup) Go(fn func() error) {
+	g.wg.Add(1)
+	t := func() {
+		defer g.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				g.errs.Add(perrors.Errorf("task panic: %v", r))
+			}
+		}()
+		g.errs.Add(fn())
+	}
+
+	if g.pool == nil {
+		go t()
+		return
+	}
+	g.pool.AddTaskAlways(t)
+}
+
+// Wait blocks until all tasks finish, and returns a *gxerrors.MultiError holding
+// the errors of all failed tasks, or nil if all tasks succeed
+func (g *TaskGroup) Wait() error {
+	g.wg.Wait()
+	return g.errs.ErrorOrNil()
+}
+
+// Succeed returns how many tasks succeed and how many tasks have finished
+func (g *TaskGroup) Succeed() (succeed int, total int) {
+	return g.errs.Succeed()
+}
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxsync
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	gxerrors "github.com/dubbogo/gost/errors"
+)
+
+func TestTaskGroup(t *testing.T) {
+	errOdd := errors.New("odd")
+	for _, pool := range []GenericTaskPool{nil, NewTaskPoolSimple(2)} {
+		g := NewTaskGroup(pool)
+		for i := 0; i < 10; i++ {
+			i := i
+			g.Go(func() error {
+				if i == 9 {
+					panic("boom")
+				}
+				if i%2 == 1 {
+					return errOdd
+				}
+				return nil
+			})
+		}
+
+		err := g.Wait()
+		assert.True(t, errors.Is(err, errOdd))
+		me, ok := err.(*gxerrors.MultiError)
+		assert.True(t, ok)
+		assert.Equal(t, 5, me.Len())
+		assert.Contains(t, err.Error(), "task panic: boom")
+		succeed, total := g.Succeed()
+		assert.Equal(t, 5, succeed)
+		assert.Equal(t, 10, total)
+
+		if pool != nil {
+			pool.Close()
+		}
+	}
+
+	g := NewTaskGroup(nil)
+	g.Go(func() error { return nil })
+	assert.Nil(t, g.Wait())
+}
@@ -18,6 +18,7 @@
 package gxsync
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
@@ -49,10 +50,34 @@ type GenericTaskPool interface {
 	IsClosed() bool
 }
 
+// GracefulTaskPool is a task pool which can be shut down gracefully. The task
+// pools returned by NewTaskPool and NewTaskPoolSimple implement it.
+type GracefulTaskPool interface {
+	GenericTaskPool
+	// Shutdown closes the task pool, and waits for the running tasks until @ctx is done
+	Shutdown(ctx context.Context) error
+}
+
 func goSafely(fn func()) {
 	gxruntime.GoSafely(nil, false, fn, nil)
 }
 
+// shutdownPool runs @closeFn in background, and waits for it until @ctx is done
+func shutdownPool(ctx context.Context, closeFn func()) error {
+	done := make(chan struct{})
+	go func() {
+		closeFn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 /////////////////////////////////////////
 // Task Pool
 /////////////////////////////////////////
@@ -66,6 +91,10 @@ type TaskPool struct {
 
 	once sync.Once
 	done chan struct{}
+
+	// limiterCtx is cancelled when the pool is closed, to stop waiting for the limiter
+	limiterCtx    context.Context
+	limiterCancel context.CancelFunc
 }
 
 // NewTaskPool build a task pool
@@ -82,6 +111,7 @@ func NewTaskPool(opts ...TaskPoolOption) GenericTaskPool {
 		qArray:          make([]chan task, tOpts.tQNumber),
 		done:            make(chan struct{}),
 	}
+	p.limiterCtx, p.limiterCancel = context.WithCancel(context.Background())
 
 	for i := 0; i < p.tQNumber; i++ {
 		p.qArray[i] = make(chan task, p.tQLen)
@@ -135,20 +165,36 @@ func (p *TaskPool) run(id int, q chan task) error {
 
 		case t, ok = <-q:
 			if ok {
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							fmt.Fprintf(os.Stderr, "%s goroutine panic: %v\n%s\n",
-								time.Now(), r, string(debug.Stack()))
-						}
-					}()
-					t()
-				}()
+				p.runTask(t)
 			}
 		}
 	}
 }
 
+func (p *TaskPool) runTask(t task) {
+	var (
+		start    time.Time
+		acquired bool
+		panicked bool
+	)
+	if p.limiter != nil {
+		// the pool is closed if it fails, run the task to drain the queue anyway
+		acquired = p.limiter.Acquire(p.limiterCtx) == nil
+		start = time.Now()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			fmt.Fprintf(os.Stderr, "%s goroutine panic: %v\n%s\n",
+				time.Now(), r, string(debug.Stack()))
+		}
+		if acquired {
+			p.limiter.Release(time.Since(start), !panicked)
+		}
+	}()
+	t()
+}
+
 // return false when the pool is stop
 func (p *TaskPool) AddTask(t task) (ok bool) {
 	idx := atomic.AddUint32(&p.idx, 1)
@@ -170,7 +216,7 @@ func (p *TaskPool) AddTaskAlways(t task) {
 	case p.qArray[id] <- t:
 		return
 	default:
-		goSafely(t)
+		p.runTaskAsync(t)
 	}
 }
 
@@ -188,7 +234,13 @@ func (p *TaskPool) AddTaskBalance(t task) {
 		}
 	}
 
-	goSafely(t)
+	p.runTaskAsync(t)
+}
+
+// runTaskAsync runs the overflowed task in a temporary goroutine, and it is still
+// throttled by the limiter
+func (p *TaskPool) runTaskAsync(t task) {
+	goSafely(func() { p.runTask(t) })
 }
 
 // stop all tasks
@@ -199,6 +251,7 @@ func (p *TaskPool) stop() {
 	default:
 		p.once.Do(func() {
 			close(p.done)
+			p.limiterCancel()
 		})
 	}
 }
@@ -222,6 +275,12 @@ func (p *TaskPool) Close() {
 	}
 }
 
+// Shutdown closes the task pool like Close, but returns ctx.Err() if the running
+// tasks do not finish before @ctx is done
+func (p *TaskPool) Shutdown(ctx context.Context) error {
+	return shutdownPool(ctx, p.Close)
+}
+
 /////////////////////////////////////////
 // Task Pool Simple
 /////////////////////////////////////////
@@ -325,6 +384,12 @@ func (p *taskPoolSimple) Close() {
 	p.wg.Wait()
 }
 
+// Shutdown closes the task pool like Close, but returns ctx.Err() if the running
+// tasks do not finish before @ctx is done
+func (p *taskPoolSimple) Shutdown(ctx context.Context) error {
+	return shutdownPool(ctx, p.Close)
+}
+
 // check whether the session has been closed.
 func (p *taskPoolSimple) IsClosed() bool {
 	select {
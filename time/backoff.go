@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtime
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff decides the delay before the next attempt
+type Backoff interface {
+	// Next returns the delay before the @attempt-th retry, @attempt starts from 1
+	Next(attempt int) time.Duration
+}
+
+// ConstantBackoff retries after the same delay
+type ConstantBackoff time.Duration
+
+// Next returns the constant delay
+func (b ConstantBackoff) Next(_ int) time.Duration {
+	return time.Duration(b)
+}
+
+// ExponentialBackoff multiplies the delay by Multiplier on every retry until Max.
+// Jitter in [0, 1] randomizes the delay by ±Jitter.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// NewExponentialBackoff returns an ExponentialBackoff doubling the delay from @initial to @max
+func NewExponentialBackoff(initial, max time.Duration) *ExponentialBackoff {
+	return &ExponentialBackoff{
+		Initial:    initial,
+		Max:        max,
+		Multiplier: 2,
+	}
+}
+
+// Next returns the delay before the @attempt-th retry
+func (b *ExponentialBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	return Jitter(time.Duration(d), b.Jitter)
+}
+
+// Jitter randomizes @d by ±@ratio, @ratio is in [0, 1]
+func Jitter(d time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 || d <= 0 {
+		return d
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return time.Duration(float64(d) * (1 + ratio*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrMaxAttempts is returned when a RetryTimer gives up after the max attempts
+var ErrMaxAttempts = errors.New("max attempts reached")
+
+// RetryFunc is invoked by RetryTimer with the @attempt number starting from 1.
+// It returns true to be rescheduled after the next backoff delay.
+type RetryFunc func(ctx context.Context, attempt int) (retry bool)
+
+// RetryTimer invokes a RetryFunc and reschedules it on the timer wheel with
+// the backoff delay until it succeeds, reaches the max attempts or the
+// context is done.
+type RetryTimer struct {
+	w           *TimerWheel
+	fn          RetryFunc
+	backoff     Backoff
+	maxAttempts int
+	ctx         context.Context
+	cancel      context.CancelFunc
+
+	lock    sync.Mutex
+	timer   *Timer
+	attempt int
+	err     error
+	done    chan struct{}
+}
+
+// NewRetryTimer invokes @fn at once, and retries it on the default timer wheel.
+// A non-positive @maxAttempts means no limit.
+func NewRetryTimer(ctx context.Context, fn RetryFunc, backoff Backoff, maxAttempts int) *RetryTimer {
+	return defaultTimerWheel.NewRetryTimer(ctx, fn, backoff, maxAttempts)
+}
+
+// NewRetryTimer invokes @fn at once, and retries it on the timer wheel w.
+// A non-positive @maxAttempts means no limit.
+func (w *TimerWheel) NewRetryTimer(ctx context.Context, fn RetryFunc, backoff Backoff, maxAttempts int) *RetryTimer {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &RetryTimer{
+		w:           w,
+		fn:          fn,
+		backoff:     backoff,
+		maxAttempts: maxAttempts,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+
+	go func() {
+		<-ctx.Done()
+		t.finish(ctx.Err())
+	}()
+	go t.invoke()
+	return t
+}
+
+func (t *RetryTimer) invoke() {
+	t.lock.Lock()
+	// the timer has fired
+	t.timer = nil
+	if t.isDone() {
+		t.lock.Unlock()
+		return
+	}
+	t.attempt++
+	attempt := t.attempt
+	t.lock.Unlock()
+
+	if !t.fn(t.ctx, attempt) {
+		t.finish(nil)
+		return
+	}
+	if t.maxAttempts > 0 && attempt >= t.maxAttempts {
+		t.finish(ErrMaxAttempts)
+		return
+	}
+
+	delay := t.backoff.Next(attempt)
+	if delay < minTickerInterval {
+		delay = minTickerInterval
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.isDone() {
+		return
+	}
+	if t.timer = t.w.AfterFunc(delay, t.invoke); t.timer == nil {
+		t.setErr(ErrTimeChannelFull)
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (t *RetryTimer) isDone() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (t *RetryTimer) setErr(err error) {
+	if t.isDone() {
+		return
+	}
+	t.err = err
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	close(t.done)
+	t.cancel()
+}
+
+func (t *RetryTimer) finish(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.setErr(err)
+}
+
+// Stop stops retrying, Err returns context.Canceled after that
+func (t *RetryTimer) Stop() {
+	t.cancel()
+}
+
+// Done returns a chan which is closed when the RetryTimer ends
+func (t *RetryTimer) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns nil if the RetryFunc succeeds, ErrMaxAttempts if it gives up
+// after the max attempts, or the error of the context
+func (t *RetryTimer) Err() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.err
+}
+
+// Attempts returns the number of the invocations
+func (t *RetryTimer) Attempts() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.attempt
+}
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, b.Next(1))
+	assert.Equal(t, 20*time.Millisecond, b.Next(2))
+	assert.Equal(t, 40*time.Millisecond, b.Next(3))
+	assert.Equal(t, 50*time.Millisecond, b.Next(4))
+	assert.Equal(t, time.Second, ConstantBackoff(time.Second).Next(10))
+
+	b.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		d := b.Next(1)
+		assert.True(t, d >= 8*time.Millisecond && d <= 12*time.Millisecond)
+	}
+}
+
+func TestRetryTimer(t *testing.T) {
+	InitDefaultTimerWheel()
+
+	rt := NewRetryTimer(context.Background(), func(ctx context.Context, attempt int) bool {
+		return attempt < 3
+	}, ConstantBackoff(10*time.Millisecond), 0)
+	select {
+	case <-rt.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("retry timer should succeed")
+	}
+	assert.Nil(t, rt.Err())
+	assert.Equal(t, 3, rt.Attempts())
+
+	rt = NewRetryTimer(context.Background(), func(ctx context.Context, attempt int) bool {
+		return true
+	}, NewExponentialBackoff(10*time.Millisecond, 20*time.Millisecond), 4)
+	<-rt.Done()
+	assert.Equal(t, ErrMaxAttempts, rt.Err())
+	assert.Equal(t, 4, rt.Attempts())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rt = NewRetryTimer(ctx, func(ctx context.Context, attempt int) bool {
+		return true
+	}, ConstantBackoff(time.Hour), 0)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-rt.Done()
+	assert.Equal(t, context.Canceled, rt.Err())
+	assert.Equal(t, 1, rt.Attempts())
+}
@@ -177,3 +177,49 @@ func TestTimer_Stop(t *testing.T) {
 	// assert.Equalf(t, 0, defaultTimerWheel.TimerNumber(), "after stop")
 	time.Sleep(3e9)
 }
+
+func TestTimerWheelIdle(t *testing.T) {
+	w := NewTimerWheel()
+	defer w.Close()
+
+	// the wheel keeps running after all the timers fired
+	for i := 0; i < 2; i++ {
+		fired := make(chan struct{})
+		w.AfterFunc(20*time.Millisecond, func() {
+			close(fired)
+		})
+		select {
+		case <-fired:
+		case <-time.After(time.Second):
+			t.Fatalf("timer %d added to the idle wheel should fire", i)
+		}
+		assert.Eventually(t, func() bool {
+			return w.TimerNumber() == 0
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	// the timer added after a long idle period fires in time
+	time.Sleep(1100 * time.Millisecond)
+	fired := make(chan struct{})
+	w.AfterFunc(20*time.Millisecond, func() {
+		close(fired)
+	})
+	select {
+	case <-fired:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timer added after idle should fire in time")
+	}
+
+	// close returns while some timers are pending
+	w.AfterFunc(time.Hour, func() {})
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close should not wait for the pending timers")
+	}
+}
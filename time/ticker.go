@@ -38,6 +38,16 @@ func NewTicker(d time.Duration) *Ticker {
 	return defaultTimerWheel.NewTicker(d)
 }
 
+// NewTickerWithOptions returns a new Ticker which may send the first tick
+// immediately and randomize every tick interval by @opts
+func NewTickerWithOptions(d time.Duration, opts ...TickerOption) *Ticker {
+	if d <= 0 {
+		return nil
+	}
+
+	return defaultTimerWheel.NewTickerWithOptions(d, opts...)
+}
+
 // TickFunc returns a Ticker
 func TickFunc(d time.Duration, f func()) *Ticker {
 	if d <= 0 {
@@ -19,10 +19,16 @@
 package gxtime
 
 import (
+	"container/list"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+import (
+	"github.com/stretchr/testify/assert"
+)
+
 import (
 	gxlog "github.com/dubbogo/gost/log"
 )
@@ -100,3 +106,77 @@ func TestTicker_Stop(t *testing.T) {
 	// timerNumber = defaultTimerWheel.TimerNumber()
 	// xassert.Equal(timerNumber, 0, "")
 }
+
+func TestNewTickerWithOptions(t *testing.T) {
+	InitDefaultTimerWheel()
+
+	start := time.Now()
+	ticker := NewTickerWithOptions(100*time.Millisecond, WithImmediateTick())
+	<-ticker.C
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+	<-ticker.C
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+	ticker.Stop()
+
+	ticker = NewTickerWithOptions(100*time.Millisecond, WithTickJitter(0.5))
+	last := time.Now()
+	for i := 0; i < 5; i++ {
+		now := <-ticker.C
+		interval := now.Sub(last)
+		assert.True(t, interval >= 40*time.Millisecond && interval <= 170*time.Millisecond, "interval %v", interval)
+		last = now
+	}
+	ticker.Stop()
+}
+
+func TestJitterTickerReschedule(t *testing.T) {
+	w := &TimerWheel{timerQ: make(chan *timerNodeAction, 1)}
+	for i := 0; i < maxTimerLevel; i++ {
+		w.slot[i] = list.New()
+	}
+
+	jt := &jitterTicker{c: make(chan time.Time, 1), period: 100 * time.Millisecond, jitter: 0.5}
+	node := newTimerNode(sendTimeWithJitter, TimerLoop, int64(jt.period), jt)
+	node.trig = 0
+	w.insertSlot(0, node)
+	w.number.Add(1)
+
+	for i := 0; i < 10; i++ {
+		trig := node.trig
+		atomic.StoreInt64(&w.clock, trig)
+		w.run()
+
+		// the next tick is rescheduled by the wheel goroutine, not by the timer queue
+		<-jt.c
+		assert.Equal(t, 0, len(w.timerQ))
+		assert.True(t, node.period >= int64(50*time.Millisecond) && node.period <= int64(150*time.Millisecond))
+		assert.Equal(t, trig+node.period, node.trig)
+	}
+}
+
+func TestJitterTickerReset(t *testing.T) {
+	w := &TimerWheel{timerQ: make(chan *timerNodeAction, 1)}
+	for i := 0; i < maxTimerLevel; i++ {
+		w.slot[i] = list.New()
+	}
+
+	jt := &jitterTicker{c: make(chan time.Time, 1), period: 100 * time.Millisecond, jitter: 0.5}
+	node := newTimerNode(sendTimeWithJitter, TimerLoop, int64(jt.period), jt)
+	node.trig = 0
+	w.insertSlot(0, node)
+	w.number.Add(1)
+
+	w.resetTimerNode(&timerNode{ID: node.ID, period: int64(time.Second)})
+	assert.Equal(t, int64(time.Second), node.period)
+
+	// all the ticks after reset are jittered around the new period
+	for i := 0; i < 10; i++ {
+		trig := node.trig
+		atomic.StoreInt64(&w.clock, trig)
+		w.run()
+
+		<-jt.c
+		assert.True(t, node.period >= int64(500*time.Millisecond) && node.period <= int64(1500*time.Millisecond))
+		assert.Equal(t, trig+node.period, node.trig)
+	}
+}
@@ -98,6 +98,14 @@ type timerNode struct {
 	arg      interface{} // func arg
 }
 
+// periodPicker is implemented by the arg of a loop timer whose period changes
+// on every run, it is called by the wheel goroutine before the node is reinserted
+type periodPicker interface {
+	nextPeriod() time.Duration
+	// resetPeriod changes the base period when the timer is reset
+	resetPeriod(d time.Duration)
+}
+
 func newTimerNode(f TimerFunc, typ TimerType, period int64, arg interface{}) *timerNode {
 	return &timerNode{
 		ID:       atomic.AddUint64(&nextID, 1),
@@ -168,6 +176,7 @@ type TimerWheel struct {
 
 	once   sync.Once      // for close ticker
 	ticker *time.Ticker   // virtual atomic clock
+	done   chan struct{}  // closed when the wheel is stopped
 	wg     sync.WaitGroup // gr sync
 }
 
@@ -178,6 +187,7 @@ func NewTimerWheel() *TimerWheel {
 		// in fact, the minimum time accuracy is 10ms.
 		ticker: time.NewTicker(time.Duration(minTickerInterval)),
 		timerQ: make(chan *timerNodeAction, timerNodeQueueSize),
+		done:   make(chan struct{}),
 	}
 	w.start = w.clock
 
@@ -201,11 +211,20 @@ func NewTimerWheel() *TimerWheel {
 				break LOOP
 			}
 			select {
+			case <-w.done:
+				break LOOP
+
 			case t, cFlag = <-w.ticker.C:
 				atomic.StoreInt64(&curGxTime, t.UnixNano())
-				if cFlag && 0 != w.number.Load() {
-					ret := w.timerUpdate(t)
-					if ret == 0 {
+				if cFlag {
+					if 0 == w.number.Load() {
+						// skip the wheel scan while it is idle, but keep the clock
+						// up to date so the timers added later are placed correctly.
+						// The hands catch up by deltaDiff in the next update.
+						atomic.StoreInt64(&w.clock, t.UnixNano())
+						continue
+					}
+					if w.timerUpdate(t) == 0 {
 						w.run()
 					}
 
@@ -222,7 +241,6 @@ func NewTimerWheel() *TimerWheel {
 						w.number.Add(1)
 						w.insertTimerNode(nodeAction.node)
 					case nodeAction.action == TimerActionDel:
-						w.number.Add(-1)
 						w.deleteTimerNode(nodeAction.node)
 					case nodeAction.action == TimerActionReset:
 						// log.CInfo("node action:%#v", nodeAction)
@@ -290,6 +308,9 @@ func (w *TimerWheel) run() {
 		slot.Remove(e)
 	}
 	for idx := range array[:] {
+		if picker, ok := array[idx].arg.(periodPicker); ok {
+			array[idx].period = int64(picker.nextPeriod())
+		}
 		array[idx].trig += array[idx].period
 		w.insertTimerNode(array[idx])
 	}
@@ -326,7 +347,8 @@ LOOP:
 		for e := w.slot[level].Front(); e != nil; e = e.Next() {
 			if e.Value.(*timerNode).ID == node.ID {
 				w.slot[level].Remove(e)
-				// atomic.AddInt64(&w.number, -1)
+				// the node of a fired once timer has been removed and counted
+				w.number.Add(-1)
 				break LOOP
 			}
 		}
@@ -343,6 +365,9 @@ LOOP:
 				n := e.Value.(*timerNode)
 				n.trig -= n.period
 				n.period = node.period
+				if picker, ok := n.arg.(periodPicker); ok {
+					picker.resetPeriod(time.Duration(node.period))
+				}
 				n.trig += n.period
 				w.slot[level].Remove(e)
 				w.insertTimerNode(n)
@@ -471,6 +496,7 @@ func (w *TimerWheel) Stop() {
 		w.enable.Store(false)
 		// close(w.timerQ) // to defend data race warning
 		w.ticker.Stop()
+		close(w.done)
 	})
 }
 
@@ -632,6 +658,89 @@ func (w *TimerWheel) NewTicker(d time.Duration) *Ticker {
 	return nil
 }
 
+type tickerOptions struct {
+	immediate bool
+	jitter    float64
+}
+
+// TickerOption is optional settings for ticker
+type TickerOption func(*tickerOptions)
+
+// WithImmediateTick sends the first tick as soon as the ticker is created
+func WithImmediateTick() TickerOption {
+	return func(o *tickerOptions) {
+		o.immediate = true
+	}
+}
+
+// WithTickJitter randomizes every tick interval by ±@ratio of the period, so that
+// the tickers of many processes do not fire at the same time. @ratio is in [0, 1].
+func WithTickJitter(ratio float64) TickerOption {
+	return func(o *tickerOptions) {
+		o.jitter = ratio
+	}
+}
+
+type jitterTicker struct {
+	c      chan time.Time
+	period time.Duration
+	jitter float64
+}
+
+// nextPeriod picks the jittered period of the next tick
+func (jt *jitterTicker) nextPeriod() time.Duration {
+	return Jitter(jt.period, jt.jitter)
+}
+
+// resetPeriod changes the period which the next ticks are jittered around
+func (jt *jitterTicker) resetPeriod(d time.Duration) {
+	jt.period = d
+}
+
+func sendTimeWithJitter(_ TimerID, t time.Time, arg interface{}) error {
+	select {
+	case arg.(*jitterTicker).c <- t:
+	default:
+	}
+
+	return nil
+}
+
+// NewTickerWithOptions returns a new Ticker like NewTicker, which may send the
+// first tick immediately and randomize every tick interval by @opts.
+// Reset changes the period which the intervals of a ticker with jitter are
+// randomized around. The jittered intervals are picked by the wheel goroutine when the ticker is
+// rescheduled, so no tick is lost when the timer queue is full.
+func (w *TimerWheel) NewTickerWithOptions(d time.Duration, opts ...TickerOption) *Ticker {
+	var o tickerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := make(chan time.Time, 1)
+	if o.immediate {
+		c <- w.Now()
+	}
+
+	var (
+		timer *Timer
+		err   error
+	)
+	if o.jitter > 0 {
+		jt := &jitterTicker{c: c, period: d, jitter: o.jitter}
+		timer, err = w.AddTimer(sendTimeWithJitter, TimerLoop, Jitter(d, o.jitter), jt)
+	} else {
+		timer, err = w.AddTimer(sendTime, TimerLoop, d, c)
+	}
+	if err == nil {
+		timer.C = c
+		return (*Ticker)(timer)
+	}
+
+	close(c)
+	return nil
+}
+
 // TickFunc returns a Ticker
 func (w *TimerWheel) TickFunc(d time.Duration, f func()) *Ticker {
 	t, err := w.AddTimer(goFunc, TimerLoop, d, f)
@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket refilled with @rate tokens per second up to @burst tokens.
+// It is safe to be shared by many goroutines, so it can be used either as a budget of
+// a single user or as a budget shared by a group of users.
+type TokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  int64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket. It is unlimited if @rate is not positive.
+func NewTokenBucket(rate float64, burst int64) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// NOTICE: need to get the lock before calling this method
+func (b *TokenBucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+	}
+	b.last = now
+}
+
+// Reserve takes @n tokens and returns how long the caller should wait before using them.
+// The tokens are borrowed from the future if there are not enough tokens now.
+func (b *TokenBucket) Reserve(n int64) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+	b.advance(time.Now())
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// TryTake takes @n tokens only if they are available now
+func (b *TokenBucket) TryTake(n int64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.rate <= 0 {
+		return true
+	}
+	b.advance(time.Now())
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// Wait takes @n tokens, blocks until they are available or @ctx is done.
+// The tokens are given back if @ctx is done before they are available.
+func (b *TokenBucket) Wait(ctx context.Context, n int64) error {
+	d := b.Reserve(n)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.refund(n)
+		return ctx.Err()
+	}
+}
+
+func (b *TokenBucket) refund(n int64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.advance(time.Now())
+	b.tokens += float64(n)
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+}
+
+// SetRate changes the rate and burst, the tokens in the bucket are kept
+func (b *TokenBucket) SetRate(rate float64, burst int64) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.advance(time.Now())
+	b.rate = rate
+	b.burst = burst
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+}
+
+// Rate returns the tokens refilled per second
+func (b *TokenBucket) Rate() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.rate
+}
+
+// Burst returns the capacity of the bucket
+func (b *TokenBucket) Burst() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.burst
+}
+
+// Available returns the tokens in the bucket, it is negative if tokens are borrowed
+func (b *TokenBucket) Available() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.advance(time.Now())
+	return b.tokens
+}
@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gxtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 10)
+	assert.Equal(t, int64(10), b.Burst())
+	assert.True(t, b.TryTake(10))
+	assert.False(t, b.TryTake(1))
+
+	// 5 tokens are borrowed, wait for 50ms
+	d := b.Reserve(5)
+	assert.True(t, d > 30*time.Millisecond && d <= 50*time.Millisecond, d)
+	assert.True(t, b.Available() < 0)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, float64(10), b.Available())
+
+	b.SetRate(100, 5)
+	assert.Equal(t, float64(5), b.Available())
+	assert.Equal(t, float64(100), b.Rate())
+
+	unlimited := NewTokenBucket(0, 1)
+	assert.True(t, unlimited.TryTake(1<<20))
+	assert.Equal(t, time.Duration(0), unlimited.Reserve(1<<20))
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b := NewTokenBucket(100, 10)
+	start := time.Now()
+	assert.Nil(t, b.Wait(context.Background(), 10))
+	assert.Nil(t, b.Wait(context.Background(), 5))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, b.Wait(ctx, 1000))
+	// the tokens are given back
+	assert.True(t, b.Available() > -100)
+}
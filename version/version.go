@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gxversion reports the build metadata of the running binary, which is
+// set by ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/dubbogo/gost/version.Version=v1.2.3 \
+//	    -X github.com/dubbogo/gost/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// or read from the build info embedded by the go toolchain when not set.
+package gxversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// These are set by ldflags, and take precedence over the embedded build info.
+var (
+	// Version is the version of the main module
+	Version string
+	// Revision is the vcs revision
+	Revision string
+	// BuildTime is the time when the binary is built
+	BuildTime string
+)
+
+// Info is the build metadata of the binary
+type Info struct {
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build metadata, which is collected once
+func Get() Info {
+	once.Do(func() {
+		info = collect(debug.ReadBuildInfo())
+	})
+	return info
+}
+
+func collect(bi *debug.BuildInfo, ok bool) Info {
+	i := Info{
+		Version:   "(devel)",
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if ok && bi != nil {
+		i.Path = bi.Main.Path
+		if bi.Main.Version != "" {
+			i.Version = bi.Main.Version
+		}
+		if bi.GoVersion != "" {
+			i.GoVersion = bi.GoVersion
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				i.Revision = s.Value
+			case "vcs.time":
+				i.BuildTime = s.Value
+			case "vcs.modified":
+				i.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	if Version != "" {
+		i.Version = Version
+	}
+	if Revision != "" {
+		i.Revision = Revision
+	}
+	if BuildTime != "" {
+		i.BuildTime = BuildTime
+	}
+	return i
+}
+
+// String returns the metadata in one line, e.g. "v1.2.3 (rev 1a2b3c4d, built 2022-01-02T15:04:05Z, go1.18 linux/amd64)"
+func (i Info) String() string {
+	s := i.Version + " ("
+	if i.Revision != "" {
+		rev := i.Revision
+		if len(rev) > 12 {
+			rev = rev[:12]
+		}
+		s += "rev " + rev
+		if i.Modified {
+			s += "-dirty"
+		}
+		s += ", "
+	}
+	if i.BuildTime != "" {
+		s += "built " + i.BuildTime + ", "
+	}
+	return s + fmt.Sprintf("%s %s)", i.GoVersion, i.Platform)
+}
+
+// JSON returns the metadata in json
+func (i Info) JSON() []byte {
+	data, _ := json.Marshal(i)
+	return data
+}
+
+// Details returns the metadata as a flat map, which is used as the details of
+// health check reports
+func (i Info) Details() map[string]string {
+	details := map[string]string{
+		"version":   i.Version,
+		"goVersion": i.GoVersion,
+		"platform":  i.Platform,
+	}
+	if i.Path != "" {
+		details["path"] = i.Path
+	}
+	if i.Revision != "" {
+		details["revision"] = i.Revision
+		details["modified"] = fmt.Sprint(i.Modified)
+	}
+	if i.BuildTime != "" {
+		details["buildTime"] = i.BuildTime
+	}
+	return details
+}
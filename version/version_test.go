@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gxversion
+
+import (
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollect(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.18",
+		Main:      debug.Module{Path: "example.com/app", Version: "v1.0.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2022-01-02T15:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	i := collect(bi, true)
+	assert.Equal(t, Info{
+		Path:      "example.com/app",
+		Version:   "v1.0.0",
+		Revision:  "0123456789abcdef0123",
+		Modified:  true,
+		BuildTime: "2022-01-02T15:04:05Z",
+		GoVersion: "go1.18",
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}, i)
+	assert.Equal(t, "v1.0.0 (rev 0123456789ab-dirty, built 2022-01-02T15:04:05Z, go1.18 "+i.Platform+")", i.String())
+
+	var decoded Info
+	assert.Nil(t, json.Unmarshal(i.JSON(), &decoded))
+	assert.Equal(t, i, decoded)
+	assert.Equal(t, "true", i.Details()["modified"])
+
+	// ldflags take precedence
+	Version, BuildTime = "v2.0.0", "now"
+	defer func() {
+		Version, BuildTime = "", ""
+	}()
+	i = collect(bi, true)
+	assert.Equal(t, "v2.0.0", i.Version)
+	assert.Equal(t, "now", i.BuildTime)
+	assert.Equal(t, "0123456789abcdef0123", i.Revision)
+
+	i = collect(nil, false)
+	assert.Equal(t, "v2.0.0", i.Version)
+	assert.Equal(t, runtime.Version(), i.GoVersion)
+	assert.Equal(t, "v2.0.0 (built now, "+runtime.Version()+" "+i.Platform+")", i.String())
+	assert.NotContains(t, i.Details(), "revision")
+}
+
+func TestGet(t *testing.T) {
+	i := Get()
+	assert.NotEmpty(t, i.Version)
+	assert.NotEmpty(t, i.GoVersion)
+	assert.Equal(t, i, Get())
+}